@@ -220,12 +220,7 @@ func (c *FloorClient) WriteTextFile(ctx context.Context, params acpsdk.WriteText
 
 	if c.Sandbox != nil {
 		// Write into sandbox container
-		dir := filepath.Dir(path)
-		_, _ = c.Sandbox.Execute(fmt.Sprintf("mkdir -p %q", dir))
-		// Use heredoc to avoid shell escaping issues with content
-		cmd := fmt.Sprintf("cat > %q << 'OFC_EOF'\n%s\nOFC_EOF", path, params.Content)
-		_, err := c.Sandbox.Execute(cmd)
-		if err != nil {
+		if err := c.Sandbox.WriteFile(path, params.Content); err != nil {
 			return acpsdk.WriteTextFileResponse{}, fmt.Errorf("write %s in sandbox: %w", path, err)
 		}
 	} else {
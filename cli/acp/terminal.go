@@ -10,6 +10,15 @@ import (
 	"github.com/openfloorcontrol/ofc/sandbox"
 )
 
+// ErrTerminalNotFound is returned for an unknown or released terminal ID.
+type ErrTerminalNotFound struct {
+	ID string
+}
+
+func (e *ErrTerminalNotFound) Error() string {
+	return fmt.Sprintf("terminal %s not found", e.ID)
+}
+
 // Terminal tracks a single terminal session.
 type Terminal struct {
 	ID     string
@@ -115,7 +124,7 @@ func (tm *TerminalManager) GetOutput(id string) (string, bool, error) {
 	term, ok := tm.terminals[id]
 	tm.mu.Unlock()
 	if !ok {
-		return "", false, fmt.Errorf("terminal %s not found", id)
+		return "", false, &ErrTerminalNotFound{ID: id}
 	}
 
 	term.mu.Lock()
@@ -131,7 +140,7 @@ func (tm *TerminalManager) WaitForExit(id string) (int, error) {
 	term, ok := tm.terminals[id]
 	tm.mu.Unlock()
 	if !ok {
-		return -1, fmt.Errorf("terminal %s not found", id)
+		return -1, &ErrTerminalNotFound{ID: id}
 	}
 
 	<-term.Done
@@ -147,7 +156,7 @@ func (tm *TerminalManager) Kill(id string) error {
 	_, ok := tm.terminals[id]
 	tm.mu.Unlock()
 	if !ok {
-		return fmt.Errorf("terminal %s not found", id)
+		return &ErrTerminalNotFound{ID: id}
 	}
 	return nil
 }
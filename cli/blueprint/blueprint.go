@@ -2,42 +2,198 @@
 package blueprint
 
 import (
+	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Agent configuration
 type Agent struct {
-	ID          string            `yaml:"id"`
-	Name        string            `yaml:"name"`
-	Type        string            `yaml:"type"`    // "llm" (default) or "acp"
-	Model       string            `yaml:"model"`
-	Endpoint    string            `yaml:"endpoint"`
-	Command     string            `yaml:"command"` // ACP: command to launch agent
-	Args        []string          `yaml:"args"`    // ACP: args for the command
-	Env         map[string]string `yaml:"env"`     // ACP: env vars for agent process
-	Prompt      string            `yaml:"prompt"`
-	Activation  string            `yaml:"activation"`
-	CanUseTools bool              `yaml:"can_use_tools"`
-	Temperature float64           `yaml:"temperature"`
-	ToolContext string            `yaml:"tool_context"`
-	Furniture   []string          `yaml:"furniture,omitempty"` // names of accessible furniture
+	ID                  string            `yaml:"id"`
+	Name                string            `yaml:"name"`
+	Type                string            `yaml:"type"` // "llm" (default), "acp", "router", or "floor"
+	Model               string            `yaml:"model"`
+	Endpoint            string            `yaml:"endpoint"`
+	API                 string            `yaml:"api,omitempty"`     // LLM: "openai" or "anthropic" (default inferred from endpoint)
+	APIKey              string            `yaml:"api_key,omitempty"` // LLM: API key (supports ${VAR} expansion)
+	Command             string            `yaml:"command"`           // ACP: command to launch agent
+	Args                []string          `yaml:"args"`              // ACP: args for the command
+	Env                 map[string]string `yaml:"env"`               // ACP: env vars for agent process
+	Prompt              string            `yaml:"prompt"`
+	PromptFile          string            `yaml:"prompt_file,omitempty"`           // read into Prompt by Load, relative to the blueprint
+	InheritSharedPrompt *bool             `yaml:"inherit_shared_prompt,omitempty"` // prepend Blueprint.SharedPrompt (nil = true)
+	Activation          string            `yaml:"activation"`
+	Priority            int               `yaml:"priority"` // wake-poll order among always agents (higher first)
+	CanUseTools         bool              `yaml:"can_use_tools"`
+	Temperature         float64           `yaml:"temperature"`
+	ToolContext         string            `yaml:"tool_context"`
+	ContextScope        string            `yaml:"context_scope,omitempty"`          // "all" (default) or "involved": only messages involving the agent
+	PeerToolContext     map[string]string `yaml:"tool_context_overrides,omitempty"` // tool_context per peer agent ID
+	Furniture           []string          `yaml:"furniture,omitempty"`              // names of accessible furniture ("name:ro" for read-only)
+	RateLimit           *RateLimit        `yaml:"rate_limit,omitempty"`             // LLM: throttling for this agent's endpoint (nil = defaults.rate_limit)
+	ReasoningEffort     string            `yaml:"reasoning_effort,omitempty"`       // LLM only: reasoning effort for models that take one ("low", "medium", "high"); ACP agents ignore it, as their SDK has no such option
+	Seed                *int              `yaml:"seed,omitempty"`                   // LLM: sampling seed, for backends that honor one
+	CanAskUser          bool              `yaml:"can_ask_user,omitempty"`           // LLM: offer the ask_user tool, to ask @user mid-turn
+	Icon                string            `yaml:"icon,omitempty"`                   // emoji or prefix shown before the name in output labels
+	Color               string            `yaml:"color,omitempty"`                  // label color: a ColorNames entry or "#rrggbb" (default: the palette)
+	Fallbacks           []Fallback        `yaml:"fallbacks,omitempty"`              // LLM: backends to try in turn when the endpoint is down or failing
+	Blueprint           string            `yaml:"blueprint,omitempty"`              // floor: the sub-floor's blueprint file, relative to this one
+	Cooldown            Cooldown          `yaml:"cooldown,omitempty"`               // always: how long after speaking the agent stays asleep unless @mentioned
+	ToolHistoryMode     string            `yaml:"tool_history_mode,omitempty"`      // LLM: "native" (default): past tool calls as tool_calls/tool messages; "inline": as text
+	ReasoningModel      *bool             `yaml:"reasoning_model,omitempty"`        // LLM: send developer messages and no temperature (nil = detect from the model name)
+}
+
+// Fallback is a backup backend for an LLM agent, used for a request when
+// the endpoint before it in the chain is unreachable or answers with a 5xx.
+type Fallback struct {
+	Endpoint string `yaml:"endpoint"`
+	Model    string `yaml:"model"`             // default: the agent's model
+	API      string `yaml:"api,omitempty"`     // "openai" or "anthropic" (default inferred from endpoint)
+	APIKey   string `yaml:"api_key,omitempty"` // supports ${VAR} expansion
+}
+
+// ToolContextFor returns how much of peerID's tool output the agent sees:
+// its tool_context_overrides entry for the peer, or else tool_context.
+func (a *Agent) ToolContextFor(peerID string) string {
+	if level, ok := a.PeerToolContext[peerID]; ok {
+		return level
+	}
+	return a.ToolContext
+}
+
+// Label returns how the agent is shown in output: its icon and name, or
+// its ID if it has no name.
+func (a *Agent) Label() string {
+	label := a.Name
+	if label == "" {
+		label = a.ID
+	}
+	if a.Icon != "" {
+		label = a.Icon + " " + label
+	}
+	return label
+}
+
+// UsesLLM reports whether the agent is served by an LLM endpoint: an
+// "llm" agent, or a "router" that picks who responds next.
+func (a *Agent) UsesLLM() bool {
+	return a.Type == "llm" || a.Type == "router"
+}
+
+// UsesSharedPrompt reports whether the blueprint's shared prompt should be
+// prepended to this agent's prompt. Defaults to true when unset.
+func (a *Agent) UsesSharedPrompt() bool {
+	return a.InheritSharedPrompt == nil || *a.InheritSharedPrompt
 }
 
 // Workstation configuration
 type Workstation struct {
-	Type       string `yaml:"type"`
-	Name       string `yaml:"name"`
-	Image      string `yaml:"image"`
-	Dockerfile string `yaml:"dockerfile"`
-	Mount      string `yaml:"mount"`
+	Type       string     `yaml:"type"`
+	Name       string     `yaml:"name"`
+	Image      string     `yaml:"image"`
+	Dockerfile string     `yaml:"dockerfile"`
+	Mount      StringList `yaml:"mount"`          // extra bind mounts, "host:container[:ro]"
+	User       string     `yaml:"user,omitempty"` // docker run --user; "" = the host's UID:GID
+	Init       string     `yaml:"init,omitempty"` // bash script run once after the container starts
+
+	// Confirm lists regular expressions for dangerous commands. An LLM
+	// agent's bash call that matches one waits for the user's approval.
+	Confirm []string `yaml:"confirm,omitempty"`
+
+	// AllowCommands and DenyCommands restrict LLM agents' bash calls. Each
+	// entry is a command name, matched on the first word of every command
+	// in the call, or a /regex/ matched on the whole of each command (see
+	// CommandPattern).
+	AllowCommands []string `yaml:"allow_commands,omitempty"`
+	DenyCommands  []string `yaml:"deny_commands,omitempty"`
+}
+
+// CommandPattern splits an allow_commands or deny_commands entry into a
+// command name or, for an entry written /like this/, a regular expression.
+func CommandPattern(entry string) (pattern string, isRegex bool) {
+	if len(entry) >= 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+		return entry[1 : len(entry)-1], true
+	}
+	return entry, false
+}
+
+// StringList is a list of strings that may also be written as a single
+// string in YAML.
+type StringList []string
+
+// UnmarshalYAML accepts a scalar or a sequence of scalars.
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = StringList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// ParseMount splits a "host:container[:ro]" mount spec. The mode may be
+// "ro" or "rw"; ok is false if the spec is malformed.
+func ParseMount(spec string) (host, container, mode string, ok bool) {
+	parts := strings.Split(spec, ":")
+	switch {
+	case len(parts) == 3 && (parts[2] == "ro" || parts[2] == "rw"):
+		mode = parts[2]
+	case len(parts) != 2:
+		return "", "", "", false
+	}
+	host, container = parts[0], parts[1]
+	if host == "" || !strings.HasPrefix(container, "/") {
+		return "", "", "", false
+	}
+	return host, container, mode, true
 }
 
 // Defaults for the blueprint
 type Defaults struct {
-	Endpoint string `yaml:"endpoint"`
-	Model    string `yaml:"model"`
+	Endpoint  string    `yaml:"endpoint"`
+	Model     string    `yaml:"model"`
+	RateLimit RateLimit `yaml:"rate_limit,omitempty"`
+}
+
+// RateLimit throttles the requests sent to an LLM endpoint. Agents that
+// share an endpoint share its limit.
+type RateLimit struct {
+	MinInterval   time.Duration `yaml:"min_interval,omitempty"`   // minimum time between request starts (e.g. "500ms")
+	MaxConcurrent int           `yaml:"max_concurrent,omitempty"` // requests in flight at once (0 = unlimited)
+}
+
+// Cooldown keeps an always agent from waking again too soon after it
+// speaks. It is written in YAML as a number of messages (3) or a duration
+// ("2m"); explicit @mentions ignore it.
+type Cooldown struct {
+	Messages int           // messages the agent sits out after speaking
+	Duration time.Duration // time since the agent last spoke
+}
+
+// UnmarshalYAML accepts a message count or a duration.
+func (c *Cooldown) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: cooldown must be a number of messages or a duration", value.Line)
+	}
+	if n, err := strconv.Atoi(value.Value); err == nil {
+		*c = Cooldown{Messages: n}
+		return nil
+	}
+	d, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: cooldown %q must be a number of messages or a duration (e.g. 3 or 2m)", value.Line, value.Value)
+	}
+	*c = Cooldown{Duration: d}
+	return nil
 }
 
 // FurnitureDef configures a piece of furniture on the floor.
@@ -47,21 +203,128 @@ type FurnitureDef struct {
 	Command string            `yaml:"command,omitempty"` // executable for external MCP servers
 	Args    []string          `yaml:"args,omitempty"`    // arguments for external MCP command
 	Config  map[string]string `yaml:"config,omitempty"`  // type-specific configuration
+
+	// AllowedAgents restricts the furniture to these agents, even if
+	// others list it. Empty allows every agent that lists it.
+	AllowedAgents []string `yaml:"allowed_agents,omitempty"`
+}
+
+// Allows reports whether the agent may use the furniture.
+func (fd FurnitureDef) Allows(agentID string) bool {
+	return len(fd.AllowedAgents) == 0 || slices.Contains(fd.AllowedAgents, agentID)
+}
+
+// ParseFurnitureRef splits an agent's furniture entry into the furniture
+// name and whether the agent asked for it read-only ("tasks:ro").
+func ParseFurnitureRef(ref string) (name string, readOnly bool) {
+	if name, ok := strings.CutSuffix(ref, ":ro"); ok {
+		return name, true
+	}
+	return ref, false
+}
+
+// ModelPrice is what a model costs, in dollars per 1,000 tokens, for
+// cost estimates.
+type ModelPrice struct {
+	Input  float64 `yaml:"input"`  // per 1K prompt tokens
+	Output float64 `yaml:"output"` // per 1K completion tokens
+}
+
+// Cost estimates the dollar cost of a request's tokens.
+func (p ModelPrice) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / 1000
+}
+
+// Redact configures the removal of secrets from tool output before the
+// floor shows, stores, or logs it.
+type Redact struct {
+	Secrets  bool     `yaml:"secrets,omitempty"`  // common token shapes (sk-..., ghp_..., AKIA...) and the blueprint's own ${VAR} secrets
+	Patterns []string `yaml:"patterns,omitempty"` // more regular expressions to redact
+}
+
+// Limits caps how much text the floor keeps, and how long agents may go
+// round in circles, as a guard against runaway tools and models. Load
+// fills in the defaults.
+type Limits struct {
+	MaxMessageSize    ByteSize `yaml:"max_message_size,omitempty"`    // one message: its content and tool output (default 1MB)
+	MaxTranscriptSize ByteSize `yaml:"max_transcript_size,omitempty"` // all messages together (default 16MB)
+
+	// MaxRepeats is how many times in one user turn an agent may send the
+	// same message in a row, ignoring case and spacing, before the floor
+	// stops the loop and returns to the user (default 3).
+	MaxRepeats int `yaml:"max_repeats,omitempty"`
+}
+
+// Default limits.
+const (
+	DefaultMaxMessageSize    ByteSize = 1 << 20
+	DefaultMaxTranscriptSize ByteSize = 16 << 20
+	DefaultMaxRepeats                 = 3
+)
+
+// ByteSize is a size in bytes, written in YAML as a number of bytes or
+// with a KB, MB or GB suffix (powers of 1024).
+type ByteSize int64
+
+// UnmarshalYAML accepts 4096, "512KB", "2MB" or "1GB".
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	text := strings.ToUpper(strings.TrimSpace(value.Value))
+	unit := int64(1)
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if num, ok := strings.CutSuffix(text, u.suffix); ok {
+			text, unit = strings.TrimSpace(num), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if value.Kind != yaml.ScalarNode || err != nil {
+		return fmt.Errorf("line %d: size %q must be a number of bytes, optionally with KB, MB or GB", value.Line, value.Value)
+	}
+	*b = ByteSize(n * unit)
+	return nil
+}
+
+// PassConfig controls how an agent's [PASS] response is detected.
+type PassConfig struct {
+	Token string `yaml:"token"` // default "[PASS]"
+	Match string `yaml:"match"` // "line" (default): whole message or a line on its own; "contains": anywhere (legacy)
 }
 
 // Blueprint is a complete floor configuration
 type Blueprint struct {
-	Name         string         `yaml:"name"`
-	Description  string         `yaml:"description"`
-	Defaults     Defaults       `yaml:"defaults"`
-	Agents       []Agent        `yaml:"agents"`
-	Workstations []Workstation  `yaml:"workstations"`
-	Furniture    []FurnitureDef `yaml:"furniture,omitempty"`
+	Name                string                `yaml:"name"`
+	Description         string                `yaml:"description"`
+	SharedPrompt        string                `yaml:"shared_prompt,omitempty"` // prepended to every agent's prompt
+	Pass                PassConfig            `yaml:"pass,omitempty"`
+	ConcurrentBroadcast bool                  `yaml:"concurrent_broadcast,omitempty"` // run @everyone? fan-outs concurrently
+	HTTPProxy           string                `yaml:"http_proxy,omitempty"`           // proxy URL for LLM requests (supports ${VAR}; default: $HTTPS_PROXY etc.)
+	CACert              string                `yaml:"ca_cert,omitempty"`              // PEM file of extra trusted CAs, relative to the blueprint
+	APIToken            string                `yaml:"api_token,omitempty"`            // bearer token required by the furniture API server (supports ${VAR})
+	Pricing             map[string]ModelPrice `yaml:"pricing,omitempty"`              // model name → price, for cost estimates
+	Palette             []string              `yaml:"palette,omitempty"`              // label colors cycled through for agents without a color
+	Redact              *Redact               `yaml:"redact,omitempty"`               // secrets to remove from tool output (nil = none)
+	Limits              Limits                `yaml:"limits,omitempty"`               // size caps protecting the floor from runaway output
+	Defaults            Defaults              `yaml:"defaults"`
+	Agents              []Agent               `yaml:"agents"`
+	Workstations        []Workstation         `yaml:"workstations"`
+	Furniture           []FurnitureDef        `yaml:"furniture,omitempty"`
 }
 
-// Load reads a blueprint from a YAML file
+// Load reads a blueprint from a YAML file. If the file has an
+// `extends: base.yaml` key, the base blueprint is loaded first (relative
+// to the file, recursively) and the file is overlaid on it: mappings merge
+// key by key, agents merge by id, furniture merges by name, and other
+// values (including lists) replace the base's. Agent prompt_file paths are
+// resolved relative to the file that sets them.
 func Load(path string) (*Blueprint, error) {
-	data, err := os.ReadFile(path)
+	doc, err := loadDocument(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(doc)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +335,25 @@ func Load(path string) (*Blueprint, error) {
 	}
 
 	// Apply defaults
+	if bp.Limits.MaxMessageSize == 0 {
+		bp.Limits.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if bp.Limits.MaxTranscriptSize == 0 {
+		bp.Limits.MaxTranscriptSize = DefaultMaxTranscriptSize
+	}
+	if bp.Limits.MaxRepeats == 0 {
+		bp.Limits.MaxRepeats = DefaultMaxRepeats
+	}
+	if bp.Pass.Token == "" {
+		bp.Pass.Token = "[PASS]"
+	}
+	if bp.Pass.Match == "" {
+		bp.Pass.Match = "line"
+	}
 	for i := range bp.Agents {
+		if err := loadPromptFile(&bp.Agents[i]); err != nil {
+			return nil, err
+		}
 		if bp.Agents[i].Endpoint == "" {
 			bp.Agents[i].Endpoint = bp.Defaults.Endpoint
 		}
@@ -88,10 +369,55 @@ func Load(path string) (*Blueprint, error) {
 		if bp.Agents[i].ToolContext == "" {
 			bp.Agents[i].ToolContext = "full"
 		}
+		if bp.Agents[i].ContextScope == "" {
+			bp.Agents[i].ContextScope = "all"
+		}
 		if bp.Agents[i].Type == "" {
 			bp.Agents[i].Type = "llm"
 		}
+		if bp.Agents[i].UsesLLM() && bp.Agents[i].API == "" {
+			bp.Agents[i].API = inferAPI(bp.Agents[i].Endpoint)
+		}
+		for j := range bp.Agents[i].Fallbacks {
+			fb := &bp.Agents[i].Fallbacks[j]
+			if fb.Model == "" {
+				fb.Model = bp.Agents[i].Model
+			}
+			if fb.API == "" {
+				fb.API = inferAPI(fb.Endpoint)
+			}
+		}
+		if bp.Agents[i].RateLimit == nil && bp.Defaults.RateLimit != (RateLimit{}) {
+			rl := bp.Defaults.RateLimit
+			bp.Agents[i].RateLimit = &rl
+		}
 	}
 
 	return &bp, nil
 }
+
+// inferAPI returns the API flavor of an endpoint that doesn't set one:
+// "anthropic" for Anthropic's own API, else "openai".
+func inferAPI(endpoint string) string {
+	if strings.Contains(endpoint, "anthropic.com") {
+		return "anthropic"
+	}
+	return "openai"
+}
+
+// loadPromptFile sets agent.Prompt from agent.PromptFile, if set.
+// loadDocument has already made the path absolute.
+func loadPromptFile(agent *Agent) error {
+	if agent.PromptFile == "" {
+		return nil
+	}
+	if agent.Prompt != "" {
+		return fmt.Errorf("agent %s: prompt and prompt_file are both set", agent.ID)
+	}
+	data, err := os.ReadFile(agent.PromptFile)
+	if err != nil {
+		return fmt.Errorf("agent %s: %w", agent.ID, err)
+	}
+	agent.Prompt = string(data)
+	return nil
+}
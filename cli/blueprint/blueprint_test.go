@@ -0,0 +1,368 @@
+package blueprint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles creates files (path → content) under a temp dir and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadPromptFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"floor/blueprint.yaml": `
+name: files
+agents:
+  - id: "@data"
+    prompt_file: prompts/data.md
+  - id: "@code"
+    prompt: inline
+`,
+		"floor/prompts/data.md": "# You are @data\n\nAnalyze things.\n",
+	})
+
+	bp, err := Load(filepath.Join(dir, "floor", "blueprint.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := bp.Agents[0].Prompt; got != "# You are @data\n\nAnalyze things.\n" {
+		t.Errorf("prompt from file: got %q", got)
+	}
+	if got := bp.Agents[1].Prompt; got != "inline" {
+		t.Errorf("inline prompt: got %q", got)
+	}
+}
+
+func TestLoadPromptFileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		agent string
+		want  string
+	}{
+		{"both set", "prompt: inline\n    prompt_file: p.md", "prompt and prompt_file are both set"},
+		{"missing file", "prompt_file: missing.md", "missing.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{
+				"blueprint.yaml": "name: x\nagents:\n  - id: \"@a\"\n    " + tt.agent + "\n",
+				"p.md":           "from file",
+			})
+			_, err := Load(filepath.Join(dir, "blueprint.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadExtends(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base/team.yaml": `
+name: base
+description: shared team
+defaults:
+  endpoint: http://base/v1
+  model: base-model
+agents:
+  - id: "@lead"
+    activation: always
+    can_use_tools: true
+    prompt_file: prompts/lead.md
+  - id: "@code"
+    temperature: 0.2
+workstations:
+  - type: sandbox
+    image: base-image
+furniture:
+  - name: tasks
+    type: taskboard
+`,
+		"base/prompts/lead.md": "lead from file",
+		"floor/blueprint.yaml": `
+extends: ../base/team.yaml
+name: floor
+defaults:
+  model: floor-model
+agents:
+  - id: "@lead"
+    can_use_tools: false
+    prompt: lead inline
+  - id: "@review"
+    prompt_file: review.md
+workstations: []
+`,
+		"floor/review.md": "review from file",
+	})
+
+	bp, err := Load(filepath.Join(dir, "floor", "blueprint.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if bp.Name != "floor" || bp.Description != "shared team" {
+		t.Errorf("scalars: name=%q description=%q", bp.Name, bp.Description)
+	}
+	if bp.Defaults.Endpoint != "http://base/v1" || bp.Defaults.Model != "floor-model" {
+		t.Errorf("defaults not merged: %+v", bp.Defaults)
+	}
+	var ids []string
+	for _, a := range bp.Agents {
+		ids = append(ids, a.ID)
+	}
+	if strings.Join(ids, ",") != "@lead,@code,@review" {
+		t.Fatalf("agents: got %v", ids)
+	}
+	lead, code, review := bp.Agents[0], bp.Agents[1], bp.Agents[2]
+	if lead.CanUseTools || lead.Activation != "always" || lead.Prompt != "lead inline" {
+		t.Errorf("@lead not overlaid: %+v", lead)
+	}
+	if code.Temperature != 0.2 || code.Model != "floor-model" {
+		t.Errorf("@code not inherited: %+v", code)
+	}
+	if review.Prompt != "review from file" {
+		t.Errorf("@review prompt_file not relative to its file: %q", review.Prompt)
+	}
+	if len(bp.Workstations) != 0 {
+		t.Errorf("workstations should be replaced, got %+v", bp.Workstations)
+	}
+	if len(bp.Furniture) != 1 || bp.Furniture[0].Name != "tasks" {
+		t.Errorf("furniture not inherited: %+v", bp.Furniture)
+	}
+}
+
+func TestLoadExtendsPromptFileFromBase(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml":      "name: base\nagents:\n  - id: \"@a\"\n    prompt_file: a.md\n",
+		"a.md":           "from base",
+		"sub/child.yaml": "extends: ../base.yaml\nname: child\n",
+	})
+	bp, err := Load(filepath.Join(dir, "sub", "child.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if bp.Agents[0].Prompt != "from base" {
+		t.Errorf("prompt: got %q", bp.Agents[0].Prompt)
+	}
+}
+
+func TestLoadCACertRelativeToBlueprint(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml":      "name: base\nca_cert: certs/ca.pem\nagents:\n  - id: \"@a\"\n",
+		"sub/child.yaml": "extends: ../base.yaml\nname: child\n",
+	})
+	bp, err := Load(filepath.Join(dir, "sub", "child.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := filepath.Join(dir, "certs", "ca.pem"); bp.CACert != want {
+		t.Errorf("ca_cert: got %q, want %q", bp.CACert, want)
+	}
+}
+
+func TestLoadExtendsCycle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.yaml": "extends: b.yaml\nname: a\n",
+		"b.yaml": "extends: a.yaml\nname: b\n",
+	})
+	_, err := Load(filepath.Join(dir, "a.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "extends cycle") {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+}
+
+func TestLoadRateLimitDefaults(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"blueprint.yaml": `
+name: limits
+defaults:
+  rate_limit:
+    min_interval: 500ms
+    max_concurrent: 1
+agents:
+  - id: "@a"
+  - id: "@b"
+    rate_limit:
+      max_concurrent: 2
+`,
+	})
+	bp, err := Load(filepath.Join(dir, "blueprint.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if rl := bp.Agents[0].RateLimit; rl == nil || rl.MinInterval != 500*time.Millisecond || rl.MaxConcurrent != 1 {
+		t.Errorf("@a should inherit defaults.rate_limit, got %+v", rl)
+	}
+	if rl := bp.Agents[1].RateLimit; rl == nil || rl.MinInterval != 0 || rl.MaxConcurrent != 2 {
+		t.Errorf("@b's own rate_limit should replace the default, got %+v", rl)
+	}
+}
+
+func TestLoadFallbackDefaults(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"blueprint.yaml": `
+name: fallbacks
+agents:
+  - id: "@a"
+    endpoint: http://localhost:8080/v1
+    model: primary
+    fallbacks:
+      - endpoint: https://api.anthropic.com/v1
+        model: claude
+      - endpoint: http://backup:8080/v1
+`,
+	})
+	bp, err := Load(filepath.Join(dir, "blueprint.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	fbs := bp.Agents[0].Fallbacks
+	if len(fbs) != 2 {
+		t.Fatalf("expected 2 fallbacks, got %+v", fbs)
+	}
+	if fbs[0].Model != "claude" || fbs[0].API != "anthropic" {
+		t.Errorf("first fallback: got %+v", fbs[0])
+	}
+	if fbs[1].Model != "primary" || fbs[1].API != "openai" {
+		t.Errorf("second fallback should default to the agent's model and openai, got %+v", fbs[1])
+	}
+}
+
+func TestLoadCooldown(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"blueprint.yaml": `
+name: cooldowns
+agents:
+  - id: "@a"
+    cooldown: 3
+  - id: "@b"
+    cooldown: 2m
+`,
+		"bad.yaml": `
+name: bad
+agents:
+  - id: "@a"
+    cooldown: soon
+`,
+	})
+	bp, err := Load(filepath.Join(dir, "blueprint.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := bp.Agents[0].Cooldown; got != (Cooldown{Messages: 3}) {
+		t.Errorf("message cooldown: got %+v", got)
+	}
+	if got := bp.Agents[1].Cooldown; got != (Cooldown{Duration: 2 * time.Minute}) {
+		t.Errorf("duration cooldown: got %+v", got)
+	}
+	if _, err := Load(filepath.Join(dir, "bad.yaml")); err == nil || !strings.Contains(err.Error(), "cooldown") {
+		t.Errorf("expected a cooldown error, got %v", err)
+	}
+}
+
+func TestLoadLimits(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"blueprint.yaml": `
+name: limits
+limits:
+  max_message_size: 512KB
+  max_repeats: 5
+agents:
+  - id: "@a"
+`,
+		"bad.yaml": `
+name: bad
+limits:
+  max_transcript_size: lots
+`,
+	})
+	bp, err := Load(filepath.Join(dir, "blueprint.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := Limits{MaxMessageSize: 512 << 10, MaxTranscriptSize: DefaultMaxTranscriptSize, MaxRepeats: 5}
+	if bp.Limits != want {
+		t.Errorf("limits: got %+v, want %+v", bp.Limits, want)
+	}
+	if _, err := Load(filepath.Join(dir, "bad.yaml")); err == nil || !strings.Contains(err.Error(), `size "lots"`) {
+		t.Errorf("expected a size error, got %v", err)
+	}
+}
+
+func TestLoadMounts(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"blueprint.yaml": `
+name: mounts
+workstations:
+  - type: sandbox
+    mount: ./workspace:/workspace
+  - type: sandbox
+    mount:
+      - ./data:/data:ro
+      - ./cache:/cache
+`,
+	})
+	bp, err := Load(filepath.Join(dir, "blueprint.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := strings.Join(bp.Workstations[0].Mount, ","); got != "./workspace:/workspace" {
+		t.Errorf("single mount: got %q", got)
+	}
+	if got := strings.Join(bp.Workstations[1].Mount, ","); got != "./data:/data:ro,./cache:/cache" {
+		t.Errorf("mount list: got %q", got)
+	}
+}
+
+func TestParseMount(t *testing.T) {
+	tests := []struct {
+		spec                  string
+		host, container, mode string
+		ok                    bool
+	}{
+		{"./data:/data", "./data", "/data", "", true},
+		{"/srv/data:/data:ro", "/srv/data", "/data", "ro", true},
+		{"./data", "", "", "", false},
+		{"./data:data", "", "", "", false},
+		{"./data:/data:rx", "", "", "", false},
+		{":/data", "", "", "", false},
+	}
+	for _, tt := range tests {
+		host, container, mode, ok := ParseMount(tt.spec)
+		if host != tt.host || container != tt.container || mode != tt.mode || ok != tt.ok {
+			t.Errorf("ParseMount(%q) = %q, %q, %q, %v", tt.spec, host, container, mode, ok)
+		}
+	}
+}
+
+func TestAgentLabel(t *testing.T) {
+	cases := []struct {
+		agent Agent
+		want  string
+	}{
+		{Agent{ID: "@data"}, "@data"},
+		{Agent{ID: "@data", Name: "Researcher"}, "Researcher"},
+		{Agent{ID: "@data", Name: "Researcher", Icon: "🔬"}, "🔬 Researcher"},
+		{Agent{ID: "@data", Icon: "🔬"}, "🔬 @data"},
+	}
+	for _, c := range cases {
+		if got := c.agent.Label(); got != c.want {
+			t.Errorf("%+v: got %q, want %q", c.agent, got, c.want)
+		}
+	}
+}
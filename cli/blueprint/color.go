@@ -0,0 +1,17 @@
+package blueprint
+
+import (
+	"regexp"
+	"slices"
+)
+
+// ColorNames are the named colors an agent's color or the palette may use.
+// Any "#rrggbb" hex color is accepted too.
+var ColorNames = []string{"red", "green", "yellow", "blue", "purple", "cyan", "gray", "white"}
+
+var hexColorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ValidColor reports whether c is a ColorNames entry or a "#rrggbb" color.
+func ValidColor(c string) bool {
+	return slices.Contains(ColorNames, c) || hexColorRe.MatchString(c)
+}
@@ -0,0 +1,158 @@
+package blueprint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadDocument reads the blueprint at path as a YAML mapping, with the
+// blueprint it extends (if any) merged underneath. chain holds the files
+// already being loaded, to detect cycles. Relative prompt_file and ca_cert
+// paths are made absolute so they stay relative to the file that names them.
+func loadDocument(path string, chain []string) (map[string]any, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(chain, abs) {
+		return nil, fmt.Errorf("extends cycle: %s", strings.Join(append(chain, abs), " -> "))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	dir := filepath.Dir(abs)
+	if file, ok := doc["ca_cert"].(string); ok && file != "" && !filepath.IsAbs(file) {
+		doc["ca_cert"] = filepath.Join(dir, file)
+	}
+	if agents, ok := doc["agents"].([]any); ok {
+		for _, a := range agents {
+			agent, ok := a.(map[string]any)
+			if !ok {
+				continue
+			}
+			for _, key := range []string{"prompt_file", "blueprint"} {
+				if file, ok := agent[key].(string); ok && file != "" && !filepath.IsAbs(file) {
+					agent[key] = filepath.Join(dir, file)
+				}
+			}
+		}
+	}
+
+	ext, ok := doc["extends"]
+	if !ok {
+		return doc, nil
+	}
+	delete(doc, "extends")
+	base, ok := ext.(string)
+	if !ok || base == "" {
+		return nil, fmt.Errorf("%s: extends must be a file path", path)
+	}
+	if !filepath.IsAbs(base) {
+		base = filepath.Join(dir, base)
+	}
+	parent, err := loadDocument(base, append(chain, abs))
+	if err != nil {
+		return nil, err
+	}
+	return mergeDocuments(parent, doc), nil
+}
+
+// mergeDocuments overlays a blueprint on the one it extends. Agents are
+// merged by id and furniture by name; everything else follows mergeValues.
+func mergeDocuments(base, over map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		switch k {
+		case "agents":
+			out[k] = mergeListByKey(base[k], v, "id")
+		case "furniture":
+			out[k] = mergeListByKey(base[k], v, "name")
+		default:
+			out[k] = mergeValues(base[k], v)
+		}
+	}
+	return out
+}
+
+// mergeValues merges mappings key by key; any other value (scalars and
+// lists alike) in over replaces the one in base.
+func mergeValues(base, over any) any {
+	bm, ok1 := base.(map[string]any)
+	om, ok2 := over.(map[string]any)
+	if !ok1 || !ok2 {
+		return over
+	}
+	out := make(map[string]any, len(bm)+len(om))
+	for k, v := range bm {
+		out[k] = v
+	}
+	for k, v := range om {
+		out[k] = mergeValues(bm[k], v)
+	}
+	return out
+}
+
+// mergeListByKey merges two lists of mappings: items of over whose key
+// matches an item of base are merged into it, the rest are appended.
+// Setting prompt or prompt_file replaces both inherited ones.
+func mergeListByKey(base, over any, key string) any {
+	ol, ok := over.([]any)
+	if !ok {
+		return over
+	}
+	bl, _ := base.([]any)
+	out := slices.Clone(bl)
+	for _, item := range ol {
+		om, ok := item.(map[string]any)
+		if !ok || om[key] == nil {
+			out = append(out, item)
+			continue
+		}
+		i := slices.IndexFunc(out, func(b any) bool {
+			bm, ok := b.(map[string]any)
+			return ok && bm[key] == om[key]
+		})
+		if i < 0 {
+			out = append(out, item)
+			continue
+		}
+		bm := out[i].(map[string]any)
+		if _, ok := om["prompt"]; ok {
+			bm = without(bm, "prompt_file")
+		}
+		if _, ok := om["prompt_file"]; ok {
+			bm = without(bm, "prompt")
+		}
+		out[i] = mergeValues(bm, om)
+	}
+	return out
+}
+
+// without returns a copy of m without key.
+func without(m map[string]any, key string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if k != key {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,254 @@
+package blueprint
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// agentIDRe matches IDs that can be addressed with an @id? mention.
+var agentIDRe = regexp.MustCompile(`^@\w+$`)
+
+// reservedIDs are mentions with a special meaning on the floor.
+var reservedIDs = map[string]bool{"@user": true, "@everyone": true, "@system": true}
+
+// ValidationError lists every problem found by Validate.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid blueprint: " + e.Problems[0]
+	}
+	return fmt.Sprintf("invalid blueprint: %d problems: %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// Validate checks the blueprint for configuration errors: missing or
+// duplicate fields and unknown enum values. It does not touch the system
+// (no PATH lookups, Docker, or network). Returns a *ValidationError
+// listing all problems, or nil. Call it on a blueprint returned by Load,
+// so that defaults are applied.
+func (bp *Blueprint) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if bp.Name == "" {
+		add("name is required")
+	}
+	if len(bp.Agents) == 0 {
+		add("at least one agent is required")
+	}
+	if proxy := os.ExpandEnv(bp.HTTPProxy); proxy != "" {
+		if u, err := url.Parse(proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			add("http_proxy %q must be a URL like http://proxy:3128", bp.HTTPProxy)
+		}
+	}
+	switch bp.Pass.Match {
+	case "", "line", "contains":
+	default:
+		add("pass.match %q must be \"line\" or \"contains\"", bp.Pass.Match)
+	}
+
+	for _, model := range slices.Sorted(maps.Keys(bp.Pricing)) {
+		if p := bp.Pricing[model]; p.Input < 0 || p.Output < 0 {
+			add("pricing %s: rates must not be negative", model)
+		}
+	}
+	for i, c := range bp.Palette {
+		if !ValidColor(c) {
+			add("palette[%d]: %s", i, colorProblem(c))
+		}
+	}
+	if bp.Limits.MaxMessageSize < 0 || bp.Limits.MaxTranscriptSize < 0 {
+		add("limits: sizes must not be negative")
+	}
+	if bp.Limits.MaxRepeats < 0 || bp.Limits.MaxRepeats == 1 {
+		add("limits: max_repeats must be at least 2")
+	}
+	if bp.Redact != nil {
+		for _, pattern := range bp.Redact.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				add("redact: pattern %q is not a valid regular expression", pattern)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, a := range bp.Agents {
+		name := fmt.Sprintf("agent %s", a.ID)
+		switch {
+		case a.ID == "":
+			name = fmt.Sprintf("agents[%d]", i)
+			add("%s: id is required", name)
+		case !agentIDRe.MatchString(a.ID):
+			add("%s: id must be @ followed by letters, digits, or underscores", name)
+		case reservedIDs[a.ID]:
+			add("%s: id is reserved", name)
+		case seen[a.ID]:
+			add("%s: duplicate id", name)
+		}
+		seen[a.ID] = true
+
+		switch a.Activation {
+		case "", "mention", "always":
+		default:
+			add("%s: activation %q must be \"mention\" or \"always\"", name, a.Activation)
+		}
+		switch a.ToolContext {
+		case "", "full", "summary", "none":
+		default:
+			add("%s: tool_context %q must be \"full\", \"summary\", or \"none\"", name, a.ToolContext)
+		}
+		switch a.ContextScope {
+		case "", "all", "involved":
+		default:
+			add("%s: context_scope %q must be \"all\" or \"involved\"", name, a.ContextScope)
+		}
+		if a.Color != "" && !ValidColor(a.Color) {
+			add("%s: color %s", name, colorProblem(a.Color))
+		}
+
+		if rl := a.RateLimit; rl != nil && (rl.MinInterval < 0 || rl.MaxConcurrent < 0) {
+			add("%s: rate_limit values must not be negative", name)
+		}
+		if a.Cooldown.Messages < 0 || a.Cooldown.Duration < 0 {
+			add("%s: cooldown must not be negative", name)
+		}
+
+		switch a.Type {
+		case "", "llm", "router":
+			if a.Endpoint == "" {
+				add("%s: no endpoint (set endpoint or defaults.endpoint)", name)
+			}
+			if a.Model == "" {
+				add("%s: no model (set model or defaults.model)", name)
+			}
+			switch a.API {
+			case "", "openai", "anthropic":
+			default:
+				add("%s: api %q must be \"openai\" or \"anthropic\"", name, a.API)
+			}
+			switch a.ToolHistoryMode {
+			case "", "native", "inline":
+			default:
+				add("%s: tool_history_mode %q must be \"native\" or \"inline\"", name, a.ToolHistoryMode)
+			}
+			for j, fb := range a.Fallbacks {
+				if fb.Endpoint == "" {
+					add("%s: fallbacks[%d]: endpoint is required", name, j)
+				}
+				switch fb.API {
+				case "", "openai", "anthropic":
+				default:
+					add("%s: fallbacks[%d]: api %q must be \"openai\" or \"anthropic\"", name, j, fb.API)
+				}
+			}
+		case "acp":
+			if a.Command == "" {
+				add("%s: ACP agents need a command", name)
+			}
+		case "floor":
+			if a.Blueprint == "" {
+				add("%s: floor agents need a blueprint", name)
+			}
+		default:
+			add("%s: type %q must be \"llm\", \"acp\", \"router\", or \"floor\"", name, a.Type)
+		}
+	}
+
+	for i, ws := range bp.Workstations {
+		if ws.Type != "sandbox" {
+			add("workstations[%d]: type %q must be \"sandbox\"", i, ws.Type)
+		}
+		for _, m := range ws.Mount {
+			if _, _, _, ok := ParseMount(m); !ok {
+				add("workstations[%d]: mount %q must be host:/container, optionally with :ro", i, m)
+			}
+		}
+		for _, pattern := range ws.Confirm {
+			if _, err := regexp.Compile(pattern); err != nil {
+				add("workstations[%d]: confirm %q is not a valid regular expression", i, pattern)
+			}
+		}
+		for _, list := range []struct {
+			field   string
+			entries []string
+		}{{"allow_commands", ws.AllowCommands}, {"deny_commands", ws.DenyCommands}} {
+			field := list.field
+			for _, entry := range list.entries {
+				pattern, isRegex := CommandPattern(entry)
+				switch {
+				case strings.TrimSpace(pattern) == "":
+					add("workstations[%d]: %s has an empty entry", i, field)
+				case isRegex:
+					if _, err := regexp.Compile(pattern); err != nil {
+						add("workstations[%d]: %s %q is not a valid regular expression", i, field, entry)
+					}
+				case strings.ContainsAny(pattern, " \t"):
+					add("workstations[%d]: %s %q must be a command name or a /regex/", i, field, entry)
+				}
+			}
+		}
+	}
+
+	furniture := make(map[string]bool)
+	for i, fd := range bp.Furniture {
+		name := fmt.Sprintf("furniture %s", fd.Name)
+		switch {
+		case fd.Name == "":
+			name = fmt.Sprintf("furniture[%d]", i)
+			add("%s: name is required", name)
+		case furniture[fd.Name]:
+			add("%s: duplicate name", name)
+		}
+		furniture[fd.Name] = true
+
+		if fd.Type == "" {
+			add("%s: type is required", name)
+		}
+		if fd.Type == "mcp" && fd.Command == "" {
+			add("%s: mcp furniture needs a command", name)
+		}
+		for _, id := range fd.AllowedAgents {
+			if !seen[id] {
+				add("%s: allowed_agents names unknown agent %q", name, id)
+			}
+		}
+	}
+	for _, a := range bp.Agents {
+		for _, peer := range slices.Sorted(maps.Keys(a.PeerToolContext)) {
+			switch level := a.PeerToolContext[peer]; {
+			case !seen[peer]:
+				add("agent %s: tool_context_overrides names unknown agent %q", a.ID, peer)
+			case level != "full" && level != "summary" && level != "none":
+				add("agent %s: tool_context_overrides[%s] %q must be \"full\", \"summary\", or \"none\"", a.ID, peer, level)
+			}
+		}
+		for _, ref := range a.Furniture {
+			f, _ := ParseFurnitureRef(ref)
+			switch {
+			case strings.Contains(f, ":"):
+				add("agent %s: furniture %q has an unknown mode (use %s:ro for read-only)", a.ID, ref, f[:strings.Index(f, ":")])
+			case !furniture[f]:
+				add("agent %s: furniture %q is not defined", a.ID, f)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// colorProblem describes an invalid color.
+func colorProblem(c string) string {
+	return fmt.Sprintf("%q must be one of %s, or #rrggbb", c, strings.Join(ColorNames, ", "))
+}
@@ -0,0 +1,75 @@
+package blueprint
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateAcceptsLoadedExample(t *testing.T) {
+	bp, err := Load("../../examples/data-analysis/blueprint.yaml")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := bp.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	bp := &Blueprint{
+		Agents: []Agent{
+			{ID: "@a", Type: "llm", Endpoint: "http://x", Model: "m", Activation: "sometimes", Furniture: []string{"tools", "notes", "tools:rw"}},
+			{ID: "@a", Type: "acp", ContextScope: "mine", Color: "teal", PeerToolContext: map[string]string{"@a": "some", "@z": "full"}},
+			{ID: "@user", Type: "llm", Endpoint: "http://x", Model: "m", Cooldown: Cooldown{Messages: -1}, ToolHistoryMode: "flat", Fallbacks: []Fallback{{Model: "m2"}, {Endpoint: "http://y", API: "grpc"}}},
+			{ID: "b", Type: "grpc"},
+		},
+		Furniture:    []FurnitureDef{{Name: "tools", Type: "mcp", AllowedAgents: []string{"@a", "@c"}}},
+		Workstations: []Workstation{{Type: "sandbox", Mount: StringList{"./data"}, Confirm: []string{`rm\s+-rf`, "reset (--hard"}, AllowCommands: []string{"ls", "git status"}, DenyCommands: []string{"/(/"}}},
+		HTTPProxy:    "proxy:3128",
+		Pricing:      map[string]ModelPrice{"m": {Input: -1}},
+		Palette:      []string{"green", "#12345"},
+		Redact:       &Redact{Patterns: []string{`ticket-\d+`, "("}},
+		Limits:       Limits{MaxMessageSize: -1, MaxRepeats: 1},
+	}
+
+	err := bp.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	want := []string{
+		"name is required",
+		`http_proxy "proxy:3128" must be a URL like http://proxy:3128`,
+		"pricing m: rates must not be negative",
+		`palette[1]: "#12345" must be one of red, green, yellow, blue, purple, cyan, gray, white, or #rrggbb`,
+		"limits: sizes must not be negative",
+		"limits: max_repeats must be at least 2",
+		`redact: pattern "(" is not a valid regular expression`,
+		`agent @a: activation "sometimes" must be "mention" or "always"`,
+		"agent @a: duplicate id",
+		`agent @a: context_scope "mine" must be "all" or "involved"`,
+		`agent @a: color "teal" must be one of red, green, yellow, blue, purple, cyan, gray, white, or #rrggbb`,
+		"agent @a: ACP agents need a command",
+		"agent @user: id is reserved",
+		"agent @user: cooldown must not be negative",
+		`agent @user: tool_history_mode "flat" must be "native" or "inline"`,
+		"agent @user: fallbacks[0]: endpoint is required",
+		`agent @user: fallbacks[1]: api "grpc" must be "openai" or "anthropic"`,
+		"agent b: id must be @ followed by letters, digits, or underscores",
+		`agent b: type "grpc" must be "llm", "acp", "router", or "floor"`,
+		`workstations[0]: mount "./data" must be host:/container, optionally with :ro`,
+		`workstations[0]: confirm "reset (--hard" is not a valid regular expression`,
+		`workstations[0]: allow_commands "git status" must be a command name or a /regex/`,
+		`workstations[0]: deny_commands "/(/" is not a valid regular expression`,
+		"furniture tools: mcp furniture needs a command",
+		`furniture tools: allowed_agents names unknown agent "@c"`,
+		`agent @a: furniture "notes" is not defined`,
+		`agent @a: furniture "tools:rw" has an unknown mode (use tools:ro for read-only)`,
+		`agent @a: tool_context_overrides[@a] "some" must be "full", "summary", or "none"`,
+		`agent @a: tool_context_overrides names unknown agent "@z"`,
+	}
+	if !reflect.DeepEqual(verr.Problems, want) {
+		t.Errorf("problems:\n got %q\nwant %q", verr.Problems, want)
+	}
+}
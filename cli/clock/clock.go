@@ -0,0 +1,181 @@
+// Package clock abstracts the system clock so time-based behavior
+// (timeouts, timestamps, elapsed times) can be tested without real waits.
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits.
+type Clock interface {
+	Now() time.Time
+	// After is time.After: it sends the time on the returned channel once
+	// d has passed.
+	After(d time.Duration) <-chan time.Time
+	// NewTimer is time.NewTimer, for waits that are stopped or reset.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a time.Timer from a Clock. As with time.Timer since Go 1.23,
+// Stop and Reset discard a time that fired but was not yet received.
+type Timer interface {
+	C() <-chan time.Time
+	// Stop stops the timer, reporting whether it was still pending.
+	Stop() bool
+	// Reset restarts the timer to fire after d, reporting whether it was
+	// still pending.
+	Reset(d time.Duration) bool
+}
+
+// Real is the system clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (r realTimer) C() <-chan time.Time        { return r.t.C }
+func (r realTimer) Stop() bool                 { return r.t.Stop() }
+func (r realTimer) Reset(d time.Duration) bool { return r.t.Reset(d) }
+
+// Fake is a Clock for tests that only moves when Advance is called. It is
+// safe for concurrent use.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // signalled when waiters are added or removed
+	now     time.Time
+	waiters []*waiter
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFake creates a fake clock that reads now until advanced.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake's current time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives the time once the fake has been
+// advanced by d. d <= 0 fires at once.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &waiter{ch: make(chan time.Time, 1)}
+	f.start(w, d)
+	return w.ch
+}
+
+// NewTimer returns a timer that fires once the fake has been advanced by d.
+// Pending timers count as waiters for BlockUntil.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &waiter{ch: make(chan time.Time, 1)}
+	f.start(w, d)
+	return &fakeTimer{f: f, w: w}
+}
+
+// start fires w after d, or at once if d <= 0. f.mu must be held.
+func (f *Fake) start(w *waiter, d time.Duration) {
+	if d <= 0 {
+		w.ch <- f.now
+		return
+	}
+	w.at = f.now.Add(d)
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+}
+
+// stop removes w from the pending waiters and discards a time it fired but
+// nobody received, reporting whether it was still pending. f.mu must be
+// held.
+func (f *Fake) stop(w *waiter) bool {
+	pending := false
+	if i := slices.Index(f.waiters, w); i >= 0 {
+		f.waiters = slices.Delete(f.waiters, i, i+1)
+		f.cond.Broadcast()
+		pending = true
+	}
+	select {
+	case <-w.ch:
+		pending = true
+	default:
+	}
+	return pending
+}
+
+type fakeTimer struct {
+	f *Fake
+	w *waiter
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.w.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	return t.f.stop(t.w)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	pending := t.f.stop(t.w)
+	t.f.start(t.w, d)
+	return pending
+}
+
+// Advance moves the fake forward by d, firing every After and timer whose
+// time has come.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- f.now
+	}
+	f.waiters = pending
+	f.cond.Broadcast()
+}
+
+// BlockUntil waits until n calls to After or timers are pending, so a test
+// can advance the clock only once the code under test is waiting on it.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// BlockUntilFewer waits until fewer than n calls to After or timers are
+// pending, such as once the code under test has stopped a timer.
+func (f *Fake) BlockUntilFewer(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) >= n {
+		f.cond.Wait()
+	}
+}
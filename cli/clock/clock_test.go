@@ -0,0 +1,98 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	f := NewFake(start)
+	short, long := f.After(time.Second), f.After(time.Minute)
+
+	f.Advance(30 * time.Second)
+	if got := f.Now(); !got.Equal(start.Add(30 * time.Second)) {
+		t.Errorf("Now = %v after advancing 30s", got)
+	}
+	select {
+	case at := <-short:
+		if !at.Equal(start.Add(30 * time.Second)) {
+			t.Errorf("short timer fired with %v", at)
+		}
+	default:
+		t.Error("the 1s timer should have fired")
+	}
+	select {
+	case <-long:
+		t.Error("the 1m timer fired early")
+	default:
+	}
+
+	f.Advance(30 * time.Second)
+	select {
+	case <-long:
+	default:
+		t.Error("the 1m timer should have fired")
+	}
+	select {
+	case <-f.After(0):
+	default:
+		t.Error("After(0) should fire at once")
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	f := NewFake(time.Now())
+	fired := make(chan struct{})
+	go func() {
+		<-f.After(time.Hour)
+		close(fired)
+	}()
+	f.BlockUntil(1)
+	f.Advance(time.Hour)
+	select {
+	case <-fired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the waiting goroutine was not woken")
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	f := NewFake(time.Now())
+	timer := f.NewTimer(time.Second)
+	fired := func() bool {
+		select {
+		case <-timer.C():
+			return true
+		default:
+			return false
+		}
+	}
+
+	f.Advance(500 * time.Millisecond)
+	if !timer.Reset(time.Second) {
+		t.Error("Reset of a pending timer should report it pending")
+	}
+	f.Advance(900 * time.Millisecond)
+	if fired() {
+		t.Error("the reset timer fired early")
+	}
+	f.Advance(100 * time.Millisecond)
+	if !fired() {
+		t.Error("the reset timer should have fired")
+	}
+
+	// A time fired but not received is discarded by Stop
+	timer.Reset(time.Second)
+	f.Advance(time.Second)
+	if !timer.Stop() {
+		t.Error("Stop should report the unreceived time as pending")
+	}
+	if fired() || timer.Stop() {
+		t.Error("a stopped timer should neither fire nor be pending")
+	}
+	f.Advance(time.Hour)
+	if fired() {
+		t.Error("a stopped timer fired")
+	}
+}
@@ -1,17 +1,46 @@
 package cmd
 
 import (
+	"bytes"
+	"embed"
 	"fmt"
 	"os"
+	"path"
+	"strings"
+	"text/template"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// templates holds the blueprints ofc init can scaffold. Each file is a
+// text/template executed with the floor name as .Name.
+//
+//go:embed templates/*.yaml
+var templates embed.FS
+
+const defaultTemplate = "assistant"
+
+var (
+	initTemplate string
+	initList     bool
 )
 
 var initCmd = &cobra.Command{
 	Use:   "init [name]",
 	Short: "Create a new blueprint",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Create blueprint.yaml in the current directory from a template.
+Use --list to see the available templates.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if initList {
+			if err := listTemplates(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		name := "my-floor"
 		if len(args) > 0 {
 			name = args[0]
@@ -23,28 +52,13 @@ var initCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		template := fmt.Sprintf(`# OFC Blueprint - %s
-# Run with: ofc run
-
-name: %s
-description: "Describe your floor here"
-
-defaults:
-  endpoint: http://localhost:11434/v1
-  model: llama3
-
-agents:
-  - id: "@assistant"
-    name: "Assistant"
-    activation: always
-    can_use_tools: false
-    temperature: 0.7
-    prompt: |
-      You are a helpful assistant.
-      Keep responses concise and helpful.
-`, name, name)
+		content, err := renderTemplate(initTemplate, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 
-		if err := os.WriteFile(filename, []byte(template), 0644); err != nil {
+		if err := os.WriteFile(filename, content, 0644); err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating blueprint: %v\n", err)
 			os.Exit(1)
 		}
@@ -53,3 +67,61 @@ agents:
 		fmt.Println("Run with: ofc run")
 	},
 }
+
+// templateNames returns the names of the embedded templates, sorted.
+func templateNames() ([]string, error) {
+	entries, err := templates.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	return names, nil
+}
+
+// renderTemplate executes the named template for a floor called name.
+func renderTemplate(tmplName, name string) ([]byte, error) {
+	src, err := templates.ReadFile(path.Join("templates", tmplName+".yaml"))
+	if err != nil {
+		names, _ := templateNames()
+		return nil, fmt.Errorf("unknown template %q (available: %s)", tmplName, strings.Join(names, ", "))
+	}
+	tmpl, err := template.New(tmplName).Parse(string(src))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ Name string }{name}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// listTemplates prints each template with the description from its blueprint.
+func listTemplates() error {
+	names, err := templateNames()
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		content, err := renderTemplate(n, n)
+		if err != nil {
+			return err
+		}
+		var bp struct {
+			Description string `yaml:"description"`
+		}
+		if err := yaml.Unmarshal(content, &bp); err != nil {
+			return fmt.Errorf("template %s: %w", n, err)
+		}
+		fmt.Printf("  %-12s %s\n", n, bp.Description)
+	}
+	return nil
+}
+
+func init() {
+	initCmd.Flags().StringVarP(&initTemplate, "template", "t", defaultTemplate, "Template to start from (see --list)")
+	initCmd.Flags().BoolVar(&initList, "list", false, "List the available templates")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/floor"
+	"github.com/spf13/cobra"
+)
+
+var lintStrict bool
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check a blueprint's routing and capabilities for common mistakes",
+	Long: `Check a blueprint for settings that are valid but probably wrong:
+mention agents no other agent knows about, tool-using agents without
+a sandbox, and settings an agent's backend ignores. Warnings don't change the exit
+code unless --strict is given; an invalid blueprint always does.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		bp, err := blueprint.Load(blueprintFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading blueprint: %v\n", err)
+			os.Exit(1)
+		}
+		if err := bp.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Run ofc validate for details")
+			os.Exit(1)
+		}
+
+		warnings := floor.Lint(bp)
+		if len(warnings) == 0 {
+			fmt.Printf("%s: OK\n", blueprintFile)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d warning(s)\n", blueprintFile, len(warnings))
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "  - %s\n", w)
+		}
+		if lintStrict {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVarP(&blueprintFile, "file", "f", "blueprint.yaml", "Blueprint file")
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Exit non-zero if there are warnings")
+}
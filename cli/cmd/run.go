@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/openfloorcontrol/ofc/blueprint"
@@ -16,8 +20,51 @@ var (
 	debug         bool
 	logFile       string
 	useTUI        bool
+	quiet         bool
+	outputFormat  string
+	historyFile   string
+	historySkip   bool
+	requireAnswer bool
+	runTimeout    time.Duration
+	serveToken    string
+	serveAddr     string
+	serveUnsafe   bool
+	idleTimeout   time.Duration
+	idleCancel    bool
+	auditFile     string
+	auditRedact   []string
+	seed          int
+	seedSet       bool // --seed was given
+	colorMode     string
+	timestamps    bool
 )
 
+// Exit codes of ofc run. Documented in the README; keep them stable.
+const (
+	exitError       = 1   // blueprint, startup, or other floor error
+	exitAgentFailed = 2   // a one-shot run ended with an agent error
+	exitNoAnswer    = 3   // --require-answer and no agent replied
+	exitTimeout     = 124 // --timeout expired (same as timeout(1))
+	exitCancelled   = 130 // the turn was cancelled with Ctrl-C
+)
+
+// exitCode maps an error returned by Coordinator.Run to an exit code.
+func exitCode(err error) int {
+	var failed *floor.AgentFailedError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.Is(err, floor.ErrTurnCancelled):
+		return exitCancelled
+	case errors.As(err, &failed):
+		return exitAgentFailed
+	case errors.Is(err, floor.ErrNoAnswer):
+		return exitNoAnswer
+	default:
+		return exitError
+	}
+}
+
 var runCmd = &cobra.Command{
 	Use:   "run [prompt]",
 	Short: "Run a floor",
@@ -32,26 +79,128 @@ var runCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		seedSet = cmd.Flags().Changed("seed")
+
 		// Get initial prompt if provided
 		var initialPrompt string
 		if len(args) > 0 {
 			initialPrompt = args[0]
 		}
 
-		if useTUI {
+		if runTimeout > 0 && initialPrompt == "" {
+			fmt.Fprintln(os.Stderr, "Error: --timeout needs a prompt argument")
+			os.Exit(1)
+		}
+
+		mode, err := floor.ParseColorMode(colorMode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --color: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch {
+		case outputFormat == "json":
+			runJSON(bp, initialPrompt)
+		case outputFormat != "text":
+			fmt.Fprintf(os.Stderr, "Error: unknown --output %q (want text or json)\n", outputFormat)
+			os.Exit(1)
+		case useTUI:
 			runTUI(bp, initialPrompt)
-		} else {
-			co := floor.NewCoordinator(bp, debug, logFile)
-			if err := co.Run(initialPrompt); err != nil {
+		default:
+			var co *floor.Coordinator
+			if quiet {
+				co = floor.NewQuietCoordinator(bp, debug, logFile)
+			} else {
+				co = floor.NewCoordinator(bp, debug, logFile)
+			}
+			co.RequireAnswer(requireAnswer)
+			co.SetColorMode(mode)
+			co.SetTimestamps(timestamps)
+			co.SetAPIToken(serveToken)
+			co.SetAPIAddr(serveAddr, serveUnsafe)
+			co.SetIdleTimeout(idleTimeout, idleCancel)
+			setAuditLog(co)
+			setSeed(co)
+			co.EnableReload(blueprintFile)
+			if err := runFloor(co, initialPrompt); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				os.Exit(1)
+				os.Exit(exitCode(err))
 			}
 		}
 	},
 }
 
+// setAuditLog opens the --audit-log file for co, exiting if it can't.
+func setAuditLog(co *floor.Coordinator) {
+	if auditFile == "" {
+		return
+	}
+	audit, err := floor.OpenAuditLog(auditFile, auditRedact)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot open audit log: %v\n", err)
+		os.Exit(1)
+	}
+	co.SetAuditLog(audit)
+}
+
+// setSeed applies --seed to co, if given.
+func setSeed(co *floor.Coordinator) {
+	if seedSet {
+		co.SetSeed(seed)
+	}
+}
+
+// runFloor runs the floor, bounded by --timeout if set.
+func runFloor(co *floor.Coordinator, initialPrompt string) error {
+	ctx := context.Background()
+	if runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, runTimeout)
+		defer cancel()
+	}
+	return co.RunContext(ctx, initialPrompt)
+}
+
+// runJSON runs a one-shot floor and prints a JSON summary (replies, token
+// usage, errors) to stdout. Exits non-zero if any error occurred (see
+// exitCode; errors from earlier in the run that the floor recovered from
+// count as exitAgentFailed).
+func runJSON(bp *blueprint.Blueprint, initialPrompt string) {
+	if initialPrompt == "" {
+		fmt.Fprintln(os.Stderr, "Error: --output json needs a prompt argument")
+		os.Exit(1)
+	}
+
+	frontend := floor.NewJSONFrontend(os.Stdout, logFile, debug)
+	co := floor.NewCoordinatorWith(bp, frontend, frontend, nil, frontend.LogWriter(), os.Stderr)
+	co.RequireAnswer(requireAnswer)
+	co.SetAPIToken(serveToken)
+	co.SetAPIAddr(serveAddr, serveUnsafe)
+	co.SetIdleTimeout(idleTimeout, idleCancel)
+	setAuditLog(co)
+	setSeed(co)
+	err := runFloor(co, initialPrompt)
+	var failed *floor.AgentFailedError
+	if err != nil && !errors.As(err, &failed) {
+		// Agent errors are already in the result.
+		frontend.Fail(err)
+	}
+	frontend.Close()
+	switch {
+	case err != nil:
+		os.Exit(exitCode(err))
+	case frontend.Failed():
+		os.Exit(exitAgentFailed)
+	}
+}
+
 func runTUI(bp *blueprint.Blueprint, initialPrompt string) {
-	frontend, model := floor.NewTUIFrontend(logFile, debug, floor.BuildColorMap(bp))
+	frontend, model := floor.NewTUIFrontend(logFile, debug, floor.BuildColorMap(bp), floor.AgentIDs(bp))
+	model.SetLabels(floor.BuildLabelMap(bp))
+	model.SetTimestamps(timestamps)
+	if err := model.LoadHistory(historyFile, historySkip); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot read history file %s: %v\n", historyFile, err)
+	}
 
 	p := tea.NewProgram(model,
 		tea.WithAltScreen(),
@@ -75,12 +224,22 @@ func runTUI(bp *blueprint.Blueprint, initialPrompt string) {
 	}
 
 	co := floor.NewCoordinatorWith(bp, frontend, frontend, debugFn, frontend.LogWriter(), stderrWriter)
+	co.RequireAnswer(requireAnswer)
+	co.SetAPIToken(serveToken)
+	co.SetAPIAddr(serveAddr, serveUnsafe)
+	co.SetIdleTimeout(idleTimeout, idleCancel)
+	setAuditLog(co)
+	setSeed(co)
+	co.EnableReload(blueprintFile)
 
 	// Run coordinator in background goroutine
+	runErr := make(chan error, 1)
 	go func() {
-		if err := co.Run(initialPrompt); err != nil {
+		err := runFloor(co, initialPrompt)
+		if err != nil {
 			p.Send(floor.SystemInfo{Text: fmt.Sprintf("[ERROR: %v]", err)})
 		}
+		runErr <- err
 		// Coordinator finished — quit the TUI
 		p.Send(floor.FloorStopped{})
 	}()
@@ -90,6 +249,20 @@ func runTUI(bp *blueprint.Blueprint, initialPrompt string) {
 		fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
 		os.Exit(1)
 	}
+	if summary := model.Summary(); summary != "" {
+		fmt.Println(summary)
+	}
+
+	// The TUI may also be closed by the user while the floor is still
+	// running; only report how the floor ended if it did.
+	select {
+	case err := <-runErr:
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+	default:
+	}
 }
 
 func init() {
@@ -97,4 +270,29 @@ func init() {
 	runCmd.Flags().BoolVar(&debug, "debug", false, "Enable debug output")
 	runCmd.Flags().StringVar(&logFile, "log", "", "Log output to file (plain text, no colors)")
 	runCmd.Flags().BoolVar(&useTUI, "tui", false, "Use terminal UI with split layout")
+	runCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text, or json (one-shot runs; prints a summary at the end)")
+	runCmd.Flags().StringVar(&colorMode, "color", "auto", "Color output and highlight code: auto (when stdout is a terminal and NO_COLOR is unset), always, or never")
+	runCmd.Flags().BoolVar(&timestamps, "timestamps", false, "Show the clock time before each agent label and system line (the --log file always has them)")
+	runCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print only agent replies (no system messages, tool output, or thinking indicators)")
+	runCmd.Flags().BoolVar(&requireAnswer, "require-answer", false, "With a prompt argument, exit non-zero if no agent replies with content")
+	runCmd.Flags().DurationVar(&runTimeout, "timeout", 0, "With a prompt argument, stop the run after this long (e.g. 5m; 0 for no limit)")
+	runCmd.Flags().StringVar(&serveToken, "serve-token", "", "Require this bearer token on the furniture API server (overrides the blueprint's api_token)")
+	runCmd.Flags().DurationVar(&idleTimeout, "idle-timeout", 0, "Warn when an agent produces no output for this long (e.g. 2m; 0 to disable)")
+	runCmd.Flags().BoolVar(&idleCancel, "idle-cancel", false, "With --idle-timeout, cancel the idle agent's turn instead of only warning")
+	runCmd.Flags().StringVar(&serveAddr, "serve-addr", "", "Furniture API server address (default 127.0.0.1 on a random port; a bare :port binds 127.0.0.1)")
+	runCmd.Flags().BoolVar(&serveUnsafe, "serve-unsafe", false, "Allow --serve-addr to bind a non-loopback address, exposing furniture to the network")
+	runCmd.Flags().StringVar(&auditFile, "audit-log", "", "Append a JSON line per agent tool call (agent, tool, arguments, result, time) to this file")
+	runCmd.Flags().StringSliceVar(&auditRedact, "audit-redact", nil, "Argument keys whose values the audit log replaces with [REDACTED] (e.g. password,token)")
+	runCmd.Flags().IntVar(&seed, "seed", 0, "Send this sampling seed with every LLM request, overriding the agents' seed, for reproducible runs (backend permitting)")
+	runCmd.Flags().StringVar(&historyFile, "history", defaultHistoryFile(), "TUI input history file (empty to disable)")
+	runCmd.Flags().BoolVar(&historySkip, "history-skip-commands", false, "Don't record /commands in TUI history")
+}
+
+// defaultHistoryFile returns ~/.ofc/history, or "" if there is no home directory.
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ofc", "history")
 }
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/furniture"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a blueprint without running it",
+	Long: `Check a blueprint without running it: the configuration itself, and
+whether the commands it needs (docker, ACP agents, MCP servers) are on
+the PATH. Nothing is started. Exits non-zero if there is any problem.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		bp, err := blueprint.Load(blueprintFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading blueprint: %v\n", err)
+			os.Exit(1)
+		}
+
+		problems := validateBlueprint(bp)
+		if len(problems) == 0 {
+			fmt.Printf("%s: OK\n", blueprintFile)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d problem(s)\n", blueprintFile, len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "  - %s\n", p)
+		}
+		os.Exit(1)
+	},
+}
+
+// validateBlueprint returns every problem with bp: the errors from
+// Blueprint.Validate, then anything the floor would fail to resolve at
+// startup. It only looks things up; nothing is launched.
+func validateBlueprint(bp *blueprint.Blueprint) []string {
+	var problems []string
+	if err := bp.Validate(); err != nil {
+		var verr *blueprint.ValidationError
+		if !errors.As(err, &verr) {
+			return []string{err.Error()}
+		}
+		problems = append(problems, verr.Problems...)
+	}
+
+	lookPath := func(what, command string) {
+		if command == "" {
+			return // reported by Validate
+		}
+		if _, err := exec.LookPath(command); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: command %q not found", what, command))
+		}
+	}
+
+	if bp.CACert != "" {
+		if _, err := os.Stat(bp.CACert); err != nil {
+			problems = append(problems, fmt.Sprintf("ca_cert %s not found", bp.CACert))
+		}
+	}
+
+	for _, ws := range bp.Workstations {
+		if ws.Type != "sandbox" {
+			continue
+		}
+		lookPath("sandbox", "docker")
+		if ws.Dockerfile != "" {
+			if _, err := os.Stat(ws.Dockerfile); err != nil {
+				problems = append(problems, fmt.Sprintf("sandbox: dockerfile %s not found", ws.Dockerfile))
+			}
+		}
+		for _, m := range ws.Mount {
+			if host, _, _, ok := blueprint.ParseMount(m); ok {
+				if _, err := os.Stat(host); err != nil {
+					problems = append(problems, fmt.Sprintf("sandbox: mount %s: %s not found", m, host))
+				}
+			}
+		}
+		break // the floor only uses the first sandbox
+	}
+
+	for _, a := range bp.Agents {
+		if a.Type == "acp" {
+			lookPath("agent "+a.ID, a.Command)
+		}
+	}
+
+	types := furniture.DefaultRegistry.Types()
+	for _, fd := range bp.Furniture {
+		if fd.Type != "" && !slices.Contains(types, fd.Type) {
+			problems = append(problems, fmt.Sprintf("furniture %s: unknown type %q", fd.Name, fd.Type))
+		}
+		if fd.Type == "mcp" {
+			lookPath("furniture "+fd.Name, fd.Command)
+		}
+	}
+
+	return problems
+}
+
+func init() {
+	validateCmd.Flags().StringVarP(&blueprintFile, "file", "f", "blueprint.yaml", "Blueprint file")
+}
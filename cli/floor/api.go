@@ -2,18 +2,67 @@ package floor
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/openfloorcontrol/ofc/furniture"
 )
 
 // APIServer serves MCP endpoints for furniture over HTTP.
 type APIServer struct {
-	echo     *echo.Echo
-	listener net.Listener
+	echo          *echo.Echo
+	listener      net.Listener
+	allowExternal bool // Start may bind a non-loopback address
+
+	// ShutdownTimeout bounds how long Stop waits for requests in flight
+	// before closing their connections (0 = DefaultShutdownTimeout).
+	ShutdownTimeout time.Duration
+
+	audit    *AuditLog // records furniture calls; nil = off
+	redactor *Redactor // redacts audited arguments and results
+
+	mu     sync.RWMutex
+	floors map[string]map[string][]furniture.Tool // floor -> furniture name -> tools (nil if unknown)
+}
+
+// AgentHeader names the agent calling furniture through the server. The
+// floor sets it on ACP agents' MCP servers, so their calls are audited
+// under their ID.
+const AgentHeader = "X-Ofc-Agent"
+
+// DefaultShutdownTimeout is how long Stop waits for requests in flight.
+const DefaultShutdownTimeout = 5 * time.Second
+
+// FloorListing is the response of GET /api/v1/floors/{floor}.
+type FloorListing struct {
+	Floor     string             `json:"floor"`
+	Furniture []FurnitureListing `json:"furniture"`
+}
+
+// FurnitureListing describes one piece of furniture in a FloorListing.
+type FurnitureListing struct {
+	Name  string        `json:"name"`
+	MCP   string        `json:"mcp"` // Streamable HTTP endpoint path
+	SSE   string        `json:"sse"` // SSE endpoint path
+	Tools []ToolListing `json:"tools"`
+}
+
+// ToolListing describes one furniture tool.
+type ToolListing struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters,omitempty"` // JSON Schema
 }
 
 // NewAPIServer creates a new API server.
@@ -21,7 +70,25 @@ func NewAPIServer() *APIServer {
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
-	return &APIServer{echo: e}
+	s := &APIServer{echo: e, floors: make(map[string]map[string][]furniture.Tool)}
+	e.GET("/api/v1/floors/:floor", s.listFloor)
+	return s
+}
+
+// SetAudit records every call to furniture registered with RegisterFloor
+// in a, tagged with the calling agent (see AgentHeader), with secrets
+// redacted by r. Call it before RegisterFloor.
+func (s *APIServer) SetAudit(a *AuditLog, r *Redactor) {
+	s.audit, s.redactor = a, r
+}
+
+// RegisterFloor wraps each piece of furniture as an MCP server and
+// registers it on the floor (see RegisterFurniture), keyed by its map key.
+func (s *APIServer) RegisterFloor(floor string, fs map[string]furniture.Furniture) {
+	for name, f := range fs {
+		s.register(floor, name, s.serverFor(name, f))
+		s.setTools(floor, name, f.Tools())
+	}
 }
 
 // RegisterFurniture adds MCP endpoints for a piece of furniture.
@@ -29,7 +96,78 @@ func NewAPIServer() *APIServer {
 //   - /api/v1/floors/{floor}/mcp/{name}/ — Streamable HTTP
 //   - /api/v1/floors/{floor}/sse/{name}/ — SSE (legacy, used by claude-code-acp)
 func (s *APIServer) RegisterFurniture(floor, name string, mcpSrv *mcp.Server) {
-	getServer := func(r *http.Request) *mcp.Server { return mcpSrv }
+	s.setTools(floor, name, nil)
+	s.register(floor, name, func(*http.Request) *mcp.Server { return mcpSrv })
+}
+
+// serverFor returns the MCP server handling each request to a piece of
+// furniture. With an audit log, each calling agent gets its own, which
+// records the agent's calls.
+func (s *APIServer) serverFor(name string, f furniture.Furniture) func(*http.Request) *mcp.Server {
+	if s.audit == nil {
+		srv := furniture.WrapAsMCP(f)
+		return func(*http.Request) *mcp.Server { return srv }
+	}
+	var mu sync.Mutex
+	byAgent := make(map[string]*mcp.Server)
+	return func(r *http.Request) *mcp.Server {
+		agentID := r.Header.Get(AgentHeader)
+		mu.Lock()
+		defer mu.Unlock()
+		srv, ok := byAgent[agentID]
+		if !ok {
+			srv = furniture.WrapAsMCP(f)
+			srv.AddReceivingMiddleware(s.auditCalls(agentID, name))
+			byAgent[agentID] = srv
+		}
+		return srv
+	}
+}
+
+// auditCalls is MCP middleware recording agentID's tool calls to the named
+// furniture, as LLMRunner records the calls LLM agents make.
+func (s *APIServer) auditCalls(agentID, name string) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			res, err := next(ctx, method, req)
+			call, ok := req.(*mcp.CallToolRequest)
+			if !ok {
+				return res, err
+			}
+			entry := AuditEntry{AgentID: agentID, Tool: name + "." + call.Params.Name}
+			var args map[string]any
+			if json.Unmarshal(call.Params.Arguments, &args) == nil && args != nil {
+				entry.Args = s.redactor.redactArgs(args).(map[string]any)
+			}
+			result, _ := res.(*mcp.CallToolResult)
+			switch {
+			case err != nil:
+				entry.Error = err.Error()
+			case result != nil && result.IsError:
+				entry.Error = s.redactor.Redact(resultText(result))
+			case result != nil:
+				entry.Result = s.redactor.Redact(resultText(result))
+			}
+			s.audit.Record(entry)
+			return res, err
+		}
+	}
+}
+
+// resultText joins the text content of a tool result.
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if tc, ok := c.(*mcp.TextContent); ok {
+			parts = append(parts, tc.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// register serves the MCP endpoints of a piece of furniture, taking each
+// request's server from getServer.
+func (s *APIServer) register(floor, name string, getServer func(*http.Request) *mcp.Server) {
 
 	// Streamable HTTP endpoint
 	httpPath := fmt.Sprintf("/api/v1/floors/%s/mcp/%s", floor, name)
@@ -46,9 +184,74 @@ func (s *APIServer) RegisterFurniture(floor, name string, mcpSrv *mcp.Server) {
 	s.echo.Any(ssePath+"/", echo.WrapHandler(sseHandler))
 }
 
+// setTools records a registered furniture for the floor listing.
+func (s *APIServer) setTools(floor, name string, tools []furniture.Tool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.floors[floor] == nil {
+		s.floors[floor] = make(map[string][]furniture.Tool)
+	}
+	s.floors[floor][name] = tools
+}
+
+// listFloor serves the furniture registered on a floor, sorted by name,
+// with their endpoints and tool schemas. Tools are listed only for
+// furniture registered with RegisterFloor.
+func (s *APIServer) listFloor(c echo.Context) error {
+	floor := c.Param("floor")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fs, ok := s.floors[floor]
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("no floor %q", floor))
+	}
+
+	listing := FloorListing{Floor: floor, Furniture: []FurnitureListing{}}
+	for name, tools := range fs {
+		fl := FurnitureListing{
+			Name:  name,
+			MCP:   fmt.Sprintf("/api/v1/floors/%s/mcp/%s/", floor, name),
+			SSE:   fmt.Sprintf("/api/v1/floors/%s/sse/%s", floor, name),
+			Tools: []ToolListing{},
+		}
+		for _, t := range tools {
+			fl.Tools = append(fl.Tools, ToolListing{Name: t.Name, Description: t.Description, Parameters: t.Parameters})
+		}
+		listing.Furniture = append(listing.Furniture, fl)
+	}
+	sort.Slice(listing.Furniture, func(i, j int) bool { return listing.Furniture[i].Name < listing.Furniture[j].Name })
+	return c.JSON(http.StatusOK, listing)
+}
+
+// RequireToken makes every endpoint require an "Authorization: Bearer
+// <token>" header; other requests get 401 Unauthorized. Call it before
+// Start. Without it the server is open to anyone who can reach the port.
+func (s *APIServer) RequireToken(token string) {
+	s.echo.Use(middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		Validator: func(key string, c echo.Context) (bool, error) {
+			return subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1, nil
+		},
+		ErrorHandler: func(err error, c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+			return echo.NewHTTPError(http.StatusUnauthorized, "Unauthorized")
+		},
+	}))
+}
+
+// AllowExternal lets Start bind addresses other than loopback, exposing
+// the furniture to the network. Pair it with RequireToken.
+func (s *APIServer) AllowExternal(allow bool) {
+	s.allowExternal = allow
+}
+
 // Start begins listening in a background goroutine on the given address.
-// Pass ":0" for auto-assigned port.
+// A bare port (":0" for auto-assigned) binds 127.0.0.1. Non-loopback
+// addresses are refused unless AllowExternal was called.
 func (s *APIServer) Start(addr string) error {
+	addr, err := s.checkAddr(addr)
+	if err != nil {
+		return err
+	}
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
 		return fmt.Errorf("failed to listen on %s: %w", addr, err)
@@ -59,18 +262,82 @@ func (s *APIServer) Start(addr string) error {
 	return nil
 }
 
-// Stop shuts down the server.
+// checkAddr validates a listen address and fills in the default host.
+func (s *APIServer) checkAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid API server address %q: %w", addr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if !isLoopback(host) && !s.allowExternal {
+		return "", fmt.Errorf("refusing to expose the API server on %s: only loopback addresses are allowed without opting in", addr)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Addr returns the address the server is bound to, or "" before Start.
+func (s *APIServer) Addr() string {
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Exposed reports whether the server listens on a non-loopback address.
+func (s *APIServer) Exposed() bool {
+	if s.listener == nil {
+		return false
+	}
+	host, _, _ := net.SplitHostPort(s.listener.Addr().String())
+	return !isLoopback(host)
+}
+
+// isLoopback reports whether host is "localhost" or a loopback IP.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Stop shuts down the server, letting requests in flight finish for up to
+// ShutdownTimeout. Connections still open then (a stuck MCP call, an SSE
+// stream) are closed, and Stop reports that it had to force them.
 func (s *APIServer) Stop() error {
-	if s.echo != nil {
-		return s.echo.Shutdown(context.Background())
+	if s.echo == nil {
+		return nil
 	}
-	return nil
+	timeout := s.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := s.echo.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		if closeErr := s.echo.Close(); closeErr != nil {
+			return fmt.Errorf("API server shutdown timed out after %v, and closing failed: %w", timeout, closeErr)
+		}
+		return fmt.Errorf("API server shutdown timed out after %v; closed open connections", timeout)
+	}
+	return err
 }
 
-// BaseURL returns the base URL of the running server (e.g. "http://localhost:12345").
+// BaseURL returns the base URL of the running server (e.g.
+// "http://127.0.0.1:12345"). For a wildcard address such as 0.0.0.0 it
+// uses 127.0.0.1, so local agents can still connect.
 func (s *APIServer) BaseURL() string {
 	if s.listener == nil {
 		return ""
 	}
-	return fmt.Sprintf("http://%s", s.listener.Addr().String())
+	addr := s.listener.Addr().(*net.TCPAddr)
+	host := addr.IP.String()
+	if addr.IP.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	return fmt.Sprintf("http://%s", net.JoinHostPort(host, fmt.Sprint(addr.Port)))
 }
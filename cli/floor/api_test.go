@@ -1,10 +1,17 @@
 package floor
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/openfloorcontrol/ofc/blueprint"
 	"github.com/openfloorcontrol/ofc/furniture"
 )
 
@@ -115,3 +122,256 @@ func contentText(result *mcp.CallToolResult) string {
 	}
 	return "<non-text content>"
 }
+
+// bearerTransport adds an Authorization header to every request.
+type bearerTransport struct {
+	token string
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// agentTransport sets AgentHeader on every request, as ACP agents do.
+type agentTransport struct {
+	agentID string
+}
+
+func (t agentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(AgentHeader, t.agentID)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestAPIServerAuditsFurnitureCalls(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := NewRedactor(&blueprint.Blueprint{Redact: &blueprint.Redact{Secrets: true}})
+	api := NewAPIServer()
+	api.SetAudit(NewAuditLog(&buf, nil), r)
+	api.RegisterFloor("default", map[string]furniture.Furniture{"tasks": furniture.NewTaskBoard()})
+	if err := api.Start(":0"); err != nil {
+		t.Fatalf("failed to start API server: %v", err)
+	}
+	defer api.Stop()
+
+	base := api.BaseURL() + "/api/v1/floors/default/"
+	transports := map[string]mcp.Transport{
+		"@http": &mcp.StreamableClientTransport{
+			Endpoint:   base + "mcp/tasks/",
+			HTTPClient: &http.Client{Transport: agentTransport{"@http"}},
+		},
+		"@sse": &mcp.SSEClientTransport{
+			Endpoint:   base + "sse/tasks",
+			HTTPClient: &http.Client{Transport: agentTransport{"@sse"}},
+		},
+	}
+	for _, agentID := range []string{"@http", "@sse"} {
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+		session, err := client.Connect(context.Background(), transports[agentID], nil)
+		if err != nil {
+			t.Fatalf("%s: connect: %v", agentID, err)
+		}
+		for _, params := range []*mcp.CallToolParams{
+			{Name: "add_task", Arguments: map[string]any{"title": "rotate sk-ant-REDACTED"}},
+			{Name: "get_task", Arguments: map[string]any{"id": 99}},
+		} {
+			if _, err := session.CallTool(context.Background(), params); err != nil {
+				t.Fatalf("%s: %s: %v", agentID, params.Name, err)
+			}
+		}
+		session.Close()
+	}
+
+	if strings.Contains(buf.String(), "abcdefghijklmnopqrstuvwxyz") {
+		t.Errorf("secret in audit log: %s", buf.String())
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("bad audit line %q: %v", line, err)
+		}
+		got = append(got, fmt.Sprintf("%s %s %v %t", e.AgentID, e.Tool, e.Args, e.Error != ""))
+	}
+	want := []string{
+		"@http tasks.add_task map[title:rotate [REDACTED]] false",
+		"@http tasks.get_task map[id:99] true",
+		"@sse tasks.add_task map[title:rotate [REDACTED]] false",
+		"@sse tasks.get_task map[id:99] true",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestAPIServerRequireToken(t *testing.T) {
+	api := NewAPIServer()
+	api.RegisterFurniture("default", "tasks", furniture.WrapAsMCP(furniture.NewTaskBoard()))
+	api.RequireToken("s3cret")
+	if err := api.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("failed to start API server: %v", err)
+	}
+	defer api.Stop()
+	endpoint := api.BaseURL() + "/api/v1/floors/default/mcp/tasks/"
+
+	for name, header := range map[string]string{
+		"missing": "",
+		"wrong":   "Bearer nope",
+		"basic":   "Basic czNjcmV0",
+	} {
+		req, _ := http.NewRequest("POST", endpoint, strings.NewReader("{}"))
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s token: expected 401, got %d", name, resp.StatusCode)
+		}
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Transport: bearerTransport{token: "s3cret"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("connect with token: %v", err)
+	}
+	defer session.Close()
+	if _, err := session.ListTools(context.Background(), nil); err != nil {
+		t.Fatalf("ListTools with token: %v", err)
+	}
+}
+
+func TestAPIServerCheckAddr(t *testing.T) {
+	tests := []struct {
+		addr, want    string
+		allowExternal bool
+		wantErr       bool
+	}{
+		{addr: ":0", want: "127.0.0.1:0"},
+		{addr: ":8080", want: "127.0.0.1:8080"},
+		{addr: "localhost:8080", want: "localhost:8080"},
+		{addr: "[::1]:8080", want: "[::1]:8080"},
+		{addr: "0.0.0.0:8080", wantErr: true},
+		{addr: "192.168.1.5:8080", wantErr: true},
+		{addr: "0.0.0.0:8080", allowExternal: true, want: "0.0.0.0:8080"},
+		{addr: "8080", wantErr: true},
+	}
+	for _, tt := range tests {
+		s := NewAPIServer()
+		s.AllowExternal(tt.allowExternal)
+		got, err := s.checkAddr(tt.addr)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("checkAddr(%q, external=%v) = %q, %v", tt.addr, tt.allowExternal, got, err)
+		}
+	}
+}
+
+func TestAPIServerDefaultsToLoopback(t *testing.T) {
+	api := NewAPIServer()
+	if err := api.Start(":0"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer api.Stop()
+	if !strings.HasPrefix(api.Addr(), "127.0.0.1:") || api.Exposed() {
+		t.Errorf("expected a loopback bind, got %s (exposed=%v)", api.Addr(), api.Exposed())
+	}
+}
+
+func TestAPIServerExternalBaseURL(t *testing.T) {
+	api := NewAPIServer()
+	api.AllowExternal(true)
+	if err := api.Start("0.0.0.0:0"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer api.Stop()
+	if !api.Exposed() {
+		t.Error("0.0.0.0 should count as exposed")
+	}
+	if !strings.HasPrefix(api.BaseURL(), "http://127.0.0.1:") {
+		t.Errorf("BaseURL should stay reachable locally, got %s", api.BaseURL())
+	}
+}
+
+func TestAPIServerRegisterFloorListing(t *testing.T) {
+	api := NewAPIServer()
+	api.RegisterFloor("default", map[string]furniture.Furniture{
+		"tasks": furniture.NewTaskBoard(),
+		"calc":  furniture.NewCalculator("calc"),
+	})
+	if err := api.Start(":0"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer api.Stop()
+
+	resp, err := http.Get(api.BaseURL() + "/api/v1/floors/default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var listing FloorListing
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		t.Fatalf("decode listing: %v", err)
+	}
+	if len(listing.Furniture) != 2 || listing.Furniture[0].Name != "calc" || listing.Furniture[1].Name != "tasks" {
+		t.Fatalf("expected calc and tasks, got %+v", listing.Furniture)
+	}
+	tasks := listing.Furniture[1]
+	if len(tasks.Tools) != 4 || tasks.MCP != "/api/v1/floors/default/mcp/tasks/" {
+		t.Errorf("unexpected tasks listing: %+v", tasks)
+	}
+	if tasks.Tools[0].Parameters == nil {
+		t.Errorf("tool %s has no schema", tasks.Tools[0].Name)
+	}
+
+	// The listed endpoint serves MCP.
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{Endpoint: api.BaseURL() + tasks.MCP}, nil)
+	if err != nil {
+		t.Fatalf("connect to listed endpoint: %v", err)
+	}
+	session.Close()
+
+	resp, err = http.Get(api.BaseURL() + "/api/v1/floors/nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown floor: expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIServerStopTimesOutStuckRequest(t *testing.T) {
+	api := NewAPIServer()
+	api.ShutdownTimeout = 50 * time.Millisecond
+	api.RegisterFloor("default", map[string]furniture.Furniture{"tasks": furniture.NewTaskBoard()})
+	if err := api.Start(":0"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// An SSE stream stays open until the server closes it.
+	resp, err := http.Get(api.BaseURL() + "/api/v1/floors/default/sse/tasks")
+	if err != nil {
+		t.Fatalf("open SSE stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- api.Stop() }()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("expected a shutdown timeout error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop hung on an open SSE stream")
+	}
+}
@@ -0,0 +1,108 @@
+package floor
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEntry records one tool call made by an agent.
+type AuditEntry struct {
+	Time    time.Time      `json:"time"`
+	AgentID string         `json:"agent"`
+	Tool    string         `json:"tool"` // "furniture.tool", or "bash"
+	Args    map[string]any `json:"args,omitempty"`
+	Result  string         `json:"result,omitempty"` // as returned to the agent
+	Error   string         `json:"error,omitempty"`
+}
+
+// Redacted replaces the values of redacted argument keys.
+const Redacted = "[REDACTED]"
+
+// AuditLog writes an AuditEntry per tool call as JSON lines, for reviewing
+// afterwards what agents actually did. Argument values under the redacted
+// keys (matched case-insensitively, at any depth) are replaced with
+// Redacted. A nil *AuditLog records nothing.
+type AuditLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	redact map[string]bool
+	now    func() time.Time
+}
+
+// NewAuditLog creates an audit log writing to w.
+func NewAuditLog(w io.Writer, redactKeys []string) *AuditLog {
+	a := &AuditLog{w: w, redact: make(map[string]bool), now: time.Now}
+	for _, k := range redactKeys {
+		a.redact[strings.ToLower(k)] = true
+	}
+	return a
+}
+
+// OpenAuditLog creates an audit log appending to the file at path.
+func OpenAuditLog(path string, redactKeys []string) (*AuditLog, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditLog(f, redactKeys), nil
+}
+
+// Record writes an entry, stamping its time if unset. Write errors are
+// dropped: a full disk shouldn't stop the floor.
+func (a *AuditLog) Record(e AuditEntry) {
+	if a == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = a.now()
+	}
+	if e.Args != nil {
+		e.Args = a.redactValue(e.Args).(map[string]any)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.w.Write(append(data, '\n'))
+}
+
+// Close closes the underlying file, if any.
+func (a *AuditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	if c, ok := a.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// redactValue copies v with the values of redacted keys replaced.
+func (a *AuditLog) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			if a.redact[strings.ToLower(k)] {
+				out[k] = Redacted
+			} else {
+				out[k] = a.redactValue(val)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = a.redactValue(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
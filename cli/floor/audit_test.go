@@ -0,0 +1,100 @@
+package floor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/furniture"
+	"github.com/openfloorcontrol/ofc/llm"
+	"github.com/openfloorcontrol/ofc/sandbox"
+)
+
+func TestAuditLogRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	audit := NewAuditLog(&buf, []string{"Password", "token"})
+	audit.Record(AuditEntry{
+		Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		AgentID: "@a",
+		Tool:    "deploy.login",
+		Args: map[string]any{
+			"user":     "ops",
+			"password": "hunter2",
+			"headers":  []any{map[string]any{"TOKEN": "abc", "name": "x"}},
+		},
+		Result: `"ok"`,
+	})
+
+	want := `{"time":"2026-01-02T03:04:05Z","agent":"@a","tool":"deploy.login",` +
+		`"args":{"headers":[{"TOKEN":"[REDACTED]","name":"x"}],"password":"[REDACTED]","user":"ops"},"result":"\"ok\""}` + "\n"
+	if buf.String() != want {
+		t.Errorf("got  %s\nwant %s", buf.String(), want)
+	}
+
+	var nilAudit *AuditLog
+	nilAudit.Record(AuditEntry{Tool: "bash"}) // must not panic
+}
+
+func TestLLMRunnerAuditsToolCalls(t *testing.T) {
+	var buf bytes.Buffer
+	runner := &LLMRunner{
+		Stream:    streamFunc(func(Event) {}),
+		Furniture: map[string]furniture.Furniture{"tasks": furniture.NewTaskBoard()},
+		Audit:     NewAuditLog(&buf, nil),
+		Client: llm.NewFakeClient(
+			llm.FakeReply{ToolCalls: []llm.ToolCall{
+				llm.FakeToolCall("c1", "tasks__add_task", `{"title":"a"}`),
+				llm.FakeToolCall("c2", "tasks__get_task", `{"id":7}`),
+				llm.FakeToolCall("c3", "bash", `{"cmd":"ls"}`),
+			}},
+			llm.FakeReply{Content: "Done."},
+		),
+	}
+
+	runner.Run(&blueprint.Agent{ID: "@a", Furniture: []string{"tasks"}}, nil)
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("bad audit line %q: %v", line, err)
+		}
+		if e.AgentID != "@a" || e.Time.IsZero() {
+			t.Errorf("entry missing agent or time: %s", line)
+		}
+		got = append(got, e.Tool+" "+e.Error)
+	}
+	want := []string{"tasks.add_task ", "tasks.get_task task 7 not found", "bash no sandbox available"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
+
+func TestLLMRunnerAuditRedactsArgs(t *testing.T) {
+	var buf bytes.Buffer
+	r, _ := NewRedactor(&blueprint.Blueprint{Redact: &blueprint.Redact{Secrets: true}})
+	runner := &LLMRunner{
+		Stream: streamFunc(func(Event) {}),
+		// Not started: the command fails, but is still audited with its args.
+		Sandbox:  &sandbox.Sandbox{},
+		Audit:    NewAuditLog(&buf, nil),
+		Redactor: r,
+		Client: llm.NewFakeClient(
+			llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c1", "bash",
+				`{"cmd":"curl -H \"Authorization: Bearer sk-ant-REDACTED\" https://example.com"}`)}},
+			llm.FakeReply{Content: "Done."},
+		),
+	}
+
+	runner.Run(&blueprint.Agent{ID: "@a", CanUseTools: true}, nil)
+
+	if strings.Contains(buf.String(), "abcdefghijklmnopqrstuvwxyz") {
+		t.Errorf("secret in audit log: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `curl -H \"Authorization: Bearer [REDACTED]\"`) {
+		t.Errorf("expected the command with its secret redacted, got %s", buf.String())
+	}
+}
@@ -0,0 +1,103 @@
+package floor
+
+import (
+	"context"
+	"sync"
+)
+
+// runBatch runs several agents' turns concurrently (a PromptAgents batch) and
+// returns their result events in agentIDs order.
+//
+// Output stays readable: the first unfinished agent streams live, the others
+// are buffered and replayed, with their result, once every agent before them
+// has finished. The frontend therefore sees the same sequence of events as a
+// sequential broadcast, except for questions to the user (ask_user, or a
+// command needing approval), which are shown as soon as they are asked. The
+// controller is not touched until all agents finish.
+func (co *Coordinator) runBatch(ctx context.Context, agentIDs []string) []Event {
+	ob := newOrderedBatch(co.frontend, co.stream, agentIDs)
+	results := make([]Event, len(agentIDs))
+
+	var wg sync.WaitGroup
+	for i, id := range agentIDs {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			result := co.runAgentTo(ctx, id, ob.sink(i))
+			results[i] = result.Event
+			ob.finish(i, result.Event)
+		}(i, id)
+	}
+	wg.Wait()
+	return results
+}
+
+// orderedBatch serializes the output of concurrently running agents so that
+// it reaches the frontend one agent at a time, in batch order.
+type orderedBatch struct {
+	mu       sync.Mutex
+	frontend Frontend
+	stream   StreamSink
+	ids      []string
+	current  int       // index of the agent currently shown live
+	buffered [][]Event // stream events held back per agent
+	results  []Event   // result event per agent, nil while running
+}
+
+func newOrderedBatch(frontend Frontend, stream StreamSink, ids []string) *orderedBatch {
+	ob := &orderedBatch{
+		frontend: frontend,
+		stream:   stream,
+		ids:      ids,
+		buffered: make([][]Event, len(ids)),
+		results:  make([]Event, len(ids)),
+	}
+	if len(ids) > 0 {
+		frontend.Render(AgentThinking{AgentID: ids[0]})
+	}
+	return ob
+}
+
+// batchSink is the StreamSink handed to the i-th agent's runner.
+type batchSink struct {
+	ob *orderedBatch
+	i  int
+}
+
+func (s batchSink) OnStream(ev Event) { s.ob.onStream(s.i, ev) }
+
+func (ob *orderedBatch) sink(i int) StreamSink {
+	return batchSink{ob: ob, i: i}
+}
+
+func (ob *orderedBatch) onStream(i int, ev Event) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	// The runner reads the answer right after asking, so the question
+	// can't wait for the agent's turn to be replayed.
+	if _, asking := ev.(UserInputRequested); asking || i == ob.current {
+		ob.stream.OnStream(ev)
+		return
+	}
+	ob.buffered[i] = append(ob.buffered[i], ev)
+}
+
+// finish records agent i's result and, if it was the live agent, renders it
+// and moves on through any later agents that have already finished.
+func (ob *orderedBatch) finish(i int, result Event) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.results[i] = result
+	for ob.current < len(ob.ids) && ob.results[ob.current] != nil {
+		ob.frontend.Render(ob.results[ob.current])
+		ob.current++
+		if ob.current == len(ob.ids) {
+			return
+		}
+		ob.frontend.Render(AgentThinking{AgentID: ob.ids[ob.current]})
+		for _, ev := range ob.buffered[ob.current] {
+			ob.stream.OnStream(ev)
+		}
+		ob.buffered[ob.current] = nil
+	}
+}
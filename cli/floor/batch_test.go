@@ -0,0 +1,81 @@
+package floor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingFrontend captures rendered and streamed events as strings.
+type recordingFrontend struct {
+	mu  sync.Mutex
+	log []string
+}
+
+func (r *recordingFrontend) record(ev Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch e := ev.(type) {
+	case AgentThinking:
+		r.log = append(r.log, "thinking "+e.AgentID)
+	case TokenStreamed:
+		r.log = append(r.log, e.AgentID+":"+e.Token)
+	case AgentDone:
+		r.log = append(r.log, "done "+e.AgentID)
+	case UserInputRequested:
+		r.log = append(r.log, e.AgentID+" asks "+e.Question)
+	default:
+		r.log = append(r.log, fmt.Sprintf("%T", ev))
+	}
+}
+
+func (r *recordingFrontend) Render(ev Event)           { r.record(ev) }
+func (r *recordingFrontend) OnStream(ev Event)         { r.record(ev) }
+func (r *recordingFrontend) ReadInput() (Event, error) { return nil, fmt.Errorf("no input") }
+func (r *recordingFrontend) LogWriter() io.Writer      { return nil }
+func (r *recordingFrontend) Close()                    {}
+
+func TestOrderedBatchReplaysInOrder(t *testing.T) {
+	rec := &recordingFrontend{}
+	ob := newOrderedBatch(rec, rec, []string{"@a", "@b", "@c"})
+
+	// @c and @b finish before @a; their output is held back.
+	ob.sink(2).OnStream(TokenStreamed{AgentID: "@c", Token: "c1"})
+	ob.finish(2, AgentDone{AgentID: "@c"})
+	ob.sink(1).OnStream(TokenStreamed{AgentID: "@b", Token: "b1"})
+	ob.sink(0).OnStream(TokenStreamed{AgentID: "@a", Token: "a1"})
+	ob.finish(0, AgentDone{AgentID: "@a"})
+	ob.sink(1).OnStream(TokenStreamed{AgentID: "@b", Token: "b2"})
+	ob.finish(1, AgentDone{AgentID: "@b"})
+
+	want := []string{
+		"thinking @a", "@a:a1", "done @a",
+		"thinking @b", "@b:b1", "@b:b2", "done @b",
+		"thinking @c", "@c:c1", "done @c",
+	}
+	if got := strings.Join(rec.log, " | "); got != strings.Join(want, " | ") {
+		t.Errorf("got  %s\nwant %s", got, strings.Join(want, " | "))
+	}
+}
+
+func TestOrderedBatchShowsQuestionsAtOnce(t *testing.T) {
+	rec := &recordingFrontend{}
+	ob := newOrderedBatch(rec, rec, []string{"@a", "@b"})
+
+	// @b asks while @a is live; the question can't wait for @b's replay.
+	ob.sink(1).OnStream(TokenStreamed{AgentID: "@b", Token: "b1"})
+	ob.sink(1).OnStream(UserInputRequested{AgentID: "@b", Question: "which branch?"})
+	ob.sink(0).OnStream(TokenStreamed{AgentID: "@a", Token: "a1"})
+	ob.finish(0, AgentDone{AgentID: "@a"})
+	ob.finish(1, AgentDone{AgentID: "@b"})
+
+	want := []string{
+		"thinking @a", "@b asks which branch?", "@a:a1", "done @a",
+		"thinking @b", "@b:b1", "done @b",
+	}
+	if got := strings.Join(rec.log, " | "); got != strings.Join(want, " | ") {
+		t.Errorf("got  %s\nwant %s", got, strings.Join(want, " | "))
+	}
+}
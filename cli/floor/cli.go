@@ -2,16 +2,52 @@ package floor
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// errInterrupted is returned by ReadInput when the user quits with Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+// thinkingTick is how often the CLI refreshes the elapsed time on a
+// "thinking..." line.
+const thinkingTick = time.Second
+
 // CLIFrontend implements Frontend and StreamSink for terminal-based interaction.
 type CLIFrontend struct {
-	out      *Output
-	colorMap map[string]string
-	reader   *bufio.Reader
+	out       *Output
+	colorMap  map[string]string
+	labels    map[string]string // shown instead of agent IDs (see SetLabels)
+	reader    *bufio.Reader
+	quiet     bool          // terminal shows only agents' replies (see NewQuietCLIFrontend)
+	lastStack string        // last call stack breadcrumb shown in debug output
+	thinkStop chan struct{} // closes to stop the thinking ticker
+	thinkDone chan struct{} // closed once the ticker has exited
+
+	hlMu        sync.Mutex
+	highlighter map[string]*codeHighlighter // per agent, while colors are on
+
+	lines           chan lineResult // filled by the stdin reader goroutine
+	wantLine        chan struct{}   // asks the reader goroutine for the next line
+	reading         bool            // a line has been asked for and not yet received
+	interrupts      chan os.Signal  // Ctrl-C, once EnableInterrupts is called
+	promptInterrupt chan struct{}   // Ctrl-C pressed while waiting for input
+	cancelling      atomic.Bool     // Ctrl-C already cancelled the current turn
+	quit            atomic.Bool     // Ctrl-C pressed twice during a turn
+}
+
+// lineResult is one line (or read error) from stdin.
+type lineResult struct {
+	text string
+	err  error
 }
 
 // NewCLIFrontend creates a CLI frontend with terminal output and optional log file.
@@ -23,6 +59,92 @@ func NewCLIFrontend(logPath string, debug bool, colorMap map[string]string) *CLI
 	}
 }
 
+// NewQuietCLIFrontend creates a CLI frontend for scripted runs: the terminal
+// shows only the agents' streamed replies, without labels, system messages,
+// tool output, or thinking indicators. Agent errors go to stderr. The log
+// file, if any, still records everything.
+func NewQuietCLIFrontend(logPath string, debug bool, colorMap map[string]string) *CLIFrontend {
+	f := NewCLIFrontend(logPath, debug, colorMap)
+	f.quiet = true
+	return f
+}
+
+// quietShows reports whether ev reaches the terminal in quiet mode: only
+// agents' streamed text and the newline that ends each reply.
+func quietShows(ev Event) bool {
+	switch e := ev.(type) {
+	case TokenStreamed:
+		return e.AgentID != "@user"
+	case AgentDone:
+		return true
+	}
+	return false
+}
+
+// muteUnlessShown mutes terminal output for events hidden in quiet mode.
+// The returned func restores it.
+func (f *CLIFrontend) muteUnlessShown(ev Event) func() {
+	if !f.quiet || quietShows(ev) {
+		return func() {}
+	}
+	f.out.Mute(true)
+	return func() { f.out.Mute(false) }
+}
+
+// SetLabels sets the labels shown for agents instead of their IDs, such as
+// from BuildLabelMap. Agents without one are shown by ID.
+func (f *CLIFrontend) SetLabels(labels map[string]string) {
+	f.labels = labels
+}
+
+func (f *CLIFrontend) agentLabel(id string) string {
+	if l, ok := f.labels[id]; ok {
+		return l
+	}
+	return id
+}
+
+// SetTimestamps shows the clock time before agent labels and system lines.
+func (f *CLIFrontend) SetTimestamps(on bool) {
+	f.out.SetTimestamps(on)
+}
+
+// SetColorMode sets when output is colored, including syntax highlighting
+// of code blocks and recognizable tool output. The default is ColorAuto.
+func (f *CLIFrontend) SetColorMode(mode ColorMode) {
+	f.out.SetColorMode(mode)
+}
+
+// printAgentText prints an agent's streamed text, highlighting its code
+// blocks when colors are on.
+func (f *CLIFrontend) printAgentText(agentID, token string) {
+	if !f.out.Colors() {
+		f.out.Print("%s", token)
+		return
+	}
+	f.hlMu.Lock()
+	defer f.hlMu.Unlock()
+	if f.highlighter == nil {
+		f.highlighter = make(map[string]*codeHighlighter)
+	}
+	h := f.highlighter[agentID]
+	if h == nil {
+		h = &codeHighlighter{}
+		f.highlighter[agentID] = h
+	}
+	h.write(token, func(s string) { f.out.Print("%s", s) })
+}
+
+// flushAgentText prints a code block the agent left open, before whatever
+// interrupts its text.
+func (f *CLIFrontend) flushAgentText(agentID string) {
+	f.hlMu.Lock()
+	defer f.hlMu.Unlock()
+	if h := f.highlighter[agentID]; h != nil {
+		h.flush(func(s string) { f.out.Print("%s", s) })
+	}
+}
+
 func (f *CLIFrontend) agentColor(id string) string {
 	if c, ok := f.colorMap[id]; ok {
 		return c
@@ -32,66 +154,208 @@ func (f *CLIFrontend) agentColor(id string) string {
 
 // Render displays a floor event in the terminal.
 func (f *CLIFrontend) Render(ev Event) {
+	f.stopThinking()
+	defer f.muteUnlessShown(ev)()
 	switch e := ev.(type) {
 	case SystemInfo:
-		f.out.Print("%s[System]: %s%s\n", Dim, e.Text, Reset)
+		f.out.PrintStamped("%s[System]: %s%s\n", Dim, e.Text, Reset)
 	case AgentThinking:
 		f.out.Print("\n")
-		f.out.Terminal("%s%s[%s]:%s %sthinking...%s", Bold, f.agentColor(e.AgentID), e.AgentID, Reset, Dim, Reset)
+		if !f.quiet && f.out.Interactive() {
+			f.startThinking(e.AgentID)
+		}
 	case ConversationCleared:
 		f.out.Print("%s[Conversation cleared]%s\n", Dim, Reset)
 	case AgentDone:
+		f.flushAgentText(e.AgentID)
 		f.out.Print("\n") // newline after streaming
 	case AgentPassed:
 		f.out.Terminal("\r\033[K")
-		f.out.Terminal("%s%s[%s]:%s [PASS]\n", Bold, f.agentColor(e.AgentID), e.AgentID, Reset)
+		f.out.Terminal("%s%s%s[%s]:%s [PASS]\n", f.out.TerminalStamp(), Bold, f.agentColor(e.AgentID), f.agentLabel(e.AgentID), Reset)
+	case AgentRouted:
+		f.out.Terminal("\r\033[K")
+		f.out.Terminal("%s%s%s[%s]:%s %s→ %s%s\n", f.out.TerminalStamp(), Bold, f.agentColor(e.AgentID), f.agentLabel(e.AgentID), Reset, Dim, f.agentLabel(e.Target), Reset)
 	case AgentError:
+		f.flushAgentText(e.AgentID)
 		f.out.Terminal("\r\033[K")
-		f.out.AgentLabel(e.AgentID, f.agentColor(e.AgentID))
+		f.out.AgentLabel(f.agentLabel(e.AgentID), f.agentColor(e.AgentID))
 		f.out.Print("[ERROR: %v]\n", e.Err)
+		if f.quiet {
+			fmt.Fprintf(os.Stderr, "ofc: %s: %v\n", e.AgentID, e.Err)
+		}
+	case SessionSummary:
+		f.out.Print("\n%s%s%s\n", Dim, FormatSummary(e), Reset)
 	case FloorStopped:
 		f.out.Print("\n%sGoodbye! ofc. 🎤%s\n", Dim, Reset)
 	case WaitingForUser:
-		// nothing — ReadInput will show the prompt
+		// ReadInput will show the prompt
+		f.debugStack(e.Stack)
 	case PromptAgent:
-		// nothing — coordinator handles dispatch
+		// coordinator handles dispatch
+		f.debugStack(e.Stack)
+	case PromptAgents:
+		f.debugStack(e.Stack)
 	}
 }
 
+// startThinking shows the agent's "thinking..." line and rewrites it every
+// thinkingTick with the elapsed seconds, until stopThinking is called.
+func (f *CLIFrontend) startThinking(agentID string) {
+	line := fmt.Sprintf("%s%s%s[%s]:%s %sthinking...", f.out.TerminalStamp(), Bold, f.agentColor(agentID), f.agentLabel(agentID), Reset, Dim)
+	f.out.Terminal("%s%s", line, Reset)
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	f.thinkStop, f.thinkDone = stop, done
+	start := time.Now()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(thinkingTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				f.out.Terminal("\r\033[K%s %ds%s", line, int(time.Since(start).Seconds()), Reset)
+			}
+		}
+	}()
+}
+
+// stopThinking stops the thinking ticker, if running, and waits for it so
+// nothing is written to the line afterwards.
+func (f *CLIFrontend) stopThinking() {
+	if f.thinkStop == nil {
+		return
+	}
+	close(f.thinkStop)
+	<-f.thinkDone
+	f.thinkStop, f.thinkDone = nil, nil
+}
+
+// debugStack prints the call stack breadcrumb in debug mode when it changes.
+func (f *CLIFrontend) debugStack(stack []Frame) {
+	crumb := FormatStack(stack)
+	if crumb == f.lastStack {
+		return
+	}
+	f.lastStack = crumb
+	if crumb == "" {
+		crumb = "(empty)"
+	}
+	f.out.Debug("stack: %s", crumb)
+}
+
 // OnStream handles high-frequency streaming events from runners.
 func (f *CLIFrontend) OnStream(ev Event) {
+	f.stopThinking()
+	defer f.muteUnlessShown(ev)()
 	switch e := ev.(type) {
 	case AgentLabel:
 		f.out.Terminal("\r\033[K") // clear "thinking..." line
-		f.out.AgentLabel(e.AgentID, f.agentColor(e.AgentID))
+		f.out.AgentLabel(f.agentLabel(e.AgentID), f.agentColor(e.AgentID))
 	case TokenStreamed:
-		f.out.Print("%s", e.Token)
+		f.printAgentText(e.AgentID, e.Token)
 	case ToolCallStarted:
+		f.flushAgentText(e.AgentID)
 		f.out.Print("\n%s  ▶ %s%s\n", Dim, e.Title, Reset)
+	case ToolCallProgress:
+		f.out.Print("%s  … %s%s\n", Dim, e.Message, Reset)
+	case UserInputRequested:
+		f.out.Print("\n%s  ? %s%s\n", Bold, e.Question, Reset)
+	case SystemInfo:
+		f.out.PrintStamped("\n%s[System]: %s%s\n", Dim, e.Text, Reset)
 	case ToolCallResult:
 		if e.Output != "" {
 			display := e.Output
 			if len(display) > 500 {
 				display = display[:500] + "..."
 			}
+			if name := outputFilename(e.Title); name != "" && f.out.Colors() {
+				f.out.Print("  %s%s\n", highlightCode(display, "", name), Reset)
+				break
+			}
 			f.out.Print("%s  %s%s\n", Dim, display, Reset)
 		}
 	}
 }
 
+// EnableInterrupts takes over Ctrl-C: during an agent turn it calls
+// cancelTurn, and the floor returns to the prompt; at the prompt, or when
+// pressed again before the cancelled turn ends, it quits the floor.
+// cancelTurn reports whether a turn was running (see Coordinator.CancelTurn).
+func (f *CLIFrontend) EnableInterrupts(cancelTurn func() bool) {
+	f.interrupts = make(chan os.Signal, 1)
+	f.promptInterrupt = make(chan struct{}, 1)
+	signal.Notify(f.interrupts, os.Interrupt)
+
+	go func() {
+		for range f.interrupts {
+			switch {
+			case !cancelTurn():
+				select {
+				case f.promptInterrupt <- struct{}{}:
+				default:
+				}
+			case f.cancelling.Swap(true):
+				f.quit.Store(true)
+				f.out.Terminal("\n%s[Quitting when the turn stops]%s\n", Dim, Reset)
+			default:
+				f.out.Terminal("\n%s[Cancelling turn, Ctrl-C again to quit]%s\n", Dim, Reset)
+			}
+		}
+	}()
+}
+
+// readLines starts the stdin reader on first use and asks it for a line,
+// unless one is already on its way. Reading in a goroutine lets ReadInput
+// also wait for Ctrl-C; reading only on request leaves stdin alone between
+// prompts, for /edit's editor.
+func (f *CLIFrontend) readLines() <-chan lineResult {
+	if f.lines == nil {
+		f.lines = make(chan lineResult)
+		f.wantLine = make(chan struct{}, 1)
+		go func() {
+			for range f.wantLine {
+				text, err := f.reader.ReadString('\n')
+				f.lines <- lineResult{text: text, err: err}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	if !f.reading {
+		f.reading = true
+		f.wantLine <- struct{}{}
+	}
+	return f.lines
+}
+
 // ReadInput prompts the user and reads a line.
 // Returns UserMessage or UserCommand, or error on EOF/interrupt.
 func (f *CLIFrontend) ReadInput() (Event, error) {
+	f.cancelling.Store(false)
+	if f.quit.Load() {
+		return nil, errInterrupted
+	}
+
 	f.out.Print("\n")
 	f.out.AgentLabel("@user", f.agentColor("@user"))
 
-	input, err := f.reader.ReadString('\n')
-	if err != nil {
+	var line lineResult
+	select {
+	case line = <-f.readLines():
+		f.reading = false
+	case <-f.promptInterrupt:
+		line.err = errInterrupted
+	}
+	if line.err != nil {
 		f.out.Print("%s[Interrupted]%s\n", Dim, Reset)
-		return nil, err
+		return nil, line.err
 	}
 
-	text := strings.TrimSpace(input)
+	text := strings.TrimSpace(line.text)
 	f.out.Log("%s\n", text) // log user's typed input
 
 	if text == "" {
@@ -105,6 +369,14 @@ func (f *CLIFrontend) ReadInput() (Event, error) {
 	return UserMessage{Content: text}, nil
 }
 
+// EditText opens text in the user's editor on the terminal, for /edit.
+func (f *CLIFrontend) EditText(text string) (string, error) {
+	return editText(text, func(cmd *exec.Cmd) error {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return cmd.Run()
+	})
+}
+
 // LogWriter returns the log file writer for subsystems (ACP client debug).
 func (f *CLIFrontend) LogWriter() io.Writer {
 	return f.out.LogWriter()
@@ -112,6 +384,12 @@ func (f *CLIFrontend) LogWriter() io.Writer {
 
 // Close closes the log file.
 func (f *CLIFrontend) Close() {
+	f.stopThinking()
+	if f.interrupts != nil {
+		signal.Stop(f.interrupts)
+		close(f.interrupts)
+		f.interrupts = nil
+	}
 	f.out.Close()
 }
 
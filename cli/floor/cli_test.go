@@ -0,0 +1,148 @@
+package floor
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+// captureStdout runs fn with os.Stdout redirected and returns what it printed.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestQuietCLIShowsOnlyReplies(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "floor.log")
+	f := NewQuietCLIFrontend(logPath, false, map[string]string{})
+
+	out := captureStdout(t, func() {
+		f.Render(SystemInfo{Text: "sandbox ready"})
+		f.OnStream(AgentLabel{AgentID: "@user"})
+		f.OnStream(TokenStreamed{AgentID: "@user", Token: "question\n"})
+		f.Render(AgentThinking{AgentID: "@a"})
+		f.OnStream(AgentLabel{AgentID: "@a"})
+		f.OnStream(ToolCallStarted{AgentID: "@a", Title: "ls"})
+		f.OnStream(ToolCallResult{AgentID: "@a", Title: "ls", Output: "data.csv"})
+		f.OnStream(TokenStreamed{AgentID: "@a", Token: "the answer"})
+		f.Render(AgentDone{AgentID: "@a"})
+	})
+	f.Close()
+
+	if out != "the answer\n" {
+		t.Errorf("stdout = %q, want only the reply", out)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"sandbox ready", "[@user]: question", "[@a]:", "ls", "data.csv", "the answer"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("log missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestBuildColorMap(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Palette: []string{"cyan", "#ff8000"},
+		Agents:  []blueprint.Agent{{ID: "@a"}, {ID: "@b"}, {ID: "@c", Color: "red"}, {ID: "@d"}},
+	}
+	cm := BuildColorMap(bp)
+	want := map[string]string{"@user": Cyan, "@a": Cyan, "@b": "\033[38;2;255;128;0m", "@c": Red, "@d": "\033[38;2;255;128;0m"}
+	if !reflect.DeepEqual(cm, want) {
+		t.Errorf("got %q, want %q", cm, want)
+	}
+
+	for _, name := range blueprint.ColorNames {
+		if colorCode(name) == "" {
+			t.Errorf("color %q has no ANSI code", name)
+		}
+	}
+}
+
+func TestCLINoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	f := NewCLIFrontend("", false, map[string]string{"@a": Green})
+	f.SetLabels(map[string]string{"@a": "Analyst"})
+
+	out := captureStdout(t, func() {
+		f.Render(SystemInfo{Text: "sandbox ready"})
+		f.OnStream(AgentLabel{AgentID: "@a"})
+		f.OnStream(TokenStreamed{AgentID: "@a", Token: "done"})
+		f.Render(AgentDone{AgentID: "@a"})
+	})
+	f.Close()
+
+	if ansiRe.MatchString(out) {
+		t.Errorf("expected no colors, got %q", out)
+	}
+	if !strings.Contains(out, "[System]: sandbox ready") || !strings.Contains(out, "[Analyst]: done") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestPipedOutputIsPlain(t *testing.T) {
+	o := &Output{piped: true}
+	out := captureStdout(t, func() {
+		o.Terminal("\r\033[K")
+		o.AgentLabel("@a", Green)
+		o.Print("%sdone%s\n", Dim, Reset)
+	})
+	if out != "[@a]: done\n" {
+		t.Errorf("got %q, want plain text", out)
+	}
+	if o.Interactive() {
+		t.Error("piped output reported as interactive")
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "floor.log")
+	f := NewCLIFrontend(logPath, false, map[string]string{})
+	f.out.now = func() time.Time { return time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC) }
+
+	plain := captureStdout(t, func() {
+		f.Render(SystemInfo{Text: "sandbox ready"})
+	})
+	f.SetTimestamps(true)
+	stamped := captureStdout(t, func() {
+		f.OnStream(AgentLabel{AgentID: "@a"})
+		f.OnStream(TokenStreamed{AgentID: "@a", Token: "hi"})
+	})
+	f.Close()
+
+	if strings.Contains(plain, "15:04:05") {
+		t.Errorf("timestamp shown without SetTimestamps: %q", plain)
+	}
+	if got := ansiRe.ReplaceAllString(stamped, ""); got != "15:04:05 [@a]: hi" {
+		t.Errorf("got %q", got)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "15:04:05 [System]: sandbox ready\n15:04:05 [@a]: hi"; string(data) != want {
+		t.Errorf("log = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,137 @@
+package floor
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+// CommandPolicy restricts the bash commands LLM agents may run, from a
+// sandbox's allow_commands and deny_commands. A bash call is split into its
+// commands (at ;, &&, ||, |, & and newlines) and each is checked: none may
+// match a deny entry and, if there is an allowlist, every one must match
+// an allow entry. It is a guard against mistakes, not a sandbox: an allowed
+// interpreter such as python can still run anything.
+type CommandPolicy struct {
+	allow []commandRule
+	deny  []commandRule
+}
+
+// commandRule is one allow_commands or deny_commands entry.
+type commandRule struct {
+	entry string
+	name  string         // command name, matched on the first word
+	re    *regexp.Regexp // or a pattern, matched on the whole command
+}
+
+// NewCommandPolicy compiles allow and deny entries (see
+// blueprint.CommandPattern). It returns nil if both are empty.
+func NewCommandPolicy(allow, deny []string) (*CommandPolicy, error) {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil, nil
+	}
+	p := &CommandPolicy{}
+	var err error
+	if p.allow, err = compileRules(allow); err != nil {
+		return nil, fmt.Errorf("allow_commands: %w", err)
+	}
+	if p.deny, err = compileRules(deny); err != nil {
+		return nil, fmt.Errorf("deny_commands: %w", err)
+	}
+	return p, nil
+}
+
+func compileRules(entries []string) ([]commandRule, error) {
+	var rules []commandRule
+	for _, entry := range entries {
+		pattern, isRegex := blueprint.CommandPattern(entry)
+		if !isRegex {
+			rules = append(rules, commandRule{entry: entry, name: pattern})
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry, err)
+		}
+		rules = append(rules, commandRule{entry: entry, re: re})
+	}
+	return rules, nil
+}
+
+func (rule commandRule) matches(command string) bool {
+	if rule.re != nil {
+		return rule.re.MatchString(command)
+	}
+	return commandName(command) == rule.name
+}
+
+// commandSep separates the commands of a shell command line, and
+// fdRedirect matches redirections such as 2>&1 whose & doesn't.
+var (
+	commandSep = regexp.MustCompile(`&&|\|\||[;&|\n]`)
+	fdRedirect = regexp.MustCompile(`[0-9]*[<>]&[0-9]*-?|&>>?`)
+)
+
+// substitution matches shell constructs that run commands inside another
+// command's arguments, which an allowlist can't see into.
+var substitution = regexp.MustCompile("\\$\\(|`|[<>]\\(")
+
+// Check returns an error saying why command may not run, or nil if it may.
+// A nil policy permits everything.
+func (p *CommandPolicy) Check(command string) error {
+	if p == nil {
+		return nil
+	}
+	if len(p.allow) > 0 && substitution.MatchString(command) {
+		return fmt.Errorf("command not permitted: command substitution can't be checked against the allowed commands (%s)", p.allowed())
+	}
+	for _, part := range commandSep.Split(fdRedirect.ReplaceAllString(command, " "), -1) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if i := slices.IndexFunc(p.deny, func(r commandRule) bool { return r.matches(part) }); i >= 0 {
+			return fmt.Errorf("command not permitted: %q is denied (deny_commands: %s)", part, p.deny[i].entry)
+		}
+		if len(p.allow) > 0 && !slices.ContainsFunc(p.allow, func(r commandRule) bool { return r.matches(part) }) {
+			return fmt.Errorf("command not permitted: %q is not an allowed command (%s)", part, p.allowed())
+		}
+	}
+	return nil
+}
+
+// allowed lists the allow entries, for error messages the agent can act on.
+func (p *CommandPolicy) allowed() string {
+	entries := make([]string, len(p.allow))
+	for i, r := range p.allow {
+		entries[i] = r.entry
+	}
+	return "allow_commands: " + strings.Join(entries, ", ")
+}
+
+// shellKeywords may come before a command's name.
+var shellKeywords = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true, "while": true,
+	"until": true, "do": true, "time": true, "!": true, "{": true,
+}
+
+// commandName returns the program a simple command runs: its first word
+// after any subshell brackets, shell keywords and VAR=value assignments,
+// without a directory ("/bin/rm" is rm).
+func commandName(command string) string {
+	for _, word := range strings.Fields(command) {
+		word = strings.TrimLeft(word, "(")
+		if word == "" || shellKeywords[word] {
+			continue
+		}
+		if name, _, ok := strings.Cut(word, "="); ok && name != "" && !strings.ContainsAny(name, "/'\"") {
+			continue
+		}
+		return path.Base(strings.Trim(word, `'")`))
+	}
+	return ""
+}
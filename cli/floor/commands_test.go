@@ -0,0 +1,53 @@
+package floor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommandPolicy(t *testing.T) {
+	allow, err := NewCommandPolicy([]string{"ls", "cat", "grep", "python", `/^git (status|diff|log)\b/`}, []string{"rm"})
+	if err != nil {
+		t.Fatalf("NewCommandPolicy: %v", err)
+	}
+	deny, err := NewCommandPolicy(nil, []string{"rm", `/git\s+reset\s+--hard/`})
+	if err != nil {
+		t.Fatalf("NewCommandPolicy: %v", err)
+	}
+
+	tests := []struct {
+		policy  *CommandPolicy
+		command string
+		err     string // substring of the error; "" = permitted
+	}{
+		{allow, "ls -la", ""},
+		{allow, "cat data.csv | grep total 2>&1", ""},
+		{allow, "LC_ALL=C /usr/bin/grep -r x .", ""},
+		{allow, "git status", ""},
+		{allow, "git push", `"git push" is not an allowed command (allow_commands: ls, cat, grep, python, /^git (status|diff|log)\b/)`},
+		{allow, "ls && curl example.com", `"curl example.com" is not an allowed command`},
+		{allow, "cat $(find . -name '*.py')", "command substitution"},
+		{allow, "ls; rm -rf /", `"rm -rf /" is denied (deny_commands: rm)`},
+		{deny, "python main.py", ""},
+		{deny, "(cd build && rm -rf out)", `is denied (deny_commands: rm)`},
+		{deny, "if true; then rm x; fi", `is denied (deny_commands: rm)`},
+		{deny, "git reset  --hard HEAD~1", `is denied (deny_commands: /git\s+reset\s+--hard/)`},
+		{nil, "rm -rf /", ""},
+	}
+	for _, tt := range tests {
+		err := tt.policy.Check(tt.command)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%q: unexpected error %v", tt.command, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%q: got %v, want an error containing %q", tt.command, err, tt.err)
+		}
+	}
+
+	if p, err := NewCommandPolicy(nil, nil); p != nil || err != nil {
+		t.Errorf("expected no policy without entries, got %v, %v", p, err)
+	}
+	if _, err := NewCommandPolicy([]string{"/(/"}, nil); err == nil {
+		t.Error("expected an error for an invalid regex")
+	}
+}
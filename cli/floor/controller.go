@@ -1,9 +1,15 @@
 package floor
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	acpsdk "github.com/coder/acp-go-sdk"
 	"github.com/openfloorcontrol/ofc/blueprint"
@@ -18,7 +24,19 @@ type Controller struct {
 	Messages     []FloorMessage
 	CallStack    []Frame
 	passedAgents map[string]bool
+	batch        []string     // agents queued by a concurrent @everyone?, taken by advanceTurn
+	asking       string       // callee whose @user? paused a delegation; the user's reply resumes it
 	DebugFunc    func(string) // injected for debug logging; no-op in tests
+
+	// passedAgents covers the latest message and is reset by every new one;
+	// turnPassed holds top-level passes until the user speaks again.
+	turnPassed map[string]bool
+
+	// A concurrent broadcast builds its agents' contexts in parallel.
+	windowsMu sync.Mutex
+	windows   map[string]*contextWindow // per agent ID, see window
+
+	Now func() time.Time // clock for FloorMessage.Time; injected in tests
 }
 
 // NewController creates a controller for the given blueprint.
@@ -26,7 +44,9 @@ func NewController(bp *blueprint.Blueprint) *Controller {
 	return &Controller{
 		Blueprint:    bp,
 		passedAgents: make(map[string]bool),
+		turnPassed:   make(map[string]bool),
 		DebugFunc:    func(string) {}, // no-op by default
+		Now:          time.Now,
 	}
 }
 
@@ -41,70 +61,405 @@ func (c *Controller) HandleEvent(ev Event) []Event {
 		return c.handleAgentPassed(e)
 	case AgentError:
 		return c.handleAgentError(e)
+	case AgentRouted:
+		return c.handleAgentRouted(e)
+	case AgentsDone:
+		return c.handleAgentsDone(e)
+	case SystemMessage:
+		return c.handleSystemMessage(e)
 	case UserCommand:
 		return c.handleUserCommand(e)
+	case BlueprintLoaded:
+		return c.handleBlueprintLoaded(e)
+	case MessageEdited:
+		return c.handleMessageEdited(e)
 	default:
 		return nil
 	}
 }
 
+// handleUserMessage starts a new request, abandoning any delegation chain,
+// unless an agent paused the chain to ask the user something: then a reply
+// that mentions nobody goes back to that agent, whose own reply returns to
+// its caller as usual.
 func (c *Controller) handleUserMessage(e UserMessage) []Event {
-	c.Messages = append(c.Messages, FloorMessage{
+	warnings := c.appendMessage(FloorMessage{
 		FromID:  "@user",
 		Content: e.Content,
+		Time:    c.Now(),
 	})
-	c.CallStack = nil
 	c.passedAgents = make(map[string]bool)
-	return c.advanceTurn()
+	c.turnPassed = make(map[string]bool)
+	asker := c.asking
+	c.asking = ""
+	if asker != "" && len(extractMentions(e.Content)) == 0 {
+		c.debug("→ user answered %s, resuming delegation (stack=%d)", asker, len(c.CallStack))
+		return append(warnings, c.promptAgent(asker))
+	}
+	c.CallStack = nil
+	return append(warnings, c.advanceTurn()...)
+}
+
+// handleSystemMessage adds guidance from systemID to the transcript. The
+// floor stays with the user: guidance shapes the agents' next turns but
+// doesn't ask for one.
+func (c *Controller) handleSystemMessage(e SystemMessage) []Event {
+	if strings.TrimSpace(e.Content) == "" {
+		return []Event{SystemInfo{Text: "[Usage: /system <instructions for the agents>]"}}
+	}
+	warnings := c.appendMessage(FloorMessage{
+		FromID:  systemID,
+		Content: e.Content,
+		Time:    c.Now(),
+	})
+	return append(warnings, SystemInfo{Text: "[System message added]"})
 }
 
 func (c *Controller) handleAgentDone(e AgentDone) []Event {
-	c.Messages = append(c.Messages, FloorMessage{
+	warnings := c.appendMessage(FloorMessage{
 		FromID:           e.AgentID,
 		Content:          e.Content,
 		ToolInteractions: e.ToolInteractions,
+		Time:             c.Now(),
 	})
 	c.passedAgents = make(map[string]bool)
-	return c.advanceTurn()
+	if c.repeating(e.AgentID) {
+		return append(warnings, c.stopLoop(e.AgentID)...)
+	}
+	if e.Handoff != "" {
+		return append(warnings, c.handoff(e.AgentID, e.Handoff)...)
+	}
+	return append(warnings, c.advanceTurn()...)
 }
 
-func (c *Controller) handleAgentPassed(e AgentPassed) []Event {
-	// Pop frame if this agent was the callee on top of stack
-	if len(c.CallStack) > 0 && c.CallStack[len(c.CallStack)-1].Callee == e.AgentID {
-		c.CallStack = c.CallStack[:len(c.CallStack)-1]
+// handoff delegates directly to the target of a [[handoff:@id]] directive,
+// pushing a frame as if the agent had written "@id?". Falls back to normal
+// turn-taking if the target is unknown or the agent itself.
+func (c *Controller) handoff(from, to string) []Event {
+	if to == "@user" {
+		c.debug("→ handoff to @user")
+		c.pauseForUser(from)
+		return []Event{c.waitingForUser()}
+	}
+	target := c.getAgent(to)
+	if target == nil || target.ID == from {
+		c.debug("→ ignoring handoff from %s to %s", from, to)
+		return c.advanceTurn()
 	}
+	c.CallStack = append(c.CallStack, Frame{Caller: from, Callee: target.ID})
+	c.debug("→ handoff: %s → %s (pushed frame, stack=%d)", from, target.ID, len(c.CallStack))
+	return []Event{c.promptAgent(target.ID)}
+}
+
+// handleAgentPassed excludes the agent until the next message. A pass at the
+// top level, where only polled agents speak, lasts for the rest of the user's
+// turn: the agent has nothing to add to the user's request, so it is not
+// polled again when other agents reply, or when a delegation they start
+// returns. A pass inside a delegation only covers the message it was made
+// on, and an explicit @id? always reaches the agent.
+//
+// If the agent was the callee on top of the stack, a pass ends its frame in
+// one of two ways:
+//
+//   - It declined its caller's question (the question is the latest
+//     message): the caller isn't called back to reread its own question.
+//     Its message stands as its reply, so routing continues from it as if
+//     the passed agent hadn't been mentioned: other agents it asked, else
+//     back up to the caller's caller.
+//   - It passed on a reply handed back to it: it has nothing to add, so the
+//     reply carries on up the chain to its caller, or to the next agent
+//     queued by an @everyone?.
+func (c *Controller) handleAgentPassed(e AgentPassed) []Event {
 	c.passedAgents[e.AgentID] = true
+	n := len(c.CallStack)
+	if n == 0 {
+		if c.turnPassed == nil {
+			c.turnPassed = make(map[string]bool)
+		}
+		c.turnPassed[e.AgentID] = true
+	}
+	if n == 0 || c.CallStack[n-1].Callee != e.AgentID {
+		return c.advanceTurn()
+	}
+	if m := len(c.Messages); m > 0 && c.Messages[m-1].FromID != c.CallStack[n-1].Caller {
+		c.debug("→ %s passed on a reply, returning it to %s", e.AgentID, c.CallStack[n-1].Caller)
+		if next, ok := c.unwind(c.passedAgents); ok {
+			if next == nil {
+				return []Event{c.waitingForUser()}
+			}
+			return []Event{c.promptAgent(next.ID)}
+		}
+		return c.advanceTurn()
+	}
+	frame := c.CallStack[n-1]
+	c.CallStack = c.CallStack[:n-1]
+	// Mid-broadcast: hand straight to the next queued agent rather than
+	// re-reading the @everyone? message.
+	if next := c.nextBroadcastCallee(frame); next != nil {
+		return []Event{c.promptAgent(next.ID)}
+	}
 	return c.advanceTurn()
 }
 
+// handleAgentRouted delegates to the agent a router chose, as if whoever
+// the router answered had written "@id?": the router's own frame, if it was
+// called, is replaced by one from its caller to the target, so the target's
+// reply returns there rather than to the router. A router that names no
+// valid agent is treated as passing.
+func (c *Controller) handleAgentRouted(e AgentRouted) []Event {
+	if e.Target == "@user" {
+		c.debug("→ %s routed to @user", e.AgentID)
+		return []Event{c.waitingForUser()}
+	}
+	target := c.getAgent(e.Target)
+	if target == nil || target.Type == "router" {
+		c.debug("→ %s routed to %s, which can't respond; passing", e.AgentID, e.Target)
+		return c.handleAgentPassed(AgentPassed{AgentID: e.AgentID})
+	}
+
+	caller := "@user"
+	if n := len(c.Messages); n > 0 {
+		caller = c.Messages[n-1].FromID
+	}
+	if n := len(c.CallStack); n > 0 && c.CallStack[n-1].Callee == e.AgentID {
+		caller = c.CallStack[n-1].Caller
+		c.CallStack = c.CallStack[:n-1]
+	}
+	c.CallStack = append(c.CallStack, Frame{Caller: caller, Callee: target.ID})
+	c.debug("→ routed: %s → %s for %s (pushed frame, stack=%d)", e.AgentID, target.ID, caller, len(c.CallStack))
+	return []Event{c.promptAgent(target.ID)}
+}
+
 func (c *Controller) handleAgentError(e AgentError) []Event {
-	return []Event{
-		SystemInfo{Text: fmt.Sprintf("[ERROR from %s: %v]", e.AgentID, e.Err)},
-		WaitingForUser{},
+	info := c.errorInfo(e)
+	return []Event{info, c.waitingForUser()}
+}
+
+// errorInfo describes an agent error for the user. A cancelled turn also
+// abandons the delegation chain, since the user has taken back the floor.
+func (c *Controller) errorInfo(e AgentError) SystemInfo {
+	if errors.Is(e.Err, ErrTurnCancelled) {
+		c.CallStack = nil
+		c.asking = ""
+		return SystemInfo{Text: fmt.Sprintf("[%s: turn cancelled]", e.AgentID)}
 	}
+	return SystemInfo{Text: fmt.Sprintf("[ERROR from %s: %v]", e.AgentID, e.Err)}
+}
+
+// handleAgentsDone ingests the results of a concurrent broadcast in order, as
+// if the agents had responded one after another, then advances the turn once.
+// The broadcast's single frame is popped by the usual "no mentions" rule.
+func (c *Controller) handleAgentsDone(e AgentsDone) []Event {
+	var events []Event
+	failed := false
+	looping := ""
+	for _, r := range e.Results {
+		switch r := r.(type) {
+		case AgentDone:
+			events = append(events, c.appendMessage(FloorMessage{
+				FromID:           r.AgentID,
+				Content:          r.Content,
+				ToolInteractions: r.ToolInteractions,
+				Time:             c.Now(),
+			})...)
+			c.passedAgents = make(map[string]bool)
+			if looping == "" && c.repeating(r.AgentID) {
+				looping = r.AgentID
+			}
+			if r.Handoff != "" {
+				c.debug("→ ignoring handoff from %s to %s in concurrent broadcast", r.AgentID, r.Handoff)
+			}
+		case AgentPassed:
+			c.passedAgents[r.AgentID] = true
+		case AgentError:
+			events = append(events, c.errorInfo(r))
+			failed = true
+		}
+	}
+	if looping != "" {
+		return append(events, c.stopLoop(looping)...)
+	}
+	if failed {
+		return append(events, c.waitingForUser())
+	}
+	return append(events, c.advanceTurn()...)
 }
 
 func (c *Controller) handleUserCommand(e UserCommand) []Event {
+	if arg, ok := strings.CutPrefix(e.Command, "/tools"); ok && (arg == "" || arg[0] == ' ') {
+		return c.listTools(strings.TrimSpace(arg))
+	}
+	if arg, ok := strings.CutPrefix(e.Command, "/system"); ok && (arg == "" || arg[0] == ' ') {
+		return c.handleSystemMessage(SystemMessage{Content: strings.TrimSpace(arg)})
+	}
 	switch e.Command {
 	case "/quit":
 		return []Event{FloorStopped{}}
 	case "/clear":
 		c.Messages = nil
 		c.CallStack = nil
+		c.asking = ""
 		c.passedAgents = make(map[string]bool)
+		c.turnPassed = make(map[string]bool)
+		c.dropWindows()
 		return []Event{ConversationCleared{}}
+	case "/reload":
+		return []Event{ReloadBlueprint{}}
+	case "/usage":
+		return []Event{ShowUsage{}}
+	case "/edit":
+		return c.editLast()
 	default:
 		return []Event{SystemInfo{Text: fmt.Sprintf("Unknown command: %s", e.Command)}}
 	}
 }
 
+// editLast handles /edit: only an agent's reply, as the last message, can
+// be edited.
+func (c *Controller) editLast() []Event {
+	if n := len(c.Messages); n == 0 || c.Messages[n-1].FromID == "@user" || c.Messages[n-1].FromID == systemID {
+		return []Event{SystemInfo{Text: "[/edit: the last message is not an agent's]"}}
+	}
+	last := c.Messages[len(c.Messages)-1]
+	return []Event{EditMessage{AgentID: last.FromID, Content: last.Content}}
+}
+
+// handleMessageEdited replaces the content of the agent's last message,
+// keeping its tool interactions. The agent is not re-run; everyone,
+// including the agent, sees the edited text from now on.
+func (c *Controller) handleMessageEdited(e MessageEdited) []Event {
+	n := len(c.Messages)
+	if n == 0 || c.Messages[n-1].FromID != e.AgentID {
+		return []Event{SystemInfo{Text: "[/edit: the conversation changed; edit discarded]"}}
+	}
+	if c.Messages[n-1].Content == e.Content {
+		return []Event{SystemInfo{Text: "[/edit: no changes]"}}
+	}
+	c.Messages[n-1].Content = e.Content
+	return []Event{SystemInfo{Text: fmt.Sprintf("[Edited %s's last message]", e.AgentID)}}
+}
+
+// listTools handles /tools [@agent].
+func (c *Controller) listTools(agentID string) []Event {
+	if agentID == "" {
+		return []Event{ListTools{}}
+	}
+	if c.getAgent(agentID) == nil {
+		return []Event{SystemInfo{Text: fmt.Sprintf("Unknown agent: %s", agentID)}}
+	}
+	return []Event{ListTools{AgentID: agentID}}
+}
+
+// Summary counts the messages and tool calls in the conversation. The
+// coordinator fills in the rest of the SessionSummary.
+func (c *Controller) Summary() SessionSummary {
+	var s SessionSummary
+	for _, msg := range c.Messages {
+		i := slices.IndexFunc(s.Messages, func(m MessageCount) bool { return m.AgentID == msg.FromID })
+		if i < 0 {
+			i = len(s.Messages)
+			s.Messages = append(s.Messages, MessageCount{AgentID: msg.FromID})
+		}
+		s.Messages[i].Count++
+		s.ToolCalls += len(msg.ToolInteractions)
+	}
+	return s
+}
+
+// handleBlueprintLoaded applies the prompt settings of a re-read blueprint
+// (shared_prompt, and each agent's prompt, temperature, model,
+// tool_context, tool_context_overrides and context_scope) to the live
+// blueprint, keeping the conversation. Other changes, including added or
+// removed agents, are reported as needing a restart.
+func (c *Controller) handleBlueprintLoaded(e BlueprintLoaded) []Event {
+	next := e.Blueprint
+	var updated, restart []string
+
+	if c.Blueprint.SharedPrompt != next.SharedPrompt {
+		c.Blueprint.SharedPrompt = next.SharedPrompt
+		updated = append(updated, "shared_prompt")
+	}
+
+	for i := range c.Blueprint.Agents {
+		agent := &c.Blueprint.Agents[i]
+		j := slices.IndexFunc(next.Agents, func(a blueprint.Agent) bool { return a.ID == agent.ID })
+		if j < 0 {
+			restart = append(restart, agent.ID+" removed")
+			continue
+		}
+		n := next.Agents[j]
+		before := *agent
+		agent.Prompt = n.Prompt
+		agent.PromptFile = n.PromptFile
+		agent.Temperature = n.Temperature
+		agent.Model = n.Model
+		agent.ToolContext = n.ToolContext
+		agent.ContextScope = n.ContextScope
+		agent.PeerToolContext = n.PeerToolContext
+		agent.ToolHistoryMode = n.ToolHistoryMode
+		if !reflect.DeepEqual(before, *agent) {
+			updated = append(updated, agent.ID)
+			// Earlier messages render differently now
+			c.dropWindows()
+		}
+		if !reflect.DeepEqual(*agent, n) {
+			restart = append(restart, "other settings of "+agent.ID)
+		}
+	}
+	for _, n := range next.Agents {
+		if c.getAgent(n.ID) == nil {
+			restart = append(restart, n.ID+" added")
+		}
+	}
+
+	// Everything but the agents and the shared prompt needs a restart.
+	cur, nxt := *c.Blueprint, *next
+	cur.Agents, nxt.Agents = nil, nil
+	cur.SharedPrompt, nxt.SharedPrompt = "", ""
+	if !reflect.DeepEqual(cur, nxt) {
+		restart = append(restart, "floor settings")
+	}
+
+	var events []Event
+	if len(updated) > 0 {
+		events = append(events, SystemInfo{Text: fmt.Sprintf("[Reloaded: %s]", strings.Join(updated, ", "))})
+	} else {
+		events = append(events, SystemInfo{Text: "[Reloaded: no prompt changes]"})
+	}
+	if len(restart) > 0 {
+		events = append(events, SystemInfo{Text: fmt.Sprintf("[Restart to apply: %s]", strings.Join(restart, ", "))})
+	}
+	return events
+}
+
 // advanceTurn calls nextRecipient and returns the appropriate event.
 func (c *Controller) advanceTurn() []Event {
 	next := c.nextRecipient(c.passedAgents)
+	if batch := c.batch; batch != nil {
+		c.batch = nil
+		return []Event{PromptAgents{AgentIDs: batch, Stack: c.stackSnapshot()}}
+	}
 	if next == nil {
-		return []Event{WaitingForUser{}}
+		return []Event{c.waitingForUser()}
+	}
+	return []Event{c.promptAgent(next.ID)}
+}
+
+// promptAgent builds a PromptAgent event carrying a snapshot of the call stack.
+func (c *Controller) promptAgent(agentID string) PromptAgent {
+	return PromptAgent{AgentID: agentID, Stack: c.stackSnapshot()}
+}
+
+// waitingForUser builds a WaitingForUser event carrying a snapshot of the call stack.
+func (c *Controller) waitingForUser() WaitingForUser {
+	return WaitingForUser{Stack: c.stackSnapshot()}
+}
+
+func (c *Controller) stackSnapshot() []Frame {
+	if len(c.CallStack) == 0 {
+		return nil
 	}
-	return []Event{PromptAgent{AgentID: next.ID}}
+	return append([]Frame(nil), c.CallStack...)
 }
 
 func (c *Controller) debug(format string, args ...any) {
@@ -132,12 +487,30 @@ func (c *Controller) nextRecipient(excluded map[string]bool) *blueprint.Agent {
 		for _, m := range mentions {
 			if m == "@user" {
 				c.debug("→ pausing for @user")
+				c.pauseForUser(lastMsg.FromID)
 				return nil
 			}
 		}
 	}
 
-	// 1. Explicit @mentions? → push frame, wake mentioned agent
+	// 1a. @everyone? → queue a frame per agent, first in blueprint order on top
+	//     (or, with concurrent_broadcast, one frame for a batch run together)
+	for _, m := range mentions {
+		if m == everyoneID {
+			if c.Blueprint.ConcurrentBroadcast {
+				if c.pushBatch(lastMsg.FromID, excluded) {
+					return nil
+				}
+				break
+			}
+			if agent := c.pushBroadcast(lastMsg.FromID, excluded); agent != nil {
+				return agent
+			}
+			break
+		}
+	}
+
+	// 1b. Explicit @mentions? → push frame, wake mentioned agent
 	for _, agent := range c.Blueprint.Agents {
 		if excluded[agent.ID] {
 			continue
@@ -155,47 +528,179 @@ func (c *Controller) nextRecipient(excluded map[string]bool) *blueprint.Agent {
 	}
 
 	// 2. No mentions → pop call stack (return to caller)
-	if len(c.CallStack) > 0 {
+	if next, ok := c.unwind(excluded); ok {
+		return next
+	}
+
+	// 3. Stack empty → poll shouldWake, highest priority first
+	for _, agent := range c.wakeOrder() {
+		if excluded[agent.ID] || c.turnPassed[agent.ID] {
+			c.debug("should_wake(%s): skipped (passed)", agent.ID)
+			continue
+		}
+		wake := c.shouldWake(agent, &lastMsg)
+		c.debug("should_wake(%s): %v", agent.ID, wake)
+		if wake {
+			return agent
+		}
+	}
+
+	// 4. Nobody → back to user
+	c.debug("→ back to user")
+	return nil
+}
+
+// unwind pops the top frame and returns who the reply goes to: the next
+// agent queued by the same @everyone?, or the frame's caller. A caller that
+// has already passed on this message has nothing to add, so its frame is
+// popped too and the reply carries on to its own caller. Returns nil when
+// the reply goes back to the user, and ok=false when the stack ran out
+// with no one to return to.
+func (c *Controller) unwind(excluded map[string]bool) (next *blueprint.Agent, ok bool) {
+	for len(c.CallStack) > 0 {
 		frame := c.CallStack[len(c.CallStack)-1]
 		c.CallStack = c.CallStack[:len(c.CallStack)-1]
 		c.debug("→ pop stack: caller=%s, callee=%s (stack=%d)", frame.Caller, frame.Callee, len(c.CallStack))
 
+		if next := c.nextBroadcastCallee(frame); next != nil {
+			c.debug("→ broadcast continues: %s", next.ID)
+			return next, true
+		}
+
 		if frame.Caller == "@user" {
 			c.debug("→ caller is @user, back to user")
-			return nil
+			return nil, true
 		}
 
 		caller := c.getAgent(frame.Caller)
 		if caller != nil && !excluded[caller.ID] {
-			return caller
+			return caller, true
 		}
+		c.debug("→ caller %s can't respond, unwinding further", frame.Caller)
 	}
+	return nil, false
+}
 
-	// 3. Poll shouldWake
-	for _, agent := range c.Blueprint.Agents {
-		if excluded[agent.ID] {
-			c.debug("should_wake(%s): skipped (passed)", agent.ID)
+// pauseForUser remembers that agentID asked the user a question while
+// answering the frame on top of the stack, so the reply can resume the
+// delegation. A question outside a delegation needs no bookkeeping: the
+// reply is routed like any other user message.
+func (c *Controller) pauseForUser(agentID string) {
+	if n := len(c.CallStack); n > 0 && c.CallStack[n-1].Callee == agentID {
+		c.asking = agentID
+	}
+}
+
+// pushBroadcast queues one frame per eligible agent for an @everyone? mention,
+// skipping the sender, excluded agents, and routers. Frames are pushed in reverse
+// blueprint order so the first agent is on top. Returns the first agent to
+// respond, or nil if nobody is eligible.
+func (c *Controller) pushBroadcast(from string, excluded map[string]bool) *blueprint.Agent {
+	var first *blueprint.Agent
+	for i := len(c.Blueprint.Agents) - 1; i >= 0; i-- {
+		agent := &c.Blueprint.Agents[i]
+		if agent.ID == from || excluded[agent.ID] || agent.Type == "router" {
 			continue
 		}
-		wake := c.shouldWake(&agent, &lastMsg)
-		c.debug("should_wake(%s): %v", agent.ID, wake)
-		if wake {
-			return &agent
+		c.CallStack = append(c.CallStack, Frame{
+			Caller:    from,
+			Callee:    agent.ID,
+			Broadcast: true,
+		})
+		first = agent
+	}
+	if first != nil {
+		c.debug("→ @everyone: queued broadcast (first=%s, stack=%d)", first.ID, len(c.CallStack))
+	}
+	return first
+}
+
+// pushBatch queues every eligible agent for a concurrent @everyone?, in
+// blueprint order, under a single frame whose callee is @everyone. The batch
+// is picked up by advanceTurn as a PromptAgents event. Returns false if
+// nobody is eligible.
+func (c *Controller) pushBatch(from string, excluded map[string]bool) bool {
+	var ids []string
+	for _, agent := range c.Blueprint.Agents {
+		if agent.ID == from || excluded[agent.ID] || agent.Type == "router" {
+			continue
 		}
+		ids = append(ids, agent.ID)
 	}
+	if len(ids) == 0 {
+		return false
+	}
+	c.CallStack = append(c.CallStack, Frame{Caller: from, Callee: everyoneID})
+	c.batch = ids
+	c.debug("→ @everyone: concurrent batch %v (stack=%d)", ids, len(c.CallStack))
+	return true
+}
 
-	// 4. Nobody → back to user
-	c.debug("→ back to user")
-	return nil
+// nextBroadcastCallee returns the next queued agent after a broadcast frame
+// has been popped, or nil if the broadcast is finished (or frame wasn't one).
+// The sibling frame stays on the stack while that agent responds.
+func (c *Controller) nextBroadcastCallee(popped Frame) *blueprint.Agent {
+	if !popped.Broadcast || len(c.CallStack) == 0 {
+		return nil
+	}
+	top := c.CallStack[len(c.CallStack)-1]
+	if !top.Broadcast || top.Caller != popped.Caller {
+		return nil
+	}
+	return c.getAgent(top.Callee)
+}
+
+// wakeOrder returns the agents in the order they are polled by shouldWake:
+// by priority (higher first), with blueprint order as the tiebreak.
+// Priority does not affect explicit @mention routing.
+func (c *Controller) wakeOrder() []*blueprint.Agent {
+	agents := make([]*blueprint.Agent, len(c.Blueprint.Agents))
+	for i := range c.Blueprint.Agents {
+		agents[i] = &c.Blueprint.Agents[i]
+	}
+	sort.SliceStable(agents, func(i, j int) bool {
+		return agents[i].Priority > agents[j].Priority
+	})
+	return agents
 }
 
-// shouldWake determines if an agent should respond to a message.
+// shouldWake determines if an agent should respond to a message. Routers
+// only route the user's messages.
 func (c *Controller) shouldWake(agent *blueprint.Agent, lastMsg *FloorMessage) bool {
 	if lastMsg.FromID == agent.ID {
 		return false
 	}
+	if agent.Type == "router" && lastMsg.FromID != "@user" {
+		return false
+	}
 	if agent.Activation == "always" {
-		return true
+		return !c.coolingDown(agent)
+	}
+	return false
+}
+
+// coolingDown reports whether an agent spoke too recently, by its cooldown,
+// to wake for a message it wasn't mentioned in.
+func (c *Controller) coolingDown(agent *blueprint.Agent) bool {
+	cd := agent.Cooldown
+	if cd.Messages <= 0 && cd.Duration <= 0 {
+		return false
+	}
+	for i := len(c.Messages) - 1; i >= 0; i-- {
+		msg := c.Messages[i]
+		if msg.FromID != agent.ID {
+			continue
+		}
+		since := len(c.Messages) - 1 - i
+		if since <= cd.Messages {
+			c.debug("cooldown(%s): %d messages since it spoke, cooldown %d", agent.ID, since, cd.Messages)
+			return true
+		}
+		if elapsed := c.Now().Sub(msg.Time); elapsed < cd.Duration {
+			c.debug("cooldown(%s): spoke %s ago, cooldown %s", agent.ID, elapsed.Round(time.Second), cd.Duration)
+			return true
+		}
+		return false
 	}
 	return false
 }
@@ -210,9 +715,20 @@ func (c *Controller) getAgent(id string) *blueprint.Agent {
 	return nil
 }
 
+// everyoneID is the special mention that addresses every agent on the floor.
+const everyoneID = "@everyone"
+
+// systemID is the sender of SystemMessage guidance on the floor.
+const systemID = "@system"
+
+// mentionRe matches an @id? mention.
+var mentionRe = regexp.MustCompile(`@(\w+)\?`)
+
 func extractMentions(content string) []string {
-	re := regexp.MustCompile(`@(\w+)\?`)
-	matches := re.FindAllStringSubmatch(content, -1)
+	if !strings.Contains(content, "?") {
+		return nil
+	}
+	matches := mentionRe.FindAllStringSubmatch(content, -1)
 	var mentions []string
 	for _, m := range matches {
 		mentions = append(mentions, "@"+m[1])
@@ -223,70 +739,93 @@ func extractMentions(content string) []string {
 // --- Context building (moved from floor.go, unchanged) ---
 
 // BuildContext converts floor messages to LLM messages for a specific agent,
-// applying tool_context filtering.
+// applying context_scope and tool_context (or tool_context_overrides) filtering.
 func (c *Controller) BuildContext(agent *blueprint.Agent) []llm.Message {
-	messages := []llm.Message{
-		{Role: "system", Content: agent.Prompt},
+	c.windowsMu.Lock()
+	defer c.windowsMu.Unlock()
+
+	// Every visible message but the latest is rendered once and kept in
+	// the agent's window; the latest may still be edited
+	w := c.window(agent)
+	for _, i := range w.visible[w.rendered:] {
+		w.built = c.appendContext(w.built, agent, c.Messages[i])
 	}
+	w.rendered = len(w.visible)
 
-	for _, msg := range c.Messages {
-		if msg.FromID == agent.ID {
-			// Own messages: role = "assistant", full tool context
-			if len(msg.ToolInteractions) > 0 {
-				for i, ti := range msg.ToolInteractions {
-					callID := fmt.Sprintf("call_%d", i)
-					messages = append(messages, llm.Message{
-						Role:    "assistant",
-						Content: msg.Content,
-						ToolCalls: []llm.ToolCall{
-							{
-								ID:   callID,
-								Type: "function",
-								Function: struct {
-									Name      string `json:"name"`
-									Arguments string `json:"arguments"`
-								}{
-									Name:      "bash",
-									Arguments: fmt.Sprintf(`{"cmd":%q}`, ti.Command),
-								},
+	messages := make([]llm.Message, 1, len(w.built)+2)
+	messages[0] = llm.Message{Role: "system", Content: c.systemPrompt(agent)}
+	messages = append(messages, w.built...)
+	if n := len(c.Messages); n > 0 {
+		messages = c.appendContext(messages, agent, c.Messages[n-1])
+	}
+	return messages
+}
+
+// appendContext appends msg, as agent sees it, to messages.
+func (c *Controller) appendContext(messages []llm.Message, agent *blueprint.Agent, msg FloorMessage) []llm.Message {
+	if msg.FromID == systemID {
+		return append(messages, llm.Message{Role: "system", Content: msg.Content})
+	}
+	if msg.FromID == agent.ID {
+		// Own messages: role = "assistant", full tool context
+		if len(msg.ToolInteractions) > 0 && agent.ToolHistoryMode == "inline" {
+			messages = append(messages, llm.Message{
+				Role:    "assistant",
+				Content: inlineToolHistory(msg),
+			})
+		} else if len(msg.ToolInteractions) > 0 {
+			for i, ti := range msg.ToolInteractions {
+				callID := fmt.Sprintf("call_%d", i)
+				messages = append(messages, llm.Message{
+					Role:    "assistant",
+					Content: msg.Content,
+					ToolCalls: []llm.ToolCall{
+						{
+							ID:   callID,
+							Type: "function",
+							Function: struct {
+								Name      string `json:"name"`
+								Arguments string `json:"arguments"`
+							}{
+								Name:      "bash",
+								Arguments: fmt.Sprintf(`{"cmd":%q}`, ti.Command),
 							},
 						},
-					})
-					messages = append(messages, llm.Message{
-						Role:       "tool",
-						Content:    ti.Output,
-						ToolCallID: callID,
-					})
-				}
-				if msg.Content != "" {
-					messages = append(messages, llm.Message{
-						Role:    "assistant",
-						Content: msg.Content,
-					})
-				}
-			} else {
+					},
+				})
+				messages = append(messages, llm.Message{
+					Role:       "tool",
+					Content:    ti.Output,
+					ToolCallID: callID,
+				})
+			}
+			if msg.Content != "" {
 				messages = append(messages, llm.Message{
 					Role:    "assistant",
 					Content: msg.Content,
 				})
 			}
 		} else {
-			// Other participants: role = "user", apply tool_context filtering
-			content := msg.Content
-			if len(msg.ToolInteractions) > 0 {
-				toolSummary := formatToolInteractions(msg.ToolInteractions, agent.ToolContext)
-				if toolSummary != "" {
-					content += "\n\n" + toolSummary
-				}
-			}
 			messages = append(messages, llm.Message{
-				Role:    "user",
-				Content: content,
-				Name:    strings.TrimPrefix(msg.FromID, "@"),
+				Role:    "assistant",
+				Content: msg.Content,
 			})
 		}
+	} else {
+		// Other participants: role = "user", apply tool_context filtering
+		content := msg.Content
+		if len(msg.ToolInteractions) > 0 {
+			toolSummary := formatToolInteractions(msg.ToolInteractions, agent.ToolContextFor(msg.FromID))
+			if toolSummary != "" {
+				content += "\n\n" + toolSummary
+			}
+		}
+		messages = append(messages, llm.Message{
+			Role:    "user",
+			Content: content,
+			Name:    strings.TrimPrefix(msg.FromID, "@"),
+		})
 	}
-
 	return messages
 }
 
@@ -295,18 +834,22 @@ func (c *Controller) BuildContext(agent *blueprint.Agent) []llm.Message {
 func (c *Controller) BuildACPContext(agent *blueprint.Agent) []acpsdk.ContentBlock {
 	var blocks []acpsdk.ContentBlock
 
-	if agent.Prompt != "" {
-		blocks = append(blocks, acpsdk.TextBlock("[System] "+agent.Prompt))
+	if prompt := c.systemPrompt(agent); prompt != "" {
+		blocks = append(blocks, acpsdk.TextBlock("[System] "+prompt))
 	}
 
-	for _, msg := range c.Messages {
+	for _, msg := range c.visibleMessages(agent) {
+		if msg.FromID == systemID {
+			blocks = append(blocks, acpsdk.TextBlock("[System] "+msg.Content))
+			continue
+		}
 		var sb strings.Builder
 		sb.WriteString(msg.FromID)
 		sb.WriteString(": ")
 		sb.WriteString(msg.Content)
 
 		if len(msg.ToolInteractions) > 0 {
-			level := agent.ToolContext
+			level := agent.ToolContextFor(msg.FromID)
 			if msg.FromID == agent.ID {
 				level = "full"
 			}
@@ -324,14 +867,129 @@ func (c *Controller) BuildACPContext(agent *blueprint.Agent) []acpsdk.ContentBlo
 	return blocks
 }
 
+// visibleMessages returns the floor messages the agent's context_scope lets
+// it see. With "involved" that is its own messages, messages that mention
+// it (or @everyone), the messages it replied to, system messages, and the
+// latest message, which it is about to answer.
+func (c *Controller) visibleMessages(agent *blueprint.Agent) []FloorMessage {
+	if agent.ContextScope != "involved" {
+		return c.Messages
+	}
+	c.windowsMu.Lock()
+	defer c.windowsMu.Unlock()
+	w := c.window(agent)
+	visible := make([]FloorMessage, 0, len(w.visible)+1)
+	for _, i := range w.visible {
+		visible = append(visible, c.Messages[i])
+	}
+	if n := len(c.Messages); n > 0 {
+		visible = append(visible, c.Messages[n-1])
+	}
+	return visible
+}
+
+// contextWindow is an agent's running view of the transcript, so that
+// building its context on a long floor only looks at messages added since
+// its last turn. It covers every message but the latest, whose visibility
+// depends on what comes next.
+type contextWindow struct {
+	agent    *blueprint.Agent
+	base     *FloorMessage // &Messages[0] when last updated
+	decided  int           // Messages[:decided] are sorted into visible
+	visible  []int         // indices of those the agent sees
+	rendered int           // visible[:rendered] are in built
+	built    []llm.Message // BuildContext's rendering of them
+}
+
+// window returns agent's contextWindow, brought up to date. A window is
+// rebuilt from scratch if the agent's settings or the transcript were
+// replaced, or if the transcript shrank; the controller drops all windows
+// when it rewrites earlier messages (see trimTranscript) or changes how
+// agents see them (/reload). The caller holds windowsMu.
+func (c *Controller) window(agent *blueprint.Agent) *contextWindow {
+	var base *FloorMessage
+	if len(c.Messages) > 0 {
+		base = &c.Messages[0]
+	}
+	settled := max(len(c.Messages)-1, 0)
+	w := c.windows[agent.ID]
+	if w == nil || w.agent != agent || w.base != base || w.decided > settled {
+		if c.windows == nil {
+			c.windows = make(map[string]*contextWindow)
+		}
+		w = &contextWindow{agent: agent}
+		c.windows[agent.ID] = w
+	}
+	for i := w.decided; i < settled; i++ {
+		if agent.ContextScope != "involved" || c.involves(agent, i) {
+			w.visible = append(w.visible, i)
+		}
+	}
+	w.base, w.decided = base, settled
+	return w
+}
+
+// dropWindows discards every agent's contextWindow.
+func (c *Controller) dropWindows() {
+	c.windowsMu.Lock()
+	defer c.windowsMu.Unlock()
+	c.windows = nil
+}
+
+// involves reports whether the i'th message, not the latest, is visible to
+// an agent with context_scope "involved".
+func (c *Controller) involves(agent *blueprint.Agent, i int) bool {
+	msg := c.Messages[i]
+	if msg.FromID == agent.ID || msg.FromID == systemID || c.Messages[i+1].FromID == agent.ID {
+		return true
+	}
+	// Mentions last: they are the costly check
+	mentions := extractMentions(msg.Content)
+	return slices.Contains(mentions, agent.ID) || slices.Contains(mentions, everyoneID)
+}
+
+// systemPrompt returns the agent's full system prompt: the blueprint's
+// shared prompt (unless the agent opts out) followed by the agent's own prompt,
+// separated by a blank line.
+func (c *Controller) systemPrompt(agent *blueprint.Agent) string {
+	shared := strings.TrimSpace(c.Blueprint.SharedPrompt)
+	if shared == "" || !agent.UsesSharedPrompt() {
+		return agent.Prompt
+	}
+	if agent.Prompt == "" {
+		return shared
+	}
+	return shared + "\n\n" + agent.Prompt
+}
+
 // --- Helpers (moved from floor.go) ---
 
+// summarizeLines returns the first maxLines lines of text, noting how many
+// more there were. It scans only as far as it needs to, since tool output
+// is summarized for every peer message on every turn.
 func summarizeLines(text string, maxLines int) string {
-	lines := strings.Split(strings.TrimSpace(text), "\n")
-	if len(lines) <= maxLines {
-		return strings.TrimSpace(text)
+	text = strings.TrimSpace(text)
+	cut := -1
+	for range maxLines {
+		next := strings.IndexByte(text[cut+1:], '\n')
+		if next < 0 {
+			return text
+		}
+		cut += next + 1
+	}
+	return text[:cut] + fmt.Sprintf("\n... (%d more lines)", strings.Count(text[cut+1:], "\n")+1)
+}
+
+// inlineToolHistory renders an agent's own message for tool_history_mode
+// "inline": its tool calls, in full, as text before its reply, for servers
+// that can't take tool_calls and tool messages in the history.
+func inlineToolHistory(msg FloorMessage) string {
+	var sb strings.Builder
+	for _, ti := range msg.ToolInteractions {
+		fmt.Fprintf(&sb, "I ran `%s` and got:\n```\n%s\n```\n\n", ti.Command, strings.TrimRight(ti.Output, "\n"))
 	}
-	return strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-maxLines)
+	sb.WriteString(msg.Content)
+	return strings.TrimRight(sb.String(), "\n")
 }
 
 func formatToolInteractions(interactions []ToolInteraction, level string) string {
@@ -2,7 +2,12 @@ package floor
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/openfloorcontrol/ofc/blueprint"
 )
@@ -43,6 +48,23 @@ func TestUserMessageTriggersAlwaysAgent(t *testing.T) {
 	}
 }
 
+func TestMessagesAreTimestamped(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+	clock := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	ctrl.Now = func() time.Time { return clock }
+
+	ctrl.HandleEvent(UserMessage{Content: "hello"})
+	clock = clock.Add(3 * time.Second)
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "hi"})
+
+	if len(ctrl.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(ctrl.Messages))
+	}
+	if got := ctrl.Messages[1].Time.Sub(ctrl.Messages[0].Time); got != 3*time.Second {
+		t.Errorf("expected messages 3s apart, got %v (%v)", got, ctrl.Messages)
+	}
+}
+
 func TestMentionDelegation(t *testing.T) {
 	ctrl := NewController(twoAgentBlueprint())
 
@@ -153,12 +175,251 @@ func TestPassAfterMentionFallsBack(t *testing.T) {
 	}
 }
 
+func TestTopLevelPassLastsForTurn(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@watch", Activation: "always"},
+			{ID: "@data", Activation: "always"},
+			{ID: "@code", Activation: "mention"},
+		},
+	}
+	ctrl := NewController(bp)
+
+	requirePrompt(t, ctrl.HandleEvent(UserMessage{Content: "hello"}), "@watch")
+	requirePrompt(t, ctrl.HandleEvent(AgentPassed{AgentID: "@watch"}), "@data")
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? run it"}), "@code")
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "ran"}), "@data")
+
+	// @watch passed on the user's request, so @data's reply doesn't wake it
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "all done"}), 0)
+
+	// ...but the user's next message does
+	requirePrompt(t, ctrl.HandleEvent(UserMessage{Content: "thanks"}), "@watch")
+}
+
+func TestTopLevelPassStillAnswersMentions(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@watch", Activation: "always"},
+			{ID: "@data", Activation: "always"},
+		},
+	}
+	ctrl := NewController(bp)
+
+	ctrl.HandleEvent(UserMessage{Content: "hello"})
+	ctrl.HandleEvent(AgentPassed{AgentID: "@watch"})
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@watch? anything to add?"}), "@watch")
+}
+
+func TestPassInsideDelegationCoversOnlyItsMessage(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@data", Activation: "always"},
+			{ID: "@code", Activation: "always"},
+		},
+	}
+	ctrl := NewController(bp)
+
+	requirePrompt(t, ctrl.HandleEvent(UserMessage{Content: "@code? run it"}), "@code")
+
+	// @code declines the question → @data is polled on the user's message
+	requirePrompt(t, ctrl.HandleEvent(AgentPassed{AgentID: "@code"}), "@data")
+
+	// The pass was about that question only: @code hears @data's reply
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "here's the plan"}), "@code")
+}
+
+func TestCalleePassDeclinesQuestion(t *testing.T) {
+	ctrl := NewController(delegationBlueprint())
+
+	ctrl.HandleEvent(UserMessage{Content: "@data? load the csv"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? parse it"})
+
+	// @code declines: @data's question stands as its reply to the user
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentPassed{AgentID: "@code"}), 0)
+	if len(ctrl.CallStack) != 0 {
+		t.Errorf("expected empty stack, got %+v", ctrl.CallStack)
+	}
+}
+
+func TestCalleePassThenCallerPasses(t *testing.T) {
+	bp := delegationBlueprint()
+	bp.Agents = append(bp.Agents, blueprint.Agent{ID: "@watch", Activation: "always"})
+	ctrl := NewController(bp)
+
+	ctrl.HandleEvent(UserMessage{Content: "@lead? build the report"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@lead", Content: "@data? get the numbers"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? query the db"})
+
+	// @code declines → @data's question is its reply, which returns to @lead
+	events := ctrl.HandleEvent(AgentPassed{AgentID: "@code"})
+	requirePrompt(t, events, "@lead")
+	if got := FormatStack(ctrl.CallStack); got != "@user → @lead" {
+		t.Fatalf("expected stack @user → @lead, got %q", got)
+	}
+
+	// @lead has nothing to add → back to the user, without polling @watch
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentPassed{AgentID: "@lead"}), 0)
+	if len(ctrl.CallStack) != 0 {
+		t.Errorf("expected empty stack, got %+v", ctrl.CallStack)
+	}
+}
+
+func TestMiddleAgentPassReturnsToItsCaller(t *testing.T) {
+	bp := delegationBlueprint()
+	bp.Agents = append(bp.Agents, blueprint.Agent{ID: "@watch", Activation: "always"})
+	ctrl := NewController(bp)
+
+	ctrl.HandleEvent(UserMessage{Content: "@lead? build the report"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@lead", Content: "@data? get the numbers"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? query the db"})
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "42 rows"}), "@data")
+
+	// @data passes on @code's answer → it goes on to @lead, whose frame is intact
+	events := ctrl.HandleEvent(AgentPassed{AgentID: "@data"})
+	requirePrompt(t, events, "@lead")
+	if got := FormatStack(requireEvent[PromptAgent](t, events, 0).Stack); got != "@user → @lead" {
+		t.Fatalf("expected stack @user → @lead, got %q", got)
+	}
+
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentDone{AgentID: "@lead", Content: "report done"}), 0)
+}
+
+func TestExcludedCallerIsUnwoundOnPop(t *testing.T) {
+	bp := delegationBlueprint()
+	bp.Agents = append(bp.Agents, blueprint.Agent{ID: "@watch", Activation: "always"})
+	ctrl := NewController(bp)
+
+	ctrl.HandleEvent(UserMessage{Content: "@lead? plan it"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@lead", Content: "@data? any numbers?"})
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@lead? which quarter?"}), "@lead")
+
+	// @lead declines @data's question. The reply would return to @lead,
+	// which has just passed, so the chain unwinds past it to the user
+	// rather than leaving its frame behind and polling @watch.
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentPassed{AgentID: "@lead"}), 0)
+	if len(ctrl.CallStack) != 0 {
+		t.Errorf("expected empty stack, got %+v", ctrl.CallStack)
+	}
+}
+
+func TestBroadcastPassAfterAnswerReturnsToCaller(t *testing.T) {
+	ctrl := NewController(delegationBlueprint())
+
+	ctrl.HandleEvent(UserMessage{Content: "@lead? poll the team"})
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@lead", Content: "@everyone? thoughts?"}), "@data")
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "looks good"}), "@code")
+
+	// @code passes on the last broadcast slot → @lead gets @data's answer
+	events := ctrl.HandleEvent(AgentPassed{AgentID: "@code"})
+	requirePrompt(t, events, "@lead")
+	if got := FormatStack(ctrl.CallStack); got != "@user → @lead" {
+		t.Errorf("expected stack @user → @lead, got %q", got)
+	}
+}
+
 func TestQuitCommand(t *testing.T) {
 	ctrl := NewController(twoAgentBlueprint())
 	events := ctrl.HandleEvent(UserCommand{Command: "/quit"})
 	requireEvent[FloorStopped](t, events, 0)
 }
 
+func TestToolsCommand(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+	if lt := requireEvent[ListTools](t, ctrl.HandleEvent(UserCommand{Command: "/tools"}), 0); lt.AgentID != "" {
+		t.Errorf("expected all agents, got %q", lt.AgentID)
+	}
+	if lt := requireEvent[ListTools](t, ctrl.HandleEvent(UserCommand{Command: "/tools @code"}), 0); lt.AgentID != "@code" {
+		t.Errorf("expected @code, got %q", lt.AgentID)
+	}
+	info := requireEvent[SystemInfo](t, ctrl.HandleEvent(UserCommand{Command: "/tools @ghost"}), 0)
+	if info.Text != "Unknown agent: @ghost" {
+		t.Errorf("unexpected reply: %q", info.Text)
+	}
+	requireEvent[SystemInfo](t, ctrl.HandleEvent(UserCommand{Command: "/toolsx"}), 0)
+}
+
+func TestReloadAppliesPromptChanges(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+	ctrl.HandleEvent(UserMessage{Content: "hello"})
+
+	requireEvent[ReloadBlueprint](t, ctrl.HandleEvent(UserCommand{Command: "/reload"}), 0)
+
+	next := twoAgentBlueprint()
+	next.Agents[0].Prompt = "You are @data, now terser."
+	next.Agents[0].Model = "bigger-model"
+	events := ctrl.HandleEvent(BlueprintLoaded{Blueprint: next})
+
+	if info := requireEvent[SystemInfo](t, events, 0); info.Text != "[Reloaded: @data]" {
+		t.Errorf("unexpected reload notice: %q", info.Text)
+	}
+	if len(events) != 1 {
+		t.Errorf("expected no restart warning, got %v", events)
+	}
+	data := ctrl.getAgent("@data")
+	if data.Prompt != "You are @data, now terser." || data.Model != "bigger-model" {
+		t.Errorf("changes not applied: %+v", data)
+	}
+	if len(ctrl.Messages) != 1 {
+		t.Errorf("reload should keep the conversation, got %d messages", len(ctrl.Messages))
+	}
+}
+
+func TestReloadWarnsAboutStructuralChanges(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+
+	next := twoAgentBlueprint()
+	next.Agents[1] = blueprint.Agent{ID: "@review", Activation: "mention", ToolContext: "full"}
+	next.Agents[0].Activation = "mention"
+	next.Agents[0].ToolContext = "summary"
+	next.ConcurrentBroadcast = true
+	events := ctrl.HandleEvent(BlueprintLoaded{Blueprint: next})
+
+	requireEvent[SystemInfo](t, events, 0)
+	want := "[Restart to apply: other settings of @data, @code removed, @review added, floor settings]"
+	if info := requireEvent[SystemInfo](t, events, 1); info.Text != want {
+		t.Errorf("restart warning:\n got %q\nwant %q", info.Text, want)
+	}
+	data := ctrl.getAgent("@data")
+	if data.ToolContext != "summary" || data.Activation != "always" {
+		t.Errorf("expected only prompt settings to change: %+v", data)
+	}
+	if ctrl.getAgent("@code") == nil || ctrl.getAgent("@review") != nil {
+		t.Error("agents should not be added or removed by a reload")
+	}
+}
+
+func TestReloadRebuildsContext(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+	ctrl.Messages = []FloorMessage{
+		{FromID: "@user", Content: "@code check the config"},
+		{FromID: "@code", Content: "Checked.", ToolInteractions: []ToolInteraction{{Command: "cat config", Output: "peer tool output"}}},
+		{FromID: "@user", Content: "thanks"},
+	}
+	data := ctrl.getAgent("@data")
+	sees := func() bool {
+		for _, m := range ctrl.BuildContext(data) {
+			if strings.Contains(m.Content, "peer tool output") {
+				return true
+			}
+		}
+		return false
+	}
+	if !sees() {
+		t.Fatal("with tool_context full, @data should see @code's tool output")
+	}
+
+	next := twoAgentBlueprint()
+	next.Agents[0].ToolContext = "none"
+	ctrl.HandleEvent(BlueprintLoaded{Blueprint: next})
+	if sees() {
+		t.Error("after reloading with tool_context none, @data still sees @code's tool output")
+	}
+}
+
 func TestClearCommand(t *testing.T) {
 	ctrl := NewController(twoAgentBlueprint())
 
@@ -178,6 +439,41 @@ func TestClearCommand(t *testing.T) {
 	}
 }
 
+func TestSystemMessageGuidesAgents(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+	ctrl.Blueprint.Agents[1].ContextScope = "involved"
+
+	ctrl.HandleEvent(UserMessage{Content: "hello"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "hi"})
+
+	// Guidance doesn't start a turn
+	events := ctrl.HandleEvent(SystemMessage{Content: "Answer in French from now on."})
+	if len(events) != 1 {
+		t.Fatalf("expected only a SystemInfo, got %v", events)
+	}
+	requireEvent[SystemInfo](t, events, 0)
+	ctrl.HandleEvent(UserCommand{Command: "/system Keep it short."})
+	ctrl.HandleEvent(UserMessage{Content: "@code? bonjour"})
+
+	// Rendered as system messages in place, even for an "involved" scope
+	msgs := ctrl.BuildContext(ctrl.getAgent("@code"))
+	var roles []string
+	for _, m := range msgs {
+		roles = append(roles, m.Role)
+	}
+	if want := []string{"system", "system", "system", "user"}; !slices.Equal(roles, want) {
+		t.Fatalf("roles: got %v, want %v", roles, want)
+	}
+	if msgs[1].Content != "Answer in French from now on." || msgs[1].Name != "" {
+		t.Errorf("unexpected system message: %+v", msgs[1])
+	}
+
+	// Not an agent's reply to edit
+	requireEvent[SystemInfo](t, ctrl.HandleEvent(UserCommand{Command: "/system"}), 0)
+	ctrl.HandleEvent(SystemMessage{Content: "One more thing."})
+	requireEvent[SystemInfo](t, ctrl.HandleEvent(UserCommand{Command: "/edit"}), 0)
+}
+
 func TestUnknownCommand(t *testing.T) {
 	ctrl := NewController(twoAgentBlueprint())
 	events := ctrl.HandleEvent(UserCommand{Command: "/foo"})
@@ -201,6 +497,21 @@ func TestAgentErrorReturnsToUser(t *testing.T) {
 	requireEvent[WaitingForUser](t, events, 1)
 }
 
+func TestCancelledTurnClearsStack(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+	ctrl.HandleEvent(UserMessage{Content: "@code? run it"})
+
+	events := ctrl.HandleEvent(AgentError{AgentID: "@code", Err: ErrTurnCancelled})
+	info := requireEvent[SystemInfo](t, events, 0)
+	if info.Text != "[@code: turn cancelled]" {
+		t.Errorf("unexpected info: %q", info.Text)
+	}
+	requireEvent[WaitingForUser](t, events, 1)
+	if len(ctrl.CallStack) != 0 {
+		t.Errorf("expected empty stack, got %+v", ctrl.CallStack)
+	}
+}
+
 func TestMentionsUserPausesForUser(t *testing.T) {
 	ctrl := NewController(twoAgentBlueprint())
 
@@ -215,6 +526,105 @@ func TestMentionsUserPausesForUser(t *testing.T) {
 	requireEvent[WaitingForUser](t, events, 0)
 }
 
+// delegationBlueprint has three mention-only agents, so only @mentions and
+// the call stack decide who speaks.
+func delegationBlueprint() *blueprint.Blueprint {
+	return &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@lead", Activation: "mention"},
+			{ID: "@data", Activation: "mention"},
+			{ID: "@code", Activation: "mention"},
+		},
+	}
+}
+
+// requirePrompt asserts that events hold a single PromptAgent for agentID.
+func requirePrompt(t *testing.T, events []Event, agentID string) {
+	t.Helper()
+	if pa := requireEvent[PromptAgent](t, events, 0); pa.AgentID != agentID {
+		t.Fatalf("expected %s to be prompted, got %s", agentID, pa.AgentID)
+	}
+}
+
+func TestUserQuestionMidDelegationTwoLevels(t *testing.T) {
+	ctrl := NewController(delegationBlueprint())
+
+	requirePrompt(t, ctrl.HandleEvent(UserMessage{Content: "@data? load the csv"}), "@data")
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? parse it"}), "@code")
+
+	// @code asks the user → pause with the delegation intact
+	events := ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "@user? which delimiter?"})
+	wait := requireEvent[WaitingForUser](t, events, 0)
+	if got := FormatStack(wait.Stack); got != "@user → @data → @code" {
+		t.Fatalf("expected stack @user → @data → @code, got %q", got)
+	}
+
+	// The answer goes to @code, which still owes @data a reply
+	events = ctrl.HandleEvent(UserMessage{Content: "semicolons"})
+	requirePrompt(t, events, "@code")
+	if got := FormatStack(requireEvent[PromptAgent](t, events, 0).Stack); got != "@user → @data → @code" {
+		t.Errorf("expected stack @user → @data → @code, got %q", got)
+	}
+
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "parsed"}), "@data")
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "loaded"}), 0)
+	if len(ctrl.CallStack) != 0 {
+		t.Errorf("expected empty stack, got %+v", ctrl.CallStack)
+	}
+}
+
+func TestUserQuestionMidDelegationThreeLevels(t *testing.T) {
+	ctrl := NewController(delegationBlueprint())
+
+	ctrl.HandleEvent(UserMessage{Content: "@lead? build the report"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@lead", Content: "@data? get the numbers"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? query the db"})
+
+	// The middle agent's callee asks, and is answered twice in a row
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "@user? which table?"}), 0)
+	requirePrompt(t, ctrl.HandleEvent(UserMessage{Content: "sales"}), "@code")
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "@user? which year?"}), 0)
+	requirePrompt(t, ctrl.HandleEvent(UserMessage{Content: "2025"}), "@code")
+
+	// Unwinds back through every caller
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "42 rows"}), "@data")
+
+	// A question further up resumes at that level
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@user? totals or averages?"}), 0)
+	requirePrompt(t, ctrl.HandleEvent(UserMessage{Content: "totals"}), "@data")
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "total is 9000"}), "@lead")
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentDone{AgentID: "@lead", Content: "report done"}), 0)
+	if len(ctrl.CallStack) != 0 {
+		t.Errorf("expected empty stack, got %+v", ctrl.CallStack)
+	}
+}
+
+func TestUserQuestionAnsweredWithMentionStartsOver(t *testing.T) {
+	ctrl := NewController(delegationBlueprint())
+
+	ctrl.HandleEvent(UserMessage{Content: "@data? load the csv"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? parse it"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "@user? which delimiter?"})
+
+	// Mentioning an agent redirects: the old chain is abandoned
+	requirePrompt(t, ctrl.HandleEvent(UserMessage{Content: "@lead? take over"}), "@lead")
+	if got := FormatStack(ctrl.CallStack); got != "@user → @lead" {
+		t.Errorf("expected stack @user → @lead, got %q", got)
+	}
+}
+
+func TestHandoffToUserMidDelegationResumes(t *testing.T) {
+	ctrl := NewController(delegationBlueprint())
+
+	ctrl.HandleEvent(UserMessage{Content: "@data? load the csv"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? parse it"})
+	requireEvent[WaitingForUser](t, ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "need input", Handoff: "@user"}), 0)
+
+	requirePrompt(t, ctrl.HandleEvent(UserMessage{Content: "commas"}), "@code")
+	requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "parsed"}), "@data")
+}
+
 func TestToolInteractionsPreserved(t *testing.T) {
 	ctrl := NewController(twoAgentBlueprint())
 
@@ -245,3 +655,499 @@ func TestNoMessagesReturnsNil(t *testing.T) {
 	events := ctrl.advanceTurn()
 	requireEvent[WaitingForUser](t, events, 0)
 }
+
+func TestSharedPromptPrepended(t *testing.T) {
+	optOut := false
+	bp := &blueprint.Blueprint{
+		Name:         "test",
+		SharedPrompt: "Use @name? to ask someone.\n",
+		Agents: []blueprint.Agent{
+			{ID: "@a", Prompt: "You are @a.", ToolContext: "full"},
+			{ID: "@b", Prompt: "You are @b.", ToolContext: "full", InheritSharedPrompt: &optOut},
+		},
+	}
+	ctrl := NewController(bp)
+
+	msgs := ctrl.BuildContext(&bp.Agents[0])
+	if want := "Use @name? to ask someone.\n\nYou are @a."; msgs[0].Content != want {
+		t.Errorf("expected shared prompt first, got %q", msgs[0].Content)
+	}
+
+	msgs = ctrl.BuildContext(&bp.Agents[1])
+	if msgs[0].Content != "You are @b." {
+		t.Errorf("expected opted-out agent to keep own prompt, got %q", msgs[0].Content)
+	}
+
+	blocks := ctrl.BuildACPContext(&bp.Agents[0])
+	if got := blocks[0].Text.Text; got != "[System] Use @name? to ask someone.\n\nYou are @a." {
+		t.Errorf("unexpected ACP system block: %q", got)
+	}
+}
+
+func TestContextScopeInvolved(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@lead", Prompt: "You lead.", ToolContext: "full"},
+			{ID: "@sql", Prompt: "You write SQL.", ToolContext: "full", ContextScope: "involved"},
+		},
+	}
+	ctrl := NewController(bp)
+	ctrl.Messages = []FloorMessage{
+		{FromID: "@user", Content: "plan the release"},
+		{FromID: "@lead", Content: "drafting the changelog"},
+		{FromID: "@lead", Content: "@sql? how many users signed up?"},
+		{FromID: "@sql", Content: "42"},
+		{FromID: "@user", Content: "thanks"},
+		{FromID: "@lead", Content: "@everyone? anything else?"},
+		{FromID: "@user", Content: "unrelated chatter"},
+		{FromID: "@lead", Content: "last call"},
+	}
+
+	var got []string
+	for _, m := range ctrl.BuildContext(&bp.Agents[1]) {
+		got = append(got, m.Role+": "+m.Content)
+	}
+	want := []string{
+		"system: You write SQL.",
+		"user: @sql? how many users signed up?",
+		"assistant: 42",
+		"user: @everyone? anything else?",
+		"user: last call",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("context:\n got %q\nwant %q", got, want)
+	}
+
+	if blocks := ctrl.BuildACPContext(&bp.Agents[1]); len(blocks) != 6 {
+		t.Errorf("expected system, 4 messages and the turn prompt, got %d blocks", len(blocks))
+	}
+	if msgs := ctrl.BuildContext(&bp.Agents[0]); len(msgs) != 9 {
+		t.Errorf("expected @lead to see every message, got %d", len(msgs)-1)
+	}
+}
+
+func TestToolContextOverrides(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@code", ToolContext: "full"},
+			{ID: "@research", ToolContext: "full"},
+			{ID: "@review", ToolContext: "summary", PeerToolContext: map[string]string{"@code": "full", "@research": "none"}},
+		},
+	}
+	ctrl := NewController(bp)
+	ls := []ToolInteraction{{Command: "ls", Output: "a\nb\nc\nd"}}
+	ctrl.Messages = []FloorMessage{
+		{FromID: "@code", Content: "built it", ToolInteractions: ls},
+		{FromID: "@research", Content: "found it", ToolInteractions: ls},
+		{FromID: "@user", Content: "review please", ToolInteractions: ls},
+	}
+
+	msgs := ctrl.BuildContext(&bp.Agents[2])
+	want := []string{
+		"built it\n\n$ ls\na\nb\nc\nd",
+		"found it",
+		"review please\n\n$ ls\na\nb\nc\n... (1 more lines)",
+	}
+	for i, w := range want {
+		if got := msgs[i+1].Content; got != w {
+			t.Errorf("message %d = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestInlineToolHistory(t *testing.T) {
+	bp := twoAgentBlueprint()
+	bp.Agents[0].ToolHistoryMode = "inline"
+	ctrl := NewController(bp)
+	ctrl.Messages = []FloorMessage{
+		{FromID: "@user", Content: "what's here?"},
+		{FromID: "@data", Content: "Two files.", ToolInteractions: []ToolInteraction{
+			{Command: "ls", Output: "a.csv\nb.csv\n"},
+		}},
+		{FromID: "@user", Content: "thanks"},
+	}
+
+	msgs := ctrl.BuildContext(&bp.Agents[0])
+	if len(msgs) != 4 {
+		t.Fatalf("expected system + 3 messages, got %d: %+v", len(msgs), msgs)
+	}
+	own := msgs[2]
+	want := "I ran `ls` and got:\n```\na.csv\nb.csv\n```\n\nTwo files."
+	if own.Role != "assistant" || own.Content != want || own.ToolCalls != nil {
+		t.Errorf("own message = %+v, want assistant text %q", own, want)
+	}
+	for _, m := range msgs {
+		if m.Role == "tool" {
+			t.Errorf("unexpected tool message: %+v", m)
+		}
+	}
+}
+
+func threeAgentBlueprint() *blueprint.Blueprint {
+	return &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@a", Activation: "mention", ToolContext: "full"},
+			{ID: "@b", Activation: "mention", ToolContext: "full"},
+			{ID: "@c", Activation: "mention", ToolContext: "full"},
+		},
+	}
+}
+
+func TestEveryoneBroadcastFromUser(t *testing.T) {
+	ctrl := NewController(threeAgentBlueprint())
+
+	events := ctrl.HandleEvent(UserMessage{Content: "standup, @everyone?"})
+	pa := requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@a" {
+		t.Fatalf("expected @a first, got %s", pa.AgentID)
+	}
+
+	events = ctrl.HandleEvent(AgentDone{AgentID: "@a", Content: "a update"})
+	pa = requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@b" {
+		t.Fatalf("expected @b next, got %s", pa.AgentID)
+	}
+
+	// @b passes → still continues the broadcast
+	events = ctrl.HandleEvent(AgentPassed{AgentID: "@b"})
+	pa = requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@c" {
+		t.Fatalf("expected @c next, got %s", pa.AgentID)
+	}
+
+	events = ctrl.HandleEvent(AgentDone{AgentID: "@c", Content: "c update"})
+	requireEvent[WaitingForUser](t, events, 0)
+	if len(ctrl.CallStack) != 0 {
+		t.Errorf("expected empty stack, got %+v", ctrl.CallStack)
+	}
+}
+
+func TestEveryoneBroadcastFromAgentReturnsToCaller(t *testing.T) {
+	ctrl := NewController(threeAgentBlueprint())
+
+	ctrl.HandleEvent(UserMessage{Content: "@a? run a retro"})
+	events := ctrl.HandleEvent(AgentDone{AgentID: "@a", Content: "@everyone? what went well?"})
+	pa := requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@b" {
+		t.Fatalf("expected @b (sender excluded), got %s", pa.AgentID)
+	}
+
+	events = ctrl.HandleEvent(AgentDone{AgentID: "@b", Content: "b"})
+	pa = requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@c" {
+		t.Fatalf("expected @c, got %s", pa.AgentID)
+	}
+
+	// Broadcast done → back to @a, the sender
+	events = ctrl.HandleEvent(AgentDone{AgentID: "@c", Content: "c"})
+	pa = requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@a" {
+		t.Fatalf("expected return to @a, got %s", pa.AgentID)
+	}
+}
+
+func TestConcurrentBroadcastBatch(t *testing.T) {
+	bp := threeAgentBlueprint()
+	bp.ConcurrentBroadcast = true
+	ctrl := NewController(bp)
+
+	events := ctrl.HandleEvent(UserMessage{Content: "standup, @everyone?"})
+	batch := requireEvent[PromptAgents](t, events, 0)
+	if got := strings.Join(batch.AgentIDs, ","); got != "@a,@b,@c" {
+		t.Fatalf("expected batch @a,@b,@c, got %s", got)
+	}
+	if got := FormatStack(batch.Stack); got != "@user → @everyone" {
+		t.Errorf("stack = %q", got)
+	}
+
+	events = ctrl.HandleEvent(AgentsDone{Results: []Event{
+		AgentDone{AgentID: "@a", Content: "a update"},
+		AgentPassed{AgentID: "@b"},
+		AgentDone{AgentID: "@c", Content: "c update"},
+	}})
+	requireEvent[WaitingForUser](t, events, 0)
+	if len(ctrl.Messages) != 3 || ctrl.Messages[1].FromID != "@a" || ctrl.Messages[2].FromID != "@c" {
+		t.Fatalf("expected user, @a, @c messages in order, got %+v", ctrl.Messages)
+	}
+	if len(ctrl.CallStack) != 0 {
+		t.Errorf("expected empty stack, got %+v", ctrl.CallStack)
+	}
+}
+
+func TestConcurrentBroadcastReturnsToCaller(t *testing.T) {
+	bp := threeAgentBlueprint()
+	bp.ConcurrentBroadcast = true
+	ctrl := NewController(bp)
+
+	ctrl.HandleEvent(UserMessage{Content: "@a? run a retro"})
+	events := ctrl.HandleEvent(AgentDone{AgentID: "@a", Content: "@everyone? what went well?"})
+	batch := requireEvent[PromptAgents](t, events, 0)
+	if got := strings.Join(batch.AgentIDs, ","); got != "@b,@c" {
+		t.Fatalf("expected batch @b,@c (sender excluded), got %s", got)
+	}
+
+	// Everyone passes → back to @a, the sender
+	events = ctrl.HandleEvent(AgentsDone{Results: []Event{
+		AgentPassed{AgentID: "@b"},
+		AgentPassed{AgentID: "@c"},
+	}})
+	pa := requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@a" {
+		t.Fatalf("expected return to @a, got %s", pa.AgentID)
+	}
+}
+
+func TestConcurrentBroadcastErrorReturnsToUser(t *testing.T) {
+	bp := threeAgentBlueprint()
+	bp.ConcurrentBroadcast = true
+	ctrl := NewController(bp)
+
+	ctrl.HandleEvent(UserMessage{Content: "@everyone?"})
+	events := ctrl.HandleEvent(AgentsDone{Results: []Event{
+		AgentDone{AgentID: "@a", Content: "a"},
+		AgentError{AgentID: "@b", Err: fmt.Errorf("boom")},
+		AgentDone{AgentID: "@c", Content: "c"},
+	}})
+	info := requireEvent[SystemInfo](t, events, 0)
+	if !strings.Contains(info.Text, "boom") {
+		t.Errorf("expected error info, got %q", info.Text)
+	}
+	requireEvent[WaitingForUser](t, events, 1)
+	if len(ctrl.Messages) != 3 {
+		t.Errorf("expected successful replies kept, got %d messages", len(ctrl.Messages))
+	}
+}
+
+func TestPriorityOrdersAlwaysAgents(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@a", Activation: "always", ToolContext: "full"},
+			{ID: "@b", Activation: "always", ToolContext: "full", Priority: 10},
+			{ID: "@c", Activation: "always", ToolContext: "full", Priority: 10},
+		},
+	}
+	ctrl := NewController(bp)
+
+	// @b and @c outrank @a; blueprint order breaks the tie
+	events := ctrl.HandleEvent(UserMessage{Content: "hello"})
+	pa := requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@b" {
+		t.Fatalf("expected @b, got %s", pa.AgentID)
+	}
+
+	events = ctrl.HandleEvent(AgentPassed{AgentID: "@b"})
+	pa = requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@c" {
+		t.Fatalf("expected @c, got %s", pa.AgentID)
+	}
+
+	events = ctrl.HandleEvent(AgentPassed{AgentID: "@c"})
+	pa = requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@a" {
+		t.Fatalf("expected @a, got %s", pa.AgentID)
+	}
+}
+
+func TestCooldownSkipsAlwaysAgent(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@helper", Activation: "always", ToolContext: "full", Cooldown: blueprint.Cooldown{Messages: 1}},
+		},
+	}
+	ctrl := NewController(bp)
+
+	ctrl.HandleEvent(UserMessage{Content: "hello"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@helper", Content: "hi"})
+
+	// Sits out the next message...
+	events := ctrl.HandleEvent(UserMessage{Content: "thanks"})
+	requireEvent[WaitingForUser](t, events, 0)
+
+	// ...but wakes for the one after.
+	events = ctrl.HandleEvent(UserMessage{Content: "one more thing"})
+	if pa := requireEvent[PromptAgent](t, events, 0); pa.AgentID != "@helper" {
+		t.Fatalf("expected @helper, got %s", pa.AgentID)
+	}
+	ctrl.HandleEvent(AgentDone{AgentID: "@helper", Content: "sure"})
+
+	// A mention overrides the cooldown.
+	events = ctrl.HandleEvent(UserMessage{Content: "@helper? again"})
+	if pa := requireEvent[PromptAgent](t, events, 0); pa.AgentID != "@helper" {
+		t.Fatalf("expected @helper, got %s", pa.AgentID)
+	}
+}
+
+func TestCooldownDuration(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "test",
+		Agents: []blueprint.Agent{
+			{ID: "@helper", Activation: "always", ToolContext: "full", Cooldown: blueprint.Cooldown{Duration: time.Minute}},
+		},
+	}
+	ctrl := NewController(bp)
+	clock := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	ctrl.Now = func() time.Time { return clock }
+
+	ctrl.HandleEvent(UserMessage{Content: "hello"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@helper", Content: "hi"})
+
+	clock = clock.Add(30 * time.Second)
+	events := ctrl.HandleEvent(UserMessage{Content: "thanks"})
+	requireEvent[WaitingForUser](t, events, 0)
+
+	clock = clock.Add(30 * time.Second)
+	events = ctrl.HandleEvent(UserMessage{Content: "one more thing"})
+	if pa := requireEvent[PromptAgent](t, events, 0); pa.AgentID != "@helper" {
+		t.Fatalf("expected @helper, got %s", pa.AgentID)
+	}
+}
+
+func TestPromptAgentCarriesStack(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+
+	ctrl.HandleEvent(UserMessage{Content: "@data? look at this"})
+	events := ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? help"})
+	pa := requireEvent[PromptAgent](t, events, 0)
+	if got := FormatStack(pa.Stack); got != "@user → @data → @code" {
+		t.Errorf("unexpected breadcrumb: %q", got)
+	}
+
+	// Snapshot must not alias the live stack
+	ctrl.CallStack[0].Caller = "@mutated"
+	if pa.Stack[0].Caller != "@user" {
+		t.Error("stack snapshot aliases controller state")
+	}
+}
+
+func TestFormatStackBroadcast(t *testing.T) {
+	frames := []Frame{
+		{Caller: "@user", Callee: "@c", Broadcast: true},
+		{Caller: "@user", Callee: "@b", Broadcast: true},
+		{Caller: "@b", Callee: "@code"},
+	}
+	if got := FormatStack(frames); got != "@user → @b → @code" {
+		t.Errorf("unexpected breadcrumb: %q", got)
+	}
+	if got := FormatStack(nil); got != "" {
+		t.Errorf("expected empty breadcrumb, got %q", got)
+	}
+}
+
+func TestHandoffPushesFrame(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+
+	ctrl.HandleEvent(UserMessage{Content: "hello"})
+	events := ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "over to you", Handoff: "@code"})
+	pa := requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@code" {
+		t.Fatalf("expected @code, got %s", pa.AgentID)
+	}
+	if len(ctrl.CallStack) != 1 || ctrl.CallStack[0].Caller != "@data" {
+		t.Fatalf("expected @data → @code frame, got %+v", ctrl.CallStack)
+	}
+
+	// @code answers → returns to @data like a normal delegation
+	events = ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "done"})
+	pa = requireEvent[PromptAgent](t, events, 0)
+	if pa.AgentID != "@data" {
+		t.Errorf("expected return to @data, got %s", pa.AgentID)
+	}
+}
+
+func TestHandoffToUnknownAgentFallsBack(t *testing.T) {
+	ctrl := NewController(twoAgentBlueprint())
+
+	ctrl.HandleEvent(UserMessage{Content: "@code? hi"})
+	events := ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "hi", Handoff: "@nobody"})
+	requireEvent[WaitingForUser](t, events, 0)
+}
+
+// longFloor returns a controller holding n messages of a busy floor: user
+// requests, delegations, and tool output.
+func longFloor(n int) *Controller {
+	ctrl := NewController(twoAgentBlueprint())
+	ls := []ToolInteraction{{Command: "ls -la /workspace", Output: strings.Repeat("-rw-r--r-- 1 ofc ofc 1234 data.csv\n", 20)}}
+	for i := range n {
+		var msg FloorMessage
+		switch i % 4 {
+		case 0:
+			msg = FloorMessage{FromID: "@user", Content: fmt.Sprintf("request %d: what's in the workspace?", i)}
+		case 1:
+			msg = FloorMessage{FromID: "@data", Content: "@code? list the files please"}
+		case 2:
+			msg = FloorMessage{FromID: "@code", Content: "Here they are.", ToolInteractions: ls}
+		case 3:
+			msg = FloorMessage{FromID: "@data", Content: "The workspace holds data.csv, among others."}
+		}
+		ctrl.Messages = append(ctrl.Messages, msg)
+	}
+	return ctrl
+}
+
+func TestBuildContextWindowMatchesFreshBuild(t *testing.T) {
+	all := longFloor(40).Messages
+	ctrl := NewController(twoAgentBlueprint())
+	ctrl.Blueprint.Limits.MaxTranscriptSize = 5000
+	agent := *ctrl.getAgent("@data")
+	agent.ContextScope = "involved"
+	agent.ToolContext = "summary"
+
+	check := func(step string) {
+		t.Helper()
+		fresh := NewController(ctrl.Blueprint)
+		fresh.Messages = slices.Clone(ctrl.Messages)
+		got, want := ctrl.BuildContext(&agent), fresh.BuildContext(&agent)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: incremental context differs from a fresh build:\n got %d messages\nwant %d messages", step, len(got), len(want))
+		}
+	}
+	for i, msg := range all {
+		ctrl.appendMessage(msg) // trims once the transcript passes 5000 bytes
+		check(fmt.Sprintf("message %d", i))
+		if msg.FromID == "@code" {
+			ctrl.Messages[len(ctrl.Messages)-1].Content = "@data? edited"
+			check(fmt.Sprintf("edit after message %d", i))
+		}
+	}
+	ctrl.HandleEvent(UserCommand{Command: "/clear"})
+	check("clear")
+	ctrl.Messages = all[:3]
+	check("replaced transcript")
+}
+
+func BenchmarkBuildContext(b *testing.B) {
+	ctrl := longFloor(4000)
+	for _, scope := range []string{"all", "involved"} {
+		b.Run(scope, func(b *testing.B) {
+			agent := *ctrl.getAgent("@data")
+			agent.ContextScope = scope
+			agent.ToolContext = "summary"
+			b.ReportAllocs()
+			for b.Loop() {
+				ctrl.BuildContext(&agent)
+			}
+		})
+	}
+}
+
+func BenchmarkExtractMentions(b *testing.B) {
+	content := "Thanks @data, that helps. @code? can you rerun the query, and @review? have a look after."
+	b.Run("shared", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			extractMentions(content)
+		}
+	})
+	// What extractMentions used to do, for comparison
+	b.Run("compiled per call", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			regexp.MustCompile(`@(\w+)\?`).FindAllStringSubmatch(content, -1)
+		}
+	})
+}
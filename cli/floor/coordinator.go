@@ -2,16 +2,23 @@ package floor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	acpsdk "github.com/coder/acp-go-sdk"
 	acpclient "github.com/openfloorcontrol/ofc/acp"
 	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/clock"
 	"github.com/openfloorcontrol/ofc/furniture"
+	"github.com/openfloorcontrol/ofc/llm"
 	"github.com/openfloorcontrol/ofc/sandbox"
 )
 
@@ -25,25 +32,75 @@ type Coordinator struct {
 	logWriter    io.Writer
 	stderrWriter io.Writer // if set, ACP subprocess stderr goes here instead of os.Stderr
 	sandbox      *sandbox.Sandbox
+	confirm      []*regexp.Regexp // bash commands that need the user's approval (the sandbox's confirm)
+	commands     *CommandPolicy   // bash commands agents may run (the sandbox's allow/deny_commands)
 	sessions     map[string]*acpclient.AgentSession
 	bp           *blueprint.Blueprint
 	colorMap     map[string]string
 	furnitureMap map[string]furniture.Furniture // furniture instances keyed by name
 	apiServer    *APIServer                     // serves MCP endpoints for furniture
+	apiToken     string                         // bearer token for apiServer; overrides the blueprint's api_token
+	apiAddr      string                         // listen address for apiServer ("" = 127.0.0.1, random port)
+	apiExternal  bool                           // apiAddr may be a non-loopback address
+	limiters     map[string]*RateLimiter        // per-endpoint request throttling, keyed by endpointKey
+	llmClient    llm.ChatStreamer               // if set, serves every LLM agent instead of its endpoint (tests, demos)
+	httpClient   *http.Client                   // LLM HTTP client with the blueprint's proxy and CA; nil = llm default
+	redactor     *Redactor                      // removes secrets from tool output; nil = none
+
+	idleTimeout time.Duration // warn when an agent streams nothing for this long; 0 = never
+	idleCancel  bool          // cancel the agent's run instead of only warning
+
+	turnMu     sync.Mutex
+	cancelTurn context.CancelFunc // cancels the turn in progress; nil between turns
+
+	askMu sync.Mutex            // one ask_user question at a time, for concurrent agents
+	ask   func() (Event, error) // reads the user's answer to ask_user; nil in one-shot runs
+
+	bpPath        string               // blueprint file, re-read by /reload; "" disables it
+	requireAnswer bool                 // one-shot runs fail with ErrNoAnswer if no agent replied
+	answered      bool                 // some agent replied with content this session
+	lastErr       *AgentError          // the agent error that ended the last turn, if any
+	turns         int                  // agent turns taken this session, for the SessionSummary
+	usage         map[string]llm.Usage // tokens used this session per agent, for /usage and the SessionSummary
+	audit         *AuditLog            // records every tool call agents make; nil = off
+	seed          *int                 // overrides every LLM agent's seed; nil = their own
+	metrics       Metrics              // measures agent turns and tool calls; NopMetrics by default
+	clock         clock.Clock          // times turns and stamps messages; clock.Real except in tests
+	cancelKey     string               // the key that cancels a turn, named in idle warnings; "" if the frontend has none
+
+	subFloors  map[string]*subFloor // floor agents' sub-floors, by agent ID
+	floorChain []string             // blueprints of the floors enclosing this one, if it is a sub-floor
 }
 
 // NewCoordinator creates a coordinator with a CLI frontend.
-// Convenience wrapper for the common CLI case.
+// Convenience wrapper for the common CLI case. Ctrl-C cancels the current
+// agent turn rather than killing the process (see CLIFrontend.EnableInterrupts).
 func NewCoordinator(bp *blueprint.Blueprint, debug bool, logPath string) *Coordinator {
 	cm := BuildColorMap(bp)
-	frontend := NewCLIFrontend(logPath, debug, cm)
+	return newCLICoordinator(bp, NewCLIFrontend(logPath, debug, cm), cm)
+}
 
+// NewQuietCoordinator is NewCoordinator with a quiet CLI frontend (see
+// NewQuietCLIFrontend), for one-shot runs in scripts and pipelines. Sandbox
+// and ACP subprocess output goes to stderr so stdout holds only the replies.
+func NewQuietCoordinator(bp *blueprint.Blueprint, debug bool, logPath string) *Coordinator {
+	cm := BuildColorMap(bp)
+	co := newCLICoordinator(bp, NewQuietCLIFrontend(logPath, debug, cm), cm)
+	co.stderrWriter = os.Stderr
+	return co
+}
+
+func newCLICoordinator(bp *blueprint.Blueprint, frontend *CLIFrontend, cm map[string]string) *Coordinator {
 	var debugFn func(string)
-	if debug {
+	if frontend.IsDebug() {
 		debugFn = frontend.Debug
 	}
 
-	return newCoordinator(bp, frontend, frontend, debugFn, frontend.LogWriter(), cm)
+	co := newCoordinator(bp, frontend, frontend, debugFn, frontend.LogWriter(), cm)
+	frontend.SetLabels(BuildLabelMap(bp))
+	frontend.EnableInterrupts(co.CancelTurn)
+	co.cancelKey = "Ctrl-C"
+	return co
 }
 
 // NewCoordinatorWith creates a coordinator with a custom frontend.
@@ -70,20 +127,78 @@ func newCoordinator(bp *blueprint.Blueprint, frontend Frontend, stream StreamSin
 		bp:        bp,
 		colorMap:  colorMap,
 		sessions:  make(map[string]*acpclient.AgentSession),
+		subFloors: make(map[string]*subFloor),
+		limiters:  newRateLimiters(bp),
+		metrics:   NopMetrics{},
+		clock:     clock.Real,
 	}
 }
 
-// BuildColorMap assigns colors to agents, cycling through the palette.
+// BuildColorMap assigns colors to agents: their own color if set, else
+// the next in the blueprint's palette (or the default one), cycling.
 func BuildColorMap(bp *blueprint.Blueprint) map[string]string {
+	palette := agentColors
+	if len(bp.Palette) > 0 {
+		palette = make([]string, 0, len(bp.Palette))
+		for _, c := range bp.Palette {
+			if code := colorCode(c); code != "" {
+				palette = append(palette, code)
+			}
+		}
+		if len(palette) == 0 {
+			palette = agentColors
+		}
+	}
 	cm := map[string]string{"@user": Cyan}
 	for i, a := range bp.Agents {
-		cm[a.ID] = agentColors[i%len(agentColors)]
+		if c := colorCode(a.Color); c != "" {
+			cm[a.ID] = c
+		} else {
+			cm[a.ID] = palette[i%len(palette)]
+		}
 	}
 	return cm
 }
 
+// BuildLabelMap maps agent IDs to the labels shown in output (see
+// blueprint.Agent.Label).
+func BuildLabelMap(bp *blueprint.Blueprint) map[string]string {
+	labels := map[string]string{}
+	for i := range bp.Agents {
+		labels[bp.Agents[i].ID] = bp.Agents[i].Label()
+	}
+	return labels
+}
+
+// AgentIDs returns the blueprint's agent IDs in blueprint order.
+func AgentIDs(bp *blueprint.Blueprint) []string {
+	ids := make([]string, len(bp.Agents))
+	for i, a := range bp.Agents {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
 // Start initializes sandbox and ACP agent sessions.
 func (co *Coordinator) Start() error {
+	return co.StartContext(context.Background())
+}
+
+// StartContext is Start bounded by ctx. When ctx is done, startup work in
+// progress (pulling or building the sandbox image, sandbox init, starting
+// MCP servers and ACP agents) is abandoned, its processes are killed, and
+// StartContext returns an error wrapping ctx.Err(). Call Stop to tear down
+// whatever had started.
+func (co *Coordinator) StartContext(ctx context.Context) error {
+	httpClient, err := newHTTPClient(co.bp)
+	if err != nil {
+		return err
+	}
+	co.httpClient = httpClient
+	if co.redactor, err = NewRedactor(co.bp); err != nil {
+		return err
+	}
+
 	var sandboxWS *blueprint.Workstation
 	for i := range co.bp.Workstations {
 		if co.bp.Workstations[i].Type == "sandbox" {
@@ -94,24 +209,57 @@ func (co *Coordinator) Start() error {
 
 	if sandboxWS != nil {
 		co.sandbox = sandbox.New("./workspace", sandboxWS.Image, sandboxWS.Dockerfile)
+		co.sandbox.Progress = func(msg string) {
+			co.frontend.Render(SystemInfo{Text: msg})
+		}
+		co.sandbox.Clock = co.clock
+		co.sandbox.User = sandboxWS.User
+		co.sandbox.Mounts = sandboxWS.Mount
+		for _, pattern := range sandboxWS.Confirm {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("sandbox confirm %q: %w", pattern, err)
+			}
+			co.confirm = append(co.confirm, re)
+		}
+		policy, err := NewCommandPolicy(sandboxWS.AllowCommands, sandboxWS.DenyCommands)
+		if err != nil {
+			return fmt.Errorf("sandbox %w", err)
+		}
+		co.commands = policy
+		if co.sandbox.User == "" {
+			co.sandbox.User = sandbox.HostUser()
+		}
 		co.frontend.Render(SystemInfo{Text: "Starting sandbox..."})
-		if err := co.sandbox.Start(); err != nil {
+		if err := co.sandbox.StartContext(ctx); err != nil {
 			return fmt.Errorf("failed to start sandbox: %w", err)
 		}
+		if sandboxWS.Init != "" {
+			co.frontend.Render(SystemInfo{Text: "Running sandbox init..."})
+			output, err := co.sandbox.InitContext(ctx, sandboxWS.Init)
+			if output != "" {
+				co.frontend.Render(SystemInfo{Text: output})
+			}
+			if err != nil {
+				co.sandbox.Stop()
+				return fmt.Errorf("failed to start sandbox: %w", err)
+			}
+		}
 		co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Sandbox ready (%s)", co.sandbox.ContainerID[:12])})
 	}
 
 	// Initialize furniture
-	if err := co.initFurniture(); err != nil {
+	if err := co.initFurniture(ctx); err != nil {
 		return err
 	}
+	co.warnFurnitureAccess()
 
 	for _, agent := range co.bp.Agents {
 		if agent.Type != "acp" {
 			continue
 		}
 		if agent.Command == "" {
-			return fmt.Errorf("ACP agent %s has no command configured", agent.ID)
+			return &ConfigError{Msg: fmt.Sprintf("ACP agent %s has no command configured", agent.ID)}
 		}
 
 		co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Starting ACP agent %s (%s)...", agent.ID, agent.Command)})
@@ -127,29 +275,32 @@ func (co *Coordinator) Start() error {
 
 		session, err := acpclient.NewAgentSession(agent.Command, agent.Args, agent.Env, client, co.stderrWriter)
 		if err != nil {
-			return fmt.Errorf("failed to start ACP agent %s: %w", agent.ID, err)
+			return &ACPStartError{AgentID: agent.ID, Op: "start", Err: err}
 		}
 
-		ctx := context.Background()
 		if err := session.Initialize(ctx); err != nil {
 			session.Close()
-			return fmt.Errorf("failed to initialize ACP agent %s: %w", agent.ID, err)
+			return &ACPStartError{AgentID: agent.ID, Op: "initialize", Err: err}
 		}
 		mcpServers := co.buildACPMCPServers(agent, session)
 		if err := session.StartSession(ctx, workDir, mcpServers); err != nil {
 			session.Close()
-			return fmt.Errorf("failed to create session for ACP agent %s: %w", agent.ID, err)
+			return &ACPStartError{AgentID: agent.ID, Op: "create session for", Err: err}
 		}
 
 		co.sessions[agent.ID] = session
 		co.frontend.Render(SystemInfo{Text: fmt.Sprintf("ACP agent %s ready", agent.ID)})
 	}
 
-	return nil
+	return co.startSubFloors(ctx)
 }
 
-// Stop tears down ACP sessions, furniture, API server, and sandbox.
+// Stop tears down sub-floors, ACP sessions, furniture, API server, and
+// sandbox.
 func (co *Coordinator) Stop() {
+	for _, sf := range co.subFloors {
+		sf.co.Stop()
+	}
 	for id, session := range co.sessions {
 		if co.debugFn != nil {
 			co.debugFn(fmt.Sprintf("closing ACP session for %s", id))
@@ -157,7 +308,9 @@ func (co *Coordinator) Stop() {
 		session.Close()
 	}
 	if co.apiServer != nil {
-		co.apiServer.Stop()
+		if err := co.apiServer.Stop(); err != nil && co.debugFn != nil {
+			co.debugFn(fmt.Sprintf("stopping furniture API server: %v", err))
+		}
 	}
 	// Close furniture that needs cleanup (e.g. external MCP subprocesses)
 	for _, f := range co.furnitureMap {
@@ -168,11 +321,51 @@ func (co *Coordinator) Stop() {
 	if co.sandbox != nil {
 		co.sandbox.Stop()
 	}
+	co.audit.Close()
 }
 
-// Run is the main loop.
+// Run is the main loop. It reads input from the frontend and blocks until
+// the floor stops.
 func (co *Coordinator) Run(initialPrompt string) error {
-	if err := co.Start(); err != nil {
+	return co.RunContext(context.Background(), initialPrompt)
+}
+
+// RunContext is Run bounded by ctx. When ctx is done, the turn in progress
+// is cancelled, the floor stops, and RunContext returns an error wrapping
+// ctx.Err(). A frontend blocked in ReadInput is not interrupted, so this is
+// mainly useful for one-shot runs.
+func (co *Coordinator) RunContext(ctx context.Context, initialPrompt string) error {
+	return co.runLoop(ctx, initialPrompt, co.frontend.ReadInput)
+}
+
+// RunAsync is the channel-driven alternative to Run. It runs the floor in
+// its own goroutine, taking UserMessage/UserCommand events from in instead
+// of calling Frontend.ReadInput. Output still goes to the frontend and
+// stream sink (use a ChannelFrontend to receive it as a channel).
+// The floor stops when in is closed or /quit is processed; the returned
+// channel then yields the result (including any startup error).
+func (co *Coordinator) RunAsync(in <-chan Event) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- co.runLoop(context.Background(), "", func() (Event, error) {
+			ev, ok := <-in
+			if !ok {
+				return nil, io.EOF
+			}
+			return ev, nil
+		})
+	}()
+	return done
+}
+
+// runLoop starts the floor and processes input from next until it fails
+// or the floor stops.
+func (co *Coordinator) runLoop(ctx context.Context, initialPrompt string, next func() (Event, error)) error {
+	if err := co.StartContext(ctx); err != nil {
+		co.Stop()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("run stopped during startup: %w", ctxErr)
+		}
 		return err
 	}
 	defer co.Stop()
@@ -182,18 +375,26 @@ func (co *Coordinator) Run(initialPrompt string) error {
 
 	if initialPrompt != "" {
 		co.renderInitialPrompt(initialPrompt)
-		co.processEvents(co.ctrl.HandleEvent(UserMessage{Content: initialPrompt}))
-		return nil
+		co.runTurn(ctx, UserMessage{Content: initialPrompt})
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("run stopped: %w", err)
+		}
+		co.frontend.Render(co.sessionSummary())
+		return co.outcome()
 	}
 
+	co.ask = next
 	for {
-		ev, err := co.frontend.ReadInput()
+		ev, err := next()
 		if err != nil {
+			co.frontend.Render(co.sessionSummary())
 			break
 		}
 
-		events := co.ctrl.HandleEvent(ev)
-		stopped := co.processEvents(events)
+		stopped := co.runTurn(ctx, ev)
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("run stopped: %w", err)
+		}
 		if stopped {
 			break
 		}
@@ -202,18 +403,201 @@ func (co *Coordinator) Run(initialPrompt string) error {
 	return nil
 }
 
+// EnableReload lets /reload re-read the blueprint from path and apply
+// prompt changes to the running floor.
+func (co *Coordinator) EnableReload(path string) {
+	co.bpPath = path
+}
+
+// reloadBlueprint re-reads the blueprint file for /reload and hands it to
+// the controller.
+func (co *Coordinator) reloadBlueprint() []Event {
+	if co.bpPath == "" {
+		return []Event{SystemInfo{Text: "[/reload is not available on this floor]"}}
+	}
+	bp, err := blueprint.Load(co.bpPath)
+	if err == nil {
+		err = bp.Validate()
+	}
+	if err != nil {
+		return []Event{SystemInfo{Text: fmt.Sprintf("[Reload failed: %v]", err)}}
+	}
+	return co.ctrl.HandleEvent(BlueprintLoaded{Blueprint: bp})
+}
+
+// askUser waits for the user's next input as the answer to an agent's
+// ask_user question. Commands can't run mid-turn, so they are refused.
+func (co *Coordinator) askUser() (string, error) {
+	co.askMu.Lock()
+	defer co.askMu.Unlock()
+	ev, err := co.ask()
+	if err != nil {
+		return "", fmt.Errorf("no answer from the user: %w", err)
+	}
+	switch e := ev.(type) {
+	case UserMessage:
+		return e.Content, nil
+	case UserCommand:
+		co.frontend.Render(SystemInfo{Text: fmt.Sprintf("[%s can't run while an agent waits for an answer]", e.Command)})
+		return "", fmt.Errorf("the user ran %s instead of answering", e.Command)
+	}
+	return "", errors.New("no answer from the user")
+}
+
+// SetColorMode sets when a CLI frontend colors its output (see
+// CLIFrontend.SetColorMode). Other frontends ignore it.
+func (co *Coordinator) SetColorMode(mode ColorMode) {
+	if f, ok := co.frontend.(interface{ SetColorMode(ColorMode) }); ok {
+		f.SetColorMode(mode)
+	}
+}
+
+// SetTimestamps makes a CLI frontend show the clock time before agent
+// labels and system lines (see CLIFrontend.SetTimestamps). Other
+// frontends ignore it.
+func (co *Coordinator) SetTimestamps(on bool) {
+	if f, ok := co.frontend.(interface{ SetTimestamps(bool) }); ok {
+		f.SetTimestamps(on)
+	}
+}
+
+// SetLLMClient serves every LLM agent from client instead of its endpoint,
+// for tests and offline demos (see llm.FakeClient).
+func (co *Coordinator) SetLLMClient(client llm.ChatStreamer) {
+	co.llmClient = client
+}
+
+// RequireAnswer makes a one-shot Run fail with ErrNoAnswer when no agent
+// replies with any content (for example, when every agent passed).
+func (co *Coordinator) RequireAnswer(require bool) {
+	co.requireAnswer = require
+}
+
+// outcome reports how a one-shot run ended: an *AgentFailedError if the turn
+// ended with an agent error, ErrNoAnswer if an answer was required and none
+// was given, nil otherwise.
+func (co *Coordinator) outcome() error {
+	if co.lastErr != nil {
+		return &AgentFailedError{AgentID: co.lastErr.AgentID, Err: co.lastErr.Err}
+	}
+	if co.requireAnswer && !co.answered {
+		return ErrNoAnswer
+	}
+	return nil
+}
+
+// record tracks agent results for outcome and the SessionSummary.
+func (co *Coordinator) record(ev Event) {
+	co.turns++
+	switch e := ev.(type) {
+	case AgentDone:
+		if strings.TrimSpace(e.Content) != "" {
+			co.answered = true
+		}
+		co.addUsage(e.AgentID, e.Usage)
+	case AgentPassed:
+		co.addUsage(e.AgentID, e.Usage)
+	case AgentRouted:
+		co.addUsage(e.AgentID, e.Usage)
+	case AgentError:
+		co.lastErr = &e
+	}
+}
+
+// sessionSummary recaps the session so far: the controller's message
+// counts, plus turns, token usage and cost, and the task boards' counts.
+func (co *Coordinator) sessionSummary() SessionSummary {
+	s := co.ctrl.Summary()
+	s.Turns = co.turns
+	s.Agents = co.usageReport()
+	for _, u := range s.Agents {
+		s.Usage = s.Usage.Add(u.Usage)
+	}
+	for _, f := range co.furnitureMap {
+		if tb, ok := f.(*furniture.TaskBoard); ok {
+			total, done := tb.Counts()
+			s.TasksCreated += total
+			s.TasksCompleted += done
+		}
+	}
+	return s
+}
+
+// runTurn feeds one input event to the controller and runs the agents it
+// prompts, until the floor returns to the user. The turn can be cancelled
+// with CancelTurn, and ends early if parent is done. Returns true if the
+// floor should stop.
+func (co *Coordinator) runTurn(parent context.Context, ev Event) bool {
+	ctx, cancel := context.WithCancel(parent)
+	co.lastErr = nil
+	co.turnMu.Lock()
+	co.cancelTurn = cancel
+	co.turnMu.Unlock()
+
+	defer func() {
+		co.turnMu.Lock()
+		co.cancelTurn = nil
+		co.turnMu.Unlock()
+		cancel()
+	}()
+
+	return co.processEvents(ctx, co.ctrl.HandleEvent(ev))
+}
+
+// CancelTurn cancels the agent turn in progress, if any: the running agent
+// stops (an LLM request is aborted, an ACP agent gets session/cancel) and the
+// floor returns to the user. Safe to call from any goroutine. Reports whether
+// a turn was running.
+func (co *Coordinator) CancelTurn() bool {
+	co.turnMu.Lock()
+	defer co.turnMu.Unlock()
+	if co.cancelTurn == nil {
+		return false
+	}
+	co.cancelTurn()
+	return true
+}
+
 // processEvents handles events from the controller.
 // Returns true if the floor should stop.
-func (co *Coordinator) processEvents(events []Event) bool {
+func (co *Coordinator) processEvents(ctx context.Context, events []Event) bool {
 	for _, ev := range events {
+		if _, ok := ev.(FloorStopped); ok {
+			co.frontend.Render(co.sessionSummary())
+		}
 		co.frontend.Render(ev)
 
 		switch e := ev.(type) {
 		case PromptAgent:
 			co.frontend.Render(AgentThinking{AgentID: e.AgentID})
-			result := co.runAgent(e.AgentID)
+			result := co.runAgent(ctx, e.AgentID)
 			co.frontend.Render(result.Event)
-			if stopped := co.processEvents(co.ctrl.HandleEvent(result.Event)); stopped {
+			co.record(result.Event)
+			if stopped := co.processEvents(ctx, co.ctrl.HandleEvent(result.Event)); stopped {
+				return true
+			}
+		case PromptAgents:
+			results := co.runBatch(ctx, e.AgentIDs)
+			for _, r := range results {
+				co.record(r)
+			}
+			if stopped := co.processEvents(ctx, co.ctrl.HandleEvent(AgentsDone{Results: results})); stopped {
+				return true
+			}
+		case ReloadBlueprint:
+			if stopped := co.processEvents(ctx, co.reloadBlueprint()); stopped {
+				return true
+			}
+		case ShowUsage:
+			if stopped := co.processEvents(ctx, co.showUsage()); stopped {
+				return true
+			}
+		case ListTools:
+			if stopped := co.processEvents(ctx, co.listTools(e.AgentID)); stopped {
+				return true
+			}
+		case EditMessage:
+			if stopped := co.processEvents(ctx, co.editMessage(e)); stopped {
 				return true
 			}
 		case FloorStopped:
@@ -224,47 +608,184 @@ func (co *Coordinator) processEvents(events []Event) bool {
 }
 
 // runAgent dispatches to the right runner.
-func (co *Coordinator) runAgent(agentID string) RunnerResult {
+func (co *Coordinator) runAgent(ctx context.Context, agentID string) RunnerResult {
+	return co.runAgentTo(ctx, agentID, co.stream)
+}
+
+// runAgentTo is runAgent with the runner's stream events sent to stream.
+func (co *Coordinator) runAgentTo(ctx context.Context, agentID string, stream StreamSink) RunnerResult {
+	ctx, stream, stop := co.watchIdle(ctx, agentID, stream)
+	co.metrics.TurnStarted(agentID)
+	start := co.clock.Now()
+	result := co.runAgentWith(ctx, agentID, stream)
+	if err := idleError(ctx); err != nil {
+		if e, ok := result.Event.(AgentError); ok {
+			e.Err = err
+			result.Event = e
+		}
+	}
+	stop()
+	co.turnCompleted(agentID, start, result)
+	return result
+}
+
+// turnCompleted reports a turn that began at start to co.metrics.
+func (co *Coordinator) turnCompleted(agentID string, start time.Time, result RunnerResult) {
+	var usage llm.Usage
+	var err error
+	switch e := result.Event.(type) {
+	case AgentDone:
+		usage = e.Usage
+	case AgentPassed:
+		usage = e.Usage
+	case AgentRouted:
+		usage = e.Usage
+	case AgentError:
+		err = e.Err
+	}
+	co.metrics.TurnCompleted(agentID, co.clock.Now().Sub(start), usage, err)
+}
+
+// runAgentWith runs one agent's turn, streaming to stream.
+func (co *Coordinator) runAgentWith(ctx context.Context, agentID string, stream StreamSink) RunnerResult {
 	agent := co.ctrl.getAgent(agentID)
 	if agent == nil {
 		return RunnerResult{Event: AgentError{
 			AgentID: agentID,
-			Err:     fmt.Errorf("unknown agent %s", agentID),
+			Err:     &ErrUnknownAgent{ID: agentID},
 		}}
 	}
+	stream = co.redactor.wrap(stream)
 
 	if agent.Type == "acp" {
 		runner := &ACPRunner{
 			Sessions: co.sessions,
-			Stream:   co.stream,
+			Stream:   stream,
+			Pass:     co.bp.Pass,
+			Redactor: co.redactor,
 		}
 		blocks := co.ctrl.BuildACPContext(agent)
 		if co.debugFn != nil {
 			co.debugFn(fmt.Sprintf("ACP prompt for %s (%d blocks)", agent.ID, len(blocks)))
 		}
-		return runner.Run(agent, blocks)
+		return runner.RunContext(ctx, agent, blocks)
 	}
 
+	if agent.Type == "floor" {
+		sf, ok := co.subFloors[agent.ID]
+		if !ok {
+			return RunnerResult{Event: AgentError{AgentID: agent.ID, Err: &ConfigError{Msg: "sub-floor not started"}}}
+		}
+		return sf.run(ctx, co.ctrl.SubFloorPrompt(agent), stream)
+	}
+
+	if agent.Type == "router" {
+		return co.llmRunner(agent, stream).Route(ctx, agent, co.ctrl.BuildRouterContext(agent))
+	}
+
+	messages := co.ctrl.BuildContext(agent)
+	return co.llmRunner(agent, stream).RunContext(ctx, agent, messages)
+}
+
+// llmRunner creates the runner for an LLM agent's turn.
+func (co *Coordinator) llmRunner(agent *blueprint.Agent, stream StreamSink) *LLMRunner {
 	runner := &LLMRunner{
 		Sandbox:   co.sandbox,
-		Stream:    co.stream,
-		Furniture: co.furnitureMap,
+		Stream:    stream,
+		Furniture: co.agentFurniture(agent),
+		Pass:      co.bp.Pass,
+		Limiter:   co.limiters[endpointKey(agent.Endpoint)],
+		Client:    co.llmClient,
+		HTTP:      co.httpClient,
+		Audit:     co.audit,
+		Seed:      co.seed,
+		Metrics:   co.metrics,
+		Confirm:   co.confirm,
+		Commands:  co.commands,
+		Redactor:  co.redactor,
 	}
-	messages := co.ctrl.BuildContext(agent)
-	return runner.Run(agent, messages)
+	if co.ask != nil {
+		runner.AskUser = co.askUser
+	}
+	if co.debugFn != nil {
+		// Raw traffic is too noisy for the terminal; log file only.
+		runner.Debug = co.logWriter
+	}
+	return runner
+}
+
+// listTools reports, for /tools, the tools agentID ("" = every agent)
+// would be offered on its next turn. LLM agents get exactly what their
+// runner would send; ACP agents bring their own tools, so only the
+// furniture passed to them over MCP is listed.
+func (co *Coordinator) listTools(agentID string) []Event {
+	var events []Event
+	for i := range co.bp.Agents {
+		agent := &co.bp.Agents[i]
+		if agentID != "" && agent.ID != agentID {
+			continue
+		}
+		if agent.Type == "acp" {
+			var names []string
+			if session, ok := co.sessions[agent.ID]; ok {
+				for _, s := range co.buildACPMCPServers(*agent, session) {
+					switch {
+					case s.Sse != nil:
+						names = append(names, s.Sse.Name)
+					case s.Http != nil:
+						names = append(names, s.Http.Name)
+					}
+				}
+			}
+			text := fmt.Sprintf("%s: own tools (ACP agent)", agent.ID)
+			if len(names) > 0 {
+				text += fmt.Sprintf("; furniture over MCP: %s", strings.Join(names, ", "))
+			}
+			events = append(events, SystemInfo{Text: text})
+			continue
+		}
+		if agent.Type == "router" || agent.Type == "floor" {
+			events = append(events, SystemInfo{Text: fmt.Sprintf("%s: no tools (%s)", agent.ID, agent.Type)})
+			continue
+		}
+
+		var names []string
+		for _, t := range co.llmRunner(agent, nil).buildTools(agent) {
+			names = append(names, t.Function.Name)
+		}
+		text := fmt.Sprintf("%s: %s", agent.ID, strings.Join(names, ", "))
+		if len(names) == 0 {
+			text = fmt.Sprintf("%s: no tools", agent.ID)
+		}
+		switch {
+		case agent.CanUseTools && co.sandbox == nil:
+			text += " (no bash: the floor has no sandbox)"
+		case !agent.CanUseTools && co.sandbox != nil:
+			text += " (no bash: can_use_tools is off)"
+		}
+		events = append(events, SystemInfo{Text: text})
+	}
+	return events
 }
 
+// readOnlyFloor is the API server floor serving every piece of furniture
+// with only its non-mutating tools, for ACP agents that list "name:ro".
+const readOnlyFloor = "readonly"
+
 // initFurniture creates furniture instances from the blueprint and starts the API server.
-func (co *Coordinator) initFurniture() error {
+func (co *Coordinator) initFurniture(ctx context.Context) error {
 	if len(co.bp.Furniture) == 0 {
 		return nil
 	}
 
 	co.furnitureMap = make(map[string]furniture.Furniture)
 
-	ctx := context.Background()
+	env := furniture.Env{Context: ctx}
+	if co.sandbox != nil {
+		env.Exec = co.sandbox.Execute
+	}
 	for _, fd := range co.bp.Furniture {
-		f, err := createFurniture(ctx, fd)
+		f, err := furniture.DefaultRegistry.Create(fd, env)
 		if err != nil {
 			return fmt.Errorf("failed to create furniture %q: %w", fd.Name, err)
 		}
@@ -274,18 +795,155 @@ func (co *Coordinator) initFurniture() error {
 
 	// Start API server for MCP access
 	co.apiServer = NewAPIServer()
+	if token := co.furnitureToken(); token != "" {
+		co.apiServer.RequireToken(token)
+	}
+	co.apiServer.SetAudit(co.audit, co.redactor)
+	co.apiServer.RegisterFloor("default", co.furnitureMap)
+	readOnly := make(map[string]furniture.Furniture, len(co.furnitureMap))
 	for name, f := range co.furnitureMap {
-		mcpSrv := furniture.WrapAsMCP(f)
-		co.apiServer.RegisterFurniture("default", name, mcpSrv)
+		readOnly[name] = furniture.ReadOnly(f)
+	}
+	co.apiServer.RegisterFloor(readOnlyFloor, readOnly)
+	co.apiServer.AllowExternal(co.apiExternal)
+	addr := co.apiAddr
+	if addr == "" {
+		addr = ":0"
 	}
-	if err := co.apiServer.Start(":0"); err != nil {
+	if err := co.apiServer.Start(addr); err != nil {
 		return fmt.Errorf("failed to start furniture API server: %w", err)
 	}
-	co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Furniture API server at %s", co.apiServer.BaseURL())})
+	co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Furniture API server at %s (bound to %s)", co.apiServer.BaseURL(), co.apiServer.Addr())})
+	if co.apiServer.Exposed() {
+		warning := "Warning: the furniture API server is reachable from the network"
+		if co.furnitureToken() == "" {
+			warning += " without authentication (set --serve-token or api_token)"
+		}
+		co.frontend.Render(SystemInfo{Text: warning})
+	}
 
 	return nil
 }
 
+// warnFurnitureAccess warns about agent furniture names with no matching
+// furniture, or whose furniture doesn't allow the agent. Blueprint.Validate
+// rejects unknown names, but blueprints built in code may not have been
+// validated, and either way the agent would silently lack the tools.
+func (co *Coordinator) warnFurnitureAccess() {
+	for _, agent := range co.bp.Agents {
+		for _, ref := range agent.Furniture {
+			name, _ := blueprint.ParseFurnitureRef(ref)
+			if _, ok := co.furnitureMap[name]; !ok {
+				co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Warning: agent %s uses furniture %q, which is not defined; it gets none of its tools", agent.ID, name)})
+				continue
+			}
+			if fd := co.furnitureDef(name); !fd.Allows(agent.ID) {
+				co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Warning: agent %s uses furniture %q, which only allows %s; it gets none of its tools", agent.ID, name, strings.Join(fd.AllowedAgents, ", "))})
+			}
+		}
+	}
+}
+
+// furnitureDef returns the blueprint definition of the named furniture.
+func (co *Coordinator) furnitureDef(name string) blueprint.FurnitureDef {
+	for _, fd := range co.bp.Furniture {
+		if fd.Name == name {
+			return fd
+		}
+	}
+	return blueprint.FurnitureDef{Name: name}
+}
+
+// agentFurniture returns the furniture the agent lists and is allowed to
+// use, keyed by name, so it neither sees nor can call anything else.
+// Furniture listed only as "name:ro" is wrapped to offer just its
+// non-mutating tools.
+func (co *Coordinator) agentFurniture(agent *blueprint.Agent) map[string]furniture.Furniture {
+	fs := make(map[string]furniture.Furniture, len(agent.Furniture))
+	full := make(map[string]bool)
+	for _, ref := range agent.Furniture {
+		name, readOnly := blueprint.ParseFurnitureRef(ref)
+		f, ok := co.furnitureMap[name]
+		if !ok || full[name] || !co.furnitureDef(name).Allows(agent.ID) {
+			continue
+		}
+		if readOnly {
+			f = furniture.ReadOnly(f)
+		} else {
+			full[name] = true
+		}
+		fs[name] = f
+	}
+	return fs
+}
+
+// SetIdleTimeout starts a watchdog on every agent run: if the agent streams
+// nothing (no tokens or tool calls) for d, the user is warned; with cancel,
+// the run is cancelled instead and ends with an ErrAgentIdle AgentError.
+// d = 0 disables it.
+func (co *Coordinator) SetIdleTimeout(d time.Duration, cancel bool) {
+	co.idleTimeout = d
+	co.idleCancel = cancel
+}
+
+// SetAuditLog records every tool call agents make to a: LLM agents'
+// furniture and bash calls, and ACP agents' furniture calls through the
+// API server. The coordinator closes it when the floor stops.
+func (co *Coordinator) SetAuditLog(a *AuditLog) {
+	co.audit = a
+}
+
+// SetMetrics reports every agent turn and tool call to m, for exporting
+// to an embedder's monitoring. nil turns it off again.
+func (co *Coordinator) SetMetrics(m Metrics) {
+	if m == nil {
+		m = NopMetrics{}
+	}
+	co.metrics = m
+}
+
+// SetClock replaces the system clock for the floor's message times, turn
+// durations, sandbox command timeouts, idle timeouts, and rate limits, so
+// tests can control time (see clock.Fake). Call it before Start.
+func (co *Coordinator) SetClock(c clock.Clock) {
+	co.clock = c
+	co.ctrl.Now = c.Now
+	for _, l := range co.limiters {
+		l.Clock = c
+	}
+}
+
+// SetSeed sends seed with every LLM agent's requests, overriding the
+// agents' own seed settings, for reproducible runs on backends that honor
+// it.
+func (co *Coordinator) SetSeed(seed int) {
+	co.seed = &seed
+}
+
+// SetAPIToken requires token on every request to the furniture API server,
+// overriding the blueprint's api_token. Call it before Start.
+func (co *Coordinator) SetAPIToken(token string) {
+	co.apiToken = token
+}
+
+// SetAPIAddr sets the furniture API server's listen address (default
+// 127.0.0.1 on a random port; a bare ":port" binds 127.0.0.1). Non-loopback
+// addresses are refused at Start unless allowExternal is set. Call it
+// before Start.
+func (co *Coordinator) SetAPIAddr(addr string, allowExternal bool) {
+	co.apiAddr = addr
+	co.apiExternal = allowExternal
+}
+
+// furnitureToken returns the bearer token the furniture API server
+// requires, or "" if it is open.
+func (co *Coordinator) furnitureToken() string {
+	if co.apiToken != "" {
+		return co.apiToken
+	}
+	return os.ExpandEnv(co.bp.APIToken)
+}
+
 // buildACPMCPServers builds the MCP server list for an ACP agent based on its
 // furniture access and MCP capabilities reported during initialization.
 func (co *Coordinator) buildACPMCPServers(agent blueprint.Agent, session *acpclient.AgentSession) []acpsdk.McpServer {
@@ -295,32 +953,46 @@ func (co *Coordinator) buildACPMCPServers(agent blueprint.Agent, session *acpcli
 
 	caps := session.McpCapabilities
 	base := co.apiServer.BaseURL()
+	headers := []acpsdk.HttpHeader{{Name: AgentHeader, Value: agent.ID}}
+	if token := co.furnitureToken(); token != "" {
+		headers = append(headers, acpsdk.HttpHeader{Name: "Authorization", Value: "Bearer " + token})
+	}
 
+	allowed := co.agentFurniture(&agent)
 	var servers []acpsdk.McpServer
-	for _, fname := range agent.Furniture {
-		if _, ok := co.furnitureMap[fname]; !ok {
+	for _, ref := range agent.Furniture {
+		fname, _ := blueprint.ParseFurnitureRef(ref)
+		f, ok := allowed[fname]
+		if !ok {
 			continue
 		}
+		delete(allowed, fname) // listed twice, e.g. as "tasks" and "tasks:ro"
+
+		// Read-only views are served from their own floor
+		floor := "default"
+		if furniture.IsReadOnly(f) {
+			floor = readOnlyFloor
+		}
 
 		switch {
 		case caps.Sse:
-			url := base + "/api/v1/floors/default/sse/" + fname
+			url := base + "/api/v1/floors/" + floor + "/sse/" + fname
 			servers = append(servers, acpsdk.McpServer{
 				Sse: &acpsdk.McpServerSse{
 					Type:    "sse",
 					Name:    fname,
 					Url:     url,
-					Headers: []acpsdk.HttpHeader{},
+					Headers: headers,
 				},
 			})
 		case caps.Http:
-			url := base + "/api/v1/floors/default/mcp/" + fname + "/"
+			url := base + "/api/v1/floors/" + floor + "/mcp/" + fname + "/"
 			servers = append(servers, acpsdk.McpServer{
 				Http: &acpsdk.McpServerHttp{
 					Type:    "http",
 					Name:    fname,
 					Url:     url,
-					Headers: []acpsdk.HttpHeader{},
+					Headers: headers,
 				},
 			})
 		default:
@@ -332,21 +1004,6 @@ func (co *Coordinator) buildACPMCPServers(agent blueprint.Agent, session *acpcli
 	return servers
 }
 
-// createFurniture instantiates a furniture from its blueprint definition.
-func createFurniture(ctx context.Context, fd blueprint.FurnitureDef) (furniture.Furniture, error) {
-	switch fd.Type {
-	case "taskboard":
-		return furniture.NewTaskBoard(), nil
-	case "mcp":
-		if fd.Command == "" {
-			return nil, fmt.Errorf("mcp furniture %q requires a command", fd.Name)
-		}
-		return furniture.NewExternalMCP(ctx, fd.Name, fd.Command, fd.Args)
-	default:
-		return nil, fmt.Errorf("unknown furniture type %q", fd.Type)
-	}
-}
-
 // renderHeader prints the floor header.
 func (co *Coordinator) renderHeader() {
 	co.frontend.Render(SystemInfo{Text: fmt.Sprintf("%s%s%s", Bold, strings.Repeat("=", 50), Reset)})
@@ -371,7 +1028,11 @@ func (co *Coordinator) renderHeader() {
 		}
 		co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Furniture: %s", strings.Join(furnitureNames, ", "))})
 	}
-	co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Type %s/quit%s to exit, %s/clear%s to reset", Bold, Reset, Bold, Reset)})
+	if co.bpPath != "" {
+		co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Type %s/quit%s to exit, %s/clear%s to reset, %s/reload%s to re-read prompts", Bold, Reset, Bold, Reset, Bold, Reset)})
+	} else {
+		co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Type %s/quit%s to exit, %s/clear%s to reset", Bold, Reset, Bold, Reset)})
+	}
 	co.frontend.Render(SystemInfo{Text: fmt.Sprintf("%s%s%s", Bold, strings.Repeat("=", 50), Reset)})
 }
 
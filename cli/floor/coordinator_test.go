@@ -0,0 +1,680 @@
+package floor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/clock"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+func TestRunUnknownAgentIsTyped(t *testing.T) {
+	ch := NewChannelFrontend(1, nil)
+	co := NewCoordinatorWith(&blueprint.Blueprint{Name: "errors"}, ch, ch, nil, nil, nil)
+
+	result := co.runAgent(context.Background(), "@ghost")
+	ae, ok := result.Event.(AgentError)
+	if !ok {
+		t.Fatalf("expected AgentError, got %T", result.Event)
+	}
+	var unknown *ErrUnknownAgent
+	if !errors.As(ae.Err, &unknown) || unknown.ID != "@ghost" {
+		t.Errorf("expected ErrUnknownAgent{@ghost}, got %v", ae.Err)
+	}
+	if ae.Err.Error() != "unknown agent @ghost" {
+		t.Errorf("unexpected message: %q", ae.Err)
+	}
+}
+
+func TestSeedOverridesAgentSeed(t *testing.T) {
+	var seeds []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Seed *int `json:"seed"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		seed := "none"
+		if req.Seed != nil {
+			seed = fmt.Sprint(*req.Seed)
+		}
+		seeds = append(seeds, seed)
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"[PASS]\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	seven := 7
+	for _, override := range []*int{nil, &seven} {
+		bp := &blueprint.Blueprint{
+			Name: "seed",
+			Agents: []blueprint.Agent{
+				{ID: "@a", Activation: "always", Endpoint: srv.URL, Seed: new(int)},
+				{ID: "@b", Activation: "always", Endpoint: srv.URL},
+			},
+		}
+		ch := NewChannelFrontend(64, nil)
+		co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+		if override != nil {
+			co.SetSeed(*override)
+		}
+		if err := co.Run("hi"); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		for range ch.Events() {
+		}
+	}
+
+	if got, want := strings.Join(seeds, ","), "0,none,7,7"; got != want {
+		t.Errorf("seeds = %s, want %s", got, want)
+	}
+}
+
+func TestCancelTurnStopsStreamingAgent(t *testing.T) {
+	// An LLM endpoint that sends one token, then stalls until the client goes away.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"thinking hard\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	bp := &blueprint.Blueprint{
+		Name:   "cancel",
+		Agents: []blueprint.Agent{{ID: "@slow", Activation: "always", Endpoint: srv.URL}},
+	}
+	ch := NewChannelFrontend(64, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+	if co.CancelTurn() {
+		t.Error("CancelTurn reported a turn before any input")
+	}
+
+	in := make(chan Event, 1)
+	done := co.RunAsync(in)
+	in <- UserMessage{Content: "go"}
+
+	timeout := time.After(5 * time.Second)
+	var info string
+	for waiting := false; !waiting; {
+		select {
+		case ev := <-ch.Events():
+			switch e := ev.(type) {
+			case TokenStreamed:
+				if !co.CancelTurn() {
+					t.Fatal("CancelTurn found no running turn")
+				}
+			case SystemInfo:
+				info = e.Text
+			case WaitingForUser:
+				waiting = true
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the cancelled turn to end")
+		}
+	}
+	if info != "[@slow: turn cancelled]" {
+		t.Errorf("expected cancellation notice, got %q", info)
+	}
+
+	close(in)
+	for range ch.Events() {
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("RunAsync: %v", err)
+	}
+}
+
+func TestOneShotRunOutcome(t *testing.T) {
+	reply := func(content string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", content)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}
+	}
+	broken := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		require bool
+		check   func(error) bool
+	}{
+		{"answered", reply("42"), true, func(err error) bool { return err == nil }},
+		{"passed", reply("[PASS]"), false, func(err error) bool { return err == nil }},
+		{"passed, answer required", reply("[PASS]"), true, func(err error) bool { return errors.Is(err, ErrNoAnswer) }},
+		{"agent error", broken, false, func(err error) bool {
+			var failed *AgentFailedError
+			return errors.As(err, &failed) && failed.AgentID == "@a"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			bp := &blueprint.Blueprint{
+				Name:   "outcome",
+				Agents: []blueprint.Agent{{ID: "@a", Activation: "always", Endpoint: srv.URL}},
+			}
+			ch := NewChannelFrontend(64, nil)
+			co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+			co.RequireAnswer(tt.require)
+			if err := co.Run("question"); !tt.check(err) {
+				t.Errorf("unexpected Run result: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunContextTimeoutStopsStalledRun(t *testing.T) {
+	// An LLM endpoint that never answers. The body must be read before the
+	// server notices the client going away.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	bp := &blueprint.Blueprint{
+		Name:   "timeout",
+		Agents: []blueprint.Agent{{ID: "@stuck", Activation: "always", Endpoint: srv.URL}},
+	}
+	ch := NewChannelFrontend(64, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- co.RunContext(ctx, "question") }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected a deadline error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not stop at the deadline")
+	}
+}
+
+func TestRunContextTimeoutCoversStartup(t *testing.T) {
+	tests := []struct {
+		name string
+		bp   *blueprint.Blueprint
+	}{
+		{"sandbox image pull", &blueprint.Blueprint{
+			Name:         "stalled pull",
+			Workstations: []blueprint.Workstation{{Type: "sandbox", Image: "never:pulled"}},
+		}},
+		{"ACP agent init", &blueprint.Blueprint{
+			Name:   "silent agent",
+			Agents: []blueprint.Agent{{ID: "@mute", Type: "acp", Command: "sleep", Args: []string{"60"}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A docker whose image pull never finishes; the ACP agent is
+			// found on the real PATH.
+			dir := t.TempDir()
+			script := "#!/bin/sh\ncase \"$1\" in\ninfo) echo 27.0 ;;\nimage) exit 1 ;;\n*) exec /bin/sleep 60 ;;\nesac\n"
+			if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+			t.Chdir(t.TempDir())
+
+			ch := NewChannelFrontend(64, nil)
+			co := NewCoordinatorWith(tt.bp, ch, ch, nil, io.Discard, io.Discard)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			done := make(chan error, 1)
+			go func() { done <- co.RunContext(ctx, "question") }()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("expected a deadline error, got %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("RunContext did not stop at the deadline during startup")
+			}
+		})
+	}
+}
+
+// runFake runs a one-shot floor against a scripted LLM and returns every
+// event the frontend saw.
+func runFake(t *testing.T, bp *blueprint.Blueprint, fake *llm.FakeClient, prompt string) []Event {
+	t.Helper()
+	ch := NewChannelFrontend(256, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+	co.SetLLMClient(fake)
+	if err := co.Run(prompt); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	var events []Event
+	for ev := range ch.Events() {
+		events = append(events, ev)
+	}
+	return events
+}
+
+// streamed returns the tokens streamed by agentID, concatenated.
+func streamed(events []Event, agentID string) string {
+	var sb strings.Builder
+	for _, ev := range events {
+		if tok, ok := ev.(TokenStreamed); ok && tok.AgentID == agentID {
+			sb.WriteString(tok.Token)
+		}
+	}
+	return sb.String()
+}
+
+func TestConcurrentBroadcastBuildsContextsSafely(t *testing.T) {
+	// Run with -race: the agents build their contexts in parallel.
+	bp := &blueprint.Blueprint{
+		Name:                "broadcast",
+		ConcurrentBroadcast: true,
+		Agents: []blueprint.Agent{
+			{ID: "@a", Type: "llm", Activation: "mention", Model: "m"},
+			{ID: "@b", Type: "llm", Activation: "mention", Model: "m", ContextScope: "involved"},
+			{ID: "@c", Type: "llm", Activation: "mention", Model: "m"},
+		},
+	}
+	fake := llm.NewFakeClient(llm.FakeReply{Content: "one"}, llm.FakeReply{Content: "two"}, llm.FakeReply{Content: "three"})
+
+	events := runFake(t, bp, fake, "@everyone? what do you think?")
+
+	var done int
+	for _, ev := range events {
+		if _, ok := ev.(AgentDone); ok {
+			done++
+		}
+	}
+	if done != 3 {
+		t.Errorf("expected all three agents to reply, got %d replies", done)
+	}
+}
+
+func TestFakeLLMStreamsReply(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:   "fake",
+		Agents: []blueprint.Agent{{ID: "@a", Type: "llm", Activation: "always", Model: "m"}},
+	}
+	fake := llm.NewFakeClient(llm.FakeReply{Content: "Hello from the fake"}, llm.FakeReply{Content: "[PASS]"})
+
+	events := runFake(t, bp, fake, "hi")
+
+	if got := streamed(events, "@a"); got != "Hello from the fake" {
+		t.Errorf("streamed %q", got)
+	}
+	var done []AgentDone
+	for _, ev := range events {
+		if d, ok := ev.(AgentDone); ok {
+			done = append(done, d)
+		}
+	}
+	if len(done) != 1 || done[0].Content != "Hello from the fake" {
+		t.Errorf("expected one AgentDone with the reply, got %+v", done)
+	}
+
+	reqs := fake.Requests()
+	if len(reqs) == 0 || reqs[0].Model != "m" {
+		t.Fatalf("unexpected requests: %+v", reqs)
+	}
+	if last := reqs[0].Messages[len(reqs[0].Messages)-1]; last.Role != "user" || last.Content != "hi" {
+		t.Errorf("expected the prompt as the last message, got %+v", last)
+	}
+}
+
+func TestFakeLLMToolDispatch(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:      "tools",
+		Agents:    []blueprint.Agent{{ID: "@a", Type: "llm", Activation: "always", Furniture: []string{"calc"}}},
+		Furniture: []blueprint.FurnitureDef{{Name: "calc", Type: "calculator"}},
+	}
+	fake := llm.NewFakeClient(
+		llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c1", "calc__eval", `{"expression":"6*7"}`)}},
+		llm.FakeReply{Content: "It is 42."},
+		llm.FakeReply{Content: "[PASS]"},
+	)
+
+	events := runFake(t, bp, fake, "what is 6*7?")
+
+	var result *ToolCallResult
+	for _, ev := range events {
+		if r, ok := ev.(ToolCallResult); ok {
+			result = &r
+		}
+	}
+	if result == nil || !strings.Contains(result.Output, "42") {
+		t.Fatalf("expected a calculator result with 42, got %+v", result)
+	}
+
+	reqs := fake.Requests()
+	if len(reqs) < 2 {
+		t.Fatalf("expected a follow-up request after the tool call, got %d", len(reqs))
+	}
+	if len(reqs[0].Tools) != 1 || reqs[0].Tools[0].Function.Name != "calc__eval" {
+		t.Errorf("expected the calculator tool to be offered, got %+v", reqs[0].Tools)
+	}
+	last := reqs[1].Messages[len(reqs[1].Messages)-1]
+	if last.Role != "tool" || last.ToolCallID != "c1" || !strings.Contains(last.Content, "42") {
+		t.Errorf("expected the tool result in the follow-up, got %+v", last)
+	}
+	if got := streamed(events, "@a"); got != "It is 42." {
+		t.Errorf("streamed %q", got)
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:   "clock",
+		Agents: []blueprint.Agent{{ID: "@a", Type: "llm", Activation: "always"}},
+	}
+	ch := NewChannelFrontend(256, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+	co.SetLLMClient(llm.NewFakeClient(llm.FakeReply{Content: "Hi."}, llm.FakeReply{Content: "[PASS]"}))
+	fake := clock.NewFake(time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC))
+	co.SetClock(fake)
+	if err := co.Run("hello"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	for range ch.Events() {
+	}
+
+	if len(co.ctrl.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(co.ctrl.Messages))
+	}
+	for _, m := range co.ctrl.Messages {
+		if !m.Time.Equal(fake.Now()) {
+			t.Errorf("message from %s stamped %v, want the fake clock's %v", m.FromID, m.Time, fake.Now())
+		}
+	}
+}
+
+func TestAskUser(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:   "ask",
+		Agents: []blueprint.Agent{{ID: "@a", Type: "llm", Activation: "always", CanAskUser: true}},
+	}
+	fake := llm.NewFakeClient(
+		llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c1", "ask_user", `{"question":"Which environment?"}`)}},
+		llm.FakeReply{Content: "Deploying to staging."},
+		llm.FakeReply{Content: "[PASS]"},
+	)
+	ch := NewChannelFrontend(256, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+	co.SetLLMClient(fake)
+
+	in := make(chan Event, 1)
+	done := co.RunAsync(in)
+	in <- UserMessage{Content: "deploy it"}
+
+	timeout := time.After(5 * time.Second)
+	var question string
+	for waiting := false; !waiting; {
+		select {
+		case ev := <-ch.Events():
+			switch e := ev.(type) {
+			case UserInputRequested:
+				question = e.Question
+				in <- UserMessage{Content: "staging"}
+			case WaitingForUser:
+				waiting = true
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the turn to end")
+		}
+	}
+	close(in)
+	for range ch.Events() {
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("RunAsync: %v", err)
+	}
+
+	if question != "Which environment?" {
+		t.Errorf("expected the question to reach the frontend, got %q", question)
+	}
+	reqs := fake.Requests()
+	if len(reqs) < 2 {
+		t.Fatalf("expected a follow-up request after the answer, got %d", len(reqs))
+	}
+	if len(reqs[0].Tools) != 1 || reqs[0].Tools[0].Function.Name != "ask_user" {
+		t.Errorf("expected ask_user to be offered, got %+v", reqs[0].Tools)
+	}
+	last := reqs[1].Messages[len(reqs[1].Messages)-1]
+	if last.Role != "tool" || last.ToolCallID != "c1" || last.Content != "staging" {
+		t.Errorf("expected the answer as the tool result, got %+v", last)
+	}
+}
+
+func TestAskUserNotOfferedInOneShotRuns(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:   "ask",
+		Agents: []blueprint.Agent{{ID: "@a", Type: "llm", Activation: "always", CanAskUser: true}},
+	}
+	fake := llm.NewFakeClient(llm.FakeReply{Content: "ok"}, llm.FakeReply{Content: "[PASS]"})
+	runFake(t, bp, fake, "hi")
+	if reqs := fake.Requests(); len(reqs) == 0 || len(reqs[0].Tools) != 0 {
+		t.Errorf("expected no tools with no one to ask, got %+v", reqs)
+	}
+}
+
+func TestListTools(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "tools",
+		Agents: []blueprint.Agent{
+			{ID: "@a", Type: "llm", CanUseTools: true, Furniture: []string{"calc"}},
+			{ID: "@b", Type: "llm"},
+		},
+		Furniture: []blueprint.FurnitureDef{{Name: "calc", Type: "calculator"}},
+	}
+	ch := NewChannelFrontend(64, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+	if err := co.initFurniture(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer co.Stop()
+
+	var lines []string
+	for _, ev := range co.listTools("") {
+		lines = append(lines, ev.(SystemInfo).Text)
+	}
+	want := []string{
+		"@a: calc__eval (no bash: the floor has no sandbox)",
+		"@b: no tools",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+	if events := co.listTools("@b"); len(events) != 1 {
+		t.Errorf("expected only @b, got %v", events)
+	}
+}
+
+func TestUnknownFurnitureWarns(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:   "typo",
+		Agents: []blueprint.Agent{{ID: "@a", Type: "llm", Activation: "always", Furniture: []string{"taks"}}},
+	}
+	events := runFake(t, bp, llm.NewFakeClient(llm.FakeReply{Content: "ok"}), "hi")
+
+	var warnings []string
+	for _, ev := range events {
+		if info, ok := ev.(SystemInfo); ok && strings.HasPrefix(info.Text, "Warning:") {
+			warnings = append(warnings, info.Text)
+		}
+	}
+	want := `Warning: agent @a uses furniture "taks", which is not defined; it gets none of its tools`
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("expected one warning %q, got %q", want, warnings)
+	}
+}
+
+func TestFurnitureAllowedAgents(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "least-privilege",
+		Agents: []blueprint.Agent{
+			{ID: "@lead", Type: "llm", Activation: "always", Furniture: []string{"tasks"}},
+			{ID: "@b", Type: "llm", Activation: "always", Furniture: []string{"tasks"}},
+		},
+		Furniture: []blueprint.FurnitureDef{{Name: "tasks", Type: "taskboard", AllowedAgents: []string{"@lead"}}},
+	}
+	fake := llm.NewFakeClient(llm.FakeReply{Content: "[PASS]"}, llm.FakeReply{Content: "[PASS]"})
+	events := runFake(t, bp, fake, "hi")
+
+	var warnings []string
+	for _, ev := range events {
+		if info, ok := ev.(SystemInfo); ok && strings.HasPrefix(info.Text, "Warning:") {
+			warnings = append(warnings, info.Text)
+		}
+	}
+	want := `Warning: agent @b uses furniture "tasks", which only allows @lead; it gets none of its tools`
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("expected one warning %q, got %q", want, warnings)
+	}
+
+	reqs := fake.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected a request per agent, got %d", len(reqs))
+	}
+	if len(reqs[0].Tools) == 0 {
+		t.Error("expected @lead to get the task board's tools")
+	}
+	if len(reqs[1].Tools) != 0 {
+		t.Errorf("expected @b to get no tools, got %d", len(reqs[1].Tools))
+	}
+}
+
+func TestFurnitureReadOnly(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:      "watchdog",
+		Agents:    []blueprint.Agent{{ID: "@watch", Type: "llm", Activation: "always", Furniture: []string{"tasks:ro"}}},
+		Furniture: []blueprint.FurnitureDef{{Name: "tasks", Type: "taskboard"}},
+	}
+	fake := llm.NewFakeClient(
+		llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c1", "tasks__add_task", `{"title":"sneaky"}`)}},
+		llm.FakeReply{Content: "[PASS]"},
+	)
+	runFake(t, bp, fake, "hi")
+
+	reqs := fake.Requests()
+	if len(reqs) < 2 {
+		t.Fatalf("expected a follow-up request after the tool call, got %d", len(reqs))
+	}
+	var tools []string
+	for _, tool := range reqs[0].Tools {
+		tools = append(tools, tool.Function.Name)
+	}
+	if want := "tasks__list_tasks,tasks__get_task"; strings.Join(tools, ",") != want {
+		t.Errorf("tools = %v, want %s", tools, want)
+	}
+	last := reqs[1].Messages[len(reqs[1].Messages)-1]
+	if want := `[ERROR: furniture "tasks" is read-only: add_task changes state]`; last.Content != want {
+		t.Errorf("expected %q in the follow-up, got %+v", want, last)
+	}
+}
+
+func TestFakeLLMInvalidToolArguments(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:      "tools",
+		Agents:    []blueprint.Agent{{ID: "@a", Type: "llm", Activation: "always", Furniture: []string{"tasks"}}},
+		Furniture: []blueprint.FurnitureDef{{Name: "tasks", Type: "taskboard"}},
+	}
+	fake := llm.NewFakeClient(
+		llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c1", "tasks__update_task", `{"id":"one"}`)}},
+		llm.FakeReply{Content: "Sorry."},
+		llm.FakeReply{Content: "[PASS]"},
+	)
+
+	runFake(t, bp, fake, "finish task one")
+
+	reqs := fake.Requests()
+	if len(reqs) < 2 {
+		t.Fatalf("expected a follow-up request after the tool call, got %d", len(reqs))
+	}
+	last := reqs[1].Messages[len(reqs[1].Messages)-1]
+	if want := `[ERROR: invalid arguments: field "id" must be integer, got string]`; last.Role != "tool" || last.Content != want {
+		t.Errorf("expected %q in the follow-up, got %+v", want, last)
+	}
+}
+
+func TestFakeLLMDelegation(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "delegate",
+		Agents: []blueprint.Agent{
+			{ID: "@lead", Type: "llm", Activation: "always"},
+			{ID: "@helper", Type: "llm", Activation: "mention"},
+		},
+	}
+	fake := llm.NewFakeClient(
+		llm.FakeReply{Content: "@helper? what is the answer"},
+		llm.FakeReply{Content: "It is 42."},
+		llm.FakeReply{Content: "The helper says 42."},
+	)
+
+	events := runFake(t, bp, fake, "question")
+
+	var order []string
+	for _, ev := range events {
+		if d, ok := ev.(AgentDone); ok {
+			order = append(order, d.AgentID)
+		}
+	}
+	if strings.Join(order, ",") != "@lead,@helper,@lead" {
+		t.Errorf("expected @lead → @helper → @lead, got %v", order)
+	}
+	// The one-shot run's SessionSummary follows the return to the user.
+	if _, ok := events[len(events)-2].(WaitingForUser); !ok {
+		t.Errorf("expected the floor to return to the user, got %T", events[len(events)-2])
+	}
+}
+
+func TestSessionSummaryEndsRun(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "summary",
+		Agents: []blueprint.Agent{
+			{ID: "@a", Type: "llm", Activation: "always", Furniture: []string{"tasks"}},
+			{ID: "@b", Type: "llm", Activation: "always"},
+		},
+		Furniture: []blueprint.FurnitureDef{{Name: "tasks", Type: "taskboard"}},
+	}
+	fake := llm.NewFakeClient(
+		llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c1", "tasks__add_task", `{"title":"write it"}`)}},
+		llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c2", "tasks__update_task", `{"id":1,"status":"done"}`)}},
+		llm.FakeReply{Content: "Done."},
+		llm.FakeReply{Content: "[PASS]"},
+	)
+
+	events := runFake(t, bp, fake, "plan it")
+
+	s, ok := events[len(events)-1].(SessionSummary)
+	if !ok {
+		t.Fatalf("expected a SessionSummary last, got %T", events[len(events)-1])
+	}
+	if s.Turns != 2 || s.ToolCalls != 2 || s.TasksCreated != 1 || s.TasksCompleted != 1 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if fmt.Sprint(s.Messages) != "[{@user 1} {@a 1}]" {
+		t.Errorf("unexpected message counts: %v", s.Messages)
+	}
+	want := "Session: 2 turns, 2 tool calls, 1 task (1 completed)\nMessages: @user 1, @a 1"
+	if got := FormatSummary(s); got != want {
+		t.Errorf("FormatSummary = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package floor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// textEditor is implemented by frontends that can hand the terminal to the
+// user's editor, for /edit.
+type textEditor interface {
+	EditText(text string) (string, error)
+}
+
+// editMessage replies to /edit: the user edits the agent's last message in
+// their editor, and the controller gets the result as MessageEdited.
+func (co *Coordinator) editMessage(e EditMessage) []Event {
+	editor, ok := co.frontend.(textEditor)
+	if !ok {
+		return []Event{SystemInfo{Text: "[/edit is not available on this floor]"}}
+	}
+	text, err := editor.EditText(e.Content)
+	switch {
+	case err != nil:
+		return []Event{SystemInfo{Text: fmt.Sprintf("[/edit failed: %v]", err)}}
+	case strings.TrimSpace(text) == "":
+		return []Event{SystemInfo{Text: "[/edit: empty message; edit discarded]"}}
+	}
+	return co.ctrl.HandleEvent(MessageEdited{AgentID: e.AgentID, Content: text})
+}
+
+// editText writes text to a temporary file, runs the user's editor on it
+// with run, and returns the file's new contents. A trailing newline the
+// editor added is dropped.
+func editText(text string, run func(*exec.Cmd) error) (string, error) {
+	f, err := os.CreateTemp("", "ofc-edit-*.md")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	_, err = f.WriteString(text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cmd := editorCommand(path)
+	if err := run(cmd); err != nil {
+		return "", fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	edited := string(data)
+	if !strings.HasSuffix(text, "\n") {
+		edited = strings.TrimSuffix(edited, "\n")
+	}
+	return edited, nil
+}
+
+// editorCommand returns the command that opens path in $VISUAL, $EDITOR,
+// or vi. The variable may include arguments ("code --wait").
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if strings.TrimSpace(editor) == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
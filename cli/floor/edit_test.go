@@ -0,0 +1,72 @@
+package floor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// editingFrontend is a ChannelFrontend whose /edit runs edit instead of an editor.
+type editingFrontend struct {
+	*ChannelFrontend
+	edit func(string) (string, error)
+}
+
+func (f editingFrontend) EditText(text string) (string, error) { return f.edit(text) }
+
+func TestEditLastMessage(t *testing.T) {
+	ch := NewChannelFrontend(16, nil)
+	var got string
+	fe := editingFrontend{ch, func(text string) (string, error) {
+		got = text
+		return "the answer is 43", nil
+	}}
+	co := NewCoordinatorWith(twoAgentBlueprint(), fe, ch, nil, nil, nil)
+
+	info := requireEvent[SystemInfo](t, co.ctrl.HandleEvent(UserCommand{Command: "/edit"}), 0)
+	if info.Text != "[/edit: the last message is not an agent's]" {
+		t.Errorf("empty floor: got %q", info.Text)
+	}
+
+	co.ctrl.HandleEvent(UserMessage{Content: "what is the answer?"})
+	co.ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "the answer is 42"})
+
+	edit := requireEvent[EditMessage](t, co.ctrl.HandleEvent(UserCommand{Command: "/edit"}), 0)
+	info = requireEvent[SystemInfo](t, co.editMessage(edit), 0)
+	if got != "the answer is 42" {
+		t.Errorf("editor got %q", got)
+	}
+	if info.Text != "[Edited @data's last message]" {
+		t.Errorf("unexpected reply %q", info.Text)
+	}
+	last := co.ctrl.Messages[len(co.ctrl.Messages)-1]
+	if last.FromID != "@data" || last.Content != "the answer is 43" {
+		t.Errorf("last message: %+v", last)
+	}
+
+	// The conversation moved on while the editor was open.
+	co.ctrl.HandleEvent(UserMessage{Content: "thanks"})
+	info = requireEvent[SystemInfo](t, co.ctrl.HandleEvent(MessageEdited{AgentID: "@data", Content: "late"}), 0)
+	if info.Text != "[/edit: the conversation changed; edit discarded]" {
+		t.Errorf("stale edit: got %q", info.Text)
+	}
+}
+
+func TestEditTextRunsEditor(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "fake-editor")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nprintf 'edited\\n' > \"$2\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", script+" --wait")
+
+	text, err := editText("original", func(cmd *exec.Cmd) error { return cmd.Run() })
+	if err != nil {
+		t.Fatalf("editText: %v", err)
+	}
+	if text != "edited" {
+		t.Errorf("got %q, want the editor's text without its trailing newline", text)
+	}
+}
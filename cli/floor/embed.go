@@ -0,0 +1,155 @@
+package floor
+
+import (
+	"io"
+	"sync"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+// ChannelFrontend implements Frontend and StreamSink over Go channels, for
+// programs that embed a floor instead of running it in a terminal.
+//
+// Every rendered and streamed event is delivered on Events(); input is fed
+// with Submit/SubmitMessage. The consumer must keep draining Events(),
+// otherwise the floor blocks. Events() is closed when the floor stops.
+type ChannelFrontend struct {
+	events    chan Event
+	input     chan Event
+	done      chan struct{} // closed by Shutdown or Close: no more input
+	stopped   chan struct{} // closed by Close: no more events
+	logWriter io.Writer
+
+	mu        sync.Mutex
+	closed    bool
+	inputDone bool
+	sending   sync.WaitGroup // sends in flight, which Close waits out
+}
+
+// NewChannelFrontend creates a channel frontend. buffer sizes the event
+// channel; logWriter is optional (nil = no log).
+func NewChannelFrontend(buffer int, logWriter io.Writer) *ChannelFrontend {
+	return &ChannelFrontend{
+		events:    make(chan Event, buffer),
+		input:     make(chan Event),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		logWriter: logWriter,
+	}
+}
+
+// Events returns the channel of floor events (rendered and streamed).
+func (f *ChannelFrontend) Events() <-chan Event {
+	return f.events
+}
+
+// Submit feeds a UserMessage, SystemMessage or UserCommand to the floor.
+// It blocks until the floor is ready for input. Returns io.EOF after
+// Shutdown, or once the floor has stopped.
+func (f *ChannelFrontend) Submit(ev Event) error {
+	select {
+	case <-f.done:
+		return io.EOF
+	default:
+	}
+	select {
+	case f.input <- ev:
+		return nil
+	case <-f.done:
+		return io.EOF
+	}
+}
+
+// SubmitMessage is shorthand for Submit(UserMessage{Content: content}).
+func (f *ChannelFrontend) SubmitMessage(content string) error {
+	return f.Submit(UserMessage{Content: content})
+}
+
+// Shutdown ends input; the floor's Run loop returns once the current turn
+// finishes, after which Events() is closed.
+func (f *ChannelFrontend) Shutdown() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.endInput()
+}
+
+// endInput closes f.done once. f.mu must be held.
+func (f *ChannelFrontend) endInput() {
+	if !f.inputDone {
+		f.inputDone = true
+		close(f.done)
+	}
+}
+
+// Render delivers an event on Events().
+func (f *ChannelFrontend) Render(ev Event) {
+	f.send(ev)
+}
+
+// OnStream delivers a streaming event on Events().
+func (f *ChannelFrontend) OnStream(ev Event) {
+	f.send(ev)
+}
+
+func (f *ChannelFrontend) send(ev Event) {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.sending.Add(1)
+	f.mu.Unlock()
+	defer f.sending.Done()
+	select {
+	case f.events <- ev:
+	case <-f.stopped:
+	}
+}
+
+// ReadInput blocks until input is submitted, or returns io.EOF after Shutdown.
+func (f *ChannelFrontend) ReadInput() (Event, error) {
+	select {
+	case ev := <-f.input:
+		return ev, nil
+	case <-f.done:
+		return nil, io.EOF
+	}
+}
+
+// LogWriter returns the log writer passed at construction, or nil.
+func (f *ChannelFrontend) LogWriter() io.Writer {
+	return f.logWriter
+}
+
+// Close ends input and closes the events channel, once sends in flight
+// have been delivered or dropped. Called by the coordinator when it stops.
+func (f *ChannelFrontend) Close() {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.closed = true
+	f.endInput()
+	close(f.stopped)
+	f.mu.Unlock()
+
+	f.sending.Wait()
+	close(f.events)
+}
+
+// NewEmbedded creates a coordinator for use as a library, driven by a
+// ChannelFrontend. Nothing is written to stdout or stderr; ACP subprocess
+// stderr and sandbox build output are discarded.
+//
+//	co, ch := floor.NewEmbedded(bp)
+//	go co.Run("")
+//	go ch.SubmitMessage("Analyze the sales data")
+//	for ev := range ch.Events() {
+//		// handle TokenStreamed, AgentDone, WaitingForUser, ...
+//	}
+func NewEmbedded(bp *blueprint.Blueprint) (*Coordinator, *ChannelFrontend) {
+	frontend := NewChannelFrontend(64, nil)
+	co := NewCoordinatorWith(bp, frontend, frontend, nil, nil, io.Discard)
+	return co, frontend
+}
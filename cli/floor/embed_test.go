@@ -0,0 +1,159 @@
+package floor
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+func TestEmbeddedFloorRoundTrip(t *testing.T) {
+	// No agents: every message goes straight back to the user.
+	co, ch := NewEmbedded(&blueprint.Blueprint{Name: "embedded"})
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- co.Run("") }()
+	go func() {
+		if err := ch.SubmitMessage("hello"); err != nil {
+			t.Errorf("SubmitMessage: %v", err)
+		}
+	}()
+
+	timeout := time.After(5 * time.Second)
+	for waiting := false; !waiting; {
+		select {
+		case ev, ok := <-ch.Events():
+			if !ok {
+				t.Fatal("events closed before WaitingForUser")
+			}
+			_, waiting = ev.(WaitingForUser)
+		case <-timeout:
+			t.Fatal("timed out waiting for WaitingForUser")
+		}
+	}
+
+	ch.Shutdown()
+	for range ch.Events() {
+		// drain until closed
+	}
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := ch.SubmitMessage("late"); err == nil {
+		t.Error("expected error submitting after shutdown")
+	}
+}
+
+func TestRunAsync(t *testing.T) {
+	ch := NewChannelFrontend(64, nil)
+	co := NewCoordinatorWith(&blueprint.Blueprint{Name: "async"}, ch, ch, nil, nil, nil)
+
+	in := make(chan Event)
+	done := co.RunAsync(in)
+
+	go func() {
+		in <- UserMessage{Content: "hello"}
+		in <- UserCommand{Command: "/quit"}
+	}()
+
+	var sawWaiting, sawStopped bool
+	for ev := range ch.Events() {
+		switch ev.(type) {
+		case WaitingForUser:
+			sawWaiting = true
+		case FloorStopped:
+			sawStopped = true
+		}
+	}
+	if !sawWaiting || !sawStopped {
+		t.Errorf("expected WaitingForUser and FloorStopped (waiting=%v, stopped=%v)", sawWaiting, sawStopped)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("RunAsync: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunAsync did not finish")
+	}
+}
+
+func TestChannelFrontendSubmitDuringShutdown(t *testing.T) {
+	// Nobody reads input, so submits block until Shutdown.
+	ch := NewChannelFrontend(1, nil)
+	var ready, wg sync.WaitGroup
+	for range 8 {
+		ready.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ready.Done()
+			for range 50 {
+				if err := ch.SubmitMessage("hi"); err != nil && !errors.Is(err, io.EOF) {
+					t.Errorf("SubmitMessage: %v", err)
+				}
+			}
+		}()
+	}
+	ready.Wait()
+	ch.Shutdown()
+	wg.Wait()
+
+	if err := ch.SubmitMessage("late"); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF after Shutdown, got %v", err)
+	}
+	if _, err := ch.ReadInput(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF from ReadInput after Shutdown, got %v", err)
+	}
+}
+
+func TestChannelFrontendRenderDuringClose(t *testing.T) {
+	// Nobody drains Events(), so renders block until Close.
+	ch := NewChannelFrontend(1, nil)
+	var ready, wg sync.WaitGroup
+	for range 8 {
+		ready.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ready.Done()
+			for range 10 {
+				ch.Render(SystemInfo{Text: "tick"})
+			}
+		}()
+	}
+	ready.Wait()
+	ch.Close()
+	wg.Wait()
+	for range ch.Events() {
+		// drain until closed
+	}
+}
+
+func TestSubmitAfterFloorStops(t *testing.T) {
+	co, ch := NewEmbedded(&blueprint.Blueprint{Name: "embedded"})
+	runErr := make(chan error, 1)
+	go func() { runErr <- co.Run("") }()
+	go ch.Submit(UserCommand{Command: "/quit"})
+	for range ch.Events() {
+		// drain until closed
+	}
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- ch.SubmitMessage("anyone there?") }()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("expected io.EOF once the floor stopped, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SubmitMessage blocked after the floor stopped")
+	}
+}
@@ -0,0 +1,68 @@
+package floor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTurnCancelled is the AgentError cause when a turn is cancelled
+// (see Coordinator.CancelTurn).
+var ErrTurnCancelled = errors.New("turn cancelled")
+
+// ErrNoAnswer is returned by Run for a one-shot run that requires an answer
+// (see Coordinator.RequireAnswer) when no agent replied with any content.
+var ErrNoAnswer = errors.New("no agent produced an answer")
+
+// AgentFailedError is returned by Run when a one-shot run ended because an
+// agent failed. Err is the AgentError cause (ErrTurnCancelled if the turn
+// was cancelled).
+type AgentFailedError struct {
+	AgentID string
+	Err     error
+}
+
+func (e *AgentFailedError) Error() string {
+	return fmt.Sprintf("agent %s failed: %v", e.AgentID, e.Err)
+}
+
+func (e *AgentFailedError) Unwrap() error { return e.Err }
+
+// ErrUnknownAgent is returned when an agent ID is not in the blueprint.
+type ErrUnknownAgent struct {
+	ID string
+}
+
+func (e *ErrUnknownAgent) Error() string {
+	return fmt.Sprintf("unknown agent %s", e.ID)
+}
+
+// ErrNoACPSession is returned when an ACP agent is prompted without a running session.
+type ErrNoACPSession struct {
+	AgentID string
+}
+
+func (e *ErrNoACPSession) Error() string {
+	return fmt.Sprintf("no ACP session for agent %s", e.AgentID)
+}
+
+// ConfigError reports an invalid blueprint configuration detected at startup.
+type ConfigError struct {
+	Msg string
+}
+
+func (e *ConfigError) Error() string { return e.Msg }
+
+// ACPStartError is returned when an ACP agent fails to launch, initialize,
+// or create its session. Op is the failed step: "start", "initialize", or
+// "create session for".
+type ACPStartError struct {
+	AgentID string
+	Op      string
+	Err     error
+}
+
+func (e *ACPStartError) Error() string {
+	return fmt.Sprintf("failed to %s ACP agent %s: %v", e.Op, e.AgentID, e.Err)
+}
+
+func (e *ACPStartError) Unwrap() error { return e.Err }
@@ -1,5 +1,10 @@
 package floor
 
+import (
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
 // Event is the base interface for all floor events.
 // Sealed — only types in this package implement it.
 type Event interface {
@@ -14,15 +19,29 @@ type UserMessage struct {
 }
 
 // AgentDone is sent when an agent finishes its full response.
+// Handoff is set when the agent ended with a [[handoff:@id]] directive.
+// Usage is the turn's token count (LLM agents whose endpoint reports it).
 type AgentDone struct {
 	AgentID          string
 	Content          string
 	ToolInteractions []ToolInteraction
+	Handoff          string
+	Usage            llm.Usage
 }
 
 // AgentPassed is sent when an agent responds with [PASS].
 type AgentPassed struct {
 	AgentID string
+	Usage   llm.Usage
+}
+
+// AgentRouted is sent when a router agent has chosen who responds next:
+// Target is an agent ID, or "@user" to hand the floor back. The router
+// adds no message to the floor.
+type AgentRouted struct {
+	AgentID string
+	Target  string
+	Usage   llm.Usage
 }
 
 // AgentError is sent when a runner encounters an error.
@@ -32,20 +51,85 @@ type AgentError struct {
 	Partial string // any content produced before the error
 }
 
-// UserCommand is sent for slash commands (/quit, /clear).
+// AgentsDone is sent after a PromptAgents batch finishes. Results holds each
+// agent's AgentDone, AgentPassed or AgentError, in PromptAgents order.
+// Handoff directives are ignored inside a batch.
+type AgentsDone struct {
+	Results []Event
+}
+
+// SystemMessage adds guidance for the agents to the floor, e.g. dynamic
+// instructions from an embedding program. Agents see it as a system
+// message at its place in the conversation, apart from their prompts. It
+// doesn't start a turn.
+type SystemMessage struct {
+	Content string
+}
+
+// UserCommand is sent for slash commands (/quit, /clear, /reload, /tools,
+// /usage, /edit, /system).
 type UserCommand struct {
 	Command string
 }
 
+// BlueprintLoaded is sent after a ReloadBlueprint with the blueprint as
+// re-read from disk.
+type BlueprintLoaded struct {
+	Blueprint *blueprint.Blueprint
+}
+
+// MessageEdited is sent after an EditMessage with the user's new text for
+// the agent's last message.
+type MessageEdited struct {
+	AgentID string
+	Content string
+}
+
 // --- Outbound events (from controller) ---
 
 // PromptAgent tells the coordinator to dispatch a runner for this agent.
+// Stack is the call stack after routing, for display (see FormatStack).
 type PromptAgent struct {
 	AgentID string
+	Stack   []Frame
+}
+
+// PromptAgents tells the coordinator to run several agents concurrently, for
+// an @everyone? fan-out when the blueprint sets concurrent_broadcast. The
+// coordinator replies with a single AgentsDone.
+type PromptAgents struct {
+	AgentIDs []string
+	Stack    []Frame
+}
+
+// ReloadBlueprint asks the coordinator to re-read the blueprint file
+// (/reload). The coordinator replies with BlueprintLoaded.
+type ReloadBlueprint struct{}
+
+// ListTools asks the coordinator to report the tools each agent would be
+// offered right now (/tools). AgentID limits it to one agent; "" means all.
+// The coordinator replies with SystemInfo.
+type ListTools struct {
+	AgentID string
+}
+
+// EditMessage asks the coordinator to let the user edit the last message,
+// an agent's, in their editor (/edit). The coordinator replies with
+// MessageEdited, or SystemInfo if the edit was abandoned.
+type EditMessage struct {
+	AgentID string
+	Content string
 }
 
+// ShowUsage asks the coordinator to report token usage and estimated cost
+// per agent (/usage). The coordinator replies with SystemInfo.
+type ShowUsage struct{}
+
 // WaitingForUser indicates the turn has returned to the user.
-type WaitingForUser struct{}
+// Stack is the call stack at that point (usually empty).
+type WaitingForUser struct {
+	Stack []Frame
+}
 
 // ConversationCleared indicates /clear was processed.
 type ConversationCleared struct{}
@@ -53,6 +137,26 @@ type ConversationCleared struct{}
 // FloorStopped indicates /quit was processed.
 type FloorStopped struct{}
 
+// SessionSummary recaps the session before the floor stops: on /quit, when
+// input ends, and at the end of a one-shot run. Messages and ToolCalls cover
+// the conversation since the last /clear; the other counts cover the whole
+// session.
+type SessionSummary struct {
+	Turns          int            // agent turns, including passes and errors
+	Messages       []MessageCount // per sender, in order of first message
+	ToolCalls      int
+	TasksCreated   int // on the floor's task boards
+	TasksCompleted int
+	Usage          llm.Usage    // total, as reported by LLM endpoints
+	Agents         []AgentUsage // per agent, with estimated costs
+}
+
+// MessageCount is the number of messages one sender posted.
+type MessageCount struct {
+	AgentID string
+	Count   int
+}
+
 // SystemInfo is an informational message (sandbox ready, agent started, etc.).
 type SystemInfo struct {
 	Text string
@@ -72,6 +176,14 @@ type ToolCallStarted struct {
 	Title   string
 }
 
+// ToolCallProgress is a progress message from a tool call still running
+// (furniture that implements furniture.StreamingFurniture).
+type ToolCallProgress struct {
+	AgentID string
+	Title   string
+	Message string
+}
+
 // ToolCallResult is the output of a completed tool call.
 type ToolCallResult struct {
 	AgentID string
@@ -89,19 +201,39 @@ type AgentLabel struct {
 	AgentID string
 }
 
+// UserInputRequested is sent when an agent calls ask_user. The runner then
+// waits for the user's next message as the answer.
+type UserInputRequested struct {
+	AgentID  string
+	Question string
+}
+
 // Seal the interface — only floor package types can implement Event.
 func (UserMessage) eventMarker()          {}
 func (AgentDone) eventMarker()            {}
 func (AgentPassed) eventMarker()          {}
 func (AgentError) eventMarker()           {}
+func (AgentRouted) eventMarker()          {}
+func (AgentsDone) eventMarker()           {}
+func (SystemMessage) eventMarker()        {}
 func (UserCommand) eventMarker()          {}
+func (BlueprintLoaded) eventMarker()      {}
+func (MessageEdited) eventMarker()        {}
 func (PromptAgent) eventMarker()          {}
+func (PromptAgents) eventMarker()         {}
+func (ReloadBlueprint) eventMarker()      {}
+func (ListTools) eventMarker()            {}
+func (ShowUsage) eventMarker()            {}
+func (EditMessage) eventMarker()          {}
 func (WaitingForUser) eventMarker()       {}
 func (ConversationCleared) eventMarker()  {}
 func (FloorStopped) eventMarker()         {}
+func (SessionSummary) eventMarker()       {}
 func (SystemInfo) eventMarker()           {}
 func (TokenStreamed) eventMarker()        {}
 func (ToolCallStarted) eventMarker()      {}
+func (ToolCallProgress) eventMarker()     {}
 func (ToolCallResult) eventMarker()       {}
 func (AgentThinking) eventMarker()        {}
 func (AgentLabel) eventMarker()           {}
+func (UserInputRequested) eventMarker()   {}
@@ -2,6 +2,13 @@
 // managing multi-agent turn-taking, event routing, and frontends.
 package floor
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // ANSI color codes
 const (
 	Bold   = "\033[1m"
@@ -20,6 +27,34 @@ const (
 // @user always gets Cyan; agents get the rest in order.
 var agentColors = []string{Green, Purple, Yellow, Blue, Red}
 
+// namedColors maps blueprint.ColorNames to ANSI codes.
+var namedColors = map[string]string{
+	"red":    Red,
+	"green":  Green,
+	"yellow": Yellow,
+	"blue":   Blue,
+	"purple": Purple,
+	"cyan":   Cyan,
+	"gray":   Gray,
+	"white":  "\033[97m",
+}
+
+// colorCode returns the ANSI code for a blueprint color: a named color, or
+// "#rrggbb" as 24-bit color. It returns "" for anything else.
+func colorCode(c string) string {
+	if code, ok := namedColors[c]; ok {
+		return code
+	}
+	if len(c) != 7 || c[0] != '#' {
+		return ""
+	}
+	rgb, err := strconv.ParseUint(c[1:], 16, 32)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("\033[38;2;%d;%d;%dm", rgb>>16, rgb>>8&0xff, rgb&0xff)
+}
+
 // ToolInteraction stores one tool call and its result.
 type ToolInteraction struct {
 	Command string
@@ -31,11 +66,30 @@ type FloorMessage struct {
 	FromID           string            // "@user", "@data", "@code"
 	Content          string            // The text content
 	ToolInteractions []ToolInteraction // Tool calls made during this turn
+	Time             time.Time         // When the message was added to the floor
 }
 
 // Frame represents one level in the delegation chain.
 // Caller asked Callee a question via @mention?
 type Frame struct {
-	Caller string // e.g. "@data"
-	Callee string // e.g. "@code"
+	Caller    string // e.g. "@data"
+	Callee    string // e.g. "@code"
+	Broadcast bool   // queued by @everyone?; sibling frames run in turn before returning to Caller
+}
+
+// FormatStack renders the call stack as a breadcrumb, e.g. "@user → @data → @code".
+// Queued @everyone? siblings that haven't started yet are omitted.
+// Returns "" for an empty stack.
+func FormatStack(frames []Frame) string {
+	if len(frames) == 0 {
+		return ""
+	}
+	parts := []string{frames[0].Caller}
+	for i, f := range frames {
+		if f.Broadcast && i+1 < len(frames) && frames[i+1].Broadcast && frames[i+1].Caller == f.Caller {
+			continue
+		}
+		parts = append(parts, f.Callee)
+	}
+	return strings.Join(parts, " → ")
 }
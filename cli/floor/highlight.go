@@ -0,0 +1,105 @@
+package floor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// codeHighlighter syntax-highlights fenced code blocks in an agent's
+// streamed text. Text outside fences passes straight through; a block is
+// held back until its closing fence, then emitted highlighted.
+type codeHighlighter struct {
+	line   strings.Builder // the current line so far
+	inCode bool
+	lang   string          // the opening fence's info string
+	code   strings.Builder // the block's lines so far
+}
+
+// write feeds a token through, passing text that is ready to emit.
+func (h *codeHighlighter) write(token string, emit func(string)) {
+	for token != "" {
+		piece, rest, newline := strings.Cut(token, "\n")
+		token = rest
+		h.line.WriteString(piece)
+		if !h.inCode {
+			if newline {
+				piece += "\n"
+			}
+			emit(piece)
+		}
+		if !newline {
+			return
+		}
+
+		line := h.line.String()
+		h.line.Reset()
+		fence := strings.TrimSpace(line)
+		switch {
+		case !h.inCode && strings.HasPrefix(fence, "```"):
+			h.inCode = true
+			h.lang = strings.TrimSpace(strings.TrimPrefix(fence, "```"))
+		case h.inCode && fence == "```":
+			emit(highlightCode(h.code.String(), h.lang, ""))
+			emit(line + "\n")
+			h.inCode = false
+			h.code.Reset()
+		case h.inCode:
+			h.code.WriteString(line + "\n")
+		}
+	}
+}
+
+// flush emits a block left open when the agent stopped streaming.
+func (h *codeHighlighter) flush(emit func(string)) {
+	if h.inCode {
+		emit(highlightCode(h.code.String()+h.line.String(), h.lang, ""))
+	}
+	h.inCode = false
+	h.code.Reset()
+	h.line.Reset()
+}
+
+// highlightCode returns code with ANSI syntax highlighting, choosing the
+// lexer by language name, then filename, then content. Code no lexer
+// recognizes is returned as is.
+func highlightCode(code, lang, filename string) string {
+	var lexer chroma.Lexer
+	if lang != "" {
+		lexer = lexers.Get(lang)
+	}
+	if lexer == nil && filename != "" {
+		lexer = lexers.Match(filename)
+	}
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		return code
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return code
+	}
+	var sb strings.Builder
+	if err := formatters.TTY256.Format(&sb, styles.Get("monokai"), it); err != nil {
+		return code
+	}
+	return sb.String()
+}
+
+// catRe matches a command that prints a single file, capturing its name.
+var catRe = regexp.MustCompile(`^\s*(?:cat|head|tail)\s+(?:-\S+(?:\s+\d+)?\s+)*([^\s|;&<>]+)\s*$`)
+
+// outputFilename returns the file a tool call printed, if its command
+// recognizably just shows one file (such as "cat main.go").
+func outputFilename(command string) string {
+	if m := catRe.FindStringSubmatch(command); m != nil {
+		return m[1]
+	}
+	return ""
+}
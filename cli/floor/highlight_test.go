@@ -0,0 +1,57 @@
+package floor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodeHighlighter(t *testing.T) {
+	var h codeHighlighter
+	var out strings.Builder
+	emit := func(s string) { out.WriteString(s) }
+
+	// Tokens split mid-line and mid-fence, as models stream them
+	for _, tok := range []string{"Here:\n``", "`go\nfunc main() {", "}\n", "```\nDone."} {
+		h.write(tok, emit)
+	}
+	h.flush(emit)
+
+	got := out.String()
+	plain := ansiRe.ReplaceAllString(got, "")
+	if plain != "Here:\n```go\nfunc main() {}\n```\nDone." {
+		t.Errorf("text changed by highlighting: %q", plain)
+	}
+	before, code, _ := strings.Cut(got, "```go\n")
+	if strings.Contains(before, "\x1b[") || !strings.Contains(code, "\x1b[") {
+		t.Errorf("expected only the code to be highlighted, got %q", got)
+	}
+}
+
+func TestCodeHighlighterFlushesOpenBlock(t *testing.T) {
+	var h codeHighlighter
+	var out strings.Builder
+	emit := func(s string) { out.WriteString(s) }
+
+	h.write("```python\nprint(1)\nprint(", emit)
+	if strings.Contains(out.String(), "print") {
+		t.Fatalf("code emitted before its block closed: %q", out.String())
+	}
+	h.flush(emit)
+	if plain := ansiRe.ReplaceAllString(out.String(), ""); plain != "```python\nprint(1)\nprint(" {
+		t.Errorf("got %q", plain)
+	}
+}
+
+func TestOutputFilename(t *testing.T) {
+	cases := map[string]string{
+		"cat main.go":          "main.go",
+		"head -n 20 app.py":    "app.py",
+		"cat a.go | grep func": "",
+		"ls -la":               "",
+	}
+	for cmd, want := range cases {
+		if got := outputFilename(cmd); got != want {
+			t.Errorf("%q: got %q, want %q", cmd, got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package floor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// historyMax is the number of inputs kept in the TUI history.
+const historyMax = 1000
+
+// inputHistory is the TUI's list of previous submissions, oldest first,
+// browsed with Up/Down like a shell. If path is set, entries are appended to
+// that file (one per line, newlines escaped) so they survive restarts.
+type inputHistory struct {
+	entries      []string
+	pos          int    // entry being shown; len(entries) when not browsing
+	draft        string // unsent input saved when browsing starts
+	path         string
+	skipCommands bool // don't record /commands
+}
+
+// loadHistory reads the history file at path, creating its directory if
+// needed. A missing file is not an error. An empty path keeps history in
+// memory only.
+func loadHistory(path string, skipCommands bool) (*inputHistory, error) {
+	h := &inputHistory{path: path, skipCommands: skipCommands}
+	if path == "" {
+		return h, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return h, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, unescapeHistory(line))
+		}
+	}
+	if len(h.entries) > historyMax {
+		h.entries = h.entries[len(h.entries)-historyMax:]
+	}
+	h.pos = len(h.entries)
+	return h, scanner.Err()
+}
+
+// add records a submitted input and stops browsing. Repeats of the previous
+// entry are not recorded. Write errors are ignored: history is best-effort.
+func (h *inputHistory) add(text string) {
+	h.pos = len(h.entries)
+	h.draft = ""
+	if text == "" || (h.skipCommands && strings.HasPrefix(text, "/")) {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == text {
+		return
+	}
+	h.entries = append(h.entries, text)
+	if len(h.entries) > historyMax {
+		h.entries = h.entries[len(h.entries)-historyMax:]
+	}
+	h.pos = len(h.entries)
+
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(escapeHistory(text) + "\n")
+}
+
+// prev steps back to the previous entry, saving current as the draft when
+// browsing starts. Returns false at the oldest entry.
+func (h *inputHistory) prev(current string) (string, bool) {
+	if h.pos == 0 {
+		return "", false
+	}
+	if h.pos == len(h.entries) {
+		h.draft = current
+	}
+	h.pos--
+	return h.entries[h.pos], true
+}
+
+// next steps forward to the next entry, restoring the draft after the
+// newest. Returns false when not browsing.
+func (h *inputHistory) next() (string, bool) {
+	if h.pos >= len(h.entries) {
+		return "", false
+	}
+	h.pos++
+	if h.pos == len(h.entries) {
+		return h.draft, true
+	}
+	return h.entries[h.pos], true
+}
+
+var (
+	historyEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	historyUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+)
+
+func escapeHistory(s string) string   { return historyEscaper.Replace(s) }
+func unescapeHistory(s string) string { return historyUnescaper.Replace(s) }
@@ -0,0 +1,69 @@
+package floor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInputHistoryBrowse(t *testing.T) {
+	h := &inputHistory{}
+	h.add("first")
+	h.add("second")
+	h.add("second") // consecutive repeat not recorded
+
+	if got, _ := h.prev("draft"); got != "second" {
+		t.Fatalf("prev = %q, want second", got)
+	}
+	if got, _ := h.prev(""); got != "first" {
+		t.Fatalf("prev = %q, want first", got)
+	}
+	if _, ok := h.prev(""); ok {
+		t.Fatal("expected no entry before the oldest")
+	}
+	if got, _ := h.next(); got != "second" {
+		t.Fatalf("next = %q, want second", got)
+	}
+	if got, _ := h.next(); got != "draft" {
+		t.Fatalf("next past newest = %q, want the draft", got)
+	}
+	if _, ok := h.next(); ok {
+		t.Fatal("expected next to stop when not browsing")
+	}
+}
+
+func TestInputHistorySkipCommands(t *testing.T) {
+	h := &inputHistory{skipCommands: true}
+	h.add("/clear")
+	h.add("hello")
+	if len(h.entries) != 1 || h.entries[0] != "hello" {
+		t.Errorf("entries = %q, want [hello]", h.entries)
+	}
+}
+
+func TestInputHistoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "history")
+
+	h, err := loadHistory(path, false)
+	if err != nil {
+		t.Fatalf("loadHistory: %v", err)
+	}
+	h.add("one")
+	h.add("two\nlines with a \\ backslash")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read history: %v", err)
+	}
+	if want := "one\ntwo\\nlines with a \\\\ backslash\n"; string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+
+	h2, err := loadHistory(path, false)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got, _ := h2.prev(""); got != "two\nlines with a \\ backslash" {
+		t.Errorf("reloaded newest = %q", got)
+	}
+}
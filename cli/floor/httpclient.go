@@ -0,0 +1,59 @@
+package floor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// newHTTPClient builds the HTTP client shared by the floor's LLM agents
+// from the blueprint's http_proxy and ca_cert. It returns nil if neither is
+// set, leaving the llm package's default client, which already honors the
+// HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables.
+func newHTTPClient(bp *blueprint.Blueprint) (*http.Client, error) {
+	proxy := os.ExpandEnv(bp.HTTPProxy)
+	if proxy == "" && bp.CACert == "" {
+		return nil, nil
+	}
+
+	client := llm.NewHTTPClient(llm.DefaultHeaderTimeout)
+	transport := client.Transport.(*http.Transport)
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, &ConfigError{Msg: fmt.Sprintf("http_proxy %q is not a valid URL", bp.HTTPProxy)}
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if bp.CACert != "" {
+		tlsConfig, err := newTLSConfig(bp.CACert)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return client, nil
+}
+
+// newTLSConfig returns a TLS config that trusts the system roots plus the
+// PEM certificates in caFile.
+func newTLSConfig(caFile string) (*tls.Config, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, &ConfigError{Msg: fmt.Sprintf("ca_cert: %v", err)}
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, &ConfigError{Msg: fmt.Sprintf("ca_cert: no PEM certificates in %s", caFile)}
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
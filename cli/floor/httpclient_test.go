@@ -0,0 +1,93 @@
+package floor
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// sseHandler answers every request with a one-token completion.
+func sseHandler(token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\ndata: [DONE]\n\n", token)
+	}
+}
+
+func TestHTTPClientUnsetUsesDefault(t *testing.T) {
+	client, err := newHTTPClient(&blueprint.Blueprint{})
+	if err != nil || client != nil {
+		t.Fatalf("expected no client, got %v, %v", client, err)
+	}
+}
+
+func TestHTTPClientProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		sseHandler("via proxy")(w, r)
+	}))
+	defer proxy.Close()
+
+	t.Setenv("OFC_TEST_PROXY", proxy.URL)
+	client, err := newHTTPClient(&blueprint.Blueprint{HTTPProxy: "${OFC_TEST_PROXY}"})
+	if err != nil {
+		t.Fatalf("newHTTPClient: %v", err)
+	}
+
+	c := llm.NewClient("http://llm.internal/v1", "")
+	c.HTTPClient = client
+	result, err := c.ChatStream("m", nil, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if result.Content != "via proxy" || proxied != "http://llm.internal/v1/chat/completions" {
+		t.Errorf("request did not go through the proxy: content %q, proxied %q", result.Content, proxied)
+	}
+}
+
+func TestHTTPClientCACert(t *testing.T) {
+	srv := httptest.NewTLSServer(sseHandler("trusted"))
+	defer srv.Close()
+
+	c := llm.NewClient(srv.URL, "")
+	if _, err := c.ChatStream("m", nil, 0, nil, nil); err == nil {
+		t.Fatal("expected the test server's certificate to be untrusted by default")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, err := newHTTPClient(&blueprint.Blueprint{CACert: caFile})
+	if err != nil {
+		t.Fatalf("newHTTPClient: %v", err)
+	}
+	c.HTTPClient = client
+	result, err := c.ChatStream("m", nil, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("ChatStream with ca_cert: %v", err)
+	}
+	if result.Content != "trusted" {
+		t.Errorf("got %q", result.Content)
+	}
+}
+
+func TestHTTPClientBadCACert(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, []byte("not a certificate"), 0o644)
+	if _, err := newHTTPClient(&blueprint.Blueprint{CACert: caFile}); err == nil {
+		t.Error("expected an error for a file without certificates")
+	}
+	if _, err := newHTTPClient(&blueprint.Blueprint{CACert: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,110 @@
+package floor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrAgentIdle is the AgentError cause when an agent's run is cancelled by
+// the idle watchdog (see Coordinator.SetIdleTimeout).
+var ErrAgentIdle = errors.New("agent idle")
+
+// idleWatch is a StreamSink that forwards events to next and tells an idle
+// watchdog goroutine about each one.
+// An ask_user question pauses the watchdog until the next event, since the
+// agent is waiting on the user rather than stalled.
+type idleWatch struct {
+	mu    sync.Mutex // serializes the agent's events with the watchdog's warnings
+	next  StreamSink
+	kick  chan struct{}
+	pause chan struct{}
+}
+
+func (w *idleWatch) OnStream(ev Event) {
+	w.forward(ev)
+	ch := w.kick
+	if _, ok := ev.(UserInputRequested); ok {
+		ch = w.pause
+	}
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// forward passes ev on to the agent's stream. The watchdog's warnings go
+// this way too, rather than straight to the frontend, so they never race
+// the agent's own output.
+func (w *idleWatch) forward(ev Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.next.OnStream(ev)
+}
+
+// watchIdle wraps an agent's stream with an idle watchdog. If no stream
+// event arrives for co.idleTimeout, it warns the user, and again after
+// each further idle period; with co.idleCancel it instead cancels ctx with
+// an ErrAgentIdle cause. Call stop when the run ends. With no idle
+// timeout set, it returns ctx and stream unchanged.
+func (co *Coordinator) watchIdle(ctx context.Context, agentID string, stream StreamSink) (context.Context, StreamSink, func()) {
+	if co.idleTimeout <= 0 {
+		return ctx, stream, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	w := &idleWatch{next: stream, kick: make(chan struct{}, 1), pause: make(chan struct{}, 1)}
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	window := co.idleTimeout
+	var hint string
+	if co.cancelKey != "" {
+		hint = fmt.Sprintf("; %s cancels the turn", co.cancelKey)
+	}
+
+	go func() {
+		defer close(exited)
+		timer := co.clock.NewTimer(window)
+		defer timer.Stop()
+		var idle time.Duration
+		for {
+			select {
+			case <-w.kick:
+				timer.Reset(window)
+				idle = 0
+			case <-w.pause:
+				timer.Stop()
+			case <-timer.C():
+				idle += window
+				if co.idleCancel {
+					w.forward(SystemInfo{Text: fmt.Sprintf("[%s: no output for %v, cancelling]", agentID, idle)})
+					cancel(fmt.Errorf("%w: no output for %v", ErrAgentIdle, idle))
+					return
+				}
+				w.forward(SystemInfo{Text: fmt.Sprintf("[%s: no output for %v%s]", agentID, idle, hint)})
+				timer.Reset(window)
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ctx, w, func() {
+		close(done)
+		<-exited // no warning may be rendered after the run
+		cancel(nil)
+	}
+}
+
+// idleError returns the idle watchdog's cause if it cancelled ctx (and
+// not its parent), else nil.
+func idleError(ctx context.Context) error {
+	if cause := context.Cause(ctx); errors.Is(cause, ErrAgentIdle) {
+		return cause
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package floor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/clock"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// slowStreamer replies with content once release is closed, or fails when
+// ctx ends.
+type slowStreamer struct {
+	release chan struct{}
+	content string
+}
+
+func (s slowStreamer) ChatStreamContext(ctx context.Context, model string, messages []llm.Message, temperature float64, tools []llm.Tool, onToken func(string)) (*llm.ChatResult, error) {
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	onToken(s.content)
+	return &llm.ChatResult{Content: s.content}, nil
+}
+
+const testIdleTimeout = 20 * time.Second
+
+// startIdle runs "@slow? question" with an idle timeout on a fake clock.
+// It returns the clock and a func that waits for the run to end and
+// returns its events.
+func startIdle(t *testing.T, client llm.ChatStreamer, cancel bool) (*clock.Fake, func() ([]Event, error)) {
+	t.Helper()
+	bp := &blueprint.Blueprint{
+		Name:   "idle",
+		Agents: []blueprint.Agent{{ID: "@slow", Type: "llm", Activation: "mention"}},
+	}
+	ch := NewChannelFrontend(64, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+	co.SetLLMClient(client)
+	fake := clock.NewFake(time.Now())
+	co.SetClock(fake)
+	co.SetIdleTimeout(testIdleTimeout, cancel)
+
+	done := make(chan error, 1)
+	go func() { done <- co.Run("@slow? question") }()
+	return fake, func() ([]Event, error) {
+		t.Helper()
+		var err error
+		select {
+		case err = <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("run did not finish")
+		}
+		var events []Event
+		for ev := range ch.Events() {
+			events = append(events, ev)
+		}
+		return events, err
+	}
+}
+
+func hasInfo(events []Event, substr string) bool {
+	for _, ev := range events {
+		if info, ok := ev.(SystemInfo); ok && strings.Contains(info.Text, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIdleTimeoutWarns(t *testing.T) {
+	release := make(chan struct{})
+	fake, wait := startIdle(t, slowStreamer{release: release, content: "finally"}, false)
+	fake.BlockUntil(1)
+	fake.Advance(testIdleTimeout)
+	fake.BlockUntil(1) // warned, and watching again
+	close(release)
+
+	events, err := wait()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !hasInfo(events, "[@slow: no output for 20s]") {
+		t.Error("expected an idle warning, without a cancel hint the frontend can't honour")
+	}
+	if got := streamed(events, "@slow"); got != "finally" {
+		t.Errorf("the slow agent should still finish, streamed %q", got)
+	}
+}
+
+func TestIdleTimeoutCancels(t *testing.T) {
+	fake, wait := startIdle(t, slowStreamer{release: make(chan struct{})}, true)
+	fake.BlockUntil(1)
+	fake.Advance(testIdleTimeout)
+
+	events, err := wait()
+	var failed *AgentFailedError
+	if !errors.As(err, &failed) || !errors.Is(err, ErrAgentIdle) {
+		t.Fatalf("expected an idle AgentFailedError, got %v", err)
+	}
+	if !hasInfo(events, "cancelling") {
+		t.Error("expected a cancellation notice")
+	}
+}
+
+func TestIdleTimeoutQuietForPromptReply(t *testing.T) {
+	_, wait := startIdle(t, llm.NewFakeClient(llm.FakeReply{Content: "quick answer"}), true)
+	events, err := wait()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if hasInfo(events, "no output") {
+		t.Error("a prompt reply should not trigger the watchdog")
+	}
+}
+
+func TestIdleWatchPausesForAskUser(t *testing.T) {
+	ch := NewChannelFrontend(64, nil)
+	co := NewCoordinatorWith(&blueprint.Blueprint{Name: "idle"}, ch, ch, nil, nil, nil)
+	fake := clock.NewFake(time.Now())
+	co.SetClock(fake)
+	co.SetIdleTimeout(testIdleTimeout, true)
+
+	ctx, stream, stop := co.watchIdle(context.Background(), "@a", ch)
+	defer stop()
+	fake.BlockUntil(1)
+	stream.OnStream(UserInputRequested{AgentID: "@a", Question: "which?"})
+	fake.BlockUntilFewer(1) // paused
+	fake.Advance(time.Hour)
+	if ctx.Err() != nil {
+		t.Fatal("watchdog cancelled an agent waiting for the user")
+	}
+
+	stream.OnStream(ToolCallResult{AgentID: "@a", Title: "ask_user: which?", Output: "that one"})
+	fake.BlockUntil(1)
+	fake.Advance(testIdleTimeout)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchdog did not resume after the answer")
+	}
+}
+
+func TestIdleWarningWithCLIFrontend(t *testing.T) {
+	out := captureStdout(t, func() {
+		f := NewCLIFrontend("", false, map[string]string{})
+		f.out.piped = false // show the thinking line, as on a terminal
+		co := NewCoordinatorWith(&blueprint.Blueprint{Name: "idle"}, f, f, nil, nil, nil)
+		co.cancelKey = "Ctrl-C" // as newCLICoordinator sets it
+		fake := clock.NewFake(time.Now())
+		co.SetClock(fake)
+		co.SetIdleTimeout(testIdleTimeout, false)
+
+		f.Render(AgentThinking{AgentID: "@a"})
+		_, stream, stop := co.watchIdle(context.Background(), "@a", f)
+		for range 5 {
+			fake.BlockUntil(1)
+			fake.Advance(testIdleTimeout)
+			stream.OnStream(TokenStreamed{AgentID: "@a", Token: "."}) // as the warning renders
+		}
+		fake.BlockUntil(1)
+		fake.Advance(testIdleTimeout)
+		fake.BlockUntil(1) // warned at least this once
+		stop()
+	})
+	if !strings.Contains(out, "[@a: no output for 20s; Ctrl-C cancels the turn]") {
+		t.Errorf("expected an idle warning, got %q", out)
+	}
+}
@@ -0,0 +1,141 @@
+package floor
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// JSONResult is the summary a JSONFrontend writes when the floor stops.
+type JSONResult struct {
+	Messages []JSONMessage        `json:"messages"`
+	Usage    map[string]llm.Usage `json:"usage"`
+	Errors   []JSONError          `json:"errors"`
+}
+
+// JSONMessage is one agent reply.
+type JSONMessage struct {
+	Agent   string `json:"agent"`
+	Content string `json:"content"`
+}
+
+// JSONError is an agent or floor error. Agent is empty for floor errors.
+type JSONError struct {
+	Agent string `json:"agent,omitempty"`
+	Error string `json:"error"`
+}
+
+// JSONFrontend implements Frontend and StreamSink for machine consumption:
+// nothing is printed while the floor runs, and a single JSONResult is
+// written to w on Close. It reads no input, so the floor handles the
+// initial prompt and stops.
+type JSONFrontend struct {
+	w      io.Writer
+	out    *Output // log file only
+	debug  bool
+	mu     sync.Mutex
+	result JSONResult
+	closed bool
+}
+
+// NewJSONFrontend creates a JSON frontend writing to w, with an optional
+// plain-text log file.
+func NewJSONFrontend(w io.Writer, logPath string, debug bool) *JSONFrontend {
+	return &JSONFrontend{
+		w:     w,
+		out:   NewOutput(logPath, false),
+		debug: debug,
+		result: JSONResult{
+			Messages: []JSONMessage{},
+			Usage:    map[string]llm.Usage{},
+			Errors:   []JSONError{},
+		},
+	}
+}
+
+// Render records agent replies, token usage, and errors.
+func (f *JSONFrontend) Render(ev Event) {
+	logEvent(f.out, f.debug, ev)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch e := ev.(type) {
+	case AgentDone:
+		f.result.Messages = append(f.result.Messages, JSONMessage{Agent: e.AgentID, Content: e.Content})
+		f.addUsage(e.AgentID, e.Usage)
+	case AgentPassed:
+		f.addUsage(e.AgentID, e.Usage)
+	case AgentRouted:
+		f.addUsage(e.AgentID, e.Usage)
+	case AgentError:
+		f.result.Errors = append(f.result.Errors, JSONError{Agent: e.AgentID, Error: e.Err.Error()})
+	}
+}
+
+func (f *JSONFrontend) addUsage(agentID string, u llm.Usage) {
+	f.result.Usage[agentID] = f.result.Usage[agentID].Add(u)
+}
+
+// OnStream logs streaming events; the result only needs complete replies.
+func (f *JSONFrontend) OnStream(ev Event) {
+	logEvent(f.out, f.debug, ev)
+}
+
+// ReadInput always returns io.EOF: JSON output is for one-shot runs.
+func (f *JSONFrontend) ReadInput() (Event, error) {
+	return nil, io.EOF
+}
+
+// Fail records a floor-level error, such as a failure to start.
+func (f *JSONFrontend) Fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.result.Errors = append(f.result.Errors, JSONError{Error: err.Error()})
+}
+
+// Failed reports whether any error was recorded.
+func (f *JSONFrontend) Failed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.result.Errors) > 0
+}
+
+// Result returns a copy of the result so far.
+func (f *JSONFrontend) Result() JSONResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := f.result
+	r.Messages = append([]JSONMessage(nil), r.Messages...)
+	r.Errors = append([]JSONError(nil), r.Errors...)
+	r.Usage = make(map[string]llm.Usage, len(f.result.Usage))
+	for k, v := range f.result.Usage {
+		r.Usage[k] = v
+	}
+	return r
+}
+
+// LogWriter returns the log file writer for subsystems.
+func (f *JSONFrontend) LogWriter() io.Writer {
+	return f.out.LogWriter()
+}
+
+// Close writes the result to w and closes the log file. Safe to call more
+// than once; only the first call writes.
+func (f *JSONFrontend) Close() {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.closed = true
+	f.mu.Unlock()
+
+	enc := json.NewEncoder(f.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(f.Result()); err != nil {
+		f.out.Log("[ERROR writing JSON result: %v]\n", err)
+	}
+	f.out.Close()
+}
@@ -0,0 +1,66 @@
+package floor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+func TestJSONFrontendOneShot(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"42\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":2,\"total_tokens\":12}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	bp := &blueprint.Blueprint{
+		Name:   "json",
+		Agents: []blueprint.Agent{{ID: "@answer", Activation: "always", Endpoint: srv.URL}},
+	}
+	var buf bytes.Buffer
+	f := NewJSONFrontend(&buf, "", false)
+	co := NewCoordinatorWith(bp, f, f, nil, nil, nil)
+	if err := co.Run("what is six times seven?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	f.Close() // already written by Run; must not write twice
+
+	var res JSONResult
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if len(res.Messages) != 1 || res.Messages[0].Agent != "@answer" || res.Messages[0].Content != "42" {
+		t.Errorf("messages = %+v", res.Messages)
+	}
+	if u := res.Usage["@answer"]; u.TotalTokens != 12 || u.PromptTokens != 10 {
+		t.Errorf("usage = %+v", u)
+	}
+	if len(res.Errors) != 0 || f.Failed() {
+		t.Errorf("unexpected errors: %+v", res.Errors)
+	}
+}
+
+func TestJSONFrontendRecordsErrors(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewJSONFrontend(&buf, "", false)
+	f.Render(AgentError{AgentID: "@a", Err: fmt.Errorf("boom")})
+	f.Close()
+
+	if !f.Failed() {
+		t.Error("expected Failed after an AgentError")
+	}
+	var res JSONResult
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Errors) != 1 || res.Errors[0].Agent != "@a" || res.Errors[0].Error != "boom" {
+		t.Errorf("errors = %+v", res.Errors)
+	}
+}
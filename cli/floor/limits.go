@@ -0,0 +1,173 @@
+package floor
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// clearedContent replaces the content of messages dropped to keep the
+// transcript under limits.max_transcript_size.
+const clearedContent = "[content removed by ofc: the transcript exceeded limits.max_transcript_size]"
+
+// appendMessage adds msg to the transcript, enforcing the blueprint's
+// limits: an oversized message is truncated, and if the transcript grows
+// too large, the content of its oldest messages is cleared. Returns a
+// SystemInfo warning for each.
+func (c *Controller) appendMessage(msg FloorMessage) []Event {
+	var warnings []Event
+	limits := c.Blueprint.Limits
+	if limit := int(limits.MaxMessageSize); limit > 0 {
+		if size := messageSize(msg); size > limit {
+			msg = truncateMessage(msg, limit)
+			warnings = append(warnings, SystemInfo{Text: fmt.Sprintf(
+				"[%s's message (%s) was truncated to %s; see limits.max_message_size]",
+				msg.FromID, formatSize(size), formatSize(limit))})
+		}
+	}
+	c.Messages = append(c.Messages, msg)
+
+	if limit := int(limits.MaxTranscriptSize); limit > 0 {
+		if n := c.trimTranscript(limit); n > 0 {
+			warnings = append(warnings, SystemInfo{Text: fmt.Sprintf(
+				"[The transcript exceeded %s; cleared the content of the oldest messages (%d); see limits.max_transcript_size]",
+				formatSize(limit), n)})
+		}
+	}
+	return warnings
+}
+
+// trimTranscript clears the content and tool output of the oldest messages,
+// but never the newest, until the transcript is at most limit bytes.
+// Returns how many messages it cleared.
+func (c *Controller) trimTranscript(limit int) int {
+	total := 0
+	for _, msg := range c.Messages {
+		total += messageSize(msg)
+	}
+	cleared := 0
+	for i := 0; i < len(c.Messages)-1 && total > limit; i++ {
+		msg := &c.Messages[i]
+		if msg.Content == clearedContent && len(msg.ToolInteractions) == 0 {
+			continue
+		}
+		total -= messageSize(*msg)
+		msg.Content = clearedContent
+		msg.ToolInteractions = nil
+		total += len(clearedContent)
+		cleared++
+	}
+	if cleared > 0 {
+		c.dropWindows()
+	}
+	return cleared
+}
+
+// repeating reports whether agentID's latest message is, in this user turn,
+// the limits.max_repeats'th in a row from it with the same text and tool
+// calls. Other agents' messages in between don't count, so two agents
+// politely repeating themselves to each other are caught too.
+func (c *Controller) repeating(agentID string) bool {
+	limit := c.Blueprint.Limits.MaxRepeats
+	if limit < 2 {
+		return false
+	}
+	var first string
+	count := 0
+	for i := len(c.Messages) - 1; i >= 0 && c.Messages[i].FromID != "@user"; i-- {
+		msg := c.Messages[i]
+		if msg.FromID != agentID {
+			continue
+		}
+		sig := repeatSignature(msg)
+		if count == 0 {
+			first = sig
+		} else if sig != first {
+			return false
+		}
+		if count++; count >= limit {
+			return true
+		}
+	}
+	return false
+}
+
+// stopLoop abandons the delegation chain after an agent repeated itself
+// too often, and hands the floor back to the user.
+func (c *Controller) stopLoop(agentID string) []Event {
+	c.debug("→ %s is repeating itself, stopping", agentID)
+	c.CallStack = nil
+	c.asking = ""
+	return []Event{
+		SystemInfo{Text: fmt.Sprintf("[%s sent the same message %d times in a row; stopped the loop (see limits.max_repeats)]",
+			agentID, c.Blueprint.Limits.MaxRepeats)},
+		c.waitingForUser(),
+	}
+}
+
+// repeatSignature is what repeating compares: the message's words, in
+// lower case, and its tool commands, so near-identical replies that only
+// differ in case or spacing still match.
+func repeatSignature(msg FloorMessage) string {
+	var sb strings.Builder
+	sb.WriteString(strings.Join(strings.Fields(strings.ToLower(msg.Content)), " "))
+	for _, ti := range msg.ToolInteractions {
+		sb.WriteString("\x00")
+		sb.WriteString(ti.Command)
+	}
+	return sb.String()
+}
+
+// messageSize is the bytes a message holds: its content and tool calls.
+func messageSize(msg FloorMessage) int {
+	size := len(msg.Content)
+	for _, ti := range msg.ToolInteractions {
+		size += len(ti.Command) + len(ti.Output)
+	}
+	return size
+}
+
+// truncateMessage shortens a message to about limit bytes, content first,
+// then tool output in order. The tool interactions are copied, not
+// changed in place.
+func truncateMessage(msg FloorMessage, limit int) FloorMessage {
+	budget := limit
+	msg.Content = truncateText(msg.Content, budget)
+	budget -= len(msg.Content)
+	interactions := make([]ToolInteraction, len(msg.ToolInteractions))
+	for i, ti := range msg.ToolInteractions {
+		budget -= len(ti.Command)
+		ti.Output = truncateText(ti.Output, budget)
+		budget -= len(ti.Output)
+		interactions[i] = ti
+	}
+	if msg.ToolInteractions != nil {
+		msg.ToolInteractions = interactions
+	}
+	return msg
+}
+
+// truncateText cuts s to at most n bytes, on a character boundary, and
+// marks how much was cut.
+func truncateText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := max(n, 0)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("\n[... %s truncated by ofc ...]", formatSize(len(s)-cut))
+}
+
+// formatSize formats a byte count for warnings ("512 bytes", "1.5MB").
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
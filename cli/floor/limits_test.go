@@ -0,0 +1,101 @@
+package floor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+func TestMessageSizeLimit(t *testing.T) {
+	bp := twoAgentBlueprint()
+	bp.Limits.MaxMessageSize = 100
+	ctrl := NewController(bp)
+
+	ctrl.HandleEvent(UserMessage{Content: "cat the file"})
+	output := strings.Repeat("é", 200) // 400 bytes
+	interactions := []ToolInteraction{{Command: "cat blob", Output: output}}
+	events := ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "Here it is.", ToolInteractions: interactions})
+
+	info := requireEvent[SystemInfo](t, events, 0)
+	if info.Text != "[@data's message (419 bytes) was truncated to 100 bytes; see limits.max_message_size]" {
+		t.Errorf("warning: %q", info.Text)
+	}
+	msg := ctrl.Messages[1]
+	got := msg.ToolInteractions[0].Output
+	if !strings.HasPrefix(got, strings.Repeat("é", 40)+"\n[... 320 bytes truncated by ofc ...]") {
+		t.Errorf("truncated output: %q", got)
+	}
+	if interactions[0].Output != output {
+		t.Error("the event's tool interactions were changed in place")
+	}
+	if msg.Content != "Here it is." {
+		t.Errorf("content within the limit changed: %q", msg.Content)
+	}
+}
+
+func TestTranscriptSizeLimit(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:   "test",
+		Agents: []blueprint.Agent{{ID: "@data", Activation: "mention", ToolContext: "full"}},
+		Limits: blueprint.Limits{MaxTranscriptSize: 280},
+	}
+	ctrl := NewController(bp)
+
+	for i := 0; i < 2; i++ {
+		if events := ctrl.HandleEvent(UserMessage{Content: strings.Repeat("x", 100)}); len(events) != 1 {
+			t.Fatalf("unexpected warning: %v", events)
+		}
+	}
+	events := ctrl.HandleEvent(UserMessage{Content: strings.Repeat("y", 100)})
+	info := requireEvent[SystemInfo](t, events, 0)
+	if !strings.Contains(info.Text, "cleared the content of the oldest messages (1)") {
+		t.Errorf("warning: %q", info.Text)
+	}
+	if len(ctrl.Messages) != 3 || ctrl.Messages[0].Content != clearedContent || ctrl.Messages[1].Content != strings.Repeat("x", 100) {
+		t.Errorf("unexpected transcript: %+v", ctrl.Messages)
+	}
+}
+
+func TestRepeatedMessagesStopTheLoop(t *testing.T) {
+	bp := delegationBlueprint()
+	bp.Limits.MaxRepeats = 3
+	ctrl := NewController(bp)
+
+	ctrl.HandleEvent(UserMessage{Content: "@data? is the job done?"})
+	for range 2 {
+		requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code? status?"}), "@code")
+		requirePrompt(t, ctrl.HandleEvent(AgentDone{AgentID: "@code", Content: "Still running."}), "@data")
+	}
+
+	// The third identical ask, give or take case and spacing, ends it
+	events := ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@code?  Status?"})
+	info := requireEvent[SystemInfo](t, events, 0)
+	if !strings.Contains(info.Text, "@data sent the same message 3 times") {
+		t.Errorf("unexpected info: %q", info.Text)
+	}
+	requireEvent[WaitingForUser](t, events, 1)
+	if len(ctrl.CallStack) != 0 {
+		t.Errorf("expected empty stack, got %+v", ctrl.CallStack)
+	}
+}
+
+func TestRepeatsCountWithinUserTurn(t *testing.T) {
+	bp := twoAgentBlueprint()
+	bp.Limits.MaxRepeats = 2
+	ctrl := NewController(bp)
+
+	// Different tool calls make different messages
+	ctrl.HandleEvent(UserMessage{Content: "check"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "ok", ToolInteractions: []ToolInteraction{{Command: "ls"}}})
+	events := ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "ok", ToolInteractions: []ToolInteraction{{Command: "ls -l"}}})
+	requireEvent[WaitingForUser](t, events, 0)
+
+	// The same answer to a new question is not a loop
+	ctrl.HandleEvent(UserMessage{Content: "again"})
+	events = ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "ok", ToolInteractions: []ToolInteraction{{Command: "ls -l"}}})
+	requireEvent[WaitingForUser](t, events, 0)
+	if len(events) != 1 {
+		t.Errorf("expected only WaitingForUser, got %v", events)
+	}
+}
@@ -0,0 +1,68 @@
+package floor
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+// referenceRe matches any @name in a prompt, with or without the "?".
+var referenceRe = regexp.MustCompile(`@(\w+)`)
+
+// Lint returns warnings about a blueprint that is valid (see
+// Blueprint.Validate) but probably doesn't do what its author meant:
+// mention agents no other agent knows about, tool agents without a
+// sandbox, and settings the agent's backend ignores. It reads prompts the way the floor builds them, shared prompt
+// included.
+func Lint(bp *blueprint.Blueprint) []string {
+	var warnings []string
+	warn := func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	ctrl := NewController(bp)
+
+	// Who can reach whom: an agent can only @mention? agents its prompt
+	// tells it about, and @everyone? reaches all of them.
+	known := make(map[string]bool)
+	broadcast := false
+	hasAlways := false
+	for i := range bp.Agents {
+		agent := &bp.Agents[i]
+		if agent.Activation == "always" {
+			hasAlways = true
+		}
+		prompt := ctrl.systemPrompt(agent)
+		if slices.Contains(extractMentions(prompt), everyoneID) {
+			broadcast = true
+		}
+		for _, m := range referenceRe.FindAllStringSubmatch(prompt, -1) {
+			if id := "@" + m[1]; id != agent.ID {
+				known[id] = true
+			}
+		}
+	}
+
+	if !hasAlways && len(bp.Agents) > 0 {
+		warn("no agent has activation: always; messages that don't @mention? an agent get no reply")
+	}
+
+	hasSandbox := slices.ContainsFunc(bp.Workstations, func(ws blueprint.Workstation) bool {
+		return ws.Type == "sandbox"
+	})
+	for _, agent := range bp.Agents {
+		if agent.Activation == "mention" && !known[agent.ID] && !broadcast {
+			warn("agent %s: activation is mention, but no other agent's prompt refers to %s, so only @user can reach it", agent.ID, agent.ID)
+		}
+		if agent.CanUseTools && agent.Type == "llm" && !hasSandbox {
+			warn("agent %s: can_use_tools is set, but there is no sandbox workstation, so it gets no bash tool", agent.ID)
+		}
+		if agent.ReasoningEffort != "" && (agent.Type == "acp" || agent.API == "anthropic") {
+			warn("agent %s: reasoning_effort is only sent to OpenAI-compatible endpoints, so it is ignored", agent.ID)
+		}
+	}
+
+	return warnings
+}
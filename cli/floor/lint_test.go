@@ -0,0 +1,52 @@
+package floor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+func TestLint(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		SharedPrompt: "Ask @helper? when stuck.",
+		Agents: []blueprint.Agent{
+			{ID: "@lead", Type: "llm", Activation: "always", CanUseTools: true, Furniture: []string{"tasks"}},
+			{ID: "@helper", Type: "llm", Activation: "mention"},
+			{ID: "@hermit", Type: "llm", Activation: "mention", Prompt: "You are @hermit."},
+			{ID: "@claude", Type: "acp", Activation: "mention", CanUseTools: true, InheritSharedPrompt: new(bool), ReasoningEffort: "high"},
+		},
+		Furniture: []blueprint.FurnitureDef{{Name: "tasks", Type: "taskboard"}},
+	}
+
+	want := []string{
+		"agent @lead: can_use_tools is set, but there is no sandbox workstation, so it gets no bash tool",
+		"agent @hermit: activation is mention, but no other agent's prompt refers to @hermit, so only @user can reach it",
+		"agent @claude: activation is mention, but no other agent's prompt refers to @claude, so only @user can reach it",
+		"agent @claude: reasoning_effort is only sent to OpenAI-compatible endpoints, so it is ignored",
+	}
+	if got := Lint(bp); !reflect.DeepEqual(got, want) {
+		t.Errorf("warnings:\n got %q\nwant %q", got, want)
+	}
+
+	// @everyone? reaches every agent.
+	bp.Agents[0].Prompt = "Poll @everyone? before deciding."
+	bp.Workstations = []blueprint.Workstation{{Type: "sandbox"}}
+	bp.Agents[3].ReasoningEffort = ""
+	if got := Lint(bp); len(got) != 0 {
+		t.Errorf("expected no warnings, got %q", got)
+	}
+}
+
+func TestLintNoAlwaysAgent(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Agents: []blueprint.Agent{
+			{ID: "@a", Type: "llm", Activation: "mention", Prompt: "Ask @b? for help."},
+			{ID: "@b", Type: "llm", Activation: "mention", Prompt: "Ask @a? for help."},
+		},
+	}
+	want := []string{"no agent has activation: always; messages that don't @mention? an agent get no reply"}
+	if got := Lint(bp); !reflect.DeepEqual(got, want) {
+		t.Errorf("warnings:\n got %q\nwant %q", got, want)
+	}
+}
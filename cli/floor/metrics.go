@@ -0,0 +1,30 @@
+package floor
+
+import (
+	"time"
+
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// Metrics receives measurements of floor activity, for embedders that
+// export them to their own monitoring (see Coordinator.SetMetrics). Calls
+// may be concurrent when agents run in a concurrent broadcast, and should
+// return quickly: they are made on the floor's goroutines.
+type Metrics interface {
+	// TurnStarted is called when an agent's turn begins.
+	TurnStarted(agentID string)
+	// TurnCompleted is called when it ends, with its duration, the tokens
+	// it used (if the endpoint reports them), and the error that ended it,
+	// if any. A pass counts as completed.
+	TurnCompleted(agentID string, dur time.Duration, usage llm.Usage, err error)
+	// ToolCalled is called after each tool call an LLM agent makes.
+	// furniture is "" for the built-in bash and ask_user tools.
+	ToolCalled(agentID, furniture, tool string, dur time.Duration, err error)
+}
+
+// NopMetrics is a Metrics that records nothing, the default.
+type NopMetrics struct{}
+
+func (NopMetrics) TurnStarted(string)                                      {}
+func (NopMetrics) TurnCompleted(string, time.Duration, llm.Usage, error)   {}
+func (NopMetrics) ToolCalled(string, string, string, time.Duration, error) {}
@@ -0,0 +1,69 @@
+package floor
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// recordingMetrics records each call as a line of text.
+type recordingMetrics struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (m *recordingMetrics) record(format string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, fmt.Sprintf(format, args...))
+}
+
+func (m *recordingMetrics) TurnStarted(agentID string) {
+	m.record("start %s", agentID)
+}
+
+func (m *recordingMetrics) TurnCompleted(agentID string, _ time.Duration, _ llm.Usage, err error) {
+	m.record("done %s err=%v", agentID, err)
+}
+
+func (m *recordingMetrics) ToolCalled(agentID, furnitureName, tool string, _ time.Duration, err error) {
+	m.record("tool %s %s.%s err=%v", agentID, furnitureName, tool, err)
+}
+
+func TestMetrics(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:      "metrics",
+		Agents:    []blueprint.Agent{{ID: "@a", Type: "llm", Activation: "always", Furniture: []string{"calc"}}},
+		Furniture: []blueprint.FurnitureDef{{Name: "calc", Type: "calculator"}},
+	}
+	fake := llm.NewFakeClient(
+		llm.FakeReply{ToolCalls: []llm.ToolCall{
+			llm.FakeToolCall("c1", "calc__eval", `{"expression":"6*7"}`),
+			llm.FakeToolCall("c2", "calc__eval", `{"expression":"1/"}`),
+		}},
+		llm.FakeReply{Content: "It is 42."},
+		llm.FakeReply{Content: "[PASS]"},
+	)
+	ch := NewChannelFrontend(256, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+	co.SetLLMClient(fake)
+	m := &recordingMetrics{}
+	co.SetMetrics(m)
+	if err := co.Run("what is 6*7?"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(m.calls) != 4 {
+		t.Fatalf("expected 4 calls, got %q", m.calls)
+	}
+	if m.calls[0] != "start @a" || m.calls[1] != "tool @a calc.eval err=<nil>" || m.calls[3] != "done @a err=<nil>" {
+		t.Errorf("unexpected calls: %q", m.calls)
+	}
+	if m.calls[2] == "tool @a calc.eval err=<nil>" {
+		t.Errorf("expected the bad expression to report an error, got %q", m.calls[2])
+	}
+}
@@ -5,22 +5,57 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 var ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
+// escapeRe matches every ANSI control sequence, cursor movement and line
+// clearing included.
+var escapeRe = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
 // Output handles all floor output to terminal and optional log file.
 // All visible output should go through Print(). ANSI codes are
 // automatically stripped when writing to the log file.
 // Use Terminal() for ephemeral terminal-only output (spinners, line clearing).
+// Setting NO_COLOR (https://no-color.org) strips colors from the terminal
+// too, and when stdout isn't a terminal (piped or redirected) every escape
+// code is stripped, so `ofc run ... > out.txt` gives plain text. A color
+// mode other than ColorAuto overrides both (see SetColorMode).
 type Output struct {
 	debug   bool
+	noColor bool // strip ANSI colors from terminal output ($NO_COLOR)
+	piped   bool // stdout isn't a terminal: strip all escape codes
+	mode    ColorMode
+	stamps  bool             // show clock times on the terminal (the log always has them)
+	now     func() time.Time // nil = time.Now
 	logFile *os.File
+	muted   atomic.Bool // terminal output suppressed; the log still gets everything
+}
+
+// ColorMode says when terminal output is colored.
+type ColorMode string
+
+const (
+	ColorAuto   ColorMode = "auto"   // when stdout is a terminal and NO_COLOR is unset
+	ColorAlways ColorMode = "always" // even when piped
+	ColorNever  ColorMode = "never"
+)
+
+// ParseColorMode parses a --color value.
+func ParseColorMode(s string) (ColorMode, error) {
+	switch m := ColorMode(s); m {
+	case ColorAuto, ColorAlways, ColorNever:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown color mode %q (want auto, always or never)", s)
 }
 
 // NewOutput creates an Output. If logPath is non-empty, a log file is opened.
 func NewOutput(logPath string, debug bool) *Output {
-	o := &Output{debug: debug}
+	o := &Output{debug: debug, noColor: os.Getenv("NO_COLOR") != "", piped: !isTerminal(os.Stdout)}
 	if logPath != "" {
 		lf, err := os.Create(logPath)
 		if err != nil {
@@ -35,7 +70,9 @@ func NewOutput(logPath string, debug bool) *Output {
 // Print writes to both terminal (with ANSI) and log file (ANSI stripped).
 func (o *Output) Print(format string, args ...any) {
 	s := fmt.Sprintf(format, args...)
-	fmt.Print(s)
+	if !o.muted.Load() {
+		fmt.Print(o.terminalText(s))
+	}
 	o.writeLog(s)
 }
 
@@ -46,19 +83,113 @@ func (o *Output) Debug(format string, args ...any) {
 		return
 	}
 	msg := fmt.Sprintf(format, args...)
-	fmt.Printf("  %s[debug] %s%s\n", Gray, msg, Reset)
+	if !o.muted.Load() {
+		fmt.Print(o.terminalText(fmt.Sprintf("  %s[debug] %s%s\n", Gray, msg, Reset)))
+	}
 	o.writeLog(fmt.Sprintf("  [debug] %s\n", msg))
 }
 
 // Terminal writes only to the terminal. Use for ephemeral output
 // like "thinking..." spinners and \r\033[K line clearing.
 func (o *Output) Terminal(format string, args ...any) {
-	fmt.Printf(format, args...)
+	if o.muted.Load() {
+		return
+	}
+	fmt.Print(o.terminalText(fmt.Sprintf(format, args...)))
+}
+
+// terminalText strips ANSI colors from s under NO_COLOR, keeping cursor
+// codes such as line clearing, or every escape code and carriage return
+// when stdout is piped.
+func (o *Output) terminalText(s string) string {
+	switch {
+	case o.mode == ColorAlways:
+		return s
+	case o.piped:
+		return strings.ReplaceAll(escapeRe.ReplaceAllString(s, ""), "\r", "")
+	case o.noColor || o.mode == ColorNever:
+		return ansiRe.ReplaceAllString(s, "")
+	}
+	return s
+}
+
+// SetColorMode sets when terminal output is colored. The default is
+// ColorAuto.
+func (o *Output) SetColorMode(mode ColorMode) {
+	o.mode = mode
+}
+
+// Colors reports whether colors reach the terminal.
+func (o *Output) Colors() bool {
+	switch {
+	case o.mode == ColorAlways:
+		return true
+	case o.mode == ColorNever:
+		return false
+	}
+	return !o.piped && !o.noColor
+}
+
+// Interactive reports whether stdout is a terminal, where ephemeral output
+// like the "thinking..." line makes sense.
+func (o *Output) Interactive() bool {
+	return !o.piped
+}
+
+// isTerminal reports whether f is a terminal (a character device).
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Mute suppresses (or restores) terminal output. Print and Debug still
+// write to the log file while muted.
+func (o *Output) Mute(muted bool) {
+	o.muted.Store(muted)
 }
 
 // AgentLabel prints a colored agent label.
-func (o *Output) AgentLabel(id string, color string) {
-	o.Print("%s%s[%s]:%s ", Bold, color, id, Reset)
+func (o *Output) AgentLabel(label string, color string) {
+	o.PrintStamped("%s%s[%s]:%s ", Bold, color, label, Reset)
+}
+
+// SetTimestamps shows the clock time before agent labels and system
+// lines on the terminal. The log file always has them.
+func (o *Output) SetTimestamps(on bool) {
+	o.stamps = on
+}
+
+// PrintStamped is Print with the clock time in front: always in the log
+// file, and on the terminal with SetTimestamps.
+func (o *Output) PrintStamped(format string, args ...any) {
+	s := fmt.Sprintf(format, args...)
+	stamp := o.stamp()
+	if !o.muted.Load() {
+		if o.stamps {
+			fmt.Print(o.terminalText(Dim + stamp + Reset + s))
+		} else {
+			fmt.Print(o.terminalText(s))
+		}
+	}
+	o.writeLog(stamp + s)
+}
+
+// TerminalStamp returns the clock time prefix for terminal-only labels,
+// or "" without SetTimestamps.
+func (o *Output) TerminalStamp() string {
+	if !o.stamps {
+		return ""
+	}
+	return Dim + o.stamp() + Reset
+}
+
+// stamp returns the current clock time as a label prefix.
+func (o *Output) stamp() string {
+	now := time.Now
+	if o.now != nil {
+		now = o.now
+	}
+	return now().Format("15:04:05") + " "
 }
 
 // LogWriter returns an io.Writer for the log file, or nil if no log is open.
@@ -91,3 +222,81 @@ func (o *Output) writeLog(s string) {
 		fmt.Fprint(o.logFile, ansiRe.ReplaceAllString(s, ""))
 	}
 }
+
+// logEvent writes a plain-text account of an event to out's log file, for
+// frontends that don't render to the terminal through Output (TUI, JSON).
+func logEvent(out *Output, debug bool, ev Event) {
+	switch e := ev.(type) {
+	case SystemInfo:
+		out.Log("%s[System]: %s\n", out.stamp(), e.Text)
+	case TokenStreamed:
+		out.Log("%s", e.Token)
+	case AgentLabel:
+		out.Log("\n%s[%s]: ", out.stamp(), e.AgentID)
+	case ToolCallStarted:
+		out.Log("\n  > %s\n", e.Title)
+	case ToolCallProgress:
+		out.Log("  … %s\n", e.Message)
+	case UserInputRequested:
+		out.Log("\n  ? %s\n", e.Question)
+	case ToolCallResult:
+		if e.Output != "" {
+			out.Log("  %s\n", e.Output)
+		}
+	case PromptAgent:
+		if debug {
+			out.Log("  [debug] stack: %s\n", FormatStack(e.Stack))
+		}
+	case PromptAgents:
+		if debug {
+			out.Log("  [debug] concurrent: %s, stack: %s\n", strings.Join(e.AgentIDs, ", "), FormatStack(e.Stack))
+		}
+	case AgentDone:
+		out.Log("\n")
+	case AgentPassed:
+		out.Log("%s[%s]: [PASS]\n", out.stamp(), e.AgentID)
+	case AgentRouted:
+		out.Log("%s[%s]: → %s\n", out.stamp(), e.AgentID, e.Target)
+	case AgentError:
+		out.Log("[ERROR from %s: %v]\n", e.AgentID, e.Err)
+	case SessionSummary:
+		out.Log("%s\n", FormatSummary(e))
+	}
+}
+
+// FormatSummary renders a SessionSummary as plain text, one line for the
+// counts and one for the messages per sender.
+func FormatSummary(s SessionSummary) string {
+	parts := []string{
+		countOf(s.Turns, "turn"),
+		countOf(s.ToolCalls, "tool call"),
+	}
+	if s.TasksCreated > 0 {
+		parts = append(parts, fmt.Sprintf("%s (%d completed)", countOf(s.TasksCreated, "task"), s.TasksCompleted))
+	}
+	if s.Usage.TotalTokens > 0 {
+		tokens := countOf(s.Usage.TotalTokens, "token")
+		if cost := formatTotalCost(s.Agents); cost != "" {
+			tokens += " (" + cost + ")"
+		}
+		parts = append(parts, tokens)
+	}
+	text := "Session: " + strings.Join(parts, ", ")
+
+	if len(s.Messages) > 0 {
+		counts := make([]string, len(s.Messages))
+		for i, m := range s.Messages {
+			counts[i] = fmt.Sprintf("%s %d", m.AgentID, m.Count)
+		}
+		text += "\nMessages: " + strings.Join(counts, ", ")
+	}
+	return text
+}
+
+// countOf formats n with noun, pluralized with an "s".
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
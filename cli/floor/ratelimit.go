@@ -0,0 +1,110 @@
+package floor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/clock"
+)
+
+// RateLimiter throttles requests to one LLM endpoint: at most one request
+// start per interval (a token bucket of size one) and at most max requests
+// in flight. A nil *RateLimiter does not limit.
+type RateLimiter struct {
+	interval time.Duration
+	slots    chan struct{} // nil = unlimited concurrency
+	Clock    clock.Clock   // spaces requests; nil = clock.Real
+
+	mu   sync.Mutex
+	next time.Time // earliest start for the next request
+}
+
+// NewRateLimiter creates a limiter from a blueprint rate limit.
+func NewRateLimiter(rl blueprint.RateLimit) *RateLimiter {
+	l := &RateLimiter{interval: rl.MinInterval}
+	if rl.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, rl.MaxConcurrent)
+	}
+	return l
+}
+
+// Wait blocks until a request may start, or ctx is done. On success the
+// caller must call release when the request has finished.
+func (l *RateLimiter) Wait(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	release = func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if l.interval > 0 {
+		l.mu.Lock()
+		start := l.next
+		if now := l.clock().Now(); start.Before(now) {
+			start = now
+		}
+		l.next = start.Add(l.interval)
+		l.mu.Unlock()
+
+		if d := start.Sub(l.clock().Now()); d > 0 {
+			timer := l.clock().NewTimer(d)
+			defer timer.Stop()
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return release, nil
+}
+
+// clock returns the limiter's clock.
+func (l *RateLimiter) clock() clock.Clock {
+	if l.Clock == nil {
+		return clock.Real
+	}
+	return l.Clock
+}
+
+// newRateLimiters builds one limiter per rate-limited LLM endpoint, keyed
+// by endpointKey. Agents sharing an endpoint share its limiter; if they
+// configure different limits, the strictest of each setting applies.
+func newRateLimiters(bp *blueprint.Blueprint) map[string]*RateLimiter {
+	limits := make(map[string]blueprint.RateLimit)
+	for _, agent := range bp.Agents {
+		if !agent.UsesLLM() || agent.RateLimit == nil || *agent.RateLimit == (blueprint.RateLimit{}) {
+			continue
+		}
+		key := endpointKey(agent.Endpoint)
+		rl := limits[key]
+		rl.MinInterval = max(rl.MinInterval, agent.RateLimit.MinInterval)
+		if n := agent.RateLimit.MaxConcurrent; n > 0 && (rl.MaxConcurrent == 0 || n < rl.MaxConcurrent) {
+			rl.MaxConcurrent = n
+		}
+		limits[key] = rl
+	}
+
+	limiters := make(map[string]*RateLimiter, len(limits))
+	for key, rl := range limits {
+		limiters[key] = NewRateLimiter(rl)
+	}
+	return limiters
+}
+
+// endpointKey normalizes an endpoint URL for limiter lookup.
+func endpointKey(endpoint string) string {
+	return strings.TrimRight(endpoint, "/")
+}
@@ -0,0 +1,110 @@
+package floor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/clock"
+)
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	l := NewRateLimiter(blueprint.RateLimit{MinInterval: 30 * time.Second})
+	l.Clock = fake
+
+	wait := func() <-chan error {
+		errc := make(chan error, 1)
+		go func() {
+			release, err := l.Wait(context.Background())
+			if err == nil {
+				release()
+			}
+			errc <- err
+		}()
+		return errc
+	}
+	if err := <-wait(); err != nil {
+		t.Fatalf("the first request should start at once: %v", err)
+	}
+	for i := 2; i <= 3; i++ {
+		errc := wait()
+		fake.BlockUntil(1)
+		fake.Advance(29 * time.Second)
+		select {
+		case <-errc:
+			t.Fatalf("request %d started before the interval passed", i)
+		default:
+		}
+		fake.Advance(time.Second)
+		if err := <-errc; err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+}
+
+func TestRateLimiterMaxConcurrent(t *testing.T) {
+	l := NewRateLimiter(blueprint.RateLimit{MaxConcurrent: 1})
+
+	release, err := l.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("second request should wait for the first, got %v", err)
+	}
+
+	release()
+	release2, err := l.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("Wait after release: %v", err)
+	}
+	release2()
+}
+
+func TestNilRateLimiterDoesNotLimit(t *testing.T) {
+	var l *RateLimiter
+	release, err := l.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	release()
+}
+
+func TestRateLimitersShareEndpointStrictest(t *testing.T) {
+	bp := &blueprint.Blueprint{Agents: []blueprint.Agent{
+		{ID: "@a", Type: "llm", Endpoint: "http://ollama:11434/v1", RateLimit: &blueprint.RateLimit{MinInterval: time.Second, MaxConcurrent: 4}},
+		{ID: "@b", Type: "llm", Endpoint: "http://ollama:11434/v1/", RateLimit: &blueprint.RateLimit{MinInterval: time.Millisecond, MaxConcurrent: 2}},
+		{ID: "@c", Type: "llm", Endpoint: "http://other/v1"},
+	}}
+
+	limiters := newRateLimiters(bp)
+	if len(limiters) != 1 {
+		t.Fatalf("expected one limited endpoint, got %d", len(limiters))
+	}
+	l := limiters["http://ollama:11434/v1"]
+	if l == nil {
+		t.Fatal("no limiter for the shared endpoint")
+	}
+	if l.interval != time.Second || cap(l.slots) != 2 {
+		t.Errorf("expected the strictest limits (1s, 2), got (%v, %d)", l.interval, cap(l.slots))
+	}
+}
+
+func TestSetClockReachesRateLimiters(t *testing.T) {
+	bp := &blueprint.Blueprint{Name: "limited", Agents: []blueprint.Agent{
+		{ID: "@a", Type: "llm", Endpoint: "http://ollama:11434/v1", RateLimit: &blueprint.RateLimit{MinInterval: time.Second}},
+	}}
+	ch := NewChannelFrontend(1, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+	fake := clock.NewFake(time.Now())
+	co.SetClock(fake)
+	if l := co.limiters["http://ollama:11434/v1"]; l == nil || l.Clock != fake {
+		t.Errorf("the endpoint's limiter should use the floor's clock, got %+v", l)
+	}
+}
@@ -0,0 +1,167 @@
+package floor
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+// Redactor removes secrets from tool output before the floor shows,
+// stores, or logs it (see blueprint.Redact). A nil *Redactor changes
+// nothing.
+type Redactor struct {
+	rules  []redactRule
+	values []string // known secret values, longest first
+}
+
+type redactRule struct {
+	re   *regexp.Regexp
+	repl string // replacement template
+}
+
+// secretValue matches the value in a NAME=value line or a config entry.
+const secretValue = `(?:"[^"\n]+"|'[^'\n]+'|[^\s"',;]{4,})`
+
+// secretShapes match common credentials by their form. The last two keep
+// the name in NAME=value lines, such as env output, and config entries.
+var secretShapes = []redactRule{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), Redacted},
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`), Redacted},
+	{regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{30,})`), Redacted},
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), Redacted},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), Redacted},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`), Redacted},
+	{regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/-]{20,}=*`), "${1}" + Redacted},
+	{regexp.MustCompile(`\b([A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|ACCESS_KEY)[A-Z0-9_]*=)` + secretValue), "${1}" + Redacted},
+	{regexp.MustCompile(`(?i)\b([a-z0-9_]*(?:password|passwd|secret|api_?key|access_?token|auth_?token)["']?\s*[=:]\s*)` + secretValue), "${1}" + Redacted},
+}
+
+// minSecretLen is the shortest known secret value that is redacted;
+// shorter ones would redact ordinary text.
+const minSecretLen = 8
+
+// NewRedactor builds the redactor for a blueprint's redact settings. It
+// returns nil if there is nothing to redact.
+func NewRedactor(bp *blueprint.Blueprint) (*Redactor, error) {
+	cfg := bp.Redact
+	if cfg == nil || (!cfg.Secrets && len(cfg.Patterns) == 0) {
+		return nil, nil
+	}
+	r := &Redactor{}
+	for _, pattern := range cfg.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %q: %w", pattern, err)
+		}
+		r.rules = append(r.rules, redactRule{re, Redacted})
+	}
+	if cfg.Secrets {
+		r.rules = append(r.rules, secretShapes...)
+		r.values = secretValues(bp)
+	}
+	return r, nil
+}
+
+// secretValues returns the values the blueprint takes from the environment
+// for API keys and tokens, and for ACP agents' env.
+func secretValues(bp *blueprint.Blueprint) []string {
+	refs := []string{bp.APIToken}
+	for _, a := range bp.Agents {
+		refs = append(refs, a.APIKey)
+		for _, fb := range a.Fallbacks {
+			refs = append(refs, fb.APIKey)
+		}
+		for _, v := range a.Env {
+			refs = append(refs, v)
+		}
+	}
+	for _, fd := range bp.Furniture {
+		refs = append(refs, fd.Config["api_key"])
+	}
+
+	var values []string
+	for _, ref := range refs {
+		if !strings.Contains(ref, "$") {
+			continue
+		}
+		if v := os.ExpandEnv(ref); len(v) >= minSecretLen && !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	// Longest first, so a secret containing another is redacted whole.
+	slices.SortFunc(values, func(a, b string) int { return len(b) - len(a) })
+	return values
+}
+
+// Redact returns s with secrets replaced by [REDACTED].
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, Redacted)
+	}
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllString(s, rule.repl)
+	}
+	return s
+}
+
+// redactArgs copies tool call arguments with secrets redacted from their
+// string values, at any depth.
+func (r *Redactor) redactArgs(v any) any {
+	if r == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case string:
+		return r.Redact(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = r.redactArgs(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = r.redactArgs(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// wrap returns a sink that redacts tool titles and output on their way to
+// s, for runners that stream tool calls as they happen.
+func (r *Redactor) wrap(s StreamSink) StreamSink {
+	if r == nil {
+		return s
+	}
+	return redactingSink{s, r}
+}
+
+type redactingSink struct {
+	StreamSink
+	r *Redactor
+}
+
+func (s redactingSink) OnStream(ev Event) {
+	switch e := ev.(type) {
+	case ToolCallStarted:
+		e.Title = s.r.Redact(e.Title)
+		ev = e
+	case ToolCallProgress:
+		e.Title, e.Message = s.r.Redact(e.Title), s.r.Redact(e.Message)
+		ev = e
+	case ToolCallResult:
+		e.Title, e.Output = s.r.Redact(e.Title), s.r.Redact(e.Output)
+		ev = e
+	}
+	s.StreamSink.OnStream(ev)
+}
@@ -0,0 +1,89 @@
+package floor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// BuildRouterContext builds the LLM messages for a router agent's turn:
+// its usual context, with instructions to name the next responder appended
+// to the system prompt.
+func (c *Controller) BuildRouterContext(agent *blueprint.Agent) []llm.Message {
+	messages := c.BuildContext(agent)
+	messages[0].Content = strings.TrimSpace(messages[0].Content + "\n\n" + c.routerInstructions(agent))
+	return messages
+}
+
+// routerInstructions tells a router which agents it can choose from and
+// how to answer.
+func (c *Controller) routerInstructions(router *blueprint.Agent) string {
+	var sb strings.Builder
+	sb.WriteString("Decide who should respond to the conversation next. Do not answer it yourself. ")
+	sb.WriteString(`Reply with only a JSON object like {"agent": "@id"}, naming one of:` + "\n")
+	for _, a := range c.Blueprint.Agents {
+		if a.Type == "router" {
+			continue
+		}
+		fmt.Fprintf(&sb, "- %s", a.ID)
+		if a.Name != "" {
+			fmt.Fprintf(&sb, " (%s)", a.Name)
+		}
+		if line, _, _ := strings.Cut(strings.TrimSpace(a.Prompt), "\n"); line != "" {
+			fmt.Fprintf(&sb, ": %s", line)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(`- @user: if no agent should respond`)
+	return sb.String()
+}
+
+// Route runs a router agent's turn: one request, in JSON mode where the
+// endpoint has one, whose reply names the agent to respond next. Nothing is
+// streamed. The result is an AgentRouted, or an AgentError if the reply
+// names no one.
+func (r *LLMRunner) Route(ctx context.Context, agent *blueprint.Agent, messages []llm.Message) RunnerResult {
+	backends := r.backends(agent)
+	release, err := r.Limiter.Wait(ctx)
+	var result *llm.ChatResult
+	if err == nil {
+		result, err = r.chat(ctx, agent, &backends, messages, nil, func(string) {})
+		release()
+	}
+	if ctx.Err() != nil {
+		err = ErrTurnCancelled
+	}
+	if err != nil {
+		return RunnerResult{Event: AgentError{AgentID: agent.ID, Err: err}}
+	}
+
+	target, err := parseRoute(result.Content)
+	if err != nil {
+		return RunnerResult{Event: AgentError{AgentID: agent.ID, Err: err}}
+	}
+	return RunnerResult{Event: AgentRouted{AgentID: agent.ID, Target: target, Usage: result.Usage}}
+}
+
+// parseRoute extracts the agent ID from a router's reply, tolerating text
+// or a code fence around the JSON object and a missing "@".
+func parseRoute(content string) (string, error) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	var choice struct {
+		Agent string `json:"agent"`
+	}
+	if start < 0 || end < start || json.Unmarshal([]byte(content[start:end+1]), &choice) != nil {
+		return "", fmt.Errorf("router reply is not a JSON object: %q", content)
+	}
+	id := strings.TrimSpace(choice.Agent)
+	if id == "" {
+		return "", fmt.Errorf("router reply names no agent: %q", content)
+	}
+	if !strings.HasPrefix(id, "@") {
+		id = "@" + id
+	}
+	return id, nil
+}
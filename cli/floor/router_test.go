@@ -0,0 +1,129 @@
+package floor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+func routerBlueprint() *blueprint.Blueprint {
+	return &blueprint.Blueprint{
+		Name: "routed",
+		Agents: []blueprint.Agent{
+			{ID: "@router", Type: "router", Activation: "always"},
+			{ID: "@data", Name: "Data Analyst", Type: "llm", Activation: "mention", Prompt: "You analyze data.\nUse pandas."},
+			{ID: "@code", Type: "llm", Activation: "mention"},
+		},
+	}
+}
+
+func TestRouterDelegates(t *testing.T) {
+	ctrl := NewController(routerBlueprint())
+
+	events := ctrl.HandleEvent(UserMessage{Content: "plot the sales"})
+	if pa := requireEvent[PromptAgent](t, events, 0); pa.AgentID != "@router" {
+		t.Fatalf("expected the router to wake, got %s", pa.AgentID)
+	}
+
+	events = ctrl.HandleEvent(AgentRouted{AgentID: "@router", Target: "@data"})
+	if pa := requireEvent[PromptAgent](t, events, 0); pa.AgentID != "@data" {
+		t.Fatalf("expected @data to be prompted, got %s", pa.AgentID)
+	}
+	if len(ctrl.Messages) != 1 {
+		t.Errorf("the router should add no message, got %d", len(ctrl.Messages))
+	}
+	if len(ctrl.CallStack) != 1 || ctrl.CallStack[0] != (Frame{Caller: "@user", Callee: "@data"}) {
+		t.Errorf("expected a frame from @user to @data, got %v", ctrl.CallStack)
+	}
+
+	// @data's reply returns to the user, without waking the router again.
+	events = ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "Here is the plot."})
+	requireEvent[WaitingForUser](t, events, 0)
+}
+
+func TestRouterReplacesItsOwnFrame(t *testing.T) {
+	ctrl := NewController(routerBlueprint())
+	ctrl.HandleEvent(UserMessage{Content: "hi"})
+	ctrl.HandleEvent(AgentRouted{AgentID: "@router", Target: "@data"})
+	ctrl.HandleEvent(AgentDone{AgentID: "@data", Content: "@router? who should check this?"})
+
+	events := ctrl.HandleEvent(AgentRouted{AgentID: "@router", Target: "@code"})
+	if pa := requireEvent[PromptAgent](t, events, 0); pa.AgentID != "@code" {
+		t.Fatalf("expected @code, got %s", pa.AgentID)
+	}
+	want := []Frame{{Caller: "@user", Callee: "@data"}, {Caller: "@data", Callee: "@code"}}
+	if FormatStack(ctrl.CallStack) != FormatStack(want) {
+		t.Errorf("stack = %s, want %s", FormatStack(ctrl.CallStack), FormatStack(want))
+	}
+}
+
+func TestRouterInvalidChoicePasses(t *testing.T) {
+	ctrl := NewController(routerBlueprint())
+	ctrl.HandleEvent(UserMessage{Content: "hi"})
+
+	for _, target := range []string{"@nobody", "@router"} {
+		events := ctrl.HandleEvent(AgentRouted{AgentID: "@router", Target: target})
+		requireEvent[WaitingForUser](t, events, 0)
+	}
+	events := ctrl.HandleEvent(AgentRouted{AgentID: "@router", Target: "@user"})
+	requireEvent[WaitingForUser](t, events, 0)
+}
+
+func TestRouterSkippedByEveryone(t *testing.T) {
+	ctrl := NewController(routerBlueprint())
+	ctrl.HandleEvent(UserMessage{Content: "@everyone? status?"})
+	for _, f := range ctrl.CallStack {
+		if f.Callee == "@router" {
+			t.Fatalf("the router should not be part of a broadcast: %v", ctrl.CallStack)
+		}
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`{"agent": "@data"}`, "@data"},
+		{"```json\n{\"agent\": \"code\"}\n```", "@code"},
+		{`Sure: {"agent":"@user"}`, "@user"},
+		{`{"agent": ""}`, ""},
+		{`@data`, ""},
+	}
+	for _, tt := range tests {
+		got, err := parseRoute(tt.in)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("parseRoute(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestFakeLLMRouter(t *testing.T) {
+	fake := llm.NewFakeClient(
+		llm.FakeReply{Content: `{"agent": "@data"}`},
+		llm.FakeReply{Content: "Here is the plot."},
+	)
+
+	events := runFake(t, routerBlueprint(), fake, "plot the sales")
+
+	if got := streamed(events, "@router"); got != "" {
+		t.Errorf("the router should stream nothing, got %q", got)
+	}
+	if got := streamed(events, "@data"); got != "Here is the plot." {
+		t.Errorf("@data streamed %q", got)
+	}
+	reqs := fake.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected a routing and a reply request, got %d", len(reqs))
+	}
+	system := reqs[0].Messages[0].Content
+	for _, want := range []string{`{"agent": "@id"}`, "- @data (Data Analyst): You analyze data.\n", "- @code\n", "- @user"} {
+		if !strings.Contains(system, want) {
+			t.Errorf("router prompt lacks %q:\n%s", want, system)
+		}
+	}
+	if strings.Contains(system, "- @router") {
+		t.Errorf("the router should not be offered itself:\n%s", system)
+	}
+}
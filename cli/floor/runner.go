@@ -4,7 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	acpsdk "github.com/coder/acp-go-sdk"
 	acpclient "github.com/openfloorcontrol/ofc/acp"
@@ -24,26 +31,57 @@ type LLMRunner struct {
 	Sandbox   *sandbox.Sandbox
 	Stream    StreamSink
 	Furniture map[string]furniture.Furniture // accessible furniture, keyed by name
+	Pass      blueprint.PassConfig
+	Limiter   *RateLimiter     // throttles requests to the agent's endpoint; nil = no limit
+	Debug     io.Writer        // if set, raw LLM traffic is logged here (see llm.Client.Debug)
+	Client    llm.ChatStreamer // if set, used instead of a client for the agent's endpoint
+	HTTP      *http.Client     // HTTP client for the agent's endpoint; nil = llm default
+	Audit     *AuditLog        // if set, every tool call is recorded here
+	Seed      *int             // if set, overrides the agent's seed
+	AskUser   AskFunc          // answers ask_user; nil = no one to ask, so the tool isn't offered
+	Metrics   Metrics          // if set, every tool call is measured here
+	Confirm   []*regexp.Regexp // bash commands matching one need the user's approval (via AskUser)
+	Commands  *CommandPolicy   // bash commands agents may run; nil = any
+	Redactor  *Redactor        // removes secrets from tool output; nil = none
+
+	audited *[]AuditEntry // if set, audit entries collect here instead of in Audit
 }
 
+// AskFunc blocks until the user answers an agent's ask_user question.
+type AskFunc func() (string, error)
+
 // Run calls the LLM for an agent, handling tool calls.
 // Streams tokens and tool events via r.Stream. Blocks until complete.
 func (r *LLMRunner) Run(agent *blueprint.Agent, messages []llm.Message) RunnerResult {
-	client := llm.NewClient(agent.Endpoint, "")
+	return r.RunContext(context.Background(), agent, messages)
+}
 
+// RunContext is Run with a context. Cancelling it ends the turn with an
+// AgentError carrying ErrTurnCancelled.
+func (r *LLMRunner) RunContext(ctx context.Context, agent *blueprint.Agent, messages []llm.Message) RunnerResult {
+	backends := r.backends(agent)
 	tools := r.buildTools(agent)
 
 	var fullResponse strings.Builder
 	var interactions []ToolInteraction
+	var usage llm.Usage
 	maxIterations := 10
 
 	// Emit agent label before first token
 	r.Stream.OnStream(AgentLabel{AgentID: agent.ID})
 
 	for i := 0; i < maxIterations; i++ {
-		result, err := client.ChatStream(agent.Model, messages, agent.Temperature, tools, func(token string) {
-			r.Stream.OnStream(TokenStreamed{AgentID: agent.ID, Token: token})
-		})
+		release, err := r.Limiter.Wait(ctx)
+		var result *llm.ChatResult
+		if err == nil {
+			result, err = r.chat(ctx, agent, &backends, messages, tools, func(token string) {
+				r.Stream.OnStream(TokenStreamed{AgentID: agent.ID, Token: token})
+			})
+			release()
+		}
+		if ctx.Err() != nil {
+			err = ErrTurnCancelled
+		}
 		if err != nil {
 			return RunnerResult{Event: AgentError{
 				AgentID: agent.ID,
@@ -53,6 +91,7 @@ func (r *LLMRunner) Run(agent *blueprint.Agent, messages []llm.Message) RunnerRe
 		}
 
 		fullResponse.WriteString(result.Content)
+		usage = usage.Add(result.Usage)
 
 		// No tool calls — done
 		if len(result.ToolCalls) == 0 {
@@ -62,8 +101,6 @@ func (r *LLMRunner) Run(agent *blueprint.Agent, messages []llm.Message) RunnerRe
 		// Execute tool calls — expand concatenated calls into separate entries
 		expanded := r.expandToolCalls(agent.ID, result.ToolCalls)
 		for _, ex := range expanded {
-			r.Stream.OnStream(ToolCallResult{AgentID: agent.ID, Title: ex.Title, Output: ex.Output})
-
 			interactions = append(interactions, ToolInteraction{
 				Command: ex.Title,
 				Output:  ex.Output,
@@ -83,16 +120,89 @@ func (r *LLMRunner) Run(agent *blueprint.Agent, messages []llm.Message) RunnerRe
 
 	content := fullResponse.String()
 
-	// Check for [PASS]
-	if strings.Contains(strings.ToLower(content), "[pass]") {
-		return RunnerResult{Event: AgentPassed{AgentID: agent.ID}}
+	if isPass(content, r.Pass) {
+		return RunnerResult{Event: AgentPassed{AgentID: agent.ID, Usage: usage}}
 	}
 
+	content, handoff := extractHandoff(content)
 	return RunnerResult{Event: AgentDone{
 		AgentID:          agent.ID,
 		Content:          content,
 		ToolInteractions: interactions,
+		Handoff:          handoff,
+		Usage:            usage,
+	}}
+}
+
+// chat sends one request to the first of *backends. If that backend can't
+// serve it at all, the request falls back to the next, and the failed
+// backend is dropped for the rest of the turn; a reply cut off mid-stream
+// isn't retried.
+func (r *LLMRunner) chat(ctx context.Context, agent *blueprint.Agent, backends *[]llmBackend, messages []llm.Message, tools []llm.Tool, onToken func(string)) (*llm.ChatResult, error) {
+	for {
+		b := (*backends)[0]
+		streamed := false
+		result, err := b.client.ChatStreamContext(ctx, b.model, messages, agent.Temperature, tools, func(token string) {
+			streamed = true
+			onToken(token)
+		})
+		if err == nil || streamed || len(*backends) == 1 || ctx.Err() != nil || !llm.IsUnavailable(err) {
+			return result, err
+		}
+		*backends = (*backends)[1:]
+		r.Stream.OnStream(SystemInfo{Text: fmt.Sprintf("[%s: %s failed (%v); falling back to %s]", agent.ID, b.endpoint, err, (*backends)[0].endpoint)})
+	}
+}
+
+// llmBackend is an endpoint an LLM agent's requests can go to: its own,
+// or one of its fallbacks.
+type llmBackend struct {
+	client   llm.ChatStreamer
+	endpoint string
+	model    string
+}
+
+// backends returns the agent's endpoint followed by its fallbacks, in the
+// order a request tries them. r.Client, if set, replaces them all.
+func (r *LLMRunner) backends(agent *blueprint.Agent) []llmBackend {
+	if r.Client != nil {
+		return []llmBackend{{client: r.Client, endpoint: agent.Endpoint, model: agent.Model}}
+	}
+	backends := []llmBackend{{
+		client:   r.newLLMClient(agent, agent.Endpoint, agent.API, agent.APIKey),
+		endpoint: agent.Endpoint,
+		model:    agent.Model,
 	}}
+	for _, fb := range agent.Fallbacks {
+		backends = append(backends, llmBackend{
+			client:   r.newLLMClient(agent, fb.Endpoint, fb.API, fb.APIKey),
+			endpoint: fb.Endpoint,
+			model:    fb.Model,
+		})
+	}
+	return backends
+}
+
+// newLLMClient creates a client for one of an LLM agent's endpoints. The
+// API key is expanded from the environment; Anthropic endpoints without
+// one use $ANTHROPIC_API_KEY.
+func (r *LLMRunner) newLLMClient(agent *blueprint.Agent, endpoint, api, apiKey string) *llm.Client {
+	apiKey = os.ExpandEnv(apiKey)
+	if apiKey == "" && api == llm.APIAnthropic {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	client := llm.NewClient(endpoint, apiKey)
+	client.API = api
+	client.ReasoningEffort = agent.ReasoningEffort
+	client.Seed = agent.Seed
+	client.JSONMode = agent.Type == "router"
+	client.ReasoningModel = agent.ReasoningModel
+	if r.Seed != nil {
+		client.Seed = r.Seed
+	}
+	client.Debug = r.Debug
+	client.HTTPClient = r.HTTP
+	return client
 }
 
 // buildTools constructs the tool list for an LLM agent, including bash and furniture tools.
@@ -101,11 +211,17 @@ func (r *LLMRunner) buildTools(agent *blueprint.Agent) []llm.Tool {
 	if agent.CanUseTools && r.Sandbox != nil {
 		tools = append(tools, llm.BashTool)
 	}
-	for _, fname := range agent.Furniture {
+	if agent.CanAskUser && r.AskUser != nil {
+		tools = append(tools, llm.AskUserTool)
+	}
+	seen := make(map[string]bool)
+	for _, ref := range agent.Furniture {
+		fname, _ := blueprint.ParseFurnitureRef(ref)
 		f, ok := r.Furniture[fname]
-		if !ok {
+		if !ok || seen[fname] {
 			continue
 		}
+		seen[fname] = true
 		for _, t := range f.Tools() {
 			tools = append(tools, furnitureToolToLLM(fname, t))
 		}
@@ -122,12 +238,175 @@ type expandedCall struct {
 
 // expandToolCalls processes tool calls, splitting concatenated JSON arguments
 // into separate calls so the conversation history stays valid for the LLM API.
+// Each call's result is redacted and streamed as it finishes.
+//
+// Calls from one reply run concurrently, at most maxParallelTools at a time,
+// but their output reaches the stream as if they ran in order (see
+// toolOutput). Calls to the same furniture, or to furniture sharing a
+// SerialKey, run one after another. If any call needs the user (ask_user,
+// or a bash command that needs approval), the whole batch runs sequentially
+// instead, so questions are asked one at a time and in order.
 func (r *LLMRunner) expandToolCalls(agentID string, toolCalls []llm.ToolCall) []expandedCall {
-	var result []expandedCall
-	for _, tc := range toolCalls {
-		result = append(result, r.dispatchToolCall(agentID, tc)...)
+	results := make([][]expandedCall, len(toolCalls))
+	if len(toolCalls) < 2 || slices.ContainsFunc(toolCalls, r.needsUser) {
+		for i, tc := range toolCalls {
+			results[i] = r.dispatchToolCall(agentID, tc)
+		}
+		return slices.Concat(results...)
+	}
+
+	// Group the calls: one group per furniture (or SerialKey), one per
+	// bash command
+	var groups [][]int
+	byKey := map[string]int{}
+	for i, tc := range toolCalls {
+		key, ok := r.serialKey(tc)
+		if !ok {
+			groups = append(groups, []int{i})
+			continue
+		}
+		g, seen := byKey[key]
+		if !seen {
+			g = len(groups)
+			byKey[key] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
 	}
-	return result
+
+	// Audit entries are held back per call and recorded in call order
+	audited := make([][]AuditEntry, len(toolCalls))
+	out := newToolOutput(r.Stream, len(toolCalls))
+	sem := make(chan struct{}, maxParallelTools)
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, i := range group {
+				pr := *r
+				pr.Stream, pr.audited = out.sink(i), &audited[i]
+				sem <- struct{}{}
+				results[i] = pr.dispatchToolCall(agentID, toolCalls[i])
+				<-sem
+				out.finish(i)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, entries := range audited {
+		for _, e := range entries {
+			r.Audit.Record(e)
+		}
+	}
+	return slices.Concat(results...)
+}
+
+// maxParallelTools bounds how many tool calls from one reply run at once.
+const maxParallelTools = 4
+
+// serialKey returns the key grouping a furniture call with the calls it
+// must not overlap: the furniture's SerialKey, or else its name. Calls to
+// anything else can run alongside any other.
+func (r *LLMRunner) serialKey(tc llm.ToolCall) (string, bool) {
+	name, _, ok := strings.Cut(tc.Function.Name, "__")
+	if !ok {
+		return "", false
+	}
+	if sf, ok := r.Furniture[name].(furniture.SerialFurniture); ok {
+		if key := sf.SerialKey(); key != "" {
+			return key, true
+		}
+	}
+	return "furniture:" + name, true
+}
+
+// needsUser reports whether a tool call waits on the user: ask_user, or a
+// bash command that needs their approval.
+func (r *LLMRunner) needsUser(tc llm.ToolCall) bool {
+	switch tc.Function.Name {
+	case "ask_user":
+		return r.AskUser != nil
+	case "bash":
+		cmd := bashCommand(tc)
+		return slices.ContainsFunc(r.Confirm, func(re *regexp.Regexp) bool { return re.MatchString(cmd) })
+	}
+	return false
+}
+
+// bashCommand returns the command a bash tool call asks to run. Arguments
+// that aren't the expected JSON are taken as the command itself.
+func bashCommand(tc llm.ToolCall) string {
+	var args struct {
+		Cmd string `json:"cmd"`
+	}
+	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+		return tc.Function.Arguments
+	}
+	return args.Cmd
+}
+
+// toolOutput streams the events of concurrently running tool calls so that
+// they read as if the calls ran one after another: the first unfinished
+// call streams live, the others are held back and replayed once every
+// call before them has finished. Each call's started, progress and result
+// events thus stay together, in call order.
+type toolOutput struct {
+	mu     sync.Mutex
+	stream StreamSink
+	live   int       // index of the call streaming live
+	held   [][]Event // events held back per call
+	done   []bool
+}
+
+func newToolOutput(stream StreamSink, n int) *toolOutput {
+	return &toolOutput{stream: stream, held: make([][]Event, n), done: make([]bool, n)}
+}
+
+// toolSink is the StreamSink handed to the i-th call.
+type toolSink struct {
+	o *toolOutput
+	i int
+}
+
+func (s toolSink) OnStream(ev Event) { s.o.emit(s.i, ev) }
+
+func (o *toolOutput) sink(i int) StreamSink {
+	return toolSink{o: o, i: i}
+}
+
+func (o *toolOutput) emit(i int, ev Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if i == o.live {
+		o.stream.OnStream(ev)
+		return
+	}
+	o.held[i] = append(o.held[i], ev)
+}
+
+// finish marks call i done and lets the next unfinished call stream live,
+// after replaying what it has held back.
+func (o *toolOutput) finish(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.done[i] = true
+	for o.live < len(o.done) && o.done[o.live] {
+		o.live++
+		if o.live < len(o.held) {
+			for _, ev := range o.held[o.live] {
+				o.stream.OnStream(ev)
+			}
+			o.held[o.live] = nil
+		}
+	}
+}
+
+// finish redacts a finished call and streams its result.
+func (r *LLMRunner) finish(agentID string, ex expandedCall) []expandedCall {
+	ex.Title, ex.Output = r.Redactor.Redact(ex.Title), r.Redactor.Redact(ex.Output)
+	r.Stream.OnStream(ToolCallResult{AgentID: agentID, Title: ex.Title, Output: ex.Output})
+	return []expandedCall{ex}
 }
 
 // dispatchToolCall executes a tool call. Returns one or more expandedCalls
@@ -140,11 +419,12 @@ func (r *LLMRunner) dispatchToolCall(agentID string, tc llm.ToolCall) []expanded
 		furnitureName, toolName := parts[0], parts[1]
 		f, ok := r.Furniture[furnitureName]
 		if !ok {
-			return []expandedCall{{
+			r.audit(AuditEntry{AgentID: agentID, Tool: name, Error: fmt.Sprintf("unknown furniture %q", furnitureName)})
+			return r.finish(agentID, expandedCall{
 				Call:   tc,
 				Title:  name,
 				Output: fmt.Sprintf("[ERROR: unknown furniture %q]", furnitureName),
-			}}
+			})
 		}
 
 		title := fmt.Sprintf("%s.%s", furnitureName, toolName)
@@ -153,25 +433,34 @@ func (r *LLMRunner) dispatchToolCall(agentID string, tc llm.ToolCall) []expanded
 		// (e.g. {"title":"a"}{"title":"b"}). Use json.Decoder to split them.
 		argsList, err := parseJSONObjects(tc.Function.Arguments)
 		if err != nil {
-			return []expandedCall{{
+			r.audit(AuditEntry{AgentID: agentID, Tool: title, Error: fmt.Sprintf("invalid arguments: %v", err)})
+			return r.finish(agentID, expandedCall{
 				Call:   tc,
 				Title:  title,
 				Output: fmt.Sprintf("[ERROR: invalid arguments: %v]", err),
-			}}
+			})
 		}
 
 		var expanded []expandedCall
 		for i, args := range argsList {
 			r.Stream.OnStream(ToolCallStarted{AgentID: agentID, Title: title})
 
-			callResult, err := f.Call(toolName, args)
+			start := time.Now()
+			callResult, err := callFurniture(f, toolName, args, func(msg string) {
+				r.Stream.OnStream(ToolCallProgress{AgentID: agentID, Title: title, Message: msg})
+			})
+			r.toolCalled(agentID, furnitureName, toolName, start, err)
 			var output string
+			entry := AuditEntry{AgentID: agentID, Tool: title, Args: args}
 			if err != nil {
 				output = fmt.Sprintf("[ERROR: %v]", err)
+				entry.Error = err.Error()
 			} else {
 				data, _ := json.Marshal(callResult)
 				output = string(data)
+				entry.Result = output
 			}
+			r.audit(entry)
 
 			// Build a clean tool call with valid single-object arguments
 			argsJSON, _ := json.Marshal(args)
@@ -186,11 +475,11 @@ func (r *LLMRunner) dispatchToolCall(agentID string, tc llm.ToolCall) []expanded
 				call.ID = fmt.Sprintf("%s_%d", tc.ID, i)
 			}
 
-			expanded = append(expanded, expandedCall{
+			expanded = append(expanded, r.finish(agentID, expandedCall{
 				Call:   call,
 				Title:  title,
 				Output: output,
-			})
+			})...)
 		}
 		return expanded
 	}
@@ -198,26 +487,174 @@ func (r *LLMRunner) dispatchToolCall(agentID string, tc llm.ToolCall) []expanded
 	// Default: bash tool
 	if name == "bash" {
 		if r.Sandbox == nil {
-			return []expandedCall{{Call: tc, Title: "bash", Output: "[ERROR: no sandbox available]"}}
+			r.audit(AuditEntry{AgentID: agentID, Tool: "bash", Error: "no sandbox available"})
+			return r.finish(agentID, expandedCall{Call: tc, Title: "bash", Output: "[ERROR: no sandbox available]"})
 		}
 
-		var args struct {
-			Cmd string `json:"cmd"`
+		cmd := bashCommand(tc)
+
+		r.Stream.OnStream(ToolCallStarted{AgentID: agentID, Title: cmd})
+
+		start := time.Now()
+		err := r.Commands.Check(cmd)
+		if err == nil {
+			err = r.approve(agentID, cmd)
+		}
+		if err != nil {
+			r.toolCalled(agentID, "", "bash", start, err)
+			r.audit(AuditEntry{AgentID: agentID, Tool: "bash", Args: map[string]any{"cmd": cmd}, Error: err.Error()})
+			return r.finish(agentID, expandedCall{Call: tc, Title: cmd, Output: fmt.Sprintf("[NOT RUN: %v]", err)})
+		}
+		output, err := r.Sandbox.Execute(cmd)
+		r.toolCalled(agentID, "", "bash", start, err)
+		entry := AuditEntry{AgentID: agentID, Tool: "bash", Args: map[string]any{"cmd": cmd}, Result: output}
+		if err != nil {
+			entry.Error = err.Error()
 		}
-		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-			args.Cmd = tc.Function.Arguments
+		r.audit(entry)
+		if err != nil {
+			return r.finish(agentID, expandedCall{Call: tc, Title: cmd, Output: fmt.Sprintf("[ERROR: %v]", err)})
 		}
+		return r.finish(agentID, expandedCall{Call: tc, Title: cmd, Output: output})
+	}
 
-		r.Stream.OnStream(ToolCallStarted{AgentID: agentID, Title: args.Cmd})
+	if name == "ask_user" && r.AskUser != nil {
+		return r.finish(agentID, r.askUser(agentID, tc))
+	}
 
-		output, err := r.Sandbox.Execute(args.Cmd)
-		if err != nil {
-			return []expandedCall{{Call: tc, Title: args.Cmd, Output: fmt.Sprintf("[ERROR: %v]", err)}}
+	r.audit(AuditEntry{AgentID: agentID, Tool: name, Error: fmt.Sprintf("unknown tool %q", name)})
+	return r.finish(agentID, expandedCall{Call: tc, Title: name, Output: fmt.Sprintf("[ERROR: unknown tool %q]", name)})
+}
+
+// approve asks the user whether a bash command matching one of r.Confirm
+// may run, returning an error unless they answer yes. With no one to ask,
+// such commands are refused.
+func (r *LLMRunner) approve(agentID, cmd string) error {
+	i := slices.IndexFunc(r.Confirm, func(re *regexp.Regexp) bool { return re.MatchString(cmd) })
+	if i < 0 {
+		return nil
+	}
+	if r.AskUser == nil {
+		return fmt.Errorf("this command needs the user's approval (it matches %q), and no one is here to give it", r.Confirm[i])
+	}
+	r.Stream.OnStream(UserInputRequested{
+		AgentID:  agentID,
+		Question: fmt.Sprintf("Run `%s`? It matches %q. Answer yes to allow it.", cmd, r.Confirm[i]),
+	})
+	answer, err := r.AskUser()
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("the user did not approve this command; they said: %s", answer)
+}
+
+// askUser puts an ask_user question to the user and returns their answer
+// as the tool result.
+func (r *LLMRunner) askUser(agentID string, tc llm.ToolCall) expandedCall {
+	var args struct {
+		Question string `json:"question"`
+	}
+	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+		args.Question = tc.Function.Arguments
+	}
+	title := "ask_user: " + args.Question
+
+	r.Stream.OnStream(UserInputRequested{AgentID: agentID, Question: args.Question})
+
+	start := time.Now()
+	answer, err := r.AskUser()
+	r.toolCalled(agentID, "", "ask_user", start, err)
+	entry := AuditEntry{AgentID: agentID, Tool: "ask_user", Args: map[string]any{"question": args.Question}, Result: answer}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.audit(entry)
+	if err != nil {
+		return expandedCall{Call: tc, Title: title, Output: fmt.Sprintf("[ERROR: %v]", err)}
+	}
+	return expandedCall{Call: tc, Title: title, Output: answer}
+}
+
+// audit records a tool call in r.Audit, with secrets redacted from its
+// arguments and result.
+func (r *LLMRunner) audit(e AuditEntry) {
+	if e.Args != nil {
+		e.Args = r.Redactor.redactArgs(e.Args).(map[string]any)
+	}
+	e.Result = r.Redactor.Redact(e.Result)
+	e.Error = r.Redactor.Redact(e.Error)
+	if r.audited != nil {
+		e.Time = time.Now()
+		*r.audited = append(*r.audited, e)
+		return
+	}
+	r.Audit.Record(e)
+}
+
+// toolCalled reports a tool call that began at start to r.Metrics.
+func (r *LLMRunner) toolCalled(agentID, furnitureName, tool string, start time.Time, err error) {
+	if r.Metrics != nil {
+		r.Metrics.ToolCalled(agentID, furnitureName, tool, time.Since(start), err)
+	}
+}
+
+// callFurniture calls a furniture tool after checking args against the
+// tool's declared parameters, so the model gets a correctable "invalid
+// arguments" error instead of whatever the furniture makes of them.
+// Furniture that can report progress sends it to onProgress.
+func callFurniture(f furniture.Furniture, toolName string, args map[string]interface{}, onProgress func(string)) (interface{}, error) {
+	for _, t := range f.Tools() {
+		if t.Name == toolName {
+			if err := furniture.ValidateArgs(t.Parameters, args); err != nil {
+				return nil, err
+			}
+			break
 		}
-		return []expandedCall{{Call: tc, Title: args.Cmd, Output: output}}
 	}
+	if sf, ok := f.(furniture.StreamingFurniture); ok {
+		return sf.CallStream(toolName, args, onProgress)
+	}
+	return f.Call(toolName, args)
+}
 
-	return []expandedCall{{Call: tc, Title: name, Output: fmt.Sprintf("[ERROR: unknown tool %q]", name)}}
+// isPass reports whether content is a pass. By default the token must be the
+// whole trimmed message or sit on a line of its own, so agents can still talk
+// about "[PASS]" in prose. Match "contains" restores the legacy substring check.
+// Comparison is case-insensitive.
+func isPass(content string, cfg blueprint.PassConfig) bool {
+	token := strings.ToLower(strings.TrimSpace(cfg.Token))
+	if token == "" {
+		token = "[pass]"
+	}
+	content = strings.ToLower(content)
+
+	if cfg.Match == "contains" {
+		return strings.Contains(content, token)
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == token {
+			return true
+		}
+	}
+	return false
+}
+
+// handoffRe matches a trailing [[handoff:@id]] directive.
+var handoffRe = regexp.MustCompile(`\[\[handoff:\s*(@\w+)\s*\]\]\s*$`)
+
+// extractHandoff strips a trailing [[handoff:@id]] directive from content.
+// Returns the remaining content and the target agent ID ("" if none).
+func extractHandoff(content string) (string, string) {
+	m := handoffRe.FindStringSubmatchIndex(content)
+	if m == nil {
+		return content, ""
+	}
+	target := content[m[2]:m[3]]
+	return strings.TrimRight(content[:m[0]], " \t\n"), target
 }
 
 // furnitureToolToLLM converts a furniture tool to an LLM tool definition.
@@ -253,16 +690,24 @@ func parseJSONObjects(s string) ([]map[string]interface{}, error) {
 type ACPRunner struct {
 	Sessions map[string]*acpclient.AgentSession
 	Stream   StreamSink
+	Pass     blueprint.PassConfig
+	Redactor *Redactor // removes secrets from tool output; nil = none
 }
 
 // Run sends a prompt to an ACP agent and collects the response.
 // Streams tokens and tool events via r.Stream. Blocks until complete.
 func (r *ACPRunner) Run(agent *blueprint.Agent, blocks []acpsdk.ContentBlock) RunnerResult {
+	return r.RunContext(context.Background(), agent, blocks)
+}
+
+// RunContext is Run with a context. Cancelling it sends session/cancel to
+// the agent and ends the turn with an AgentError carrying ErrTurnCancelled.
+func (r *ACPRunner) RunContext(ctx context.Context, agent *blueprint.Agent, blocks []acpsdk.ContentBlock) RunnerResult {
 	session, ok := r.Sessions[agent.ID]
 	if !ok {
 		return RunnerResult{Event: AgentError{
 			AgentID: agent.ID,
-			Err:     fmt.Errorf("no ACP session for agent %s", agent.ID),
+			Err:     &ErrNoACPSession{AgentID: agent.ID},
 		}}
 	}
 
@@ -283,8 +728,14 @@ func (r *ACPRunner) Run(agent *blueprint.Agent, blocks []acpsdk.ContentBlock) Ru
 	// Emit agent label before first token
 	r.Stream.OnStream(AgentLabel{AgentID: agent.ID})
 
-	ctx := context.Background()
 	stopReason, err := session.Prompt(ctx, blocks)
+	if ctx.Err() != nil || stopReason == acpsdk.StopReasonCancelled {
+		return RunnerResult{Event: AgentError{
+			AgentID: agent.ID,
+			Err:     ErrTurnCancelled,
+			Partial: client.ResponseText.String(),
+		}}
+	}
 	if err != nil {
 		return RunnerResult{Event: AgentError{
 			AgentID: agent.ID,
@@ -293,27 +744,26 @@ func (r *ACPRunner) Run(agent *blueprint.Agent, blocks []acpsdk.ContentBlock) Ru
 		}}
 	}
 
-	_ = stopReason
-
 	// Convert ACP tool interactions to floor tool interactions
 	var interactions []ToolInteraction
 	for _, ti := range client.Interactions {
 		interactions = append(interactions, ToolInteraction{
-			Command: ti.Command,
-			Output:  ti.Output,
+			Command: r.Redactor.Redact(ti.Command),
+			Output:  r.Redactor.Redact(ti.Output),
 		})
 	}
 
 	content := client.ResponseText.String()
 
-	// Check for [PASS]
-	if strings.Contains(strings.ToLower(content), "[pass]") {
+	if isPass(content, r.Pass) {
 		return RunnerResult{Event: AgentPassed{AgentID: agent.ID}}
 	}
 
+	content, handoff := extractHandoff(content)
 	return RunnerResult{Event: AgentDone{
 		AgentID:          agent.ID,
 		Content:          content,
 		ToolInteractions: interactions,
+		Handoff:          handoff,
 	}}
 }
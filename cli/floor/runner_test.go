@@ -0,0 +1,385 @@
+package floor
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/furniture"
+	"github.com/openfloorcontrol/ofc/llm"
+	"github.com/openfloorcontrol/ofc/sandbox"
+)
+
+func TestIsPass(t *testing.T) {
+	line := blueprint.PassConfig{Token: "[PASS]", Match: "line"}
+	tests := []struct {
+		name    string
+		content string
+		cfg     blueprint.PassConfig
+		want    bool
+	}{
+		{"exact", "[PASS]", line, true},
+		{"trimmed lowercase", "  [pass]\n", line, true},
+		{"own line", "Nothing to add.\n[PASS]", line, true},
+		{"in prose", "Agents reply [PASS] when idle.", line, false},
+		{"default token", "[PASS]", blueprint.PassConfig{}, true},
+		{"custom token", "<skip>", blueprint.PassConfig{Token: "<skip>"}, true},
+		{"custom token ignores default", "[PASS]", blueprint.PassConfig{Token: "<skip>"}, false},
+		{"legacy contains", "Agents reply [PASS] when idle.", blueprint.PassConfig{Token: "[PASS]", Match: "contains"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPass(tt.content, tt.cfg); got != tt.want {
+				t.Errorf("isPass(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractHandoff(t *testing.T) {
+	content, target := extractHandoff("Schema is ready.\n[[handoff:@code]]\n")
+	if content != "Schema is ready." || target != "@code" {
+		t.Errorf("got (%q, %q)", content, target)
+	}
+
+	content, target = extractHandoff("Use [[handoff:@code]] to hand off, then keep talking.")
+	if target != "" || content != "Use [[handoff:@code]] to hand off, then keep talking." {
+		t.Errorf("non-trailing directive should be ignored, got (%q, %q)", content, target)
+	}
+}
+
+// progressFurniture has one tool, which reports progress when it can.
+type progressFurniture struct{}
+
+func (progressFurniture) Name() string { return "slow" }
+func (progressFurniture) Tools() []furniture.Tool {
+	return []furniture.Tool{{Name: "work", Description: "Takes a while."}}
+}
+func (progressFurniture) Call(string, map[string]interface{}) (interface{}, error) {
+	return "plain", nil
+}
+func (progressFurniture) CallStream(_ string, _ map[string]interface{}, onProgress func(string)) (interface{}, error) {
+	onProgress("halfway")
+	onProgress("almost")
+	return "streamed", nil
+}
+
+// streamFunc is a StreamSink that calls itself.
+type streamFunc func(Event)
+
+func (f streamFunc) OnStream(ev Event) { f(ev) }
+
+func TestLLMRunnerForwardsToolProgress(t *testing.T) {
+	var progress []string
+	var output string
+	runner := &LLMRunner{
+		Stream: streamFunc(func(ev Event) {
+			switch e := ev.(type) {
+			case ToolCallProgress:
+				progress = append(progress, e.Title+": "+e.Message)
+			case ToolCallResult:
+				output = e.Output
+			}
+		}),
+		Furniture: map[string]furniture.Furniture{"slow": progressFurniture{}},
+		Client: llm.NewFakeClient(
+			llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c1", "slow__work", `{}`)}},
+			llm.FakeReply{Content: "Done."},
+		),
+	}
+
+	runner.Run(&blueprint.Agent{ID: "@a", Furniture: []string{"slow"}}, nil)
+
+	if got := strings.Join(progress, ", "); got != "slow.work: halfway, slow.work: almost" {
+		t.Errorf("progress = %q", got)
+	}
+	if output != `"streamed"` {
+		t.Errorf("expected the CallStream result, got %q", output)
+	}
+}
+
+// rendezvousFurniture's tool returns only once another call to some
+// rendezvousFurniture is in progress, or fails after a while.
+type rendezvousFurniture struct {
+	name     string
+	key      string        // SerialKey
+	patience time.Duration // how long to wait; default 2s
+	arrived  chan struct{} // shared; each call sends once and receives once
+}
+
+func (f rendezvousFurniture) Name() string      { return f.name }
+func (f rendezvousFurniture) SerialKey() string { return f.key }
+func (f rendezvousFurniture) Tools() []furniture.Tool {
+	return []furniture.Tool{{Name: "meet", Description: "Waits for company."}}
+}
+func (f rendezvousFurniture) Call(string, map[string]interface{}) (interface{}, error) {
+	select {
+	case f.arrived <- struct{}{}:
+	case <-f.arrived:
+		return f.name + " met", nil
+	case <-time.After(cmp.Or(f.patience, 2*time.Second)):
+		return nil, errors.New("ran alone")
+	}
+	return f.name + " met", nil
+}
+
+func TestLLMRunnerRunsToolCallsConcurrently(t *testing.T) {
+	arrived := make(chan struct{})
+	client := llm.NewFakeClient(
+		llm.FakeReply{ToolCalls: []llm.ToolCall{
+			llm.FakeToolCall("c1", "left__meet", `{}`),
+			llm.FakeToolCall("c2", "right__meet", `{}`),
+		}},
+		llm.FakeReply{Content: "Done."},
+	)
+	var outputs []string
+	runner := &LLMRunner{
+		Stream: streamFunc(func(ev Event) {
+			if e, ok := ev.(ToolCallResult); ok {
+				outputs = append(outputs, e.Output)
+			}
+		}),
+		Furniture: map[string]furniture.Furniture{
+			"left":  rendezvousFurniture{name: "left", arrived: arrived},
+			"right": rendezvousFurniture{name: "right", arrived: arrived},
+		},
+		Client: client,
+	}
+
+	runner.Run(&blueprint.Agent{ID: "@a", Furniture: []string{"left", "right"}}, nil)
+
+	if got := strings.Join(outputs, ", "); got != `"left met", "right met"` {
+		t.Errorf("outputs = %s, want both calls to meet, in call order", got)
+	}
+	var ids []string
+	for _, m := range client.Requests()[1].Messages {
+		if m.Role == "tool" {
+			ids = append(ids, m.ToolCallID)
+		}
+	}
+	if got := strings.Join(ids, ","); got != "c1,c2" {
+		t.Errorf("tool results in history = %s, want c1,c2", got)
+	}
+}
+
+func TestLLMRunnerSerializesFurnitureSharingAKey(t *testing.T) {
+	arrived := make(chan struct{})
+	var outputs []string
+	runner := &LLMRunner{
+		Stream: streamFunc(func(ev Event) {
+			if e, ok := ev.(ToolCallResult); ok {
+				outputs = append(outputs, e.Output)
+			}
+		}),
+		Furniture: map[string]furniture.Furniture{
+			"git":   rendezvousFurniture{name: "git", key: "git:/workspace", patience: 50 * time.Millisecond, arrived: arrived},
+			"patch": rendezvousFurniture{name: "patch", key: "git:/workspace", patience: 50 * time.Millisecond, arrived: arrived},
+		},
+		Client: llm.NewFakeClient(
+			llm.FakeReply{ToolCalls: []llm.ToolCall{
+				llm.FakeToolCall("c1", "git__meet", `{}`),
+				llm.FakeToolCall("c2", "patch__meet", `{}`),
+			}},
+			llm.FakeReply{Content: "Done."},
+		),
+	}
+
+	runner.Run(&blueprint.Agent{ID: "@a", Furniture: []string{"git", "patch"}}, nil)
+
+	for _, out := range outputs {
+		if !strings.Contains(out, "ran alone") {
+			t.Errorf("outputs = %q, want each call to run alone", outputs)
+			break
+		}
+	}
+}
+
+// relayFurniture's tool waits for wait to close, if set, then closes done,
+// if set.
+type relayFurniture struct {
+	name       string
+	wait, done chan struct{}
+}
+
+func (f relayFurniture) Name() string { return f.name }
+func (f relayFurniture) Tools() []furniture.Tool {
+	return []furniture.Tool{{Name: "relay", Description: "Passes the baton."}}
+}
+func (f relayFurniture) CallStream(_ string, _ map[string]interface{}, onProgress func(string)) (interface{}, error) {
+	if f.wait != nil {
+		select {
+		case <-f.wait:
+		case <-time.After(2 * time.Second):
+			return nil, errors.New("never got the baton")
+		}
+	}
+	onProgress("running")
+	if f.done != nil {
+		close(f.done)
+	}
+	return f.name + " relayed", nil
+}
+func (f relayFurniture) Call(tool string, args map[string]interface{}) (interface{}, error) {
+	return f.CallStream(tool, args, func(string) {})
+}
+
+func TestLLMRunnerStreamsConcurrentToolCallsInOrder(t *testing.T) {
+	// The second call finishes first; its events still follow the first's.
+	baton := make(chan struct{})
+	var events []string
+	runner := &LLMRunner{
+		Stream: streamFunc(func(ev Event) {
+			switch e := ev.(type) {
+			case ToolCallStarted:
+				events = append(events, "started "+e.Title)
+			case ToolCallProgress:
+				events = append(events, e.Title+": "+e.Message)
+			case ToolCallResult:
+				events = append(events, e.Title+" = "+e.Output)
+			}
+		}),
+		Furniture: map[string]furniture.Furniture{
+			"first":  relayFurniture{name: "first", wait: baton},
+			"second": relayFurniture{name: "second", done: baton},
+		},
+		Client: llm.NewFakeClient(
+			llm.FakeReply{ToolCalls: []llm.ToolCall{
+				llm.FakeToolCall("c1", "first__relay", `{}`),
+				llm.FakeToolCall("c2", "second__relay", `{}`),
+			}},
+			llm.FakeReply{Content: "Done."},
+		),
+	}
+
+	runner.Run(&blueprint.Agent{ID: "@a", Furniture: []string{"first", "second"}}, nil)
+
+	want := []string{
+		"started first.relay", "first.relay: running", `first.relay = "first relayed"`,
+		"started second.relay", "second.relay: running", `second.relay = "second relayed"`,
+	}
+	if got := strings.Join(events, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("events:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestLLMRunnerConfirmsDangerousCommands(t *testing.T) {
+	var questions, outputs []string
+	answers := []string{"no, stash them instead", "yes"}
+	runner := &LLMRunner{
+		// Not started: a command that gets past approval fails with ErrNotStarted.
+		Sandbox: &sandbox.Sandbox{},
+		Stream: streamFunc(func(ev Event) {
+			switch e := ev.(type) {
+			case UserInputRequested:
+				questions = append(questions, e.Question)
+			case ToolCallResult:
+				outputs = append(outputs, e.Output)
+			}
+		}),
+		Confirm: []*regexp.Regexp{regexp.MustCompile(`git\s+reset\s+--hard`)},
+		AskUser: func() (string, error) {
+			answer := answers[0]
+			answers = answers[1:]
+			return answer, nil
+		},
+		Client: llm.NewFakeClient(
+			llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c1", "bash", `{"cmd":"git reset --hard"}`)}},
+			llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c2", "bash", `{"cmd":"git reset --hard"}`)}},
+			llm.FakeReply{ToolCalls: []llm.ToolCall{llm.FakeToolCall("c3", "bash", `{"cmd":"ls"}`)}},
+			llm.FakeReply{Content: "Done."},
+		),
+	}
+
+	runner.Run(&blueprint.Agent{ID: "@a", CanUseTools: true}, nil)
+
+	if len(questions) != 2 || !strings.Contains(questions[0], "git reset --hard") {
+		t.Errorf("expected two approval questions, got %q", questions)
+	}
+	notStarted := "[ERROR: " + sandbox.ErrNotStarted.Error() + "]"
+	want := []string{"[NOT RUN: the user did not approve this command; they said: no, stash them instead]", notStarted, notStarted}
+	if strings.Join(outputs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("outputs:\n got %q\nwant %q", outputs, want)
+	}
+
+	// With no one to ask, matching commands are refused.
+	runner.AskUser = nil
+	if err := runner.approve("@a", "git reset --hard HEAD~1"); err == nil {
+		t.Error("expected a refusal with no one to ask")
+	}
+}
+
+func TestLLMRunnerFallsBack(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	var model string
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Model string }
+		json.NewDecoder(r.Body).Decode(&req)
+		model = req.Model
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Backup here.\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer up.Close()
+
+	var notes []string
+	runner := &LLMRunner{Stream: streamFunc(func(ev Event) {
+		if e, ok := ev.(SystemInfo); ok {
+			notes = append(notes, e.Text)
+		}
+	})}
+	agent := &blueprint.Agent{
+		ID:        "@a",
+		Endpoint:  down.URL,
+		Model:     "primary",
+		Fallbacks: []blueprint.Fallback{{Endpoint: up.URL, Model: "backup"}},
+	}
+
+	result := runner.Run(agent, []llm.Message{{Role: "user", Content: "hi"}})
+
+	done, ok := result.Event.(AgentDone)
+	if !ok || done.Content != "Backup here." {
+		t.Fatalf("expected the fallback's reply, got %+v", result.Event)
+	}
+	if model != "backup" {
+		t.Errorf("fallback was asked for model %q", model)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "falling back to "+up.URL) {
+		t.Errorf("expected a note about the switch, got %q", notes)
+	}
+}
+
+func TestLLMRunnerDoesNotFallBackOnClientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	runner := &LLMRunner{Stream: streamFunc(func(Event) {})}
+	agent := &blueprint.Agent{
+		ID:        "@a",
+		Endpoint:  srv.URL,
+		Fallbacks: []blueprint.Fallback{{Endpoint: srv.URL}},
+	}
+
+	result := runner.Run(agent, nil)
+
+	var apiErr *llm.APIError
+	if e, ok := result.Event.(AgentError); !ok || !errors.As(e.Err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the 401 as an AgentError, got %+v", result.Event)
+	}
+	if calls != 1 {
+		t.Errorf("a 401 should not fall back, got %d requests", calls)
+	}
+}
@@ -0,0 +1,200 @@
+package floor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// subFloor backs a floor agent: a whole floor, from the agent's blueprint,
+// run by its own coordinator with its own sandbox, furniture, and
+// conversation. Each turn of the agent is one user turn on the sub-floor,
+// whose last reply is the agent's answer.
+type subFloor struct {
+	agentID string
+	co      *Coordinator
+	sink    *subFloorSink
+}
+
+// startSubFloors starts the sub-floor of every floor agent. A blueprint
+// already in co.floorChain is refused, so a sub-floor can't contain itself.
+func (co *Coordinator) startSubFloors(ctx context.Context) error {
+	for _, agent := range co.bp.Agents {
+		if agent.Type != "floor" {
+			continue
+		}
+		path, err := filepath.Abs(agent.Blueprint)
+		if err != nil {
+			return &ConfigError{Msg: fmt.Sprintf("floor agent %s: %v", agent.ID, err)}
+		}
+		if slices.Contains(co.floorChain, path) {
+			return &ConfigError{Msg: fmt.Sprintf("floor agent %s: %s is already an enclosing floor", agent.ID, agent.Blueprint)}
+		}
+		bp, err := blueprint.Load(path)
+		if err == nil {
+			err = bp.Validate()
+		}
+		if err != nil {
+			return &ConfigError{Msg: fmt.Sprintf("floor agent %s: %v", agent.ID, err)}
+		}
+
+		co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Starting sub-floor %s (%s)...", agent.ID, bp.Name)})
+		sink := &subFloorSink{agentID: agent.ID, frontend: co.frontend, logWriter: co.logWriter}
+		sub := NewCoordinatorWith(bp, sink, sink, co.debugFn, co.logWriter, co.stderrWriter)
+		sub.floorChain = append(slices.Clone(co.floorChain), path)
+		sub.llmClient = co.llmClient
+		sub.clock = co.clock
+		sub.ctrl.Now = co.clock.Now
+		sub.metrics = co.metrics
+		sub.seed = co.seed
+		if err := sub.StartContext(ctx); err != nil {
+			sub.Stop()
+			return fmt.Errorf("sub-floor %s: %w", agent.ID, err)
+		}
+		co.subFloors[agent.ID] = &subFloor{agentID: agent.ID, co: sub, sink: sink}
+		co.frontend.Render(SystemInfo{Text: fmt.Sprintf("Sub-floor %s ready", agent.ID)})
+	}
+	return nil
+}
+
+// SubFloorPrompt returns what a floor agent puts to its sub-floor: the
+// latest message it can see. The sub-floor keeps its own conversation, so
+// earlier messages it has already answered aren't repeated.
+func (c *Controller) SubFloorPrompt(agent *blueprint.Agent) string {
+	visible := c.visibleMessages(agent)
+	if len(visible) == 0 {
+		return ""
+	}
+	return visible[len(visible)-1].Content
+}
+
+// run takes one turn of the floor agent: prompt is put to the sub-floor as
+// a user message. Replies before the last are shown on stream as the
+// agent's progress and kept as its tool interactions; the last is its
+// answer. If every sub-floor agent passes, so does the floor agent.
+func (sf *subFloor) run(ctx context.Context, prompt string, stream StreamSink) RunnerResult {
+	stream.OnStream(AgentLabel{AgentID: sf.agentID})
+	sf.sink.begin(stream)
+	sf.co.runTurn(ctx, UserMessage{Content: prompt})
+	replies, usage := sf.sink.end()
+
+	if ctx.Err() != nil {
+		return RunnerResult{Event: AgentError{AgentID: sf.agentID, Err: ErrTurnCancelled}}
+	}
+	if e := sf.co.lastErr; e != nil {
+		return RunnerResult{Event: AgentError{AgentID: sf.agentID, Err: &AgentFailedError{AgentID: e.AgentID, Err: e.Err}}}
+	}
+	if len(replies) == 0 {
+		return RunnerResult{Event: AgentPassed{AgentID: sf.agentID, Usage: usage}}
+	}
+
+	last := replies[len(replies)-1]
+	stream.OnStream(TokenStreamed{AgentID: sf.agentID, Token: last.Output})
+	return RunnerResult{Event: AgentDone{
+		AgentID:          sf.agentID,
+		Content:          last.Output,
+		ToolInteractions: replies[:len(replies)-1],
+		Usage:            usage,
+	}}
+}
+
+// subFloorSink is a sub-floor's frontend and stream sink. During a turn it
+// relays the sub-floor's activity to the parent floor's stream as the
+// floor agent's progress; outside one (while starting), system lines go to
+// the parent's frontend. It reads no input: a sub-floor only answers its
+// floor agent.
+type subFloorSink struct {
+	agentID   string
+	frontend  Frontend
+	logWriter io.Writer
+
+	mu      sync.Mutex
+	stream  StreamSink        // the parent turn's stream; nil between turns
+	replies []ToolInteraction // sub-floor replies this turn, by agent
+	pending bool              // the last reply is not yet shown
+	usage   llm.Usage
+}
+
+// begin starts relaying a turn to stream.
+func (s *subFloorSink) begin(stream StreamSink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stream = stream
+	s.replies = nil
+	s.pending = false
+	s.usage = llm.Usage{}
+}
+
+// end stops relaying and returns the turn's replies and token usage. The
+// last reply is left for the caller to show as the answer.
+func (s *subFloorSink) end() ([]ToolInteraction, llm.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stream = nil
+	return s.replies, s.usage
+}
+
+// showPending shows the previous reply as progress, now that it is known
+// not to be the last.
+func (s *subFloorSink) showPending() {
+	if !s.pending {
+		return
+	}
+	last := s.replies[len(s.replies)-1]
+	s.stream.OnStream(ToolCallResult{AgentID: s.agentID, Title: last.Command, Output: last.Output})
+	s.pending = false
+}
+
+func (s *subFloorSink) Render(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream == nil {
+		if e, ok := ev.(SystemInfo); ok {
+			s.frontend.Render(SystemInfo{Text: fmt.Sprintf("[%s] %s", s.agentID, e.Text)})
+		}
+		return
+	}
+	switch e := ev.(type) {
+	case AgentDone:
+		s.showPending()
+		s.replies = append(s.replies, ToolInteraction{Command: e.AgentID, Output: e.Content})
+		s.pending = true
+		s.usage = s.usage.Add(e.Usage)
+	case AgentPassed:
+		s.usage = s.usage.Add(e.Usage)
+	case AgentRouted:
+		s.usage = s.usage.Add(e.Usage)
+	case AgentError:
+		s.showPending() // the error itself follows as a SystemInfo
+	case SystemInfo:
+		s.stream.OnStream(ToolCallProgress{AgentID: s.agentID, Title: s.agentID, Message: e.Text})
+	}
+}
+
+func (s *subFloorSink) OnStream(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream == nil {
+		return
+	}
+	switch e := ev.(type) {
+	case AgentLabel:
+		s.showPending()
+		s.stream.OnStream(ToolCallStarted{AgentID: s.agentID, Title: e.AgentID})
+	case ToolCallStarted:
+		s.stream.OnStream(ToolCallProgress{AgentID: s.agentID, Title: e.AgentID, Message: e.Title})
+	}
+}
+
+// ReadInput always returns io.EOF: nobody types into a sub-floor.
+func (s *subFloorSink) ReadInput() (Event, error) { return nil, io.EOF }
+
+func (s *subFloorSink) LogWriter() io.Writer { return s.logWriter }
+
+func (s *subFloorSink) Close() {}
@@ -0,0 +1,98 @@
+package floor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// writeSubFloor writes a two-agent sub-floor blueprint and returns its path.
+func writeSubFloor(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "team.yaml")
+	err := os.WriteFile(path, []byte(`
+name: team
+defaults:
+  endpoint: http://localhost:1/v1
+  model: m
+agents:
+  - id: "@lead"
+    activation: always
+  - id: "@check"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSubFloor(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name:   "parent",
+		Agents: []blueprint.Agent{{ID: "@team", Type: "floor", Activation: "always", Blueprint: writeSubFloor(t)}},
+	}
+	fake := llm.NewFakeClient(
+		llm.FakeReply{Content: "@check? does this hold?"},
+		llm.FakeReply{Content: "It holds."},
+		llm.FakeReply{Content: "All good."},
+	)
+
+	events := runFake(t, bp, fake, "review the plan")
+
+	if got := streamed(events, "@team"); got != "All good." {
+		t.Errorf("@team answered %q", got)
+	}
+	var done *AgentDone
+	var started []string
+	for _, ev := range events {
+		switch e := ev.(type) {
+		case AgentDone:
+			done = &e
+		case ToolCallStarted:
+			started = append(started, e.Title)
+		}
+	}
+	if done == nil || done.AgentID != "@team" || done.Content != "All good." {
+		t.Fatalf("expected @team's AgentDone with the last reply, got %+v", done)
+	}
+	if len(done.ToolInteractions) != 2 || done.ToolInteractions[1] != (ToolInteraction{Command: "@check", Output: "It holds."}) {
+		t.Errorf("expected the earlier replies as tool interactions, got %+v", done.ToolInteractions)
+	}
+	if got := strings.Join(started, ","); got != "@lead,@check,@lead" {
+		t.Errorf("expected each sub-floor turn shown, got %q", got)
+	}
+
+	reqs := fake.Requests()
+	if last := reqs[0].Messages[len(reqs[0].Messages)-1]; last.Content != "review the plan" {
+		t.Errorf("expected the prompt as the sub-floor's user message, got %+v", last)
+	}
+}
+
+func TestSubFloorCycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loop.yaml")
+	err := os.WriteFile(path, []byte(`
+name: loop
+agents:
+  - id: "@self"
+    type: floor
+    blueprint: loop.yaml
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bp, err := blueprint.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := NewChannelFrontend(256, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+
+	err = co.Run("hi")
+	if err == nil || !strings.Contains(err.Error(), "already an enclosing floor") {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+}
@@ -1,19 +1,29 @@
 package floor
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"os/exec"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const (
-	textareaHeight = 3
+	textareaHeight  = 3
 	separatorHeight = 1
+	headerHeight    = 1
 )
 
 // --- TUIFrontend: implements Frontend + StreamSink ---
@@ -29,8 +39,9 @@ type TUIFrontend struct {
 }
 
 // NewTUIFrontend creates a TUI frontend and its Bubble Tea model.
+// agents lists the agent IDs in blueprint order, for the header filter.
 // Call SetProgram() after creating the tea.Program.
-func NewTUIFrontend(logPath string, debug bool, colorMap map[string]string) (*TUIFrontend, *tuiModel) {
+func NewTUIFrontend(logPath string, debug bool, colorMap map[string]string, agents []string) (*TUIFrontend, *tuiModel) {
 	inputCh := make(chan Event, 1)
 
 	frontend := &TUIFrontend{
@@ -43,6 +54,9 @@ func NewTUIFrontend(logPath string, debug bool, colorMap map[string]string) (*TU
 	model := &tuiModel{
 		inputCh:  inputCh,
 		colorMap: colorMap,
+		markdown: true,
+		agents:   agents,
+		spinner:  spinner.New(spinner.WithSpinner(spinner.MiniDot)),
 	}
 
 	return frontend, model
@@ -78,6 +92,25 @@ func (t *TUIFrontend) ReadInput() (Event, error) {
 	return ev, nil
 }
 
+// EditText suspends the TUI and opens text in the user's editor, for /edit.
+func (t *TUIFrontend) EditText(text string) (string, error) {
+	if t.program == nil {
+		return "", errors.New("no terminal")
+	}
+	return editText(text, func(cmd *exec.Cmd) error {
+		done := make(chan error, 1)
+		t.program.Send(tuiExec{cmd: cmd, done: done})
+		return <-done
+	})
+}
+
+// tuiExec asks the model to hand the terminal to cmd, reporting how it
+// exited on done.
+type tuiExec struct {
+	cmd  *exec.Cmd
+	done chan<- error
+}
+
 // LogWriter returns the log file writer for subsystems.
 func (t *TUIFrontend) LogWriter() io.Writer {
 	return t.out.LogWriter()
@@ -90,39 +123,111 @@ func (t *TUIFrontend) Close() {
 
 // logEvent writes event details to the log file (no terminal output).
 func (t *TUIFrontend) logEvent(ev Event) {
-	switch e := ev.(type) {
-	case SystemInfo:
-		t.out.Log("[System]: %s\n", e.Text)
-	case TokenStreamed:
-		t.out.Log("%s", e.Token)
-	case AgentLabel:
-		t.out.Log("\n[%s]: ", e.AgentID)
-	case ToolCallStarted:
-		t.out.Log("\n  > %s\n", e.Title)
-	case ToolCallResult:
-		if e.Output != "" {
-			t.out.Log("  %s\n", e.Output)
-		}
-	case AgentDone:
-		t.out.Log("\n")
-	case AgentPassed:
-		t.out.Log("[%s]: [PASS]\n", e.AgentID)
-	case AgentError:
-		t.out.Log("[ERROR from %s: %v]\n", e.AgentID, e.Err)
-	}
+	logEvent(t.out, t.debug, ev)
 }
 
 // --- tuiModel: Bubble Tea Model ---
 
+// tuiBlock is one labeled section of the transcript: a user message, an
+// agent's turn, or a system line. Agent blocks stay open while the agent
+// streams, so concurrent agents each fill their own block.
+type tuiBlock struct {
+	agentID  string          // "" for system lines
+	body     strings.Builder // formatted text after the label
+	prose    strings.Builder // streamed text since the last tool line, not yet in body
+	text     strings.Builder // the agent's own streamed text, without tool output
+	thinking bool            // show the "thinking..." placeholder
+	started  time.Time       // when thinking began, for the elapsed counter
+	at       time.Time       // when the block was opened, shown with timestamps
+	done     bool
+	complete bool // finished with AgentDone (not a pass or error)
+
+	// A done block doesn't change, so its wrapped rendering is kept, for
+	// the width, label and so on in cacheKey.
+	cached   string
+	cacheKey string
+}
+
+// flushProse moves the streamed text into body, rendered by md if set.
+// Text is streamed plain and rendered once a tool line or the end of the
+// turn completes it.
+func (b *tuiBlock) flushProse(md func(string) string) {
+	if b.prose.Len() == 0 {
+		return
+	}
+	s := b.prose.String()
+	b.prose.Reset()
+	if md != nil {
+		s = md(s)
+	}
+	b.body.WriteString(s)
+}
+
+// render formats the block. spin is the current spinner frame shown while
+// the agent is thinking. With stamps, the label is preceded by the time the
+// block was opened.
+func (b *tuiBlock) render(label, color, spin string, stamps bool) string {
+	stamp := ""
+	if stamps && !b.at.IsZero() {
+		stamp = Dim + b.at.Format("15:04:05") + " " + Reset
+	}
+	if b.agentID == "" {
+		return stamp + b.body.String()
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n%s%s%s[%s]:%s ", stamp, Bold, color, label, Reset)
+	sb.WriteString(b.body.String())
+	sb.WriteString(b.prose.String())
+	if b.thinking {
+		fmt.Fprintf(&sb, "%s%sthinking... %ds%s", Dim, spin, int(time.Since(b.started).Seconds()), Reset)
+	}
+	if b.done {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
 type tuiModel struct {
 	viewport viewport.Model
 	textarea textarea.Model
-	content  strings.Builder
+	blocks   []*tuiBlock
+	open     map[string]*tuiBlock // in-progress block per agent
 	inputCh  chan<- Event
 	colorMap map[string]string
+	labels   map[string]string
+	agents   []string      // agent IDs in blueprint order, numbered 1.. in the filter
+	filter   string        // only show this agent's blocks; "" shows everything
+	history  *inputHistory // Up/Down recall of submitted input
+	spinner  spinner.Model
+	spinning bool   // a spinner tick is in flight
+	drawing  bool   // a streamFrame tick is in flight; tokens wait for it
+	stack    string // call stack breadcrumb shown in the header
+	busy     bool   // agents are working on a turn
+	summary  string // the floor's SessionSummary, once it stops
 	ready    bool
 	width    int
 	height   int
+
+	// settled is the rendering of the leading blocks that are done, which
+	// no longer change; render only appends to it. settledN counts those
+	// blocks, and settledKey holds the settings they were rendered with.
+	settled    strings.Builder
+	settledN   int
+	settledKey string
+
+	timestamps bool                  // show when each block was opened
+	markdown   bool                  // render agent text as markdown once each stretch of it completes
+	mdRenderer *glamour.TermRenderer // cached; wraps at mdWidth
+	mdWidth    int
+}
+
+// LoadHistory sets up input history (Up/Down recall), persisted to path.
+// An empty path keeps history for this session only. With skipCommands,
+// /commands are not recorded. Call before the program starts.
+func (m *tuiModel) LoadHistory(path string, skipCommands bool) error {
+	h, err := loadHistory(path, skipCommands)
+	m.history = h
+	return err
 }
 
 func (m *tuiModel) Init() tea.Cmd {
@@ -146,22 +251,26 @@ func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
-		vpHeight := m.height - textareaHeight - separatorHeight - 1
+		vpHeight := m.height - headerHeight - textareaHeight - separatorHeight - 1
 		if vpHeight < 1 {
 			vpHeight = 1
 		}
 
 		if !m.ready {
 			m.viewport = viewport.New(m.width, vpHeight)
-			m.viewport.SetContent(m.content.String())
+			m.viewport.SetContent(m.render())
 			m.viewport.MouseWheelEnabled = true
 			m.textarea.SetWidth(m.width)
 			m.ready = true
 		} else {
+			atBottom := m.viewport.AtBottom()
 			m.viewport.Width = m.width
 			m.viewport.Height = vpHeight
 			m.textarea.SetWidth(m.width)
-			m.viewport.SetContent(m.content.String())
+			m.viewport.SetContent(m.render()) // re-wrap to the new width
+			if atBottom {
+				m.viewport.GotoBottom()
+			}
 		}
 		return m, nil
 
@@ -185,21 +294,57 @@ func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlL:
 			return m, tea.ClearScreen
 
+		case tea.KeyCtrlY:
+			m.copyLastMessage()
+			return m, nil
+
+		case tea.KeyRunes:
+			// Alt+0 shows all agents, Alt+1..9 filters to that agent
+			if msg.Alt && len(msg.Runes) == 1 && msg.Runes[0] >= '0' && msg.Runes[0] <= '9' {
+				n := int(msg.Runes[0] - '0')
+				if n == 0 {
+					m.setFilter("")
+				} else if n <= len(m.agents) {
+					m.setFilter(m.agents[n-1])
+				}
+				return m, nil
+			}
+
+		case tea.KeyUp:
+			// Recall history from the first line, like a shell
+			if m.textarea.Line() == 0 {
+				if text, ok := m.inputHistory().prev(m.textarea.Value()); ok {
+					m.textarea.SetValue(text)
+					return m, nil
+				}
+			}
+
+		case tea.KeyDown:
+			if m.textarea.Line() == m.textarea.LineCount()-1 {
+				if text, ok := m.inputHistory().next(); ok {
+					m.textarea.SetValue(text)
+					return m, nil
+				}
+			}
+
 		case tea.KeyEnter:
 			text := strings.TrimSpace(m.textarea.Value())
 			if text == "" {
 				return m, nil
 			}
 			m.textarea.Reset()
+			m.inputHistory().add(text)
 
 			// Display user input in viewport
-			userColor := Cyan
-			if c, ok := m.colorMap["@user"]; ok {
-				userColor = c
-			}
-			m.appendContent(fmt.Sprintf("\n%s%s[@user]:%s %s\n", Bold, userColor, Reset, text))
+			user := &tuiBlock{agentID: "@user", done: true}
+			user.body.WriteString(text)
+			m.addBlock(user)
 
 			// Send to coordinator
+			if text == "/edit" && m.busy {
+				m.appendSystem(fmt.Sprintf("%s[/edit can't run while agents are working]%s\n", Dim, Reset))
+				return m, nil
+			}
 			if strings.HasPrefix(text, "/") {
 				select {
 				case m.inputCh <- UserCommand{Command: text}:
@@ -223,25 +368,61 @@ func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// --- Floor events (injected via p.Send()) ---
 
 	case SystemInfo:
-		m.appendContent(fmt.Sprintf("%s%s%s\n", Dim, msg.Text, Reset))
+		m.appendSystem(fmt.Sprintf("%s%s%s\n", Dim, msg.Text, Reset))
 		return m, nil
 
 	case AgentThinking:
-		color := m.agentColor(msg.AgentID)
-		m.appendContent(fmt.Sprintf("\n%s%s[%s]:%s %sthinking...%s", Bold, color, msg.AgentID, Reset, Dim, Reset))
+		b := m.startBlock(msg.AgentID)
+		b.thinking = true
+		b.started = time.Now()
+		m.refresh()
+		if !m.spinning {
+			m.spinning = true
+			return m, m.spinner.Tick
+		}
 		return m, nil
 
+	case spinner.TickMsg:
+		// Keep ticking only while someone is still thinking
+		if !m.anyThinking() {
+			m.spinning = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		m.refresh()
+		return m, cmd
+
 	case AgentLabel:
-		// Replace "thinking..." with actual agent label
-		m.replaceThinking(msg.AgentID)
+		// Replace "thinking..." with the streamed output
+		m.agentBlock(msg.AgentID).thinking = false
+		m.refresh()
 		return m, nil
 
 	case TokenStreamed:
-		m.appendContent(msg.Token)
+		b := m.agentBlock(msg.AgentID)
+		b.prose.WriteString(msg.Token)
+		b.text.WriteString(msg.Token)
+		return m, m.refreshSoon()
+
+	case tuiDraw:
+		m.drawing = false
+		m.refresh()
 		return m, nil
 
 	case ToolCallStarted:
-		m.appendContent(fmt.Sprintf("\n%s  > %s%s\n", Dim, msg.Title, Reset))
+		fmt.Fprintf(m.agentBody(msg.AgentID), "\n%s  > %s%s\n", Dim, msg.Title, Reset)
+		m.refresh()
+		return m, nil
+
+	case ToolCallProgress:
+		fmt.Fprintf(m.agentBody(msg.AgentID), "%s  … %s%s\n", Dim, msg.Message, Reset)
+		m.refresh()
+		return m, nil
+
+	case UserInputRequested:
+		fmt.Fprintf(m.agentBody(msg.AgentID), "\n%s  ? %s%s\n", Bold, msg.Question, Reset)
+		m.refresh()
 		return m, nil
 
 	case ToolCallResult:
@@ -250,44 +431,88 @@ func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(display) > 500 {
 				display = display[:500] + "..."
 			}
-			m.appendContent(fmt.Sprintf("%s  %s%s\n", Dim, display, Reset))
+			fmt.Fprintf(m.agentBody(msg.AgentID), "%s  %s%s\n", Dim, display, Reset)
+			m.refresh()
 		}
 		return m, nil
 
 	case AgentDone:
-		m.appendContent("\n")
+		m.agentBlock(msg.AgentID).complete = true
+		m.closeBlock(msg.AgentID)
 		return m, nil
 
 	case AgentPassed:
-		// Replace thinking with [PASS]
-		color := m.agentColor(msg.AgentID)
-		m.replaceThinking(msg.AgentID)
-		m.appendContent(fmt.Sprintf("%s%s[%s]:%s [PASS]\n", Bold, color, msg.AgentID, Reset))
+		b := m.agentBlock(msg.AgentID)
+		b.thinking = false
+		m.agentBody(msg.AgentID).WriteString("[PASS]")
+		m.closeBlock(msg.AgentID)
+		return m, nil
+
+	case AgentRouted:
+		b := m.agentBlock(msg.AgentID)
+		b.thinking = false
+		fmt.Fprintf(m.agentBody(msg.AgentID), "%s→ %s%s", Dim, m.agentLabel(msg.Target), Reset)
+		m.closeBlock(msg.AgentID)
 		return m, nil
 
 	case AgentError:
-		m.appendContent(fmt.Sprintf("\n%s[ERROR from %s: %v]%s\n", Red, msg.AgentID, msg.Err, Reset))
+		b := m.agentBlock(msg.AgentID)
+		b.thinking = false
+		fmt.Fprintf(m.agentBody(msg.AgentID), "\n%s[ERROR from %s: %v]%s", Red, msg.AgentID, msg.Err, Reset)
+		m.closeBlock(msg.AgentID)
 		return m, nil
 
 	case ConversationCleared:
-		m.content.Reset()
+		m.blocks = nil
+		m.open = nil
+		m.resetRender()
 		if m.ready {
 			m.viewport.SetContent("")
 			m.viewport.GotoTop()
 		}
-		m.appendContent(fmt.Sprintf("%s[Conversation cleared]%s\n", Dim, Reset))
+		m.appendSystem(fmt.Sprintf("%s[Conversation cleared]%s\n", Dim, Reset))
+		m.stack = ""
+		return m, nil
+
+	case SessionSummary:
+		m.summary = FormatSummary(msg)
+		m.appendSystem(fmt.Sprintf("\n%s%s%s\n", Dim, m.summary, Reset))
 		return m, nil
 
 	case FloorStopped:
 		return m, tea.Quit
 
 	case WaitingForUser:
-		// No-op — textarea is always ready
+		// Textarea is always ready; just refresh the header
+		m.stack = FormatStack(msg.Stack)
+		m.busy = false
 		return m, nil
 
 	case PromptAgent:
-		// No-op — coordinator handles dispatch
+		// Coordinator handles dispatch; just refresh the header
+		m.stack = FormatStack(msg.Stack)
+		m.busy = true
 		return m, nil
+
+	case PromptAgents:
+		m.stack = FormatStack(msg.Stack)
+		m.busy = true
+		return m, nil
+
+	case tuiExec:
+		return m, tea.ExecProcess(msg.cmd, func(err error) tea.Msg {
+			msg.done <- err
+			return nil
+		})
+
+	case tea.MouseMsg:
+		// Click on a header filter segment
+		if msg.Y == 0 && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			if agentID, ok := m.filterAt(msg.X); ok {
+				m.setFilter(agentID)
+				return m, nil
+			}
+		}
 	}
 
 	// Pass other messages to viewport (mouse wheel, etc.)
@@ -307,32 +532,343 @@ func (m *tuiModel) View() string {
 		Foreground(lipgloss.Color("240")).
 		Render(strings.Repeat("─", m.width))
 
-	return m.viewport.View() + "\n" + separator + "\n" + m.textarea.View()
+	return m.headerView() + "\n" + m.viewport.View() + "\n" + separator + "\n" + m.textarea.View()
+}
+
+// Summary returns the floor's session summary as plain text, or "" if the
+// floor has not stopped. The TUI's screen is gone once it exits, so callers
+// print this afterwards.
+func (m *tuiModel) Summary() string {
+	return m.summary
+}
+
+// inputHistory returns the model's history, in-memory if LoadHistory wasn't called.
+func (m *tuiModel) inputHistory() *inputHistory {
+	if m.history == nil {
+		m.history = &inputHistory{}
+	}
+	return m.history
+}
+
+// headerView renders the one-line header: the delegation breadcrumb, then
+// the agent filter ("0:all 1:@a 2:@b") with the active choice highlighted.
+func (m *tuiModel) headerView() string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	active := lipgloss.NewStyle().Reverse(true)
+
+	var sb strings.Builder
+	sb.WriteString(dim.Render(m.stackLabel()))
+	for i, seg := range m.filterSegments() {
+		sb.WriteString(" ")
+		if (i == 0 && m.filter == "") || (i > 0 && m.agents[i-1] == m.filter) {
+			sb.WriteString(active.Render(seg))
+		} else {
+			sb.WriteString(dim.Render(seg))
+		}
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(sb.String())
+}
+
+// stackLabel is the breadcrumb part of the header, followed by a divider
+// when there are agents to filter.
+func (m *tuiModel) stackLabel() string {
+	crumb := m.stack
+	if crumb == "" {
+		crumb = "@user"
+	}
+	label := "stack: " + crumb
+	if len(m.agents) > 0 {
+		label += "  │"
+	}
+	return label
+}
+
+// filterSegments returns the header filter labels: "0:all", then one per agent.
+// Agents beyond 9 have no number key but can still be clicked.
+func (m *tuiModel) filterSegments() []string {
+	if len(m.agents) == 0 {
+		return nil
+	}
+	segs := []string{"0:all"}
+	for i, id := range m.agents {
+		segs = append(segs, fmt.Sprintf("%d:%s", i+1, id))
+	}
+	return segs
+}
+
+// filterAt maps a header column to the filter segment under it.
+// Returns "" for "all".
+func (m *tuiModel) filterAt(x int) (string, bool) {
+	pos := lipgloss.Width(m.stackLabel())
+	for i, seg := range m.filterSegments() {
+		start := pos + 1
+		end := start + lipgloss.Width(seg)
+		if x >= start && x < end {
+			if i == 0 {
+				return "", true
+			}
+			return m.agents[i-1], true
+		}
+		pos = end
+	}
+	return "", false
+}
+
+// setFilter shows only agentID's blocks ("" for all) and re-renders.
+func (m *tuiModel) setFilter(agentID string) {
+	m.filter = agentID
+	m.refresh()
+}
+
+// render concatenates the blocks that pass the agent filter into the
+// viewport content. The leading done blocks are rendered once into
+// m.settled, so a redraw only renders the blocks still streaming after
+// them, however long the transcript.
+func (m *tuiModel) render() string {
+	if key := fmt.Sprintf("%d|%t|%s", m.viewport.Width, m.timestamps, m.filter); key != m.settledKey || m.settledN > len(m.blocks) {
+		m.resetRender()
+		m.settledKey = key
+	}
+	spin := ""
+	if len(m.spinner.Spinner.Frames) > 0 {
+		spin = m.spinner.View() + " "
+	}
+	for m.settledN < len(m.blocks) && m.blocks[m.settledN].done {
+		m.settled.WriteString(m.renderBlock(m.blocks[m.settledN], spin))
+		m.settledN++
+	}
+	if m.settledN == len(m.blocks) {
+		return m.settled.String()
+	}
+	var sb strings.Builder
+	sb.WriteString(m.settled.String())
+	for _, b := range m.blocks[m.settledN:] {
+		sb.WriteString(m.renderBlock(b, spin))
+	}
+	return sb.String()
+}
+
+// renderBlock wraps one block for the viewport, or returns "" if the
+// agent filter hides it. A done block reuses its last rendering.
+func (m *tuiModel) renderBlock(b *tuiBlock, spin string) string {
+	if m.filter != "" && b.agentID != m.filter {
+		return ""
+	}
+	label, color := m.agentLabel(b.agentID), m.agentColor(b.agentID)
+	key := fmt.Sprintf("%d|%t|%s|%s", m.viewport.Width, m.timestamps, label, color)
+	if b.done && b.cacheKey == key {
+		return b.cached
+	}
+	out := wrapANSI(b.render(label, color, spin, m.timestamps), m.viewport.Width)
+	if b.done {
+		b.cached, b.cacheKey = out, key
+	}
+	return out
+}
+
+// resetRender drops the settled rendering, for when the blocks or how
+// they are shown change.
+func (m *tuiModel) resetRender() {
+	m.settled.Reset()
+	m.settledN = 0
+}
+
+// wrapANSI word-wraps s to width cells (hard-wrapping words that don't fit),
+// ignoring escape codes when measuring. Colors that are active at a wrap
+// point, or at any line break, are closed at the end of the line and reopened
+// at the start of the next, so each line renders correctly on its own.
+// A width of 0 or less returns s unchanged.
+func wrapANSI(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	lines := strings.Split(ansi.Wrap(s, width, ""), "\n")
+	active := "" // SGR codes in effect, since the last reset
+	for i, line := range lines {
+		prefix := active
+		for _, code := range ansiRe.FindAllString(line, -1) {
+			if code == Reset || code == "\x1b[m" {
+				active = ""
+			} else {
+				active += code
+			}
+		}
+		if active != "" {
+			line += Reset
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
 }
 
-// appendContent adds text to the viewport and auto-scrolls to bottom.
-func (m *tuiModel) appendContent(text string) {
-	m.content.WriteString(text)
+// refresh re-renders the viewport and auto-scrolls to bottom.
+func (m *tuiModel) refresh() {
 	if m.ready {
-		m.viewport.SetContent(m.content.String())
+		m.viewport.SetContent(m.render())
 		m.viewport.GotoBottom()
 	}
 }
 
-// replaceThinking removes the last "thinking..." line for an agent,
-// replacing it with the actual agent label for streaming output.
-func (m *tuiModel) replaceThinking(agentID string) {
-	content := m.content.String()
-	thinkSuffix := fmt.Sprintf("%sthinking...%s", Dim, Reset)
-	if idx := strings.LastIndex(content, thinkSuffix); idx >= 0 {
-		// Remove "thinking..." and everything after it on that line
-		content = content[:idx]
-		m.content.Reset()
-		m.content.WriteString(content)
-		if m.ready {
-			m.viewport.SetContent(content)
+// streamFrame is how often streamed tokens are drawn. Tokens that arrive
+// within a frame are drawn together, so a fast stream re-renders the
+// transcript about 30 times a second rather than once per token.
+const streamFrame = 33 * time.Millisecond
+
+// tuiDraw is the tick that draws the tokens streamed since the last frame.
+type tuiDraw struct{}
+
+// refreshSoon schedules a refresh at the next stream frame, unless one is
+// already due.
+func (m *tuiModel) refreshSoon() tea.Cmd {
+	if m.drawing {
+		return nil
+	}
+	m.drawing = true
+	return tea.Tick(streamFrame, func(time.Time) tea.Msg { return tuiDraw{} })
+}
+
+// anyThinking reports whether an agent is waiting for its first token.
+func (m *tuiModel) anyThinking() bool {
+	for _, b := range m.open {
+		if b.thinking {
+			return true
 		}
 	}
+	return false
+}
+
+// addBlock appends a block to the transcript.
+func (m *tuiModel) addBlock(b *tuiBlock) {
+	b.at = time.Now()
+	m.blocks = append(m.blocks, b)
+	m.refresh()
+}
+
+// appendSystem adds a system line to the transcript.
+func (m *tuiModel) appendSystem(text string) {
+	b := &tuiBlock{done: true}
+	b.body.WriteString(text)
+	m.addBlock(b)
+}
+
+// startBlock opens a new block for an agent's turn.
+func (m *tuiModel) startBlock(agentID string) *tuiBlock {
+	if m.open == nil {
+		m.open = make(map[string]*tuiBlock)
+	}
+	b := &tuiBlock{agentID: agentID, at: time.Now()}
+	m.open[agentID] = b
+	m.blocks = append(m.blocks, b)
+	return b
+}
+
+// agentBlock returns the agent's in-progress block, opening one if needed
+// (e.g. stream events that arrive without a preceding AgentThinking).
+func (m *tuiModel) agentBlock(agentID string) *tuiBlock {
+	if b, ok := m.open[agentID]; ok {
+		return b
+	}
+	return m.startBlock(agentID)
+}
+
+// closeBlock marks the agent's block finished.
+func (m *tuiModel) closeBlock(agentID string) {
+	b := m.agentBlock(agentID)
+	b.flushProse(m.markdownRenderer())
+	b.done = true
+	delete(m.open, agentID)
+	m.refresh()
+}
+
+// agentBody returns the agent's block body for a tool or status line,
+// first completing the text streamed before it.
+func (m *tuiModel) agentBody(agentID string) *strings.Builder {
+	b := m.agentBlock(agentID)
+	b.flushProse(m.markdownRenderer())
+	return &b.body
+}
+
+// markdownRenderer returns a func rendering agent text as styled markdown
+// at the viewport's width, or nil when markdown is off. Text that fails to
+// render is shown as streamed.
+func (m *tuiModel) markdownRenderer() func(string) string {
+	if !m.markdown {
+		return nil
+	}
+	width := m.viewport.Width
+	if width <= 0 {
+		width = 80
+	}
+	if m.mdRenderer == nil || m.mdWidth != width {
+		r, err := glamour.NewTermRenderer(glamour.WithStandardStyle(styles.DarkStyle), glamour.WithWordWrap(width-4))
+		if err != nil {
+			return nil
+		}
+		m.mdRenderer, m.mdWidth = r, width
+	}
+	return func(s string) string {
+		out, err := m.mdRenderer.Render(s)
+		if err != nil {
+			return s
+		}
+		return "\n" + trimPadding(strings.Trim(out, "\n")) + "\n"
+	}
+}
+
+// trailingPadRe matches spaces at the end of a line, and the style codes
+// mixed in with them.
+var trailingPadRe = regexp.MustCompile(`(?m)(?: |\x1b\[[0-9;]*m)*$`)
+
+// trimPadding drops the padding glamour adds to fill each line to the wrap
+// width, which would otherwise make lines wrap when the window narrows.
+func trimPadding(s string) string {
+	return trailingPadRe.ReplaceAllString(s, Reset)
+}
+
+// copyToClipboard writes text to the system clipboard. A variable for tests.
+var copyToClipboard = clipboard.WriteAll
+
+// copyLastMessage copies the most recent complete agent message to the
+// clipboard as plain text, noting the outcome in the transcript.
+func (m *tuiModel) copyLastMessage() {
+	var last *tuiBlock
+	for i := len(m.blocks) - 1; i >= 0; i-- {
+		b := m.blocks[i]
+		if b.complete && b.agentID != "@user" && strings.TrimSpace(b.text.String()) != "" {
+			last = b
+			break
+		}
+	}
+	if last == nil {
+		m.appendSystem(fmt.Sprintf("%s[Nothing to copy yet]%s\n", Dim, Reset))
+		return
+	}
+
+	text := strings.TrimSpace(ansiRe.ReplaceAllString(last.text.String(), ""))
+	if err := copyToClipboard(text); err != nil {
+		m.appendSystem(fmt.Sprintf("%s[Clipboard unavailable: %v]%s\n", Dim, err, Reset))
+		return
+	}
+	m.appendSystem(fmt.Sprintf("%s[Copied %s's last message (%d chars)]%s\n", Dim, last.agentID, len(text), Reset))
+}
+
+// SetTimestamps shows the clock time before each message and system line.
+func (m *tuiModel) SetTimestamps(on bool) {
+	m.timestamps = on
+}
+
+// SetLabels sets the labels shown for agents instead of their IDs, such as
+// from BuildLabelMap. Agents without one are shown by ID.
+func (m *tuiModel) SetLabels(labels map[string]string) {
+	m.labels = labels
+	m.resetRender()
+}
+
+func (m *tuiModel) agentLabel(id string) string {
+	if l, ok := m.labels[id]; ok {
+		return l
+	}
+	return id
 }
 
 func (m *tuiModel) agentColor(id string) string {
@@ -0,0 +1,290 @@
+package floor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestTUIAgentLabels(t *testing.T) {
+	m := &tuiModel{colorMap: map[string]string{}}
+	m.SetLabels(map[string]string{"@data": "🔬 Researcher"})
+
+	m.Update(AgentLabel{AgentID: "@data"})
+	m.Update(TokenStreamed{AgentID: "@data", Token: "found it"})
+	m.Update(AgentDone{AgentID: "@data"})
+	m.Update(AgentPassed{AgentID: "@other"})
+
+	out := ansiRe.ReplaceAllString(m.render(), "")
+	want := "\n[🔬 Researcher]: found it\n\n[@other]: [PASS]\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func TestTUIInterleavedStreamsStayInBlocks(t *testing.T) {
+	m := &tuiModel{colorMap: map[string]string{}}
+
+	m.Update(AgentThinking{AgentID: "@a"})
+	m.Update(AgentThinking{AgentID: "@b"})
+	m.Update(AgentLabel{AgentID: "@a"})
+	m.Update(AgentLabel{AgentID: "@b"})
+	for _, tok := range []string{"one ", "two"} {
+		m.Update(TokenStreamed{AgentID: "@a", Token: "a-" + tok})
+		m.Update(TokenStreamed{AgentID: "@b", Token: "b-" + tok})
+	}
+	m.Update(AgentDone{AgentID: "@b"})
+	m.Update(AgentPassed{AgentID: "@c"})
+	m.Update(AgentDone{AgentID: "@a"})
+
+	out := ansiRe.ReplaceAllString(m.render(), "")
+	want := "\n[@a]: a-one a-two\n\n[@b]: b-one b-two\n\n[@c]: [PASS]\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+	if strings.Contains(out, "thinking") {
+		t.Error("thinking placeholder left behind")
+	}
+	if len(m.open) != 0 {
+		t.Errorf("expected all blocks closed, got %d open", len(m.open))
+	}
+}
+
+func TestTUIAgentFilter(t *testing.T) {
+	m := &tuiModel{colorMap: map[string]string{}, agents: []string{"@a", "@b"}}
+	m.Update(SystemInfo{Text: "ready"})
+	m.Update(TokenStreamed{AgentID: "@a", Token: "from a"})
+	m.Update(AgentDone{AgentID: "@a"})
+	m.Update(TokenStreamed{AgentID: "@b", Token: "from b"})
+	m.Update(AgentDone{AgentID: "@b"})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}, Alt: true})
+	out := ansiRe.ReplaceAllString(m.render(), "")
+	if out != "\n[@b]: from b\n" {
+		t.Errorf("filtered to @b, got %q", out)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'0'}, Alt: true})
+	out = ansiRe.ReplaceAllString(m.render(), "")
+	if !strings.Contains(out, "ready") || !strings.Contains(out, "from a") || !strings.Contains(out, "from b") {
+		t.Errorf("expected everything after reset, got %q", out)
+	}
+}
+
+func TestTUIFilterClick(t *testing.T) {
+	m := &tuiModel{agents: []string{"@a", "@b"}}
+	// "stack: @user  │ 0:all 1:@a 2:@b"
+	label := lipgloss.Width(m.stackLabel())
+	cases := []struct {
+		x    int
+		want string
+		ok   bool
+	}{
+		{0, "", false},
+		{label + 1, "", true},    // "0:all"
+		{label + 7, "@a", true},  // "1:@a"
+		{label + 12, "@b", true}, // "2:@b"
+		{label + 6, "", false},   // gap between segments
+	}
+	for _, c := range cases {
+		got, ok := m.filterAt(c.x)
+		if got != c.want || ok != c.ok {
+			t.Errorf("filterAt(%d) = %q, %v; want %q, %v", c.x, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestTUICopyLastMessage(t *testing.T) {
+	var copied string
+	orig := copyToClipboard
+	copyToClipboard = func(s string) error { copied = s; return nil }
+	defer func() { copyToClipboard = orig }()
+
+	m := &tuiModel{colorMap: map[string]string{}}
+	m.Update(TokenStreamed{AgentID: "@a", Token: "first\n"})
+	m.Update(AgentDone{AgentID: "@a"})
+	m.Update(ToolCallStarted{AgentID: "@b", Title: "run"})
+	m.Update(TokenStreamed{AgentID: "@b", Token: Bold + "answer" + Reset + "\n"})
+	m.Update(AgentDone{AgentID: "@b"})
+	m.Update(AgentPassed{AgentID: "@c"})
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if copied != "answer" {
+		t.Errorf("copied %q, want %q", copied, "answer")
+	}
+}
+
+func TestTUICopyWithoutClipboard(t *testing.T) {
+	orig := copyToClipboard
+	copyToClipboard = func(string) error { return fmt.Errorf("no xclip") }
+	defer func() { copyToClipboard = orig }()
+
+	m := &tuiModel{colorMap: map[string]string{}}
+	m.Update(TokenStreamed{AgentID: "@a", Token: "hi"})
+	m.Update(AgentDone{AgentID: "@a"})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+
+	if out := m.render(); !strings.Contains(out, "Clipboard unavailable: no xclip") {
+		t.Errorf("expected clipboard note, got %q", out)
+	}
+}
+
+func TestWrapANSI(t *testing.T) {
+	in := Red + "alpha beta gamma" + Reset + " delta\n  indented line"
+	got := wrapANSI(in, 11)
+	want := Red + "alpha beta" + Reset + "\n" +
+		Red + "gamma" + Reset + " delta\n" +
+		"  indented\nline"
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if w := lipgloss.Width(line); w > 11 {
+			t.Errorf("line %q is %d cells wide", line, w)
+		}
+	}
+	if wrapANSI(in, 0) != in {
+		t.Error("width 0 should leave text unchanged")
+	}
+}
+
+func TestTUIThinkingIsStateNotText(t *testing.T) {
+	// An agent whose output contains the placeholder text must not lose it
+	// when a later agent's thinking line is cleared.
+	placeholder := Dim + "thinking..." + Reset
+	m := &tuiModel{colorMap: map[string]string{}}
+	m.Update(TokenStreamed{AgentID: "@a", Token: "still " + placeholder})
+	m.Update(AgentDone{AgentID: "@a"})
+	m.Update(AgentThinking{AgentID: "@b"})
+	m.Update(AgentLabel{AgentID: "@b"})
+	m.Update(TokenStreamed{AgentID: "@b", Token: "ok"})
+
+	out := m.render()
+	if strings.Count(out, placeholder) != 1 || !strings.Contains(out, "still "+placeholder) {
+		t.Errorf("agent text was altered: %q", out)
+	}
+	if !strings.HasSuffix(ansiRe.ReplaceAllString(out, ""), "[@b]: ok") {
+		t.Errorf("expected @b's label followed by its output, got %q", out)
+	}
+}
+
+func TestTUISpinnerRunsWhileThinking(t *testing.T) {
+	m := &tuiModel{colorMap: map[string]string{}, spinner: spinner.New(spinner.WithSpinner(spinner.MiniDot))}
+
+	if _, cmd := m.Update(AgentThinking{AgentID: "@a"}); cmd == nil {
+		t.Fatal("expected AgentThinking to start the spinner")
+	}
+	if out := ansiRe.ReplaceAllString(m.render(), ""); !strings.Contains(out, "thinking... 0s") {
+		t.Errorf("expected elapsed counter, got %q", out)
+	}
+	if _, cmd := m.Update(m.spinner.Tick()); cmd == nil {
+		t.Error("expected spinner to keep ticking while @a thinks")
+	}
+
+	m.Update(AgentLabel{AgentID: "@a"})
+	if _, cmd := m.Update(m.spinner.Tick()); cmd != nil || m.spinning {
+		t.Error("expected spinner to stop once @a starts streaming")
+	}
+}
+
+func TestTUIRendersMarkdown(t *testing.T) {
+	m := &tuiModel{colorMap: map[string]string{}, markdown: true}
+
+	m.Update(AgentLabel{AgentID: "@a"})
+	m.Update(TokenStreamed{AgentID: "@a", Token: "# Plan\n\nRun **this**:\n\n```go\nfmt.Println(1)\n```\n"})
+	if out := ansiRe.ReplaceAllString(m.render(), ""); !strings.Contains(out, "# Plan") {
+		t.Errorf("streaming text should show as plain text, got %q", out)
+	}
+
+	m.Update(ToolCallStarted{AgentID: "@a", Title: "go run ."})
+	m.Update(TokenStreamed{AgentID: "@a", Token: "Done, it printed `1`."})
+	m.Update(AgentDone{AgentID: "@a"})
+
+	raw := m.render()
+	out := ansiRe.ReplaceAllString(raw, "")
+	for _, gone := range []string{"**", "```", "`1`"} {
+		if strings.Contains(out, gone) {
+			t.Errorf("markdown syntax %q left in rendered output:\n%s", gone, out)
+		}
+	}
+	for _, want := range []string{"Plan", "this", "fmt.Println(1)", "> go run .", "Done, it printed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered output missing %q:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(raw, "\x1b[") {
+		t.Error("expected styled output")
+	}
+}
+
+func TestTUICoalescesStreamedTokens(t *testing.T) {
+	m := &tuiModel{colorMap: map[string]string{}, viewport: viewport.New(80, 10), ready: true}
+
+	var ticks int
+	for i := range 100 {
+		if _, cmd := m.Update(TokenStreamed{AgentID: "@a", Token: fmt.Sprintf("t%d ", i)}); cmd != nil {
+			ticks++
+		}
+	}
+	if ticks != 1 {
+		t.Fatalf("expected one draw scheduled for the burst, got %d", ticks)
+	}
+	if strings.Contains(m.viewport.View(), "t99") {
+		t.Error("tokens were drawn before the frame")
+	}
+
+	m.Update(tuiDraw{})
+	if !strings.Contains(m.viewport.View(), "t99") {
+		t.Errorf("expected the frame to draw every token, got %q", m.viewport.View())
+	}
+	if _, cmd := m.Update(TokenStreamed{AgentID: "@a", Token: "more"}); cmd == nil {
+		t.Error("expected a new frame after the last one was drawn")
+	}
+}
+
+func TestTUIDoneBlocksRewrapOnResize(t *testing.T) {
+	m := &tuiModel{colorMap: map[string]string{}, viewport: viewport.New(80, 10)}
+	m.Update(TokenStreamed{AgentID: "@a", Token: strings.Repeat("word ", 30)})
+	m.Update(AgentDone{AgentID: "@a"})
+
+	wide := m.render()
+	if m.render() != wide {
+		t.Fatal("expected a stable rendering")
+	}
+	m.viewport.Width = 20
+	for _, line := range strings.Split(m.render(), "\n") {
+		if w := lipgloss.Width(line); w > 20 {
+			t.Errorf("line %q is %d cells wide after resizing to 20", line, w)
+		}
+	}
+}
+
+func TestTUISettledRenderMatchesFullRender(t *testing.T) {
+	m := &tuiModel{colorMap: map[string]string{}, viewport: viewport.New(40, 10)}
+	m.Update(SystemInfo{Text: "ready"})
+	for i := range 3 {
+		m.Update(TokenStreamed{AgentID: "@a", Token: fmt.Sprintf("answer %d is long enough to wrap at forty cells", i)})
+		m.render() // settle as we go, as the viewport would
+		m.Update(AgentDone{AgentID: "@a"})
+	}
+	m.Update(TokenStreamed{AgentID: "@b", Token: "still going"})
+
+	got := m.render()
+	if m.settledN != 4 {
+		t.Errorf("expected 4 settled blocks, got %d", m.settledN)
+	}
+	m.resetRender()
+	if want := m.render(); got != want {
+		t.Errorf("incremental render differs:\n got %q\nwant %q", got, want)
+	}
+
+	m.Update(ConversationCleared{})
+	if out := m.render(); strings.Contains(out, "answer") {
+		t.Errorf("cleared blocks still rendered: %q", out)
+	}
+}
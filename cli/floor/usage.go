@@ -0,0 +1,97 @@
+package floor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+// AgentUsage is one agent's token usage this session and, when the
+// blueprint's pricing lists its model, the estimated cost in dollars.
+type AgentUsage struct {
+	AgentID string
+	Model   string
+	Usage   llm.Usage
+	Cost    float64
+	Priced  bool
+}
+
+// usageReport returns every agent's usage so far, in blueprint order.
+func (co *Coordinator) usageReport() []AgentUsage {
+	report := make([]AgentUsage, 0, len(co.bp.Agents))
+	for _, agent := range co.bp.Agents {
+		u := AgentUsage{AgentID: agent.ID, Model: agent.Model, Usage: co.usage[agent.ID]}
+		if price, ok := co.bp.Pricing[agent.Model]; ok {
+			u.Cost = price.Cost(u.Usage.PromptTokens, u.Usage.CompletionTokens)
+			u.Priced = true
+		}
+		report = append(report, u)
+	}
+	return report
+}
+
+// addUsage records tokens used by an agent's turn.
+func (co *Coordinator) addUsage(agentID string, u llm.Usage) {
+	if co.usage == nil {
+		co.usage = make(map[string]llm.Usage)
+	}
+	co.usage[agentID] = co.usage[agentID].Add(u)
+}
+
+// showUsage replies to /usage with each agent's tokens and estimated cost,
+// then the session total.
+func (co *Coordinator) showUsage() []Event {
+	report := co.usageReport()
+	var events []Event
+	var total llm.Usage
+	for _, u := range report {
+		total = total.Add(u.Usage)
+		text := fmt.Sprintf("%s: no usage reported", u.AgentID)
+		if u.Usage.TotalTokens > 0 {
+			text = fmt.Sprintf("%s (%s): %s (%d in, %d out)", u.AgentID, u.Model, countOf(u.Usage.TotalTokens, "token"), u.Usage.PromptTokens, u.Usage.CompletionTokens)
+			if u.Priced {
+				text += ", ~" + formatCost(u.Cost)
+			} else {
+				text += ", no price for this model"
+			}
+		}
+		events = append(events, SystemInfo{Text: text})
+	}
+	text := "Total: " + countOf(total.TotalTokens, "token")
+	if cost := formatTotalCost(report); cost != "" {
+		text += ", " + cost
+	}
+	return append(events, SystemInfo{Text: text})
+}
+
+// formatTotalCost sums the estimated cost of the priced agents in report,
+// noting when some usage had no price. Returns "" if no agent is priced.
+func formatTotalCost(report []AgentUsage) string {
+	var cost float64
+	priced, unpriced := false, false
+	for _, u := range report {
+		switch {
+		case u.Priced:
+			cost += u.Cost
+			priced = true
+		case u.Usage.TotalTokens > 0:
+			unpriced = true
+		}
+	}
+	if !priced {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("~" + formatCost(cost))
+	if unpriced {
+		sb.WriteString(" for priced models")
+	}
+	return sb.String()
+}
+
+// formatCost formats a dollar estimate. Four decimals keep cheap turns
+// from rounding to zero.
+func formatCost(c float64) string {
+	return fmt.Sprintf("$%.4f", c)
+}
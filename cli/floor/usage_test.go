@@ -0,0 +1,54 @@
+package floor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+	"github.com/openfloorcontrol/ofc/llm"
+)
+
+func TestUsageCost(t *testing.T) {
+	bp := &blueprint.Blueprint{
+		Name: "pricing",
+		Agents: []blueprint.Agent{
+			{ID: "@big", Type: "llm", Model: "hosted"},
+			{ID: "@small", Type: "llm", Model: "local"},
+			{ID: "@idle", Type: "llm", Model: "hosted"},
+		},
+		Pricing: map[string]blueprint.ModelPrice{"hosted": {Input: 0.5, Output: 1.5}},
+	}
+	ch := NewChannelFrontend(1, nil)
+	co := NewCoordinatorWith(bp, ch, ch, nil, nil, nil)
+
+	requireEvent[ShowUsage](t, co.ctrl.HandleEvent(UserCommand{Command: "/usage"}), 0)
+
+	co.record(AgentDone{AgentID: "@big", Content: "x", Usage: llm.Usage{PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200}})
+	co.record(AgentPassed{AgentID: "@big", Usage: llm.Usage{PromptTokens: 1000, CompletionTokens: 2, TotalTokens: 1002}})
+	co.record(AgentDone{AgentID: "@small", Content: "y", Usage: llm.Usage{PromptTokens: 500, CompletionTokens: 100, TotalTokens: 600}})
+
+	var lines []string
+	for _, ev := range co.showUsage() {
+		lines = append(lines, ev.(SystemInfo).Text)
+	}
+	want := []string{
+		"@big (hosted): 2202 tokens (2000 in, 202 out), ~$1.3030",
+		"@small (local): 600 tokens (500 in, 100 out), no price for this model",
+		"@idle: no usage reported",
+		"Total: 2802 tokens, ~$1.3030 for priced models",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("/usage:\n got %q\nwant %q", lines, want)
+	}
+
+	s := co.sessionSummary()
+	if got, want := FormatSummary(s), "Session: 3 turns, 0 tool calls, 2802 tokens (~$1.3030 for priced models)"; got != want {
+		t.Errorf("FormatSummary = %q, want %q", got, want)
+	}
+
+	// Without pricing, only tokens are shown.
+	bp.Pricing = nil
+	if got, want := FormatSummary(co.sessionSummary()), "Session: 3 turns, 0 tool calls, 2802 tokens"; got != want {
+		t.Errorf("FormatSummary = %q, want %q", got, want)
+	}
+}
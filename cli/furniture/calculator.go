@@ -0,0 +1,509 @@
+package furniture
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Calculator is furniture that evaluates arithmetic and boolean expressions
+// deterministically. The eval tool uses a small built-in parser (no exec),
+// so it works without a sandbox. If a Python executor is attached via
+// WithPython, a python_eval tool is offered as well.
+type Calculator struct {
+	name   string
+	python func(cmd string) (string, error)
+}
+
+// NewCalculator creates a calculator furniture.
+func NewCalculator(name string) *Calculator {
+	return &Calculator{name: name}
+}
+
+// WithPython enables the python_eval tool, running one-line expressions
+// through exec (typically the sandbox's Execute).
+func (c *Calculator) WithPython(exec func(cmd string) (string, error)) *Calculator {
+	c.python = exec
+	return c
+}
+
+func (c *Calculator) Name() string { return c.name }
+
+func (c *Calculator) Tools() []Tool {
+	tools := []Tool{
+		{
+			Name: "eval",
+			Description: "Evaluate an arithmetic or boolean expression exactly. " +
+				"Supports + - * / % ^, comparisons, && || !, parentheses, " +
+				"pi, e, and sqrt abs floor ceil round min max pow log log10 exp sin cos tan.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"expression": map[string]interface{}{
+						"type":        "string",
+						"description": "Expression to evaluate, e.g. \"(1200 * 0.15) + 3^2\"",
+					},
+				},
+				"required": []string{"expression"},
+			},
+		},
+	}
+	if c.python != nil {
+		tools = append(tools, Tool{
+			Name:        "python_eval",
+			Mutating:    true,
+			Description: "Evaluate a one-line Python expression in the sandbox and return its printed value.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"expression": map[string]interface{}{
+						"type":        "string",
+						"description": "Python expression, e.g. \"sum(range(101))\"",
+					},
+				},
+				"required": []string{"expression"},
+			},
+		})
+	}
+	return tools
+}
+
+func (c *Calculator) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	expr, _ := args["expression"].(string)
+	switch toolName {
+	case "eval":
+		if expr == "" {
+			return nil, fmt.Errorf("expression is required")
+		}
+		v, err := Evaluate(expr)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"expression": expr, "result": v}, nil
+	case "python_eval":
+		if c.python == nil {
+			return nil, &ErrUnknownTool{Furniture: c.name, Tool: toolName}
+		}
+		if expr == "" {
+			return nil, fmt.Errorf("expression is required")
+		}
+		if strings.ContainsAny(expr, "\n\r") {
+			return nil, fmt.Errorf("expression must be a single line")
+		}
+		out, err := c.python("python3 -c 'import sys; print(eval(sys.argv[1]))' " + shellQuote(expr))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"expression": expr, "result": strings.TrimSpace(out)}, nil
+	default:
+		return nil, &ErrUnknownTool{Furniture: c.name, Tool: toolName}
+	}
+}
+
+// shellQuote wraps s in single quotes for bash.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Evaluate parses and evaluates an expression. The result is a float64 or bool.
+func Evaluate(expr string) (interface{}, error) {
+	toks, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	v, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return nil, fmt.Errorf("result is not a finite number")
+	}
+	return v, nil
+}
+
+// tokenize splits an expression into numbers, identifiers, and operators.
+func tokenize(s string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(s); {
+		r := rune(s[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.' || s[j] == '_') {
+				j++
+			}
+			// Exponent: 1e3, 2.5E-4
+			if j < len(s) && (s[j] == 'e' || s[j] == 'E') {
+				k := j + 1
+				if k < len(s) && (s[k] == '+' || s[k] == '-') {
+					k++
+				}
+				if k < len(s) && unicode.IsDigit(rune(s[k])) {
+					for k < len(s) && unicode.IsDigit(rune(s[k])) {
+						k++
+					}
+					j = k
+				}
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_') {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		default:
+			if i+1 < len(s) {
+				switch two := s[i : i+2]; two {
+				case "**", "==", "!=", "<=", ">=", "&&", "||":
+					toks = append(toks, two)
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("+-*/%^()<>!,", r) {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+			toks = append(toks, string(r))
+			i++
+		}
+	}
+	return toks, nil
+}
+
+// exprParser is a recursive-descent parser that evaluates as it parses.
+type exprParser struct {
+	toks []string
+	pos  int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *exprParser) parseOr() (interface{}, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l, r, err := bothBool(left, right, "||")
+		if err != nil {
+			return nil, err
+		}
+		left = l || r
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (interface{}, error) {
+	left, err := p.parseCompare()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseCompare()
+		if err != nil {
+			return nil, err
+		}
+		l, r, err := bothBool(left, right, "&&")
+		if err != nil {
+			return nil, err
+		}
+		left = l && r
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseCompare() (interface{}, error) {
+	left, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+
+	if op == "==" || op == "!=" {
+		eq := left == right
+		if op == "==" {
+			return eq, nil
+		}
+		return !eq, nil
+	}
+	l, r, err := bothNum(left, right, op)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default:
+		return l >= r, nil
+	}
+}
+
+func (p *exprParser) parseAdd() (interface{}, error) {
+	left, err := p.parseMul()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "+" || p.peek() == "-" {
+		op := p.next()
+		right, err := p.parseMul()
+		if err != nil {
+			return nil, err
+		}
+		l, r, err := bothNum(left, right, op)
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			left = l + r
+		} else {
+			left = l - r
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseMul() (interface{}, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "*" || p.peek() == "/" || p.peek() == "%" {
+		op := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l, r, err := bothNum(left, right, op)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "*":
+			left = l * r
+		case "/":
+			if r == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			left = l / r
+		default:
+			if r == 0 {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			left = math.Mod(l, r)
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (interface{}, error) {
+	switch p.peek() {
+	case "-", "+":
+		op := p.next()
+		v, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		n, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("operator %s needs a number", op)
+		}
+		if op == "-" {
+			return -n, nil
+		}
+		return n, nil
+	case "!":
+		p.next()
+		v, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! needs a boolean")
+		}
+		return !b, nil
+	}
+	return p.parsePow()
+}
+
+// parsePow handles ^ and ** (right-associative, binds tighter than unary
+// minus on its left: -2^2 = -4).
+func (p *exprParser) parsePow() (interface{}, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == "^" || p.peek() == "**" {
+		op := p.next()
+		exp, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		b, e, err := bothNum(base, exp, op)
+		if err != nil {
+			return nil, err
+		}
+		return math.Pow(b, e), nil
+	}
+	return base, nil
+}
+
+func (p *exprParser) parsePrimary() (interface{}, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case tok == "(":
+		v, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return v, nil
+	case unicode.IsDigit(rune(tok[0])) || tok[0] == '.':
+		n, err := strconv.ParseFloat(strings.ReplaceAll(tok, "_", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok)
+		}
+		return n, nil
+	case unicode.IsLetter(rune(tok[0])) || tok[0] == '_':
+		if p.peek() == "(" {
+			return p.parseCall(tok)
+		}
+		switch strings.ToLower(tok) {
+		case "pi":
+			return math.Pi, nil
+		case "e":
+			return math.E, nil
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("unknown identifier %q", tok)
+	}
+	return nil, fmt.Errorf("unexpected %q", tok)
+}
+
+func (p *exprParser) parseCall(name string) (interface{}, error) {
+	p.next() // (
+	var args []float64
+	if p.peek() != ")" {
+		for {
+			v, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			n, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%s() needs numeric arguments", name)
+			}
+			args = append(args, n)
+			if p.peek() != "," {
+				break
+			}
+			p.next()
+		}
+	}
+	if p.next() != ")" {
+		return nil, fmt.Errorf("missing ) after %s(", name)
+	}
+	return callFunc(strings.ToLower(name), args)
+}
+
+func callFunc(name string, args []float64) (interface{}, error) {
+	unary := map[string]func(float64) float64{
+		"sqrt": math.Sqrt, "abs": math.Abs, "floor": math.Floor, "ceil": math.Ceil,
+		"round": math.Round, "log": math.Log, "log10": math.Log10, "exp": math.Exp,
+		"sin": math.Sin, "cos": math.Cos, "tan": math.Tan,
+	}
+	if fn, ok := unary[name]; ok {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s() takes 1 argument, got %d", name, len(args))
+		}
+		return fn(args[0]), nil
+	}
+	switch name {
+	case "pow":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("pow() takes 2 arguments, got %d", len(args))
+		}
+		return math.Pow(args[0], args[1]), nil
+	case "min", "max":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s() needs at least 1 argument", name)
+		}
+		v := args[0]
+		for _, a := range args[1:] {
+			if name == "min" {
+				v = math.Min(v, a)
+			} else {
+				v = math.Max(v, a)
+			}
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("unknown function %q", name)
+}
+
+func bothNum(a, b interface{}, op string) (float64, float64, error) {
+	x, ok1 := a.(float64)
+	y, ok2 := b.(float64)
+	if !ok1 || !ok2 {
+		return 0, 0, fmt.Errorf("operator %s needs numbers", op)
+	}
+	return x, y, nil
+}
+
+func bothBool(a, b interface{}, op string) (bool, bool, error) {
+	x, ok1 := a.(bool)
+	y, ok2 := b.(bool)
+	if !ok1 || !ok2 {
+		return false, false, fmt.Errorf("operator %s needs booleans", op)
+	}
+	return x, y, nil
+}
@@ -0,0 +1,65 @@
+package furniture
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"-2^2", -4.0},
+		{"2 ** 3 ** 2", 512.0},
+		{"10 % 4", 2.0},
+		{"1_000 * 1.5e2", 150000.0},
+		{"sqrt(16) + max(1, 5, 3)", 9.0},
+		{"round(pi * 100) / 100", 3.14},
+		{"3 > 2 && !(1 == 2)", true},
+		{"false || 2 <= 1", false},
+	}
+	for _, tt := range tests {
+		got, err := Evaluate(tt.expr)
+		if err != nil {
+			t.Errorf("Evaluate(%q): %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	for _, expr := range []string{"1 / 0", "2 +", "(1 + 2", "foo", "1 + true", "os.exit(1)", "sqrt(1, 2)"} {
+		if _, err := Evaluate(expr); err == nil {
+			t.Errorf("Evaluate(%q): expected error", expr)
+		}
+	}
+}
+
+func TestCalculatorPythonEval(t *testing.T) {
+	calc := NewCalculator("calc")
+	if len(calc.Tools()) != 1 {
+		t.Fatalf("expected only eval without python, got %d tools", len(calc.Tools()))
+	}
+
+	var ran string
+	calc.WithPython(func(cmd string) (string, error) {
+		ran = cmd
+		return "5050\n", nil
+	})
+	result, err := calc.Call("python_eval", map[string]interface{}{"expression": "sum(range(101)) # it's"})
+	if err != nil {
+		t.Fatalf("python_eval: %v", err)
+	}
+	if result.(map[string]interface{})["result"] != "5050" {
+		t.Errorf("unexpected result: %v", result)
+	}
+	if !strings.Contains(ran, `'sum(range(101)) # it'\''s'`) {
+		t.Errorf("expression not shell-quoted: %s", ran)
+	}
+}
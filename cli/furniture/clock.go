@@ -0,0 +1,89 @@
+package furniture
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock is furniture that tells agents the current time and how long the
+// session has been running. It is also the smallest complete example of a
+// Furniture implementation.
+//
+// Config keys:
+//   - timezone: IANA zone name, e.g. "Europe/Berlin" (default: local time)
+//   - format: Go time layout for "now" (default RFC 3339)
+type Clock struct {
+	name    string
+	loc     *time.Location
+	format  string
+	started time.Time
+	now     func() time.Time
+}
+
+// NewClock creates a clock furniture. The session start is the time of creation.
+func NewClock(name string, cfg map[string]string) (*Clock, error) {
+	loc := time.Local
+	if tz := cfg["timezone"]; tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("clock furniture %q: invalid timezone %q: %w", name, tz, err)
+		}
+		loc = l
+	}
+	format := cfg["format"]
+	if format == "" {
+		format = time.RFC3339
+	}
+	return &Clock{
+		name:    name,
+		loc:     loc,
+		format:  format,
+		started: time.Now(),
+		now:     time.Now,
+	}, nil
+}
+
+func (c *Clock) Name() string { return c.name }
+
+func (c *Clock) Tools() []Tool {
+	return []Tool{
+		{
+			Name:        "now",
+			Description: "Get the current date and time.",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "elapsed",
+			Description: "Get the time elapsed since the session started.",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+	}
+}
+
+func (c *Clock) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	switch toolName {
+	case "now":
+		t := c.now().In(c.loc)
+		return map[string]interface{}{
+			"time":     t.Format(c.format),
+			"timezone": c.loc.String(),
+			"weekday":  t.Weekday().String(),
+			"unix":     t.Unix(),
+		}, nil
+	case "elapsed":
+		d := c.now().Sub(c.started).Round(time.Second)
+		return map[string]interface{}{
+			"elapsed":         d.String(),
+			"elapsed_seconds": int64(d.Seconds()),
+			"started":         c.started.In(c.loc).Format(c.format),
+		}, nil
+	default:
+		return nil, &ErrUnknownTool{Furniture: c.name, Tool: toolName}
+	}
+}
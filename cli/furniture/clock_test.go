@@ -0,0 +1,38 @@
+package furniture
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	clock, err := NewClock("clock", map[string]string{"timezone": "UTC", "format": "2006-01-02 15:04"})
+	if err != nil {
+		t.Fatalf("NewClock: %v", err)
+	}
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock.started = start
+	clock.now = func() time.Time { return start.Add(90 * time.Minute) }
+
+	result, err := clock.Call("now", nil)
+	if err != nil {
+		t.Fatalf("now: %v", err)
+	}
+	now := result.(map[string]interface{})
+	if now["time"] != "2026-03-01 10:30" || now["weekday"] != "Sunday" {
+		t.Errorf("unexpected now: %+v", now)
+	}
+
+	result, err = clock.Call("elapsed", nil)
+	if err != nil {
+		t.Fatalf("elapsed: %v", err)
+	}
+	elapsed := result.(map[string]interface{})
+	if elapsed["elapsed"] != "1h30m0s" || elapsed["elapsed_seconds"] != int64(5400) {
+		t.Errorf("unexpected elapsed: %+v", elapsed)
+	}
+
+	if _, err := NewClock("clock", map[string]string{"timezone": "Mars/Olympus"}); err == nil {
+		t.Error("expected error for invalid timezone")
+	}
+}
@@ -6,33 +6,48 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // ExternalMCP implements the Furniture interface by proxying to an external
 // MCP server subprocess via stdio. OFC spawns the process, connects as an
-// MCP client, discovers tools, and forwards Call() invocations.
+// MCP client, discovers tools, and forwards Call() invocations. Progress
+// notifications the server sends during a call are passed to CallStream's
+// onProgress.
 type ExternalMCP struct {
 	name    string
 	session *mcp.ClientSession
 	tools   []Tool // cached from tools/list at startup
+
+	nextToken  atomic.Int64
+	progressMu sync.Mutex
+	progress   map[string]func(string) // onProgress of calls in flight, by progress token
 }
 
 // NewExternalMCP spawns an external MCP server process and connects to it.
 // It performs the MCP handshake and discovers available tools.
 func NewExternalMCP(ctx context.Context, name, command string, args []string) (*ExternalMCP, error) {
 	cmd := exec.Command(command, args...)
-	transport := &mcp.CommandTransport{Command: cmd}
+	return connectMCP(ctx, name, command, &mcp.CommandTransport{Command: cmd})
+}
 
+// connectMCP connects to an MCP server over transport and discovers its
+// tools. label names the server in errors.
+func connectMCP(ctx context.Context, name, label string, transport mcp.Transport) (*ExternalMCP, error) {
+	e := &ExternalMCP{name: name, progress: make(map[string]func(string))}
 	client := mcp.NewClient(&mcp.Implementation{
 		Name:    "ofc",
 		Version: "0.1.0",
-	}, nil)
+	}, &mcp.ClientOptions{
+		ProgressNotificationHandler: e.onProgress,
+	})
 
 	session, err := client.Connect(ctx, transport, nil)
 	if err != nil {
-		return nil, fmt.Errorf("connect to MCP server %q (%s): %w", name, command, err)
+		return nil, fmt.Errorf("connect to MCP server %q (%s): %w", name, label, err)
 	}
 
 	// Discover tools
@@ -45,11 +60,9 @@ func NewExternalMCP(ctx context.Context, name, command string, args []string) (*
 		tools = append(tools, convertMCPTool(tool))
 	}
 
-	return &ExternalMCP{
-		name:    name,
-		session: session,
-		tools:   tools,
-	}, nil
+	e.session = session
+	e.tools = tools
+	return e, nil
 }
 
 func (e *ExternalMCP) Name() string  { return e.name }
@@ -57,10 +70,32 @@ func (e *ExternalMCP) Tools() []Tool { return e.tools }
 
 // Call proxies a tool invocation to the external MCP server.
 func (e *ExternalMCP) Call(toolName string, args map[string]interface{}) (interface{}, error) {
-	result, err := e.session.CallTool(context.Background(), &mcp.CallToolParams{
+	return e.CallStream(toolName, args, nil)
+}
+
+// CallStream is Call, asking the server for progress notifications and
+// passing their messages to onProgress.
+func (e *ExternalMCP) CallStream(toolName string, args map[string]interface{}, onProgress func(string)) (interface{}, error) {
+	params := &mcp.CallToolParams{
 		Name:      toolName,
 		Arguments: args,
-	})
+	}
+	if onProgress != nil {
+		token := fmt.Sprintf("ofc-%d", e.nextToken.Add(1))
+		// SetProgressToken drops the token when Meta is nil.
+		params.Meta = mcp.Meta{}
+		params.SetProgressToken(token)
+		e.progressMu.Lock()
+		e.progress[token] = onProgress
+		e.progressMu.Unlock()
+		defer func() {
+			e.progressMu.Lock()
+			delete(e.progress, token)
+			e.progressMu.Unlock()
+		}()
+	}
+
+	result, err := e.session.CallTool(context.Background(), params)
 	if err != nil {
 		return nil, fmt.Errorf("call tool %q on %q: %w", toolName, e.name, err)
 	}
@@ -72,6 +107,33 @@ func (e *ExternalMCP) Call(toolName string, args map[string]interface{}) (interf
 	return extractTextContent(result.Content), nil
 }
 
+// onProgress hands a progress notification to the call it belongs to. The
+// lock is held while calling it so it can't run after CallStream returns.
+func (e *ExternalMCP) onProgress(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+	e.progressMu.Lock()
+	defer e.progressMu.Unlock()
+	token, _ := req.Params.ProgressToken.(string)
+	if fn, ok := e.progress[token]; ok {
+		fn(formatProgress(req.Params))
+	}
+}
+
+// formatProgress describes a progress notification: its message, with the
+// count when the server gives a total ("indexing (3/10)").
+func formatProgress(p *mcp.ProgressNotificationParams) string {
+	count := fmt.Sprintf("%g", p.Progress)
+	if p.Total > 0 {
+		count = fmt.Sprintf("%g/%g", p.Progress, p.Total)
+	}
+	if p.Message == "" {
+		return count
+	}
+	if p.Total > 0 {
+		return fmt.Sprintf("%s (%s)", p.Message, count)
+	}
+	return p.Message
+}
+
 // Close shuts down the MCP session and kills the subprocess.
 func (e *ExternalMCP) Close() error {
 	if e.session != nil {
@@ -94,6 +156,8 @@ func convertMCPTool(t *mcp.Tool) Tool {
 		Name:        t.Name,
 		Description: t.Description,
 		Parameters:  params,
+		// MCP tools may change state unless the server hints otherwise.
+		Mutating: t.Annotations == nil || !t.Annotations.ReadOnlyHint,
 	}
 }
 
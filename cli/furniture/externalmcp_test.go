@@ -3,7 +3,10 @@ package furniture
 import (
 	"context"
 	"os/exec"
+	"strings"
 	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func TestExternalMCP_Everything(t *testing.T) {
@@ -44,3 +47,75 @@ func TestExternalMCP_Everything(t *testing.T) {
 		}
 	}
 }
+
+// countdown reports a progress message per step when streaming.
+type countdown struct{}
+
+func (countdown) Name() string { return "countdown" }
+func (countdown) Tools() []Tool {
+	return []Tool{{Name: "run", Description: "Counts down.", Parameters: map[string]interface{}{"type": "object"}}}
+}
+func (c countdown) Call(tool string, args map[string]interface{}) (interface{}, error) {
+	return c.CallStream(tool, args, nil)
+}
+func (countdown) CallStream(_ string, _ map[string]interface{}, onProgress func(string)) (interface{}, error) {
+	for _, step := range []string{"three", "two", "one"} {
+		if onProgress != nil {
+			onProgress(step)
+		}
+	}
+	return "liftoff", nil
+}
+
+func TestExternalMCPProgress(t *testing.T) {
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := WrapAsMCP(countdown{}).Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	ext, err := connectMCP(ctx, "countdown", "in-memory", clientTransport)
+	if err != nil {
+		t.Fatalf("connectMCP: %v", err)
+	}
+	defer ext.Close()
+
+	progress := make(chan string, 10)
+	result, err := ext.CallStream("run", map[string]interface{}{}, func(msg string) { progress <- msg })
+	if err != nil {
+		t.Fatalf("CallStream: %v", err)
+	}
+	if !strings.Contains(result.(string), "liftoff") {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	// Notifications are asynchronous; any that arrived came in order.
+	close(progress)
+	var got []string
+	for msg := range progress {
+		got = append(got, msg)
+	}
+	if want := "three,two,one"; strings.Join(got, ",") != want {
+		t.Errorf("progress = %q, want %q", got, want)
+	}
+
+	if _, err := ext.Call("run", map[string]interface{}{}); err != nil {
+		t.Errorf("Call: %v", err)
+	}
+}
+
+func TestFormatProgress(t *testing.T) {
+	tests := []struct {
+		params mcp.ProgressNotificationParams
+		want   string
+	}{
+		{mcp.ProgressNotificationParams{Message: "indexing", Progress: 3, Total: 10}, "indexing (3/10)"},
+		{mcp.ProgressNotificationParams{Message: "indexing", Progress: 3}, "indexing"},
+		{mcp.ProgressNotificationParams{Progress: 3, Total: 10}, "3/10"},
+		{mcp.ProgressNotificationParams{Progress: 0.5}, "0.5"},
+	}
+	for _, tt := range tests {
+		if got := formatProgress(&tt.params); got != tt.want {
+			t.Errorf("formatProgress(%+v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package furniture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFetchMaxBytes     = 1 << 20 // 1 MiB
+	defaultFetchTimeout      = 15 * time.Second
+	defaultFetchMaxRedirects = 5
+)
+
+// Fetch is furniture that retrieves the content of a URL.
+//
+// Config keys:
+//   - allowed_schemes: comma-separated URL schemes (default "http,https")
+//   - allowed_domains: comma-separated domains; subdomains match too (default: any)
+//   - max_bytes: maximum body size returned (default 1048576)
+//   - max_redirects: redirects to follow (default 5)
+//   - timeout: request timeout (default "15s")
+type Fetch struct {
+	name     string
+	schemes  map[string]bool
+	domains  []string
+	maxBytes int
+	client   *http.Client
+}
+
+// NewFetch creates a fetch furniture from its blueprint config.
+func NewFetch(name string, cfg map[string]string) (*Fetch, error) {
+	maxBytes, err := configInt(cfg, "max_bytes", defaultFetchMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	maxRedirects, err := configInt(cfg, "max_redirects", defaultFetchMaxRedirects)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := configDuration(cfg, "timeout", defaultFetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	schemes := make(map[string]bool)
+	for _, s := range splitList(cfg["allowed_schemes"]) {
+		schemes[strings.ToLower(s)] = true
+	}
+	if len(schemes) == 0 {
+		schemes["http"] = true
+		schemes["https"] = true
+	}
+
+	f := &Fetch{
+		name:     name,
+		schemes:  schemes,
+		domains:  splitList(strings.ToLower(cfg["allowed_domains"])),
+		maxBytes: maxBytes,
+	}
+	f.client = &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return f.checkURL(req.URL)
+		},
+	}
+	return f, nil
+}
+
+func (f *Fetch) Name() string { return f.name }
+
+func (f *Fetch) Tools() []Tool {
+	return []Tool{
+		{
+			Name:        "fetch_url",
+			Description: "Fetch a URL with HTTP GET. Returns status, content type, and body (HTML converted to text by default).",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "The URL to fetch",
+					},
+					"raw": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Return HTML as-is instead of converting it to text (default false)",
+					},
+				},
+				"required": []string{"url"},
+			},
+		},
+	}
+}
+
+func (f *Fetch) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	switch toolName {
+	case "fetch_url":
+		return f.fetchURL(args)
+	default:
+		return nil, &ErrUnknownTool{Furniture: f.name, Tool: toolName}
+	}
+}
+
+func (f *Fetch) fetchURL(args map[string]interface{}) (interface{}, error) {
+	rawURL, _ := args["url"].(string)
+	if rawURL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	raw, _ := args["raw"].(bool)
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if err := f.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	// Read one extra byte to detect truncation
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(f.maxBytes)+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	truncated := len(data) > f.maxBytes
+	if truncated {
+		data = data[:f.maxBytes]
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	body := string(data)
+	if !raw && strings.Contains(strings.ToLower(contentType), "html") {
+		body = htmlToText(body)
+	}
+
+	return map[string]interface{}{
+		"url":          resp.Request.URL.String(),
+		"status":       resp.StatusCode,
+		"content_type": contentType,
+		"body":         body,
+		"truncated":    truncated,
+	}, nil
+}
+
+// checkURL enforces the scheme and domain allowlists.
+func (f *Fetch) checkURL(u *url.URL) error {
+	if !f.schemes[strings.ToLower(u.Scheme)] {
+		return fmt.Errorf("scheme %q not allowed", u.Scheme)
+	}
+	if len(f.domains) == 0 {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, d := range f.domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return nil
+		}
+	}
+	return fmt.Errorf("domain %q not allowed", host)
+}
+
+// splitList splits a comma-separated config value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+var (
+	htmlDropRe  = regexp.MustCompile(`(?is)<(script|style|noscript|head)\b.*?</(script|style|noscript|head)>|<!--.*?-->`)
+	htmlBlockRe = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/li|/h[1-6]|/tr|/title)\s*/?>`)
+	htmlTagRe   = regexp.MustCompile(`<[^>]*>`)
+	blankRunRe  = regexp.MustCompile(`\n\s*\n+`)
+	spaceRunRe  = regexp.MustCompile(`[ \t\r\f]+`)
+)
+
+// htmlToText converts HTML to readable plain text. It is deliberately
+// simple: drop scripts/styles, turn block ends into newlines, strip tags,
+// unescape entities, and collapse whitespace.
+func htmlToText(s string) string {
+	s = htmlDropRe.ReplaceAllString(s, "")
+	s = htmlBlockRe.ReplaceAllString(s, "\n")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = spaceRunRe.ReplaceAllString(s, " ")
+	s = blankRunRe.ReplaceAllString(s, "\n\n")
+
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
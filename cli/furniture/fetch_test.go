@@ -0,0 +1,99 @@
+package furniture
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestFetchHTMLToText(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<html><head><title>x</title><style>p{}</style></head>
+<body><h1>Hello</h1><p>Fish &amp; chips</p><script>alert(1)</script></body></html>`)
+		}
+	}))
+	defer srv.Close()
+
+	f, err := NewFetch("web", nil)
+	if err != nil {
+		t.Fatalf("NewFetch: %v", err)
+	}
+
+	result, err := f.Call("fetch_url", map[string]interface{}{"url": srv.URL + "/old"})
+	if err != nil {
+		t.Fatalf("fetch_url: %v", err)
+	}
+	res := result.(map[string]interface{})
+	if res["status"] != 200 {
+		t.Errorf("expected status 200, got %v", res["status"])
+	}
+	if res["url"] != srv.URL+"/page" {
+		t.Errorf("expected final URL after redirect, got %v", res["url"])
+	}
+	if body := res["body"].(string); body != "Hello\nFish & chips" {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
+
+func TestFetchLimits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/loop" {
+			http.Redirect(w, r, "/loop", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, strings.Repeat("a", 100))
+	}))
+	defer srv.Close()
+
+	f, err := NewFetch("web", map[string]string{"max_bytes": "10", "max_redirects": "2"})
+	if err != nil {
+		t.Fatalf("NewFetch: %v", err)
+	}
+
+	result, err := f.Call("fetch_url", map[string]interface{}{"url": srv.URL})
+	if err != nil {
+		t.Fatalf("fetch_url: %v", err)
+	}
+	res := result.(map[string]interface{})
+	if res["body"] != "aaaaaaaaaa" || res["truncated"] != true {
+		t.Errorf("expected truncated body, got %+v", res)
+	}
+
+	if _, err := f.Call("fetch_url", map[string]interface{}{"url": srv.URL + "/loop"}); err == nil {
+		t.Error("expected redirect limit error")
+	}
+	if _, err := f.Call("fetch_url", map[string]interface{}{"url": "file:///etc/passwd"}); err == nil {
+		t.Error("expected scheme error")
+	}
+}
+
+func TestFetchDomainAllowlist(t *testing.T) {
+	f, err := NewFetch("web", map[string]string{"allowed_domains": "example.com"})
+	if err != nil {
+		t.Fatalf("NewFetch: %v", err)
+	}
+	if _, err := f.Call("fetch_url", map[string]interface{}{"url": "http://127.0.0.1/"}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected domain error, got %v", err)
+	}
+	u := mustParseURL(t, "https://docs.example.com/x")
+	if err := f.checkURL(u); err != nil {
+		t.Errorf("subdomain should be allowed: %v", err)
+	}
+}
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
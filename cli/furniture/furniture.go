@@ -1,13 +1,18 @@
 // Package furniture defines the interface for shared interactive objects on the floor.
 package furniture
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
 
 // Tool describes a single capability offered by a piece of furniture.
 type Tool struct {
 	Name        string
 	Description string
 	Parameters  map[string]interface{} // JSON Schema
+	Mutating    bool                   // changes state; left out when the furniture is read-only
 }
 
 // Furniture is the interface for all furniture implementations.
@@ -25,6 +30,31 @@ type Furniture interface {
 	Call(toolName string, args map[string]interface{}) (interface{}, error)
 }
 
+// StreamingFurniture is furniture whose tools can report progress while
+// they run, such as a slow search or a long MCP computation. The floor
+// prefers CallStream when furniture offers it and shows each message to
+// the user; plain Call is still used where progress has nowhere to go.
+type StreamingFurniture interface {
+	Furniture
+
+	// CallStream is Call, calling onProgress with a short message as the
+	// tool makes progress. onProgress may be nil. It may be called from
+	// another goroutine, but never after CallStream returns.
+	CallStream(toolName string, args map[string]interface{}, onProgress func(string)) (interface{}, error)
+}
+
+// SerialFurniture is furniture that shares something outside itself with
+// other furniture, such as a git repository. The floor never runs calls to
+// furniture with the same SerialKey at the same time, even when an agent
+// makes them in one reply.
+type SerialFurniture interface {
+	Furniture
+
+	// SerialKey names what is shared, e.g. "git:workspace". "" means
+	// nothing is.
+	SerialKey() string
+}
+
 // ErrUnknownTool is returned when a tool name is not recognized.
 type ErrUnknownTool struct {
 	Furniture string
@@ -34,3 +64,40 @@ type ErrUnknownTool struct {
 func (e *ErrUnknownTool) Error() string {
 	return fmt.Sprintf("furniture %q has no tool %q", e.Furniture, e.Tool)
 }
+
+// ErrUnknownType is returned when a blueprint names an unregistered furniture type.
+type ErrUnknownType struct {
+	Type string
+}
+
+func (e *ErrUnknownType) Error() string {
+	return fmt.Sprintf("unknown furniture type %q", e.Type)
+}
+
+// configInt reads an integer from a furniture config map, returning def if
+// the key is absent.
+func configInt(cfg map[string]string, key string, def int) (int, error) {
+	v, ok := cfg[key]
+	if !ok || v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("config %s: invalid integer %q", key, v)
+	}
+	return n, nil
+}
+
+// configDuration reads a duration (e.g. "10s") from a furniture config map,
+// returning def if the key is absent.
+func configDuration(cfg map[string]string, key string, def time.Duration) (time.Duration, error) {
+	v, ok := cfg[key]
+	if !ok || v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("config %s: invalid duration %q", key, v)
+	}
+	return d, nil
+}
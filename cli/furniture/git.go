@@ -0,0 +1,434 @@
+package furniture
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const defaultGitAllow = "status,diff,log,commit"
+
+// gitTools are all the tools Git can offer; config.allow picks among them.
+var gitTools = []string{"status", "diff", "log", "commit", "checkout"}
+
+// gitExitMarker ends the output of a git command run through an executor,
+// which reports only the combined output, followed by the exit status.
+const gitExitMarker = "__ofc_git_exit="
+
+// Git is furniture that gives agents structured access to the workspace
+// git repository: status, diff, log, commit, and checkout, with parsed
+// results instead of raw git output. Commits made this way can be reviewed
+// as ordinary history after the session.
+//
+// Only the tools in config.allow are offered. checkout, which can switch
+// the files under other agents' feet, is off by default, and it refuses to
+// run with uncommitted changes.
+//
+// git runs in the workspace directory on the host, or through an executor
+// attached via WithExec (typically the sandbox's Execute).
+//
+// Config keys:
+//   - root: workspace directory (default "workspace", the sandbox mount)
+//   - allow: comma-separated tools to offer (default "status,diff,log,commit")
+//   - user_name, user_email: identity for commits (default: git's own config)
+type Git struct {
+	name      string
+	root      string
+	allow     map[string]bool
+	userName  string
+	userEmail string
+	exec      func(cmd string) (string, error)
+}
+
+// NewGit creates a git furniture.
+func NewGit(name string, cfg map[string]string) (*Git, error) {
+	root := cfg["root"]
+	if root == "" {
+		root = defaultWorkspaceRoot
+	}
+	allowList := defaultGitAllow
+	if v, ok := cfg["allow"]; ok {
+		allowList = v
+	}
+	allow := make(map[string]bool)
+	for _, t := range splitList(allowList) {
+		if !slices.Contains(gitTools, t) {
+			return nil, fmt.Errorf("git furniture %q: unknown tool %q in allow (want %s)", name, t, strings.Join(gitTools, ", "))
+		}
+		allow[t] = true
+	}
+	return &Git{
+		name:      name,
+		root:      root,
+		allow:     allow,
+		userName:  cfg["user_name"],
+		userEmail: cfg["user_email"],
+	}, nil
+}
+
+// WithExec runs git through exec, in the workspace directory (typically
+// the sandbox's Execute, whose working directory is the mount).
+func (g *Git) WithExec(exec func(cmd string) (string, error)) *Git {
+	g.exec = exec
+	return g
+}
+
+func (g *Git) Name() string { return g.name }
+
+// SerialKey keeps git commands from overlapping with other furniture using
+// the same repository, such as patch's show_diff, since git holds a lock
+// on the index while it works.
+func (g *Git) SerialKey() string { return gitSerialKey(g.root) }
+
+// gitSerialKey is the SerialKey of furniture running git in root.
+func gitSerialKey(root string) string { return "git:" + filepath.Clean(root) }
+
+func (g *Git) Tools() []Tool {
+	all := []Tool{
+		{
+			Name:        "status",
+			Description: "Show the current branch and the changed, staged, and untracked files.",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "diff",
+			Description: "Show uncommitted changes as a unified diff, with per-file line counts.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path":   map[string]interface{}{"type": "string", "description": "Limit to this file or directory (optional)"},
+					"staged": map[string]interface{}{"type": "boolean", "description": "Show staged changes instead of unstaged ones"},
+				},
+			},
+		},
+		{
+			Name:        "log",
+			Description: "List recent commits, newest first.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"count": map[string]interface{}{"type": "integer", "description": "Number of commits (default 10, max 100)"},
+					"path":  map[string]interface{}{"type": "string", "description": "Only commits touching this file or directory (optional)"},
+				},
+			},
+		},
+		{
+			Name:        "commit",
+			Mutating:    true,
+			Description: "Stage changes and commit them. Stages all changes unless paths are given.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"message": map[string]interface{}{"type": "string", "description": "Commit message"},
+					"paths": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Files or directories to commit (default: everything)",
+					},
+				},
+				"required": []string{"message"},
+			},
+		},
+		{
+			Name:        "checkout",
+			Mutating:    true,
+			Description: "Switch to a branch or commit, optionally creating a new branch. Refuses if there are uncommitted changes.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"ref":    map[string]interface{}{"type": "string", "description": "Branch, tag, or commit"},
+					"create": map[string]interface{}{"type": "boolean", "description": "Create ref as a new branch from the current commit"},
+				},
+				"required": []string{"ref"},
+			},
+		},
+	}
+	var tools []Tool
+	for _, t := range all {
+		if g.allow[t.Name] {
+			tools = append(tools, t)
+		}
+	}
+	return tools
+}
+
+func (g *Git) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	if !slices.Contains(gitTools, toolName) {
+		return nil, &ErrUnknownTool{Furniture: g.name, Tool: toolName}
+	}
+	if !g.allow[toolName] {
+		return nil, fmt.Errorf("git %s is not allowed on this floor (add it to the furniture's allow list)", toolName)
+	}
+	switch toolName {
+	case "status":
+		return g.status()
+	case "diff":
+		return g.diff(args)
+	case "log":
+		return g.log(args)
+	case "commit":
+		return g.commit(args)
+	default:
+		return g.checkout(args)
+	}
+}
+
+// GitStatus is the result of the status tool.
+type GitStatus struct {
+	Branch   string          `json:"branch"` // "" when detached
+	Upstream string          `json:"upstream,omitempty"`
+	Ahead    int             `json:"ahead,omitempty"`
+	Behind   int             `json:"behind,omitempty"`
+	Clean    bool            `json:"clean"`
+	Files    []GitFileStatus `json:"files"`
+}
+
+// GitFileStatus is one changed file, with git's two-letter status split
+// into its index (staged) and worktree (unstaged) halves.
+type GitFileStatus struct {
+	Path     string `json:"path"`
+	Staged   string `json:"staged,omitempty"`   // e.g. "M", "A", "D", "R"
+	Unstaged string `json:"unstaged,omitempty"` // e.g. "M", "D", "?" (untracked)
+}
+
+var branchLineRe = regexp.MustCompile(`^## (?:No commits yet on |Initial commit on )?(\S+?)(?:\.\.\.(\S+))?(?: \[(.*)\])?$`)
+
+func (g *Git) status() (*GitStatus, error) {
+	out, err := g.git("status", "--porcelain=v1", "--branch", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	st := &GitStatus{Files: []GitFileStatus{}}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			if m := branchLineRe.FindStringSubmatch(line); m != nil && !strings.HasPrefix(line, "## HEAD (no branch)") {
+				st.Branch, st.Upstream = m[1], m[2]
+				for _, part := range strings.Split(m[3], ", ") {
+					if n, ok := strings.CutPrefix(part, "ahead "); ok {
+						st.Ahead, _ = strconv.Atoi(n)
+					} else if n, ok := strings.CutPrefix(part, "behind "); ok {
+						st.Behind, _ = strconv.Atoi(n)
+					}
+				}
+			}
+			continue
+		}
+		if len(line) < 4 {
+			continue
+		}
+		f := GitFileStatus{Path: line[3:]}
+		f.Staged = strings.TrimSpace(line[0:1])
+		f.Unstaged = strings.TrimSpace(line[1:2])
+		if f.Staged == "?" {
+			f.Staged = ""
+		}
+		if _, to, ok := strings.Cut(f.Path, " -> "); ok {
+			f.Path = to
+		}
+		st.Files = append(st.Files, f)
+	}
+	st.Clean = len(st.Files) == 0
+	return st, nil
+}
+
+func (g *Git) diff(args map[string]interface{}) (interface{}, error) {
+	base := []string{"diff"}
+	if staged, _ := args["staged"].(bool); staged {
+		base = append(base, "--cached")
+	}
+	var pathspec []string
+	if p, _ := args["path"].(string); p != "" {
+		rel, err := workspacePath(p)
+		if err != nil {
+			return nil, err
+		}
+		pathspec = []string{"--", rel}
+	}
+
+	numstat, err := g.git(append(append(append([]string{}, base...), "--numstat"), pathspec...)...)
+	if err != nil {
+		return nil, err
+	}
+	files := []map[string]interface{}{}
+	for _, line := range strings.Split(numstat, "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		added, _ := strconv.Atoi(parts[0]) // "-" for binary files
+		deleted, _ := strconv.Atoi(parts[1])
+		files = append(files, map[string]interface{}{"path": parts[2], "added": added, "deleted": deleted})
+	}
+	diff, err := g.git(append(base, pathspec...)...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"files": files, "diff": diff}, nil
+}
+
+// GitCommit is one entry of the log tool's result.
+type GitCommit struct {
+	Hash    string `json:"hash"`
+	Author  string `json:"author"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+}
+
+func (g *Git) log(args map[string]interface{}) (interface{}, error) {
+	count := 10
+	if _, ok := args["count"]; ok {
+		n, err := intArg(args, "count")
+		if err != nil {
+			return nil, err
+		}
+		count = max(1, min(n, 100))
+	}
+	gitArgs := []string{"log", "-n", strconv.Itoa(count), "--format=%H%x1f%an%x1f%aI%x1f%s"}
+	if p, _ := args["path"].(string); p != "" {
+		rel, err := workspacePath(p)
+		if err != nil {
+			return nil, err
+		}
+		gitArgs = append(gitArgs, "--", rel)
+	}
+	out, err := g.git(gitArgs...)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not have any commits") {
+			return []GitCommit{}, nil
+		}
+		return nil, err
+	}
+	commits := []GitCommit{}
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(line, "\x1f")
+		if len(f) != 4 {
+			continue
+		}
+		commits = append(commits, GitCommit{Hash: f[0], Author: f[1], Date: f[2], Subject: f[3]})
+	}
+	return commits, nil
+}
+
+func (g *Git) commit(args map[string]interface{}) (interface{}, error) {
+	message, _ := args["message"].(string)
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("message is required")
+	}
+	add := []string{"add", "--all", "--"}
+	if paths, ok := args["paths"].([]interface{}); ok && len(paths) > 0 {
+		for _, p := range paths {
+			s, _ := p.(string)
+			rel, err := workspacePath(s)
+			if err != nil {
+				return nil, err
+			}
+			add = append(add, rel)
+		}
+	} else {
+		add = append(add, ".")
+	}
+	if _, err := g.git(add...); err != nil {
+		return nil, err
+	}
+
+	var commit []string
+	if g.userName != "" {
+		commit = append(commit, "-c", "user.name="+g.userName)
+	}
+	if g.userEmail != "" {
+		commit = append(commit, "-c", "user.email="+g.userEmail)
+	}
+	commit = append(commit, "commit", "--quiet", "-m", message)
+	if _, err := g.git(commit...); err != nil {
+		if strings.Contains(err.Error(), "nothing to commit") || strings.Contains(err.Error(), "no changes added") {
+			return nil, fmt.Errorf("nothing to commit")
+		}
+		return nil, err
+	}
+
+	hash, err := g.git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	files, err := g.git("diff-tree", "--no-commit-id", "--name-only", "-r", "--root", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"hash":    hash,
+		"subject": strings.SplitN(message, "\n", 2)[0],
+		"files":   strings.Fields(files),
+	}, nil
+}
+
+func (g *Git) checkout(args map[string]interface{}) (interface{}, error) {
+	ref, _ := args["ref"].(string)
+	if ref == "" || strings.HasPrefix(ref, "-") || strings.ContainsAny(ref, " \t\n") {
+		return nil, fmt.Errorf("ref %q is not a valid branch, tag, or commit", ref)
+	}
+	st, err := g.status()
+	if err != nil {
+		return nil, err
+	}
+	if !st.Clean {
+		return nil, fmt.Errorf("there are uncommitted changes; commit them first")
+	}
+
+	gitArgs := []string{"checkout", "--quiet"}
+	if create, _ := args["create"].(bool); create {
+		gitArgs = append(gitArgs, "-b")
+	}
+	if _, err := g.git(append(gitArgs, ref)...); err != nil {
+		return nil, err
+	}
+	hash, err := g.git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	branch, _ := g.git("branch", "--show-current")
+	return map[string]interface{}{"branch": branch, "hash": hash}, nil
+}
+
+// git runs a git command in the workspace and returns its trimmed output.
+// A non-zero exit is an error carrying git's message.
+func (g *Git) git(args ...string) (string, error) {
+	var out string
+	var code int
+	if g.exec != nil {
+		quoted := make([]string, len(args))
+		for i, a := range args {
+			quoted[i] = shellQuote(a)
+		}
+		raw, err := g.exec("git " + strings.Join(quoted, " ") + " 2>&1; status=$?; echo; echo " + gitExitMarker + "$status")
+		if err != nil {
+			return "", err
+		}
+		i := strings.LastIndex(raw, gitExitMarker)
+		if i < 0 {
+			return "", fmt.Errorf("git: no exit status in output: %s", raw)
+		}
+		code, _ = strconv.Atoi(strings.TrimSpace(raw[i+len(gitExitMarker):]))
+		out = raw[:i]
+	} else {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = g.root
+		b, err := cmd.CombinedOutput()
+		if _, ok := err.(*exec.ExitError); err != nil && !ok {
+			return "", fmt.Errorf("git: %w", err)
+		}
+		code = cmd.ProcessState.ExitCode()
+		out = string(b)
+	}
+	out = strings.TrimRight(out, " \t\r\n")
+	if code != 0 {
+		return "", fmt.Errorf("git: %s", strings.TrimSpace(out))
+	}
+	return out, nil
+}
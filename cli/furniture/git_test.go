@@ -0,0 +1,193 @@
+package furniture
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a repository with one commit of files and returns its root.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := makeTree(t, files)
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return root
+}
+
+func newTestGit(t *testing.T, root string, cfg map[string]string) *Git {
+	t.Helper()
+	cfg["root"] = root
+	cfg["user_name"] = "agent"
+	cfg["user_email"] = "agent@example.com"
+	g, err := NewGit("git", cfg)
+	if err != nil {
+		t.Fatalf("NewGit: %v", err)
+	}
+	return g
+}
+
+func TestGitStatusDiffCommitLog(t *testing.T) {
+	root := gitRepo(t, map[string]string{"a.txt": "one\n", "b.txt": "b\n"})
+	g := newTestGit(t, root, map[string]string{})
+
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("two\n"), 0644)
+	os.WriteFile(filepath.Join(root, "new.txt"), []byte("new\n"), 0644)
+
+	result, err := g.Call("status", nil)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	st := result.(*GitStatus)
+	if st.Branch != "main" || st.Clean || len(st.Files) != 2 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+	if f := st.Files[0]; f.Path != "a.txt" || f.Unstaged != "M" || f.Staged != "" {
+		t.Errorf("a.txt: %+v", f)
+	}
+	if f := st.Files[1]; f.Path != "new.txt" || f.Unstaged != "?" {
+		t.Errorf("new.txt: %+v", f)
+	}
+
+	result, err = g.Call("diff", map[string]interface{}{"path": "a.txt"})
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	diff := result.(map[string]interface{})
+	files := diff["files"].([]map[string]interface{})
+	if len(files) != 1 || files[0]["added"] != 1 || files[0]["deleted"] != 1 || !strings.Contains(diff["diff"].(string), "+two") {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+
+	result, err = g.Call("commit", map[string]interface{}{"message": "Update a\n\nDetails.", "paths": []interface{}{"a.txt"}})
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	commit := result.(map[string]interface{})
+	if commit["subject"] != "Update a" || len(commit["hash"].(string)) != 40 || strings.Join(commit["files"].([]string), ",") != "a.txt" {
+		t.Errorf("unexpected commit: %+v", commit)
+	}
+
+	result, err = g.Call("log", map[string]interface{}{"count": float64(5)})
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	log := result.([]GitCommit)
+	if len(log) != 2 || log[0].Subject != "Update a" || log[0].Author != "agent" || log[1].Subject != "initial" {
+		t.Errorf("unexpected log: %+v", log)
+	}
+
+	// new.txt was left out of the commit.
+	if _, err := g.Call("commit", map[string]interface{}{"message": "rest"}); err != nil {
+		t.Fatalf("commit everything: %v", err)
+	}
+	if _, err := g.Call("commit", map[string]interface{}{"message": "again"}); err == nil || err.Error() != "nothing to commit" {
+		t.Errorf("expected nothing to commit, got %v", err)
+	}
+}
+
+func TestGitCheckoutIsGuarded(t *testing.T) {
+	root := gitRepo(t, map[string]string{"a.txt": "one\n"})
+
+	g := newTestGit(t, root, map[string]string{})
+	for _, tool := range g.Tools() {
+		if tool.Name == "checkout" {
+			t.Error("checkout should not be offered by default")
+		}
+	}
+	if _, err := g.Call("checkout", map[string]interface{}{"ref": "main"}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected checkout to be refused, got %v", err)
+	}
+
+	g = newTestGit(t, root, map[string]string{"allow": "status,checkout"})
+	if len(g.Tools()) != 2 {
+		t.Errorf("expected only the allowed tools, got %d", len(g.Tools()))
+	}
+	if _, err := g.Call("commit", map[string]interface{}{"message": "x"}); err == nil {
+		t.Error("commit should not be allowed")
+	}
+	if _, err := g.Call("checkout", map[string]interface{}{"ref": "--orphan"}); err == nil {
+		t.Error("expected an option-like ref to be rejected")
+	}
+
+	result, err := g.Call("checkout", map[string]interface{}{"ref": "feature", "create": true})
+	if err != nil {
+		t.Fatalf("checkout -b: %v", err)
+	}
+	if result.(map[string]interface{})["branch"] != "feature" {
+		t.Errorf("unexpected checkout result: %+v", result)
+	}
+
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("dirty\n"), 0644)
+	if _, err := g.Call("checkout", map[string]interface{}{"ref": "main"}); err == nil || !strings.Contains(err.Error(), "uncommitted") {
+		t.Errorf("expected checkout to refuse a dirty tree, got %v", err)
+	}
+
+	if _, err := NewGit("git", map[string]string{"allow": "status,push"}); err == nil {
+		t.Error("expected an unknown tool in allow to be rejected")
+	}
+}
+
+func TestGitThroughExecutor(t *testing.T) {
+	root := gitRepo(t, map[string]string{"a.txt": "one\n"})
+	var commands []string
+	g := newTestGit(t, root, map[string]string{}).WithExec(func(cmd string) (string, error) {
+		commands = append(commands, cmd)
+		c := exec.Command("bash", "-c", cmd)
+		c.Dir = root
+		out, _ := c.CombinedOutput()
+		return strings.TrimSpace(string(out)), nil // like the sandbox: output only
+	})
+
+	result, err := g.Call("status", nil)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if st := result.(*GitStatus); !st.Clean || st.Branch != "main" {
+		t.Errorf("unexpected status: %+v", st)
+	}
+	if _, err := g.Call("log", map[string]interface{}{"path": "missing.txt"}); err != nil {
+		t.Errorf("log of an untracked path: %v", err)
+	}
+	if _, err := g.Call("diff", map[string]interface{}{"path": "../outside"}); err == nil {
+		t.Error("expected a path outside the workspace to be rejected")
+	}
+	if _, err := g.git("rev-parse", "no-such-ref"); err == nil {
+		t.Error("expected a failing git command to be an error through the executor")
+	}
+	if len(commands) == 0 || !strings.HasPrefix(commands[0], "git 'status'") {
+		t.Errorf("expected git to run through the executor, got %q", commands)
+	}
+}
+
+func TestGitSharesSerialKeyWithPatch(t *testing.T) {
+	g, err := NewGit("git", map[string]string{"root": "/workspace/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPatcher("patch", map[string]string{"root": "/workspace"})
+	if g.SerialKey() != p.SerialKey() {
+		t.Errorf("git key %q != patch key %q, want one key per repository", g.SerialKey(), p.SerialKey())
+	}
+	if ro := ReadOnly(g).(SerialFurniture); ro.SerialKey() != g.SerialKey() {
+		t.Errorf("read-only git key = %q, want %q", ro.SerialKey(), g.SerialKey())
+	}
+	other := NewPatcher("patch", map[string]string{"root": "/elsewhere"})
+	if other.SerialKey() == g.SerialKey() {
+		t.Errorf("furniture in different repositories share key %q", g.SerialKey())
+	}
+}
@@ -0,0 +1,129 @@
+package furniture
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one .gitignore pattern, scoped to the directory holding the
+// file that defines it.
+type ignoreRule struct {
+	base     string // directory of the .gitignore, relative to the root ("" = root)
+	re       *regexp.Regexp
+	negate   bool // "!pattern" re-includes a path
+	dirOnly  bool // "pattern/" matches directories only
+	anchored bool // the pattern has a slash, so it matches the path below base, not just the name
+}
+
+// ignoreRules is an ordered list of rules; later rules take precedence,
+// as in git.
+type ignoreRules []ignoreRule
+
+// parseIgnore parses .gitignore content for the directory base. It supports
+// comments, negation, trailing-slash directory patterns, leading-slash
+// anchoring, and the wildcards *, ?, [...] and **.
+func parseIgnore(base, content string) ignoreRules {
+	var rules ignoreRules
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r ignoreRule
+		r.base = base
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:] // \# and \! escape a leading # or !
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+		if err != nil {
+			continue // git skips patterns it cannot parse, too
+		}
+		r.re = re
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// ignored reports whether rel (a "/"-separated path relative to the root)
+// is ignored: the last rule that matches it decides.
+func (rules ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.match(rel, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	sub := rel
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		sub = rel[len(r.base)+1:]
+	}
+	if r.anchored {
+		return r.re.MatchString(sub)
+	}
+	return r.re.MatchString(path.Base(sub))
+}
+
+// globToRegexp translates a gitignore glob to a regular expression body.
+// "*" and "?" do not cross "/"; "**" as a whole path segment matches any
+// number of directories.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case glob[i:] == "**" && i > 0 && glob[i-1] == '/':
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,161 @@
+package furniture
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Note is a message left in a Mailbox.
+type Note struct {
+	From    string `json:"from"`
+	To      string `json:"to"` // an agent ID, or "@everyone"
+	Message string `json:"message"`
+	Sent    string `json:"sent"` // RFC 3339
+}
+
+// Mailbox is furniture for directed notes between agents. An agent posts a
+// note to another agent, or to everyone, and each recipient reads it once
+// with check. Unlike the transcript, a note waits until it is read, however
+// long the conversation has grown or been trimmed since.
+//
+// Furniture calls don't say which agent is calling, so agents name
+// themselves in from and agent.
+type Mailbox struct {
+	name  string
+	mu    sync.Mutex
+	notes []*mailNote
+	now   func() time.Time
+}
+
+type mailNote struct {
+	Note
+	readBy map[string]bool // recipients who have checked it
+}
+
+// everyone addresses a note to every agent but the sender.
+const everyone = "@everyone"
+
+// NewMailbox creates an empty mailbox.
+func NewMailbox(name string) *Mailbox {
+	return &Mailbox{name: name, now: time.Now}
+}
+
+func (m *Mailbox) Name() string { return m.name }
+
+func (m *Mailbox) Tools() []Tool {
+	return []Tool{
+		{
+			Name:        "post",
+			Mutating:    true,
+			Description: "Leave a note for another agent (or @everyone), which they read with check on a later turn.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Your own agent ID (e.g. @data)",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "Recipient agent ID (e.g. @coder), or @everyone",
+					},
+					"message": map[string]interface{}{
+						"type":        "string",
+						"description": "The note",
+					},
+				},
+				"required": []string{"from", "to", "message"},
+			},
+		},
+		{
+			Name:        "check",
+			Mutating:    true,
+			Description: "Read the notes left for you since you last checked, oldest first. Each note is returned only once.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"agent": map[string]interface{}{
+						"type":        "string",
+						"description": "Your own agent ID (e.g. @data)",
+					},
+				},
+				"required": []string{"agent"},
+			},
+		},
+	}
+}
+
+func (m *Mailbox) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	switch toolName {
+	case "post":
+		return m.post(args)
+	case "check":
+		return m.check(args)
+	default:
+		return nil, &ErrUnknownTool{Furniture: m.name, Tool: toolName}
+	}
+}
+
+func (m *Mailbox) post(args map[string]interface{}) (interface{}, error) {
+	from, err := agentArg(args, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := agentArg(args, "to")
+	if err != nil {
+		return nil, err
+	}
+	message, _ := args["message"].(string)
+	if strings.TrimSpace(message) == "" {
+		return nil, fmt.Errorf("message is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	note := Note{From: from, To: to, Message: message, Sent: m.now().Format(time.RFC3339)}
+	m.notes = append(m.notes, &mailNote{Note: note, readBy: make(map[string]bool)})
+	return note, nil
+}
+
+func (m *Mailbox) check(args map[string]interface{}) (interface{}, error) {
+	agent, err := agentArg(args, "agent")
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	notes := []Note{}
+	kept := m.notes[:0]
+	for _, n := range m.notes {
+		switch {
+		case n.To == agent:
+			notes = append(notes, n.Note)
+			continue // read: drop it
+		case n.To == everyone && n.From != agent && !n.readBy[agent]:
+			notes = append(notes, n.Note)
+			n.readBy[agent] = true
+		}
+		kept = append(kept, n)
+	}
+	m.notes = kept
+	return map[string]interface{}{
+		"notes": notes,
+		"count": len(notes),
+	}, nil
+}
+
+// agentArg reads an agent ID argument, adding the "@" if it was left off.
+func agentArg(args map[string]interface{}, key string) (string, error) {
+	id, _ := args[key].(string)
+	id = strings.TrimSpace(id)
+	if id == "" || id == "@" {
+		return "", fmt.Errorf("%s is required", key)
+	}
+	if !strings.HasPrefix(id, "@") {
+		id = "@" + id
+	}
+	return id, nil
+}
@@ -0,0 +1,50 @@
+package furniture
+
+import "testing"
+
+func checkMail(t *testing.T, m *Mailbox, agent string) []Note {
+	t.Helper()
+	result, err := m.Call("check", map[string]interface{}{"agent": agent})
+	if err != nil {
+		t.Fatalf("check %s: %v", agent, err)
+	}
+	return result.(map[string]interface{})["notes"].([]Note)
+}
+
+func TestMailbox(t *testing.T) {
+	m := NewMailbox("mail")
+	post := func(from, to, message string) {
+		t.Helper()
+		if _, err := m.Call("post", map[string]interface{}{"from": from, "to": to, "message": message}); err != nil {
+			t.Fatalf("post: %v", err)
+		}
+	}
+	post("@data", "coder", "schema is ready")
+	post("@coder", "@everyone", "tests are green")
+
+	notes := checkMail(t, m, "@coder")
+	if len(notes) != 1 || notes[0].From != "@data" || notes[0].To != "@coder" || notes[0].Message != "schema is ready" {
+		t.Errorf("unexpected notes for @coder: %+v", notes)
+	}
+	if notes := checkMail(t, m, "@coder"); len(notes) != 0 {
+		t.Errorf("notes should be consumed, got %+v", notes)
+	}
+
+	// Broadcasts reach everyone but the sender, once each.
+	for _, agent := range []string{"@data", "@reviewer"} {
+		notes := checkMail(t, m, agent)
+		if len(notes) != 1 || notes[0].Message != "tests are green" {
+			t.Errorf("unexpected notes for %s: %+v", agent, notes)
+		}
+		if notes := checkMail(t, m, agent); len(notes) != 0 {
+			t.Errorf("%s read the broadcast twice: %+v", agent, notes)
+		}
+	}
+
+	if _, err := m.Call("post", map[string]interface{}{"from": "@data", "to": "@coder"}); err == nil {
+		t.Error("expected error for missing message")
+	}
+	if _, err := m.Call("check", map[string]interface{}{}); err == nil {
+		t.Error("expected error for missing agent")
+	}
+}
@@ -31,7 +31,9 @@ func WrapAsMCP(f Furniture) *mcp.Server {
 	return srv
 }
 
-// makeHandler creates a ToolHandler that delegates to the Furniture.Call method.
+// makeHandler creates a ToolHandler that delegates to the Furniture.Call
+// method, or to CallStream when the client asked for progress and the
+// furniture can report it.
 func makeHandler(f Furniture, toolName string) mcp.ToolHandler {
 	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Parse arguments from JSON
@@ -51,7 +53,22 @@ func makeHandler(f Furniture, toolName string) mcp.ToolHandler {
 		}
 
 		// Call the furniture
-		result, err := f.Call(toolName, args)
+		var result interface{}
+		var err error
+		if sf, ok := f.(StreamingFurniture); ok && req.Params.GetProgressToken() != nil {
+			token := req.Params.GetProgressToken()
+			var n float64
+			result, err = sf.CallStream(toolName, args, func(msg string) {
+				n++
+				req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+					ProgressToken: token,
+					Message:       msg,
+					Progress:      n,
+				})
+			})
+		} else {
+			result, err = f.Call(toolName, args)
+		}
 		if err != nil {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
@@ -0,0 +1,485 @@
+package furniture
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Patcher is furniture for editing workspace files with unified diffs. The
+// apply_patch tool applies a diff atomically: every hunk of every file must
+// apply, or nothing is written. The show_diff tool shows uncommitted
+// changes if the workspace is a git repository.
+//
+// Patches are applied to the workspace directory on the host, which the
+// sandbox bind-mounts, so edits are visible there immediately. If an
+// executor is attached via WithExec (typically the sandbox's Execute), git
+// runs through it; otherwise git runs on the host.
+//
+// Config keys:
+//   - root: workspace directory (default "workspace", the sandbox mount)
+type Patcher struct {
+	name string
+	root string
+	exec func(cmd string) (string, error)
+}
+
+// NewPatcher creates a patch furniture.
+func NewPatcher(name string, cfg map[string]string) *Patcher {
+	root := cfg["root"]
+	if root == "" {
+		root = defaultWorkspaceRoot
+	}
+	return &Patcher{name: name, root: root}
+}
+
+// WithExec runs show_diff's git commands through exec, in the workspace
+// directory (typically the sandbox's Execute, whose working directory is
+// the mount).
+func (p *Patcher) WithExec(exec func(cmd string) (string, error)) *Patcher {
+	p.exec = exec
+	return p
+}
+
+func (p *Patcher) Name() string { return p.name }
+
+// SerialKey is the git furniture's for the same workspace (see Git.SerialKey).
+func (p *Patcher) SerialKey() string { return gitSerialKey(p.root) }
+
+func (p *Patcher) Tools() []Tool {
+	return []Tool{
+		{
+			Name:     "apply_patch",
+			Mutating: true,
+			Description: "Apply a unified diff (as produced by diff -u or git diff) to files in the workspace. " +
+				"Paths are relative to the workspace root; a/ and b/ prefixes are stripped. " +
+				"Use /dev/null as the old file to create a file, or as the new file to delete one. " +
+				"Either every hunk applies or no file is changed; the result reports each hunk.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patch": map[string]interface{}{
+						"type":        "string",
+						"description": "The unified diff",
+					},
+				},
+				"required": []string{"patch"},
+			},
+		},
+		{
+			Name:        "show_diff",
+			Description: "Show uncommitted changes in the workspace git repository (git diff against HEAD, plus untracked files).",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Limit the diff to this file or directory (optional)",
+					},
+				},
+			},
+		},
+	}
+}
+
+func (p *Patcher) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	switch toolName {
+	case "apply_patch":
+		patch, _ := args["patch"].(string)
+		if strings.TrimSpace(patch) == "" {
+			return nil, fmt.Errorf("patch is required")
+		}
+		return ApplyPatch(p.root, patch)
+	case "show_diff":
+		dir, _ := args["path"].(string)
+		return p.showDiff(dir)
+	default:
+		return nil, &ErrUnknownTool{Furniture: p.name, Tool: toolName}
+	}
+}
+
+// showDiff runs git diff and git status in the workspace.
+func (p *Patcher) showDiff(dir string) (interface{}, error) {
+	pathspec := ""
+	if dir != "" {
+		rel, err := workspacePath(dir)
+		if err != nil {
+			return nil, err
+		}
+		pathspec = " -- " + shellQuote(rel)
+	}
+	script := "git rev-parse --is-inside-work-tree >/dev/null 2>&1 || { echo 'workspace is not a git repository'; exit 1; }; " +
+		"git status --short" + pathspec + "; echo '---'; git diff HEAD" + pathspec
+
+	var out string
+	if p.exec != nil {
+		var err error
+		if out, err = p.exec(script); err != nil {
+			return nil, err
+		}
+	} else {
+		cmd := exec.Command("bash", "-c", script)
+		cmd.Dir = p.root
+		b, _ := cmd.CombinedOutput()
+		out = strings.TrimSpace(string(b))
+	}
+	if out == "workspace is not a git repository" {
+		return nil, fmt.Errorf("%s", out)
+	}
+
+	status, diff, _ := strings.Cut(out, "---")
+	return map[string]interface{}{
+		"status": strings.TrimSpace(status),
+		"diff":   strings.TrimSpace(diff),
+	}, nil
+}
+
+// PatchResult reports how ApplyPatch went, file by file.
+type PatchResult struct {
+	Applied bool          `json:"applied"` // every hunk applied and the files were written
+	Files   []FilePatched `json:"files"`
+}
+
+// FilePatched is the outcome for one file of a patch.
+type FilePatched struct {
+	Path   string       `json:"path"`
+	Action string       `json:"action"` // "modify", "create", or "delete"
+	Hunks  []HunkResult `json:"hunks"`
+	Error  string       `json:"error,omitempty"` // a problem with the file itself
+}
+
+// HunkResult is the outcome for one hunk.
+type HunkResult struct {
+	Header  string `json:"header"` // the @@ line
+	Applied bool   `json:"applied"`
+	Line    int    `json:"line,omitempty"` // 1-based line where it applied
+	Error   string `json:"error,omitempty"`
+}
+
+// filePatch is one file's section of a unified diff.
+type filePatch struct {
+	oldPath, newPath string // "" for /dev/null
+	hunks            []hunk
+}
+
+type hunk struct {
+	header       string
+	oldStart     int
+	old, new     []string // lines without their ' ', '-', '+' prefix
+	noNewlineOld bool     // "\ No newline at end of file" after the old side's last line
+	noNewlineNew bool
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parsePatch parses a unified diff. Lines outside file sections (commit
+// messages, "diff --git", "index") are skipped.
+func parsePatch(patch string) ([]filePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	var files []filePatch
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "--- ") || i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+			continue
+		}
+		fp := filePatch{oldPath: patchPath(lines[i][4:]), newPath: patchPath(lines[i+1][4:])}
+		i += 2
+		for i < len(lines) && strings.HasPrefix(lines[i], "@@") {
+			m := hunkHeaderRe.FindStringSubmatch(lines[i])
+			if m == nil {
+				return nil, fmt.Errorf("malformed hunk header %q", lines[i])
+			}
+			h := hunk{header: lines[i]}
+			h.oldStart, _ = strconv.Atoi(m[1])
+			oldCount, newCount := 1, 1
+			if m[2] != "" {
+				oldCount, _ = strconv.Atoi(m[2])
+			}
+			if m[4] != "" {
+				newCount, _ = strconv.Atoi(m[4])
+			}
+			i++
+			last := byte(' ')
+			for i < len(lines) && (oldCount > 0 || newCount > 0 || strings.HasPrefix(lines[i], `\`)) {
+				line := lines[i]
+				switch {
+				case strings.HasPrefix(line, `\`):
+					if last != '+' {
+						h.noNewlineOld = true
+					}
+					if last != '-' {
+						h.noNewlineNew = true
+					}
+				case line == "" || line[0] == ' ':
+					// An empty line is a context line whose space was stripped in transit.
+					text := strings.TrimPrefix(line, " ")
+					h.old = append(h.old, text)
+					h.new = append(h.new, text)
+					oldCount--
+					newCount--
+					last = ' '
+				case line[0] == '-':
+					h.old = append(h.old, line[1:])
+					oldCount--
+					last = '-'
+				case line[0] == '+':
+					h.new = append(h.new, line[1:])
+					newCount--
+					last = '+'
+				default:
+					return nil, fmt.Errorf("%s: unexpected line in hunk: %q", h.header, line)
+				}
+				i++
+			}
+			if oldCount != 0 || newCount != 0 {
+				return nil, fmt.Errorf("%s: hunk is truncated", h.header)
+			}
+			fp.hunks = append(fp.hunks, h)
+		}
+		i--
+		files = append(files, fp)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file sections found (expected ---/+++ headers)")
+	}
+	return files, nil
+}
+
+// patchPath strips the timestamp and a/ or b/ prefix from a ---/+++ path.
+func patchPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+// ApplyPatch applies a unified diff to the files under root. It is atomic:
+// if any file or hunk fails, nothing is written and the result says what
+// failed. Hunks may apply at an offset from their stated line. Paths must
+// stay within root.
+func ApplyPatch(root, patch string) (*PatchResult, error) {
+	files, err := parsePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var writes []pendingWrite
+	result := &PatchResult{Applied: true}
+	for _, fp := range files {
+		fr, w := applyFile(root, fp)
+		result.Files = append(result.Files, fr)
+		if fr.Error != "" {
+			result.Applied = false
+			continue
+		}
+		for _, h := range fr.Hunks {
+			if !h.Applied {
+				result.Applied = false
+			}
+		}
+		writes = append(writes, w)
+	}
+	if !result.Applied {
+		return result, nil
+	}
+
+	for _, w := range writes {
+		var err error
+		if w.content == nil {
+			err = os.Remove(w.path)
+		} else if err = os.MkdirAll(filepath.Dir(w.path), 0o755); err == nil {
+			err = os.WriteFile(w.path, []byte(*w.content), filePerm(w.path))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("write %s: %w (earlier files in the patch were already written)", w.path, err)
+		}
+	}
+	return result, nil
+}
+
+// pendingWrite is a file change computed by applyFile, written only once
+// the whole patch has applied.
+type pendingWrite struct {
+	path    string
+	content *string // nil = delete
+}
+
+// applyFile applies one file's hunks in memory.
+func applyFile(root string, fp filePatch) (FilePatched, pendingWrite) {
+	var out pendingWrite
+	fr := FilePatched{Path: fp.newPath, Action: "modify", Hunks: []HunkResult{}}
+	switch {
+	case fp.oldPath == "" && fp.newPath == "":
+		fr.Error = "both paths are /dev/null"
+		return fr, out
+	case fp.oldPath == "":
+		fr.Action = "create"
+	case fp.newPath == "":
+		fr.Path, fr.Action = fp.oldPath, "delete"
+	}
+
+	rel, err := workspacePath(fr.Path)
+	if err != nil {
+		fr.Error = err.Error()
+		return fr, out
+	}
+	full, err := resolveInRoot(root, rel)
+	if err != nil {
+		fr.Error = err.Error()
+		return fr, out
+	}
+	out.path = full
+
+	var lines []string
+	trailingNewline := true
+	if fr.Action == "create" {
+		if _, err := os.Stat(full); err == nil {
+			fr.Error = "file already exists"
+			return fr, out
+		}
+	} else {
+		data, err := os.ReadFile(full)
+		if err != nil {
+			fr.Error = err.Error()
+			return fr, out
+		}
+		content := string(data)
+		trailingNewline = content == "" || strings.HasSuffix(content, "\n")
+		if content != "" {
+			lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+		}
+	}
+
+	offset := 0 // shift of later hunks caused by earlier ones
+	for _, h := range fp.hunks {
+		hr := HunkResult{Header: h.header}
+		want := h.oldStart - 1 + offset
+		if len(h.old) == 0 {
+			want = h.oldStart + offset // a pure insertion's start line is the line before it
+		}
+		at := findLines(lines, h.old, want)
+		if at < 0 {
+			hr.Error = "context does not match the file"
+			fr.Hunks = append(fr.Hunks, hr)
+			continue
+		}
+		hr.Applied, hr.Line = true, at+1
+		fr.Hunks = append(fr.Hunks, hr)
+
+		atEnd := at+len(h.old) == len(lines)
+		lines = append(lines[:at:at], append(append([]string{}, h.new...), lines[at+len(h.old):]...)...)
+		offset += len(h.new) - len(h.old)
+		if atEnd {
+			switch {
+			case h.noNewlineNew:
+				trailingNewline = false
+			case h.noNewlineOld:
+				trailingNewline = true
+			}
+		}
+	}
+
+	if fr.Action == "delete" {
+		if len(lines) > 0 {
+			fr.Error = "the file is not empty after removing the patch's lines"
+		}
+		return fr, out
+	}
+	content := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		content += "\n"
+	}
+	out.content = &content
+	return fr, out
+}
+
+// findLines returns the index where want occurs in lines, searching
+// outward from the expected index, or -1.
+func findLines(lines, want []string, expected int) int {
+	matches := func(at int) bool {
+		if at < 0 || at+len(want) > len(lines) {
+			return false
+		}
+		for i, w := range want {
+			if lines[at+i] != w {
+				return false
+			}
+		}
+		return true
+	}
+	for d := 0; d <= len(lines); d++ {
+		if matches(expected - d) {
+			return expected - d
+		}
+		if d > 0 && matches(expected+d) {
+			return expected + d
+		}
+	}
+	return -1
+}
+
+// workspacePath cleans a patch or tool path and checks that it is relative
+// and does not climb out of the workspace.
+func workspacePath(p string) (string, error) {
+	clean := path.Clean(filepath.ToSlash(p))
+	if clean == "." || path.IsAbs(clean) || filepath.IsAbs(p) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("path %q is outside the workspace", p)
+	}
+	if clean == ".git" || strings.HasPrefix(clean, ".git/") {
+		return "", fmt.Errorf("path %q is inside .git", p)
+	}
+	return clean, nil
+}
+
+// resolveInRoot joins rel to root and checks that symlinks along the way do
+// not lead outside root.
+func resolveInRoot(root, rel string) (string, error) {
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if r, err := filepath.EvalSymlinks(rootAbs); err == nil {
+		rootAbs = r
+	}
+	full := filepath.Join(rootAbs, filepath.FromSlash(rel))
+
+	// Resolve the deepest part of the path that exists.
+	existing := full
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	if r, err := filepath.Rel(rootAbs, resolved); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q leads outside the workspace", rel)
+	}
+	return full, nil
+}
+
+// filePerm returns the mode of an existing file, or 0644.
+func filePerm(p string) os.FileMode {
+	if info, err := os.Stat(p); err == nil {
+		return info.Mode().Perm()
+	}
+	return 0o644
+}
@@ -0,0 +1,182 @@
+package furniture
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readFile(t *testing.T, root, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestApplyPatch(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n",
+		"old.txt": "bye\n",
+	})
+	// The first hunk's line number is off by two; it still applies.
+	patch := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -3,3 +3,3 @@
+-package main
++package app
+ 
+ import "fmt"
+@@ -5,3 +5,4 @@ import "fmt"
+ func main() {
+ 	fmt.Println("hi")
++	fmt.Println("there")
+ }
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1,2 @@
++# New
++text
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+	result, err := ApplyPatch(root, patch)
+	if err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	if !result.Applied || len(result.Files) != 3 {
+		t.Fatalf("expected 3 files applied, got %+v", result)
+	}
+	if want := "package app\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n\tfmt.Println(\"there\")\n}\n"; readFile(t, root, "main.go") != want {
+		t.Errorf("main.go:\n%s", readFile(t, root, "main.go"))
+	}
+	if got := readFile(t, root, "docs/new.md"); got != "# New\ntext\n" {
+		t.Errorf("new.md: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "old.txt")); !os.IsNotExist(err) {
+		t.Error("old.txt should be deleted")
+	}
+	if actions := result.Files[1].Action + "," + result.Files[2].Action; actions != "create,delete" {
+		t.Errorf("actions: %s", actions)
+	}
+}
+
+func TestApplyPatchIsAtomic(t *testing.T) {
+	root := makeTree(t, map[string]string{"a.txt": "one\ntwo\n", "b.txt": "three\n"})
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+ one
+-two
++2
+--- a/b.txt
++++ b/b.txt
+@@ -1 +1 @@
+-not in the file
++3
+`
+	result, err := ApplyPatch(root, patch)
+	if err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	if result.Applied {
+		t.Fatal("expected the patch to fail")
+	}
+	if !result.Files[0].Hunks[0].Applied || result.Files[1].Hunks[0].Applied || result.Files[1].Hunks[0].Error == "" {
+		t.Errorf("unexpected hunk results: %+v", result.Files)
+	}
+	if got := readFile(t, root, "a.txt"); got != "one\ntwo\n" {
+		t.Errorf("a.txt was written despite the failure: %q", got)
+	}
+}
+
+func TestApplyPatchNoNewlineAtEnd(t *testing.T) {
+	root := makeTree(t, map[string]string{"a.txt": "x\ny"})
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+ x
+-y
+\ No newline at end of file
++z
+`
+	if result, err := ApplyPatch(root, patch); err != nil || !result.Applied {
+		t.Fatalf("ApplyPatch: %+v, %v", result, err)
+	}
+	if got := readFile(t, root, "a.txt"); got != "x\nz\n" {
+		t.Errorf("a.txt: %q", got)
+	}
+}
+
+func TestApplyPatchStaysInWorkspace(t *testing.T) {
+	outside := t.TempDir()
+	root := makeTree(t, map[string]string{"a.txt": "a\n"})
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"../evil.txt", "/tmp/evil.txt", "escape/evil.txt", ".git/config"} {
+		patch := "--- /dev/null\n+++ " + target + "\n@@ -0,0 +1 @@\n+evil\n"
+		result, err := ApplyPatch(root, patch)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if result.Applied || result.Files[0].Error == "" {
+			t.Errorf("%s: expected a path error, got %+v", target, result.Files[0])
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Error("a file was written outside the workspace")
+	}
+}
+
+func TestParsePatchErrors(t *testing.T) {
+	for name, patch := range map[string]string{
+		"no files":  "just text\n",
+		"truncated": "--- a/x\n+++ b/x\n@@ -1,3 +1,3 @@\n a\n",
+		"bad line":  "--- a/x\n+++ b/x\n@@ -1 +1 @@\n*oops\n",
+	} {
+		if _, err := parsePatch(patch); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPatcherShowDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := makeTree(t, map[string]string{"a.txt": "one\n"})
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	p := NewPatcher("patch", map[string]string{"root": root})
+	if _, err := p.Call("show_diff", nil); err == nil || !strings.Contains(err.Error(), "not a git repository") {
+		t.Errorf("expected a not-a-repository error, got %v", err)
+	}
+
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-qm", "init")
+	if _, err := p.Call("apply_patch", map[string]interface{}{"patch": "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+two\n"}); err != nil {
+		t.Fatalf("apply_patch: %v", err)
+	}
+	result, err := p.Call("show_diff", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("show_diff: %v", err)
+	}
+	diff := result.(map[string]interface{})
+	if !strings.Contains(diff["diff"].(string), "+two") || !strings.Contains(diff["status"].(string), "a.txt") {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+}
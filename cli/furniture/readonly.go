@@ -0,0 +1,58 @@
+package furniture
+
+import "fmt"
+
+// ReadOnly wraps furniture so only its non-mutating tools are offered and
+// callable, for agents that should see it but not change it.
+func ReadOnly(f Furniture) Furniture {
+	return &readOnly{f}
+}
+
+type readOnly struct {
+	Furniture
+}
+
+func (r *readOnly) Tools() []Tool {
+	var tools []Tool
+	for _, t := range r.Furniture.Tools() {
+		if !t.Mutating {
+			tools = append(tools, t)
+		}
+	}
+	return tools
+}
+
+func (r *readOnly) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	return r.CallStream(toolName, args, nil)
+}
+
+// CallStream passes progress through when the wrapped furniture streams it.
+func (r *readOnly) CallStream(toolName string, args map[string]interface{}, onProgress func(string)) (interface{}, error) {
+	for _, t := range r.Furniture.Tools() {
+		if t.Name != toolName {
+			continue
+		}
+		if t.Mutating {
+			return nil, fmt.Errorf("furniture %q is read-only: %s changes state", r.Name(), toolName)
+		}
+		if sf, ok := r.Furniture.(StreamingFurniture); ok {
+			return sf.CallStream(toolName, args, onProgress)
+		}
+		return r.Furniture.Call(toolName, args)
+	}
+	return nil, &ErrUnknownTool{Furniture: r.Name(), Tool: toolName}
+}
+
+// SerialKey passes through the wrapped furniture's, if it has one.
+func (r *readOnly) SerialKey() string {
+	if sf, ok := r.Furniture.(SerialFurniture); ok {
+		return sf.SerialKey()
+	}
+	return ""
+}
+
+// IsReadOnly reports whether f was wrapped by ReadOnly.
+func IsReadOnly(f Furniture) bool {
+	_, ok := f.(*readOnly)
+	return ok
+}
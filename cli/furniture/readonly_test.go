@@ -0,0 +1,39 @@
+package furniture
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	tb := NewTaskBoard()
+	if _, err := tb.Call("add_task", map[string]interface{}{"title": "Design API"}); err != nil {
+		t.Fatalf("add_task: %v", err)
+	}
+	ro := ReadOnly(tb)
+	if !IsReadOnly(ro) || IsReadOnly(tb) {
+		t.Error("IsReadOnly should report only the wrapped furniture")
+	}
+
+	var names []string
+	for _, tool := range ro.Tools() {
+		names = append(names, tool.Name)
+	}
+	if want := []string{"list_tasks", "get_task"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("tools = %v, want %v", names, want)
+	}
+
+	if _, err := ro.Call("get_task", map[string]interface{}{"id": 1}); err != nil {
+		t.Errorf("get_task: %v", err)
+	}
+	_, err := ro.Call("update_task", map[string]interface{}{"id": 1, "status": "done"})
+	if want := `furniture "tasks" is read-only: update_task changes state`; err == nil || err.Error() != want {
+		t.Errorf("update_task error = %v, want %q", err, want)
+	}
+	if _, err := ro.Call("drop_tasks", nil); err == nil {
+		t.Error("expected an error for an unknown tool")
+	}
+	if total, done := tb.Counts(); total != 1 || done != 0 {
+		t.Errorf("board changed: %d tasks, %d done", total, done)
+	}
+}
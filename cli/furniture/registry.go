@@ -0,0 +1,136 @@
+package furniture
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+// Factory builds a furniture instance from its blueprint definition,
+// using env for what it needs from the floor.
+type Factory func(def blueprint.FurnitureDef, env Env) (Furniture, error)
+
+// Env is what the floor offers the furniture it builds.
+type Env struct {
+	// Exec runs a shell command in the floor's sandbox, in the workspace
+	// directory, returning its combined output. nil when the floor has no
+	// sandbox.
+	Exec func(cmd string) (string, error)
+
+	// Context bounds work done while building the furniture, such as
+	// starting an MCP server and listing its tools. nil means
+	// context.Background().
+	Context context.Context
+}
+
+// Registry maps blueprint furniture types (e.g. "taskboard") to factories.
+// It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// DefaultRegistry is consulted by the floor when building furniture from a
+// blueprint. It comes with the built-in types pre-registered.
+var DefaultRegistry = NewBuiltinRegistry()
+
+// Register adds a furniture type to DefaultRegistry.
+func Register(typeName string, factory Factory) {
+	DefaultRegistry.Register(typeName, factory)
+}
+
+// Register adds or replaces the factory for a furniture type.
+func (r *Registry) Register(typeName string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[typeName] = factory
+}
+
+// Create builds furniture for def using the factory registered for def.Type.
+func (r *Registry) Create(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[def.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, &ErrUnknownType{Type: def.Type}
+	}
+	return factory(def, env)
+}
+
+// Types returns the registered type names, sorted.
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// NewBuiltinRegistry creates a registry with the built-in furniture types.
+func NewBuiltinRegistry() *Registry {
+	r := NewRegistry()
+	r.Register("taskboard", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		return NewTaskBoard(), nil
+	})
+	r.Register("mcp", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		if def.Command == "" {
+			return nil, fmt.Errorf("mcp furniture %q requires a command", def.Name)
+		}
+		ctx := env.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return NewExternalMCP(ctx, def.Name, def.Command, def.Args)
+	})
+	r.Register("websearch", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		return NewWebSearch(def.Name, def.Config)
+	})
+	r.Register("fetch", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		return NewFetch(def.Name, def.Config)
+	})
+	r.Register("calculator", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		calc := NewCalculator(def.Name)
+		if def.Config["python"] == "true" && env.Exec != nil {
+			calc.WithPython(env.Exec)
+		}
+		return calc, nil
+	})
+	r.Register("clock", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		return NewClock(def.Name, def.Config)
+	})
+	r.Register("git", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		git, err := NewGit(def.Name, def.Config)
+		if err != nil {
+			return nil, err
+		}
+		if env.Exec != nil {
+			git.WithExec(env.Exec)
+		}
+		return git, nil
+	})
+	r.Register("patch", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		patcher := NewPatcher(def.Name, def.Config)
+		if env.Exec != nil {
+			patcher.WithExec(env.Exec)
+		}
+		return patcher, nil
+	})
+	r.Register("workspace", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		return NewWorkspace(def.Name, def.Config)
+	})
+	r.Register("mailbox", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		return NewMailbox(def.Name), nil
+	})
+	return r
+}
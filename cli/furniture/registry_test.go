@@ -0,0 +1,86 @@
+package furniture
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/openfloorcontrol/ofc/blueprint"
+)
+
+// echoFurniture is a minimal custom furniture used to exercise the registry.
+type echoFurniture struct{ name string }
+
+func (e *echoFurniture) Name() string  { return e.name }
+func (e *echoFurniture) Tools() []Tool { return []Tool{{Name: "echo"}} }
+func (e *echoFurniture) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	return args, nil
+}
+
+func TestRegistryCustomType(t *testing.T) {
+	r := NewBuiltinRegistry()
+	r.Register("echo", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		return &echoFurniture{name: def.Name}, nil
+	})
+
+	f, err := r.Create(blueprint.FurnitureDef{Name: "e", Type: "echo"}, Env{})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if f.Name() != "e" {
+		t.Errorf("expected name e, got %s", f.Name())
+	}
+
+	if _, err := r.Create(blueprint.FurnitureDef{Name: "x", Type: "nope"}, Env{}); err == nil {
+		t.Error("expected error for unknown type")
+	}
+}
+
+func TestRegistryPassesEnv(t *testing.T) {
+	var ran []string
+	env := Env{Exec: func(cmd string) (string, error) {
+		ran = append(ran, cmd)
+		return "ok", nil
+	}}
+	r := NewBuiltinRegistry()
+	r.Register("shell", func(def blueprint.FurnitureDef, env Env) (Furniture, error) {
+		if env.Exec == nil {
+			t.Error("custom factory got no Exec")
+		} else {
+			env.Exec("hostname")
+		}
+		return &echoFurniture{name: def.Name}, nil
+	})
+	if _, err := r.Create(blueprint.FurnitureDef{Name: "sh", Type: "shell"}, env); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(ran) != 1 || ran[0] != "hostname" {
+		t.Errorf("expected the factory to run hostname via Exec, ran %q", ran)
+	}
+
+	hasPython := func(env Env) bool {
+		f, err := r.Create(blueprint.FurnitureDef{Name: "calc", Type: "calculator", Config: map[string]string{"python": "true"}}, env)
+		if err != nil {
+			t.Fatalf("Create calculator: %v", err)
+		}
+		return slices.ContainsFunc(f.Tools(), func(tool Tool) bool { return tool.Name == "python_eval" })
+	}
+	if !hasPython(env) {
+		t.Error("calculator with python: true and an Exec should offer python_eval")
+	}
+	if hasPython(Env{}) {
+		t.Error("calculator without an Exec should not offer python_eval")
+	}
+}
+
+func TestBuiltinRegistryTypes(t *testing.T) {
+	types := NewBuiltinRegistry().Types()
+	want := []string{"calculator", "clock", "fetch", "git", "mailbox", "mcp", "patch", "taskboard", "websearch", "workspace"}
+	if len(types) != len(want) {
+		t.Fatalf("expected %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, types)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package furniture
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ArgsError lists the ways a tool call's arguments don't match the tool's
+// Parameters schema, worded so a model can correct the call.
+type ArgsError struct {
+	Problems []string
+}
+
+func (e *ArgsError) Error() string {
+	return "invalid arguments: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateArgs checks args against a tool's Parameters before it is called.
+// It covers the parts of JSON Schema that tool declarations use: type,
+// required, properties, enum, items, and additionalProperties: false.
+// Unknown keywords are ignored, and so are null values for optional
+// fields, which models often send instead of leaving them out. Returns an
+// *ArgsError, or nil if args are acceptable.
+func ValidateArgs(schema map[string]interface{}, args map[string]interface{}) error {
+	var problems []string
+	validateValue(schema, args, "", &problems)
+	if len(problems) > 0 {
+		return &ArgsError{Problems: problems}
+	}
+	return nil
+}
+
+// validateValue checks v against schema, appending problems for the field
+// at path ("" for the arguments themselves).
+func validateValue(schema map[string]interface{}, v interface{}, path string, problems *[]string) {
+	if len(schema) == 0 {
+		return
+	}
+	add := func(format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		if path != "" {
+			msg = fmt.Sprintf("field %q %s", path, msg)
+		} else {
+			msg = "arguments " + msg
+		}
+		*problems = append(*problems, msg)
+	}
+
+	if types := stringList(schema["type"]); len(types) > 0 {
+		got := jsonType(v)
+		if !slices.ContainsFunc(types, func(t string) bool { return t == got || (t == "number" && got == "integer") }) {
+			add("must be %s, got %s", strings.Join(types, " or "), got)
+			return
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		if !slices.ContainsFunc(enum, func(e interface{}) bool { return reflect.DeepEqual(e, v) }) {
+			add("must be one of %s", formatEnum(enum))
+		}
+	} else if enum := stringList(schema["enum"]); len(enum) > 0 {
+		if s, ok := v.(string); !ok || !slices.Contains(enum, s) {
+			add("must be one of %s", formatEnum(enum))
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		for _, name := range stringList(schema["required"]) {
+			if fv, ok := val[name]; !ok || fv == nil {
+				*problems = append(*problems, fmt.Sprintf("field %q is required", fieldPath(path, name)))
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fv := val[name]
+			ps, known := props[name].(map[string]interface{})
+			if !known {
+				if extra, ok := schema["additionalProperties"].(bool); ok && !extra {
+					*problems = append(*problems, fmt.Sprintf("field %q is not allowed", fieldPath(path, name)))
+				}
+				continue
+			}
+			if fv == nil {
+				continue // a missing required field was reported above
+			}
+			validateValue(ps, fv, fieldPath(path, name), problems)
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	}
+}
+
+// jsonType names the JSON type of a decoded value. Whole numbers are
+// "integer", which also satisfies "number".
+func jsonType(v interface{}) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	case int, int64:
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// stringList reads a schema keyword that holds a string or a list of
+// strings. Schemas written in Go use []string; schemas decoded from JSON
+// (external MCP tools) use []interface{}.
+func stringList(v interface{}) []string {
+	switch l := v.(type) {
+	case string:
+		return []string{l}
+	case []string:
+		return l
+	case []interface{}:
+		var out []string
+		for _, e := range l {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// formatEnum lists enum values for a problem message.
+func formatEnum[T any](enum []T) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		parts[i] = fmt.Sprintf("%q", fmt.Sprint(e))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fieldPath builds the dotted path of a nested field.
+func fieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
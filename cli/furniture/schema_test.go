@@ -0,0 +1,76 @@
+package furniture
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateArgs(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id":     map[string]interface{}{"type": "integer"},
+			"status": map[string]interface{}{"type": "string", "enum": []string{"todo", "done"}},
+			"ratio":  map[string]interface{}{"type": "number"},
+			"tags":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required": []string{"id"},
+	}
+
+	valid := []map[string]interface{}{
+		{"id": float64(3)},
+		{"id": 3, "ratio": float64(2), "status": "done", "tags": []interface{}{"a"}},
+		{"id": float64(3), "status": nil, "extra": "ignored"},
+	}
+	for _, args := range valid {
+		if err := ValidateArgs(schema, args); err != nil {
+			t.Errorf("ValidateArgs(%v): %v", args, err)
+		}
+	}
+
+	err := ValidateArgs(schema, map[string]interface{}{"id": "3", "status": "blocked", "ratio": "x", "tags": []interface{}{"a", 1.0}})
+	var argsErr *ArgsError
+	if !errors.As(err, &argsErr) {
+		t.Fatalf("expected *ArgsError, got %v", err)
+	}
+	want := []string{
+		`field "id" must be integer, got string`,
+		`field "ratio" must be number, got string`,
+		`field "status" must be one of "todo", "done"`,
+		`field "tags[1]" must be string, got integer`,
+	}
+	if !reflect.DeepEqual(argsErr.Problems, want) {
+		t.Errorf("problems:\n got %q\nwant %q", argsErr.Problems, want)
+	}
+
+	err = ValidateArgs(schema, map[string]interface{}{"id": 1.5})
+	if err == nil || err.Error() != `invalid arguments: field "id" must be integer, got number` {
+		t.Errorf("unexpected error for a fractional id: %v", err)
+	}
+	err = ValidateArgs(schema, map[string]interface{}{})
+	if err == nil || err.Error() != `invalid arguments: field "id" is required` {
+		t.Errorf("unexpected error for a missing id: %v", err)
+	}
+}
+
+func TestValidateArgsDecodedSchema(t *testing.T) {
+	// Schemas from external MCP servers are decoded from JSON.
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{"path": map[string]interface{}{"type": []interface{}{"string", "null"}}},
+		"required":             []interface{}{"path"},
+		"additionalProperties": false,
+	}
+	if err := ValidateArgs(schema, map[string]interface{}{"path": "a.txt"}); err != nil {
+		t.Errorf("ValidateArgs: %v", err)
+	}
+	err := ValidateArgs(schema, map[string]interface{}{"file": "a.txt"})
+	var argsErr *ArgsError
+	if !errors.As(err, &argsErr) || !reflect.DeepEqual(argsErr.Problems, []string{`field "path" is required`, `field "file" is not allowed`}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateArgs(nil, map[string]interface{}{"anything": 1.0}); err != nil {
+		t.Errorf("a tool without a schema should accept anything, got %v", err)
+	}
+}
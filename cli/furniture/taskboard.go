@@ -45,6 +45,7 @@ func (tb *TaskBoard) Tools() []Tool {
 		},
 		{
 			Name:        "add_task",
+			Mutating:    true,
 			Description: "Add a new task to the board.",
 			Parameters: map[string]interface{}{
 				"type": "object",
@@ -63,6 +64,7 @@ func (tb *TaskBoard) Tools() []Tool {
 		},
 		{
 			Name:        "update_task",
+			Mutating:    true,
 			Description: "Update an existing task's status, assignee, or other fields.",
 			Parameters: map[string]interface{}{
 				"type": "object",
@@ -196,6 +198,19 @@ func (tb *TaskBoard) updateTask(args map[string]interface{}) (interface{}, error
 	return nil, fmt.Errorf("task %d not found", id)
 }
 
+// Counts returns the number of tasks on the board and how many are done.
+func (tb *TaskBoard) Counts() (total, done int) {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+
+	for _, t := range tb.tasks {
+		if t.Status == "done" {
+			done++
+		}
+	}
+	return len(tb.tasks), done
+}
+
 func (tb *TaskBoard) getTask(args map[string]interface{}) (interface{}, error) {
 	id, err := intArg(args, "id")
 	if err != nil {
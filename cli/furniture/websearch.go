@@ -0,0 +1,265 @@
+package furniture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultSearchResults = 5
+	maxSearchResults     = 20
+	defaultSearchTimeout = 15 * time.Second
+)
+
+// SearchResult is one hit returned by a search provider.
+type SearchResult struct {
+	Title   string `json:"title"`
+	Snippet string `json:"snippet,omitempty"`
+	URL     string `json:"url"`
+}
+
+// SearchProvider is a web search backend.
+type SearchProvider interface {
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+}
+
+// WebSearch is furniture that lets agents search the web through a
+// configurable provider.
+//
+// Config keys:
+//   - provider: "searxng" (default) or "json"
+//   - endpoint: base URL of the search service (required)
+//   - api_key: sent as a Bearer token, if set (supports ${VAR} expansion)
+//   - max_results: default result count (default 5, capped at 20)
+//   - timeout: per-request timeout (default "15s")
+type WebSearch struct {
+	name       string
+	provider   SearchProvider
+	maxResults int
+	timeout    time.Duration
+}
+
+// NewWebSearch creates a web search furniture from its blueprint config.
+func NewWebSearch(name string, cfg map[string]string) (*WebSearch, error) {
+	endpoint := cfg["endpoint"]
+	if endpoint == "" {
+		return nil, fmt.Errorf("websearch furniture %q requires config.endpoint", name)
+	}
+	maxResults, err := configInt(cfg, "max_results", defaultSearchResults)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := configDuration(cfg, "timeout", defaultSearchTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := os.ExpandEnv(cfg["api_key"])
+	httpClient := &http.Client{Timeout: timeout}
+	var provider SearchProvider
+	switch cfg["provider"] {
+	case "", "searxng":
+		provider = &SearXNGProvider{Endpoint: endpoint, APIKey: apiKey, HTTPClient: httpClient}
+	case "json":
+		provider = &JSONSearchProvider{Endpoint: endpoint, APIKey: apiKey, HTTPClient: httpClient}
+	default:
+		return nil, fmt.Errorf("websearch furniture %q: unknown provider %q", name, cfg["provider"])
+	}
+
+	return NewWebSearchWithProvider(name, provider, maxResults, timeout), nil
+}
+
+// NewWebSearchWithProvider creates a web search furniture backed by a custom provider.
+func NewWebSearchWithProvider(name string, provider SearchProvider, maxResults int, timeout time.Duration) *WebSearch {
+	if maxResults <= 0 {
+		maxResults = defaultSearchResults
+	}
+	if timeout <= 0 {
+		timeout = defaultSearchTimeout
+	}
+	return &WebSearch{
+		name:       name,
+		provider:   provider,
+		maxResults: maxResults,
+		timeout:    timeout,
+	}
+}
+
+func (w *WebSearch) Name() string { return w.name }
+
+func (w *WebSearch) Tools() []Tool {
+	return []Tool{
+		{
+			Name:        "search",
+			Description: "Search the web. Returns titles, snippets, and URLs.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Search query",
+					},
+					"count": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Number of results (default %d, max %d)", w.maxResults, maxSearchResults),
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}
+}
+
+func (w *WebSearch) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	switch toolName {
+	case "search":
+		return w.search(args)
+	default:
+		return nil, &ErrUnknownTool{Furniture: w.name, Tool: toolName}
+	}
+}
+
+func (w *WebSearch) search(args map[string]interface{}) (interface{}, error) {
+	query, _ := args["query"].(string)
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	count := w.maxResults
+	if _, ok := args["count"]; ok {
+		n, err := intArg(args, "count")
+		if err != nil {
+			return nil, err
+		}
+		count = n
+	}
+	if count < 1 {
+		count = 1
+	}
+	if count > maxSearchResults {
+		count = maxSearchResults
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+
+	results, err := w.provider.Search(ctx, query, count)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	if len(results) > count {
+		results = results[:count]
+	}
+	if results == nil {
+		results = []SearchResult{}
+	}
+	return map[string]interface{}{
+		"results": results,
+		"count":   len(results),
+	}, nil
+}
+
+// SearXNGProvider queries a SearXNG instance's JSON API
+// (GET {endpoint}/search?q=...&format=json).
+type SearXNGProvider struct {
+	Endpoint   string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+func (p *SearXNGProvider) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	u, err := url.Parse(p.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	u = u.JoinPath("search")
+	q := u.Query()
+	q.Set("q", query)
+	q.Set("format", "json")
+	u.RawQuery = q.Encode()
+
+	var body struct {
+		Results []struct {
+			Title   string `json:"title"`
+			Content string `json:"content"`
+			URL     string `json:"url"`
+		} `json:"results"`
+	}
+	if err := getJSON(ctx, p.HTTPClient, u.String(), p.APIKey, &body); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range body.Results {
+		if len(results) >= limit {
+			break
+		}
+		results = append(results, SearchResult{Title: r.Title, Snippet: r.Content, URL: r.URL})
+	}
+	return results, nil
+}
+
+// JSONSearchProvider queries a generic JSON search API
+// (GET {endpoint}?q=...&count=N) that responds with
+// {"results": [{"title", "snippet", "url"}]}.
+type JSONSearchProvider struct {
+	Endpoint   string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+func (p *JSONSearchProvider) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	u, err := url.Parse(p.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("q", query)
+	q.Set("count", strconv.Itoa(limit))
+	u.RawQuery = q.Encode()
+
+	var body struct {
+		Results []SearchResult `json:"results"`
+	}
+	if err := getJSON(ctx, p.HTTPClient, u.String(), p.APIKey, &body); err != nil {
+		return nil, err
+	}
+	return body.Results, nil
+}
+
+// getJSON performs a GET request and decodes a JSON response into v.
+func getJSON(ctx context.Context, client *http.Client, rawURL, apiKey string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package furniture
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebSearchSearXNG(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		if r.URL.Query().Get("q") != "golang" {
+			t.Errorf("unexpected query: %s", r.URL.Query().Get("q"))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]string{
+				{"title": "Go", "content": "The Go language", "url": "https://go.dev"},
+				{"title": "Go Wiki", "content": "Wiki", "url": "https://go.dev/wiki"},
+				{"title": "Go Blog", "content": "Blog", "url": "https://go.dev/blog"},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	ws, err := NewWebSearch("web", map[string]string{"endpoint": srv.URL})
+	if err != nil {
+		t.Fatalf("NewWebSearch: %v", err)
+	}
+
+	result, err := ws.Call("search", map[string]interface{}{"query": "golang", "count": float64(2)})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	listing := result.(map[string]interface{})
+	if listing["count"] != 2 {
+		t.Fatalf("expected 2 results, got %v", listing["count"])
+	}
+	first := listing["results"].([]SearchResult)[0]
+	if first.Title != "Go" || first.Snippet != "The Go language" || first.URL != "https://go.dev" {
+		t.Errorf("unexpected result: %+v", first)
+	}
+
+	if _, err := ws.Call("search", map[string]interface{}{}); err == nil {
+		t.Error("expected error for missing query")
+	}
+}
+
+func TestWebSearchRequiresEndpoint(t *testing.T) {
+	if _, err := NewWebSearch("web", nil); err == nil {
+		t.Fatal("expected error without endpoint")
+	}
+	if _, err := NewWebSearch("web", map[string]string{"endpoint": "http://x", "provider": "bogus"}); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
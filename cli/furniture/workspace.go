@@ -0,0 +1,225 @@
+package furniture
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultWorkspaceRoot       = "workspace"
+	defaultWorkspaceIgnore     = "node_modules"
+	defaultWorkspaceMaxEntries = 500
+)
+
+// ListOptions configures ListWorkspace.
+type ListOptions struct {
+	Dir        string   // directory to list, relative to the root ("" = the root)
+	Ignore     []string // extra gitignore-style patterns, applied at the root before any .gitignore
+	MaxDepth   int      // directory levels below Dir to descend into (0 = unlimited)
+	MaxEntries int      // stop after this many entries (0 = unlimited)
+}
+
+// ListWorkspace walks root/opts.Dir and returns the paths that are not
+// ignored, relative to root, "/"-separated, in walk order (each directory,
+// followed by its contents), with a trailing "/" on directories. Paths are
+// ignored by opts.Ignore and by the .gitignore files from the root down;
+// .git is always skipped. truncated reports whether MaxEntries cut the
+// listing short.
+func ListWorkspace(root string, opts ListOptions) (paths []string, truncated bool, err error) {
+	dir := path.Clean(filepath.ToSlash(opts.Dir))
+	if dir == "." || dir == "/" {
+		dir = ""
+	}
+	if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return nil, false, fmt.Errorf("path %q is outside the workspace", opts.Dir)
+	}
+
+	rules := parseIgnore("", strings.Join(opts.Ignore, "\n"))
+	rules = append(rules, readIgnore(root, "")...)
+	// Rules from the .gitignore files above dir still apply.
+	if dir != "" {
+		parts := strings.Split(dir, "/")
+		for i := range parts {
+			sub := strings.Join(parts[:i+1], "/")
+			if parts[i] == ".git" || rules.ignored(sub, true) {
+				return nil, false, fmt.Errorf("path %q is ignored", opts.Dir)
+			}
+			rules = append(rules, readIgnore(root, sub)...)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(dir))); err != nil {
+		return nil, false, err
+	} else if !info.IsDir() {
+		return nil, false, fmt.Errorf("path %q is not a directory", opts.Dir)
+	}
+
+	var walk func(dir string, rules ignoreRules, depth int) error
+	walk = func(dir string, rules ignoreRules, depth int) error {
+		entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.Name() == ".git" {
+				continue
+			}
+			rel := path.Join(dir, e.Name())
+			if rules.ignored(rel, e.IsDir()) {
+				continue
+			}
+			if opts.MaxEntries > 0 && len(paths) >= opts.MaxEntries {
+				truncated = true
+				return nil
+			}
+			if !e.IsDir() {
+				paths = append(paths, rel)
+				continue
+			}
+			paths = append(paths, rel+"/")
+			if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+				continue
+			}
+			// Copy before appending, so sibling directories don't share rules.
+			sub := append(rules[:len(rules):len(rules)], readIgnore(root, rel)...)
+			if err := walk(rel, sub, depth+1); err != nil {
+				return err
+			}
+			if truncated {
+				return nil
+			}
+		}
+		return nil
+	}
+	if err := walk(dir, rules, 1); err != nil {
+		return nil, false, err
+	}
+	return paths, truncated, nil
+}
+
+// readIgnore parses root/dir/.gitignore, if there is one.
+func readIgnore(root, dir string) ignoreRules {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	return parseIgnore(dir, string(data))
+}
+
+// Workspace is furniture that gives agents a clean overview of the project
+// files: a tree that skips .git, whatever the project's .gitignore files
+// exclude, and a configurable ignore list.
+//
+// Config keys:
+//   - root: workspace directory (default "workspace", the sandbox mount)
+//   - ignore: comma-separated gitignore-style patterns (default "node_modules")
+//   - max_entries: maximum entries per listing (default 500)
+type Workspace struct {
+	name       string
+	root       string
+	ignore     []string
+	maxEntries int
+}
+
+// NewWorkspace creates a workspace lister.
+func NewWorkspace(name string, cfg map[string]string) (*Workspace, error) {
+	root := cfg["root"]
+	if root == "" {
+		root = defaultWorkspaceRoot
+	}
+	ignore := defaultWorkspaceIgnore
+	if v, ok := cfg["ignore"]; ok {
+		ignore = v
+	}
+	maxEntries, err := configInt(cfg, "max_entries", defaultWorkspaceMaxEntries)
+	if err != nil {
+		return nil, fmt.Errorf("workspace furniture %q: %w", name, err)
+	}
+	return &Workspace{
+		name:       name,
+		root:       root,
+		ignore:     splitList(ignore),
+		maxEntries: maxEntries,
+	}, nil
+}
+
+func (w *Workspace) Name() string { return w.name }
+
+func (w *Workspace) Tools() []Tool {
+	return []Tool{
+		{
+			Name:        "list_files",
+			Description: "List the project files as a tree, skipping .git, anything in .gitignore, and dependency folders. Use this instead of ls -R or find to get an overview.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory to list, relative to the workspace root (default: the root)",
+					},
+					"max_depth": map[string]interface{}{
+						"type":        "integer",
+						"description": "How many directory levels to descend (default: unlimited)",
+					},
+				},
+			},
+		},
+	}
+}
+
+func (w *Workspace) Call(toolName string, args map[string]interface{}) (interface{}, error) {
+	if toolName != "list_files" {
+		return nil, &ErrUnknownTool{Furniture: w.name, Tool: toolName}
+	}
+	dir, _ := args["path"].(string)
+	depth := 0
+	if _, ok := args["max_depth"]; ok {
+		n, err := intArg(args, "max_depth")
+		if err != nil {
+			return nil, err
+		}
+		depth = n
+	}
+
+	paths, truncated, err := ListWorkspace(w.root, ListOptions{
+		Dir:        dir,
+		Ignore:     w.ignore,
+		MaxDepth:   depth,
+		MaxEntries: w.maxEntries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tree := formatTree(paths, dir)
+	if truncated {
+		tree += fmt.Sprintf("... (stopped after %d entries; list a subdirectory for more)\n", len(paths))
+	}
+	return map[string]interface{}{
+		"path":      path.Clean("/" + filepath.ToSlash(dir))[1:],
+		"entries":   len(paths),
+		"truncated": truncated,
+		"tree":      tree,
+	}, nil
+}
+
+// formatTree renders ListWorkspace paths as an indented tree, relative to
+// dir: one entry per line, two spaces per level.
+func formatTree(paths []string, dir string) string {
+	prefix := strings.Trim(path.Clean("/"+filepath.ToSlash(dir)), "/")
+	var sb strings.Builder
+	for _, p := range paths {
+		rel := strings.TrimSuffix(p, "/")
+		if prefix != "" {
+			rel = strings.TrimPrefix(rel, prefix+"/")
+		}
+		depth := strings.Count(rel, "/")
+		name := path.Base(rel)
+		if strings.HasSuffix(p, "/") {
+			name += "/"
+		}
+		sb.WriteString(strings.Repeat("  ", depth) + name + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,159 @@
+package furniture
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// makeTree creates files (path → content) under a temp dir and returns it.
+func makeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestIgnoreRules(t *testing.T) {
+	rules := parseIgnore("", strings.Join([]string{
+		"# comment",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/top.txt",
+		"docs/**/draft.md",
+		"tmp/**",
+		"cache?",
+		`\#hash`,
+	}, "\n"))
+	rules = append(rules, parseIgnore("sub", "local.txt\n/anchored.txt\n")...)
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"deep/nested/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build", false, false}, // a file named build is not a directory
+		{"src/build", true, true},
+		{"top.txt", false, true},
+		{"src/top.txt", false, false},
+		{"docs/draft.md", false, true},
+		{"docs/a/b/draft.md", false, true},
+		{"docs/final.md", false, false},
+		{"tmp/x/y", false, true},
+		{"tmp", true, false},
+		{"cache1", true, true},
+		{"cache12", true, false},
+		{"#hash", false, true},
+		{"sub/local.txt", false, true},
+		{"sub/deeper/local.txt", false, true},
+		{"local.txt", false, false},
+		{"sub/anchored.txt", false, true},
+		{"sub/deeper/anchored.txt", false, false},
+	}
+	for _, tt := range tests {
+		if got := rules.ignored(tt.path, tt.isDir); got != tt.ignored {
+			t.Errorf("ignored(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.ignored)
+		}
+	}
+}
+
+func TestListWorkspace(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		".gitignore":                 "*.o\ndist/\n",
+		".git/HEAD":                  "ref: refs/heads/main",
+		"main.go":                    "",
+		"main.o":                     "",
+		"dist/bundle.js":             "",
+		"node_modules/left-pad/x.js": "",
+		"src/lib.go":                 "",
+		"src/.gitignore":             "generated.go\n!keep.o\n",
+		"src/generated.go":           "",
+		"src/keep.o":                 "",
+		"src/internal/deep/file.go":  "",
+		"vendor/github.com/x/y/y.go": "",
+	})
+
+	paths, truncated, err := ListWorkspace(root, ListOptions{Ignore: []string{"node_modules", "vendor/"}})
+	if err != nil {
+		t.Fatalf("ListWorkspace: %v", err)
+	}
+	want := []string{
+		".gitignore",
+		"main.go",
+		"src/",
+		"src/.gitignore",
+		"src/internal/",
+		"src/internal/deep/",
+		"src/internal/deep/file.go",
+		"src/keep.o",
+		"src/lib.go",
+	}
+	if truncated || !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %q (truncated=%v)\nwant %q", paths, truncated, want)
+	}
+
+	// A subdirectory still honors the .gitignore files above it.
+	paths, _, err = ListWorkspace(root, ListOptions{Dir: "src", MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("ListWorkspace(src): %v", err)
+	}
+	want = []string{"src/.gitignore", "src/internal/", "src/keep.o", "src/lib.go"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("src: got %q, want %q", paths, want)
+	}
+
+	paths, truncated, _ = ListWorkspace(root, ListOptions{MaxEntries: 2})
+	if !truncated || len(paths) != 2 {
+		t.Errorf("expected 2 entries and truncation, got %q (truncated=%v)", paths, truncated)
+	}
+
+	for _, dir := range []string{"../", "/etc", "dist", ".git"} {
+		if _, _, err := ListWorkspace(root, ListOptions{Dir: dir}); err == nil {
+			t.Errorf("Dir %q: expected an error", dir)
+		}
+	}
+}
+
+func TestWorkspaceListFiles(t *testing.T) {
+	root := makeTree(t, map[string]string{
+		"README.md":                  "",
+		"cmd/app/main.go":            "",
+		"node_modules/left-pad/x.js": "",
+	})
+	ws, err := NewWorkspace("ws", map[string]string{"root": root})
+	if err != nil {
+		t.Fatalf("NewWorkspace: %v", err)
+	}
+
+	result, err := ws.Call("list_files", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("list_files: %v", err)
+	}
+	tree := result.(map[string]interface{})["tree"]
+	if want := "README.md\ncmd/\n  app/\n    main.go\n"; tree != want {
+		t.Errorf("tree:\n%s\nwant:\n%s", tree, want)
+	}
+
+	result, err = ws.Call("list_files", map[string]interface{}{"path": "cmd", "max_depth": float64(1)})
+	if err != nil {
+		t.Fatalf("list_files cmd: %v", err)
+	}
+	if tree := result.(map[string]interface{})["tree"]; tree != "app/\n" {
+		t.Errorf("cmd tree: %q", tree)
+	}
+}
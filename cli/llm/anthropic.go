@@ -0,0 +1,235 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// anthropicVersion is the Messages API version sent with every request.
+const anthropicVersion = "2023-06-01"
+
+// AnthropicMaxTokens is the max_tokens sent to the Anthropic Messages API,
+// which requires one.
+const AnthropicMaxTokens = 4096
+
+// anthropicRequest is the Messages API request body.
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float64            `json:"temperature"`
+	Stream      bool               `json:"stream"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"` // "user" or "assistant"
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicBlock is a content block: text, tool_use, or tool_result.
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`          // tool_use
+	Name      string          `json:"name,omitempty"`        // tool_use
+	Input     json.RawMessage `json:"input,omitempty"`       // tool_use
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result
+	Content   string          `json:"content,omitempty"`     // tool_result
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// anthropicEvent is one SSE data payload of a streamed Messages response.
+type anthropicEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"` // message_start
+	ContentBlock struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"content_block"` // content_block_start
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"` // content_block_delta
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"` // message_delta
+	Error *StreamError `json:"error"` // error
+}
+
+// toAnthropic translates OpenAI-style messages and tools to the Messages
+// API: system messages become the system prompt, tool results become
+// tool_result blocks in a user turn, and consecutive turns of the same
+// role are merged (the API wants them to alternate). The API has no
+// speaker names, so a named user message is prefixed with "@name: ".
+func toAnthropic(messages []Message, tools []Tool) (system string, out []anthropicMessage, outTools []anthropicTool) {
+	var systems []string
+	add := func(role string, blocks ...anthropicBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content = append(out[n-1].Content, blocks...)
+			return
+		}
+		out = append(out, anthropicMessage{Role: role, Content: blocks})
+	}
+	text := func(s string) []anthropicBlock {
+		if strings.TrimSpace(s) == "" {
+			return nil // the API rejects empty text blocks
+		}
+		return []anthropicBlock{{Type: "text", Text: s}}
+	}
+
+	for _, m := range messages {
+		switch m.Role {
+		case "system":
+			if m.Content != "" {
+				systems = append(systems, m.Content)
+			}
+		case "assistant":
+			blocks := text(m.Content)
+			for _, tc := range m.ToolCalls {
+				input := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: tc.ID, Name: tc.Function.Name, Input: input})
+			}
+			add("assistant", blocks...)
+		case "tool":
+			add("user", anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content})
+		default:
+			content := m.Content
+			if m.Name != "" {
+				content = "@" + m.Name + ": " + content
+			}
+			add("user", text(content)...)
+		}
+	}
+
+	for _, t := range tools {
+		outTools = append(outTools, anthropicTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: t.Function.Parameters,
+		})
+	}
+	return strings.Join(systems, "\n\n"), out, outTools
+}
+
+// anthropicStream is ChatStreamContext for the Anthropic Messages API.
+func (c *Client) anthropicStream(ctx context.Context, model string, messages []Message, temperature float64, tools []Tool, onToken func(string)) (*ChatResult, error) {
+	system, msgs, atools := toAnthropic(messages, tools)
+	body, err := json.Marshal(anthropicRequest{
+		Model:       model,
+		System:      system,
+		Messages:    msgs,
+		MaxTokens:   AnthropicMaxTokens,
+		Temperature: temperature,
+		Stream:      true,
+		Tools:       atools,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{"anthropic-version": anthropicVersion}
+	if c.APIKey != "" {
+		headers["x-api-key"] = c.APIKey
+	}
+	resp, err := c.post(ctx, "/messages", body, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var fullContent strings.Builder
+	var usage Usage
+	var toolCalls []ToolCall
+	blockCall := make(map[int]int) // content block index -> toolCalls index
+	reader := bufio.NewReader(resp.Body)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return &ChatResult{Content: fullContent.String()}, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line != "" {
+			c.debugf("< %s", line)
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue // blank lines and "event: <type>" (the type is repeated in the data)
+		}
+
+		var ev anthropicEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+			continue
+		}
+
+		switch ev.Type {
+		case "message_start":
+			usage.PromptTokens = ev.Message.Usage.InputTokens
+		case "content_block_start":
+			if ev.ContentBlock.Type == "tool_use" {
+				call := ToolCall{ID: ev.ContentBlock.ID, Type: "function"}
+				call.Function.Name = ev.ContentBlock.Name
+				blockCall[ev.Index] = len(toolCalls)
+				toolCalls = append(toolCalls, call)
+			}
+		case "content_block_delta":
+			switch ev.Delta.Type {
+			case "text_delta":
+				fullContent.WriteString(ev.Delta.Text)
+				if onToken != nil {
+					onToken(ev.Delta.Text)
+				}
+			case "input_json_delta":
+				if i, ok := blockCall[ev.Index]; ok {
+					toolCalls[i].Function.Arguments += ev.Delta.PartialJSON
+				}
+			}
+		case "message_delta":
+			usage.CompletionTokens = ev.Usage.OutputTokens
+		case "error":
+			if ev.Error == nil {
+				ev.Error = &StreamError{Message: "unknown error"}
+			}
+			return &ChatResult{Content: fullContent.String()}, ev.Error
+		}
+	}
+
+	for i := range toolCalls {
+		if toolCalls[i].Function.Arguments == "" {
+			toolCalls[i].Function.Arguments = "{}"
+		}
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	return &ChatResult{
+		Content:   fullContent.String(),
+		ToolCalls: toolCalls,
+		Usage:     usage,
+	}, nil
+}
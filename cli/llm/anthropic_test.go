@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnthropicTranslatesRequest(t *testing.T) {
+	call := ToolCall{ID: "call_0", Type: "function"}
+	call.Function.Name = "bash"
+	call.Function.Arguments = `{"cmd":"ls"}`
+	messages := []Message{
+		{Role: "system", Content: "You are @code."},
+		{Role: "user", Content: "list files", Name: "user"},
+		{Role: "user", Content: "please", Name: "data"},
+		{Role: "assistant", ToolCalls: []ToolCall{call}},
+		{Role: "tool", Content: "a.txt", ToolCallID: "call_0"},
+		{Role: "assistant", Content: "There is a.txt."},
+	}
+
+	system, out, tools := toAnthropic(messages, []Tool{BashTool})
+	if system != "You are @code." {
+		t.Errorf("system: got %q", system)
+	}
+	got, _ := json.Marshal(out)
+	want := `[` +
+		`{"role":"user","content":[{"type":"text","text":"@user: list files"},{"type":"text","text":"@data: please"}]},` +
+		`{"role":"assistant","content":[{"type":"tool_use","id":"call_0","name":"bash","input":{"cmd":"ls"}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"call_0","content":"a.txt"}]},` +
+		`{"role":"assistant","content":[{"type":"text","text":"There is a.txt."}]}` +
+		`]`
+	if string(got) != want {
+		t.Errorf("messages:\n got %s\nwant %s", got, want)
+	}
+	if len(tools) != 1 || tools[0].Name != "bash" || tools[0].InputSchema["type"] != "object" {
+		t.Errorf("tools: got %+v", tools)
+	}
+}
+
+func TestAnthropicStream(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"usage":{"input_tokens":12}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"check."}}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"bash","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"cmd\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"ls\"}"}}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+		`{"type":"message_stop"}`,
+	}
+	var got anthropicRequest
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			http.NotFound(w, r)
+			return
+		}
+		header = r.Header
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			var typ struct{ Type string }
+			json.Unmarshal([]byte(e), &typ)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typ.Type, e)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL+"/v1", "sk-ant")
+	client.API = APIAnthropic
+	var tokens string
+	result, err := client.ChatStream("claude", []Message{{Role: "system", Content: "sys"}, {Role: "user", Content: "hi"}}, 0.3, []Tool{BashTool}, func(tok string) {
+		tokens += tok
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	if header.Get("x-api-key") != "sk-ant" || header.Get("anthropic-version") == "" {
+		t.Errorf("missing Anthropic headers: %v", header)
+	}
+	if got.Model != "claude" || got.System != "sys" || got.MaxTokens != AnthropicMaxTokens || !got.Stream {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if result.Content != "Let me check." || tokens != "Let me check." {
+		t.Errorf("content: got %q (streamed %q)", result.Content, tokens)
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].ID != "toolu_1" || result.ToolCalls[0].Function.Arguments != `{"cmd":"ls"}` {
+		t.Errorf("tool calls: got %+v", result.ToolCalls)
+	}
+	if result.Usage != (Usage{PromptTokens: 12, CompletionTokens: 7, TotalTokens: 19}) {
+		t.Errorf("usage: got %+v", result.Usage)
+	}
+}
+
+func TestAnthropicStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "")
+	client.API = APIAnthropic
+	result, err := client.ChatStream("claude", []Message{{Role: "user", Content: "hi"}}, 0.3, nil, nil)
+	var serr *StreamError
+	if !errors.As(err, &serr) || serr.Type != "overloaded_error" {
+		t.Fatalf("expected an overloaded StreamError, got %v", err)
+	}
+	if result.Content != "Hel" {
+		t.Errorf("expected partial content, got %q", result.Content)
+	}
+}
@@ -4,11 +4,18 @@ package llm
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
+	"time"
 )
 
 // Message represents a chat message
@@ -60,13 +67,65 @@ func init() {
 	}
 }
 
+// AskUserTool is the tool definition for asking the user a question
+// mid-turn. The answer comes back as the tool result.
+var AskUserTool = Tool{
+	Type: "function",
+}
+
+func init() {
+	AskUserTool.Function.Name = "ask_user"
+	AskUserTool.Function.Description = "Ask the user a question and wait for their answer, when you need clarification to finish the task."
+	AskUserTool.Function.Parameters = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"question": map[string]interface{}{
+				"type":        "string",
+				"description": "The question for the user",
+			},
+		},
+		"required": []string{"question"},
+	}
+}
+
 // ChatRequest is the request to the chat API
 type ChatRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Temperature float64   `json:"temperature"`
-	Stream      bool      `json:"stream"`
-	Tools       []Tool    `json:"tools,omitempty"`
+	Model           string          `json:"model"`
+	Messages        []Message       `json:"messages"`
+	Temperature     *float64        `json:"temperature,omitempty"` // nil for reasoning models, which reject it
+	Stream          bool            `json:"stream"`
+	StreamOptions   *StreamOptions  `json:"stream_options,omitempty"`
+	Tools           []Tool          `json:"tools,omitempty"`
+	ReasoningEffort string          `json:"reasoning_effort,omitempty"` // e.g. "low", "medium", "high"; for reasoning models
+	Seed            *int            `json:"seed,omitempty"`             // best-effort deterministic sampling
+	ResponseFormat  *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ResponseFormat constrains the reply's format.
+type ResponseFormat struct {
+	Type string `json:"type"` // "json_object" for JSON mode
+}
+
+// StreamOptions configures a streaming request
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // send token usage in a final chunk
+}
+
+// Usage is the token count for a request, as reported by the API.
+// Zero if the server doesn't report usage.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add returns the sum of two usages.
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+	}
 }
 
 // ChatResponse is a non-streaming response
@@ -96,18 +155,216 @@ type StreamChunk struct {
 		} `json:"delta"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
+	Usage *Usage          `json:"usage,omitempty"`
+	Error json.RawMessage `json:"error,omitempty"` // set by providers that fail mid-stream (see StreamError)
+}
+
+// StreamError is an error the provider sent inside the SSE stream, after
+// answering 200 — either an OpenAI-style {"error": {"message": ...}} object
+// or a bare {"error": "..."} string.
+type StreamError struct {
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"`
+	Code    any    `json:"code,omitempty"` // string or number, depending on the provider
+}
+
+func (e *StreamError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("API error in stream: %s: %s", e.Type, e.Message)
+	}
+	return "API error in stream: " + e.Message
+}
+
+// APIError is a non-200 response from the endpoint.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
+
+// IsUnavailable reports whether err means the endpoint could not serve the
+// request at all — it was unreachable, or answered with a 5xx — so the same
+// request may succeed against another backend.
+func IsUnavailable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
+
+// parseStreamError decodes the error field of a stream chunk.
+func parseStreamError(raw json.RawMessage) *StreamError {
+	var serr StreamError
+	if err := json.Unmarshal(raw, &serr); err == nil {
+		return &serr
+	}
+	var msg string
+	if err := json.Unmarshal(raw, &msg); err == nil {
+		return &StreamError{Message: msg}
+	}
+	return &StreamError{Message: string(raw)}
 }
 
 // ChatResult contains the response and any tool calls
 type ChatResult struct {
 	Content   string
 	ToolCalls []ToolCall
+	Usage     Usage
+}
+
+// ChatStreamer streams one chat completion. *Client implements it;
+// FakeClient is a scripted stand-in for tests and offline demos.
+type ChatStreamer interface {
+	ChatStreamContext(ctx context.Context, model string, messages []Message, temperature float64, tools []Tool, onToken func(string)) (*ChatResult, error)
 }
 
 // Client is an OpenAI-compatible API client
 type Client struct {
-	Endpoint string
-	APIKey   string
+	Endpoint   string
+	APIKey     string
+	API        string       // APIOpenAI (default) or APIAnthropic
+	Debug      io.Writer    // if set, raw requests and SSE lines are logged here, with the API key redacted
+	HTTPClient *http.Client // nil = a shared client from NewHTTPClient(DefaultHeaderTimeout)
+
+	// ReasoningEffort is sent as reasoning_effort to OpenAI-compatible
+	// endpoints ("" = the model's default). The Anthropic API ignores it.
+	ReasoningEffort string
+
+	// Seed is sent as seed to OpenAI-compatible endpoints, which may use it
+	// to sample deterministically (nil = none). The Anthropic API has no seed.
+	Seed *int
+
+	// JSONMode asks OpenAI-compatible endpoints for a reply that is a
+	// single JSON object. The Anthropic API has no such mode; prompt for
+	// JSON instead.
+	JSONMode bool
+
+	// ReasoningModel says whether the model is a reasoning model, which
+	// takes system prompts as developer messages and rejects temperature
+	// (nil = guess from the model name with IsReasoningModel). Only
+	// OpenAI-compatible requests are affected.
+	ReasoningModel *bool
+}
+
+// IsReasoningModel guesses from its name whether a model is one of
+// OpenAI's reasoning models (o1, o3-mini, o4-mini, gpt-5, ...), with or
+// without a provider prefix like "openai/".
+func IsReasoningModel(model string) bool {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if len(name) >= 2 && name[0] == 'o' && name[1] >= '0' && name[1] <= '9' {
+		return true
+	}
+	return strings.HasPrefix(name, "gpt-5") && !strings.Contains(name, "chat")
+}
+
+// reasoning reports whether requests for model get reasoning-model treatment.
+func (c *Client) reasoning(model string) bool {
+	if c.ReasoningModel != nil {
+		return *c.ReasoningModel
+	}
+	return IsReasoningModel(model)
+}
+
+// developerRoles returns a copy of messages with system messages turned
+// into developer messages, as reasoning models expect.
+func developerRoles(messages []Message) []Message {
+	out := append([]Message(nil), messages...)
+	for i := range out {
+		if out[i].Role == "system" {
+			out[i].Role = "developer"
+		}
+	}
+	return out
+}
+
+// DefaultHeaderTimeout is how long the default HTTP client waits for
+// response headers. It is generous because local servers may load a model
+// before answering.
+const DefaultHeaderTimeout = 5 * time.Minute
+
+var defaultHTTPClient = NewHTTPClient(DefaultHeaderTimeout)
+
+// NewHTTPClient returns an HTTP client suited to streaming completions: it
+// bounds connecting, the TLS handshake, and waiting for response headers
+// (headerTimeout; 0 = no limit), but sets no overall timeout, since a
+// stream may legitimately run for minutes. Proxies are taken from the
+// environment, as with http.DefaultTransport.
+func NewHTTPClient(headerTimeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.ResponseHeaderTimeout = headerTimeout
+	return &http.Client{Transport: transport}
+}
+
+// API flavors a Client can speak.
+const (
+	APIOpenAI    = "openai"    // POST /chat/completions
+	APIAnthropic = "anthropic" // POST /messages (Anthropic Messages API)
+)
+
+// maxNameLen is the longest message name the OpenAI API accepts.
+const maxNameLen = 64
+
+// nameRe matches the message names the OpenAI API accepts; some
+// compatible servers are stricter still about anything else.
+var nameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// invalidNameRun matches a run of characters not allowed in a message name.
+var invalidNameRun = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// SanitizeName maps a participant name, such as an agent ID without its
+// "@", to one the OpenAI API accepts. Valid names are kept as they are.
+// Otherwise runs of other characters become "_", and a short hash of the
+// original is appended, so that names differing only in those characters
+// stay distinct. Long names are cut to 64 characters, keeping the hash.
+func SanitizeName(name string) string {
+	if name == "" || nameRe.MatchString(name) {
+		return name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	suffix := fmt.Sprintf("-%06x", h.Sum32()&0xffffff)
+	base := invalidNameRun.ReplaceAllString(name, "_")
+	if len(base) > maxNameLen-len(suffix) {
+		base = base[:maxNameLen-len(suffix)]
+	}
+	return base + suffix
+}
+
+// sanitizeNames returns messages with every Name passed through
+// SanitizeName, copying the slice only if a name changes.
+func sanitizeNames(messages []Message) []Message {
+	var out []Message
+	for i, m := range messages {
+		name := SanitizeName(m.Name)
+		if name == m.Name {
+			continue
+		}
+		if out == nil {
+			out = append([]Message(nil), messages...)
+		}
+		out[i].Name = name
+	}
+	if out == nil {
+		return messages
+	}
+	return out
 }
 
 // NewClient creates a new LLM client
@@ -120,42 +377,52 @@ func NewClient(endpoint, apiKey string) *Client {
 
 // ChatStream sends a chat request and streams the response
 func (c *Client) ChatStream(model string, messages []Message, temperature float64, tools []Tool, onToken func(string)) (*ChatResult, error) {
-	req := ChatRequest{
-		Model:       model,
-		Messages:    messages,
-		Temperature: temperature,
-		Stream:      true,
-		Tools:       tools,
+	return c.ChatStreamContext(context.Background(), model, messages, temperature, tools, onToken)
+}
+
+// ChatStreamContext is ChatStream with a context; cancelling it aborts the
+// request, including a response that is still streaming.
+func (c *Client) ChatStreamContext(ctx context.Context, model string, messages []Message, temperature float64, tools []Tool, onToken func(string)) (*ChatResult, error) {
+	if c.API == APIAnthropic {
+		return c.anthropicStream(ctx, model, messages, temperature, tools, onToken)
 	}
 
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
+	req := ChatRequest{
+		Model:           model,
+		Messages:        sanitizeNames(messages),
+		Temperature:     &temperature,
+		Stream:          true,
+		StreamOptions:   &StreamOptions{IncludeUsage: true},
+		Tools:           tools,
+		ReasoningEffort: c.ReasoningEffort,
+		Seed:            c.Seed,
+	}
+	if c.JSONMode {
+		req.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	}
+	if c.reasoning(model) {
+		req.Messages = developerRoles(req.Messages)
+		req.Temperature = nil
 	}
 
-	httpReq, err := http.NewRequest("POST", c.Endpoint+"/chat/completions", bytes.NewReader(body))
+	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	headers := map[string]string{}
 	if c.APIKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+		headers["Authorization"] = "Bearer " + c.APIKey
 	}
-
-	resp, err := http.DefaultClient.Do(httpReq)
+	resp, err := c.post(ctx, "/chat/completions", body, headers)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
-
 	// Parse SSE stream
 	var fullContent strings.Builder
+	var usage Usage
 	toolCalls := make(map[int]*ToolCall) // Index -> ToolCall
 	reader := bufio.NewReader(resp.Body)
 
@@ -169,6 +436,9 @@ func (c *Client) ChatStream(model string, messages []Message, temperature float6
 		}
 
 		line = strings.TrimSpace(line)
+		if line != "" {
+			c.debugf("< %s", line)
+		}
 		if line == "" || line == "data: [DONE]" {
 			continue
 		}
@@ -183,6 +453,14 @@ func (c *Client) ChatStream(model string, messages []Message, temperature float6
 			continue
 		}
 
+		if len(chunk.Error) > 0 && string(chunk.Error) != "null" {
+			return &ChatResult{Content: fullContent.String()}, parseStreamError(chunk.Error)
+		}
+
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+
 		if len(chunk.Choices) > 0 {
 			delta := chunk.Choices[0].Delta
 
@@ -228,5 +506,70 @@ func (c *Client) ChatStream(model string, messages []Message, temperature float6
 	return &ChatResult{
 		Content:   fullContent.String(),
 		ToolCalls: resultToolCalls,
+		Usage:     usage,
 	}, nil
 }
+
+// post sends a JSON request to c.Endpoint+path and returns the response,
+// or an error for a non-200 status. The caller closes the response body.
+func (c *Client) post(ctx context.Context, path string, body []byte, headers map[string]string) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+	c.logRequest(httpReq, body)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		c.debugf("error: %v", err)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		c.debugf("< %s %s", resp.Status, body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	c.debugf("< %s", resp.Status)
+	return resp, nil
+}
+
+// logRequest logs an outgoing request to c.Debug: method, URL, headers
+// (Authorization redacted), and body.
+func (c *Client) logRequest(req *http.Request, body []byte) {
+	if c.Debug == nil {
+		return
+	}
+	c.debugf("> %s %s", req.Method, req.URL)
+	for name, values := range req.Header {
+		for _, v := range values {
+			if name == "Authorization" || name == "X-Api-Key" {
+				v = "[REDACTED]"
+			}
+			c.debugf("> %s: %s", name, v)
+		}
+	}
+	c.debugf("> %s", body)
+}
+
+// debugf writes one line to c.Debug, with any occurrence of the API key
+// redacted.
+func (c *Client) debugf(format string, args ...any) {
+	if c.Debug == nil {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if c.APIKey != "" {
+		msg = strings.ReplaceAll(msg, c.APIKey, "[REDACTED]")
+	}
+	fmt.Fprintf(c.Debug, "  [llm] %s\n", msg)
+}
@@ -0,0 +1,298 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseServer serves the given SSE data lines for every request.
+func sseServer(t *testing.T, lines ...string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, l := range lines {
+			fmt.Fprintf(w, "data: %s\n\n", l)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDebugLogsTrafficWithKeyRedacted(t *testing.T) {
+	srv := sseServer(t, `{"choices":[{"delta":{"content":"hi"}}]}`, "[DONE]")
+
+	var log bytes.Buffer
+	client := NewClient(srv.URL, "sk-secret-key")
+	client.Debug = &log
+	messages := []Message{{Role: "user", Content: "my key is sk-secret-key"}}
+	if _, err := client.ChatStream("m", messages, 0.5, nil, nil); err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	got := log.String()
+	for _, want := range []string{
+		"> POST " + srv.URL + "/chat/completions",
+		"> Authorization: [REDACTED]",
+		`"content":"my key is [REDACTED]"`,
+		`< data: {"choices":[{"delta":{"content":"hi"}}]}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "sk-secret-key") {
+		t.Errorf("log leaks the API key:\n%s", got)
+	}
+}
+
+func TestChatStreamMidStreamError(t *testing.T) {
+	tests := []struct {
+		name  string
+		chunk string
+		want  string
+	}{
+		{"openai object", `{"error":{"message":"context length exceeded","type":"invalid_request_error","code":400}}`, "API error in stream: invalid_request_error: context length exceeded"},
+		{"bare string", `{"error":"model overloaded"}`, "API error in stream: model overloaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := sseServer(t,
+				`{"choices":[{"delta":{"content":"partial "}}]}`,
+				tt.chunk,
+				`{"choices":[{"delta":{"content":"never seen"}}]}`,
+				"[DONE]",
+			)
+
+			var tokens []string
+			result, err := NewClient(srv.URL, "").ChatStream("m", nil, 0.5, nil, func(tok string) {
+				tokens = append(tokens, tok)
+			})
+			var serr *StreamError
+			if !errors.As(err, &serr) {
+				t.Fatalf("expected a *StreamError, got %v", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("error: got %q, want %q", err.Error(), tt.want)
+			}
+			if result == nil || result.Content != "partial " {
+				t.Errorf("expected the partial content before the error, got %+v", result)
+			}
+			if strings.Join(tokens, "") != "partial " {
+				t.Errorf("streaming should stop at the error, got tokens %q", tokens)
+			}
+		})
+	}
+}
+
+func TestChatStreamHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := NewClient(srv.URL, "")
+	client.HTTPClient = NewHTTPClient(50 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.ChatStream("m", nil, 0, nil, nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "timeout") {
+			t.Fatalf("expected a header timeout, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ChatStream hung on a server that never answers")
+	}
+}
+
+// recordingTransport counts round trips before delegating.
+type recordingTransport struct {
+	n int
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.n++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestChatStreamUsesCustomHTTPClient(t *testing.T) {
+	srv := sseServer(t, `{"choices":[{"delta":{"content":"hi"}}]}`, "[DONE]")
+
+	rt := &recordingTransport{}
+	client := NewClient(srv.URL, "")
+	client.HTTPClient = &http.Client{Transport: rt}
+	if _, err := client.ChatStream("m", nil, 0, nil, nil); err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if rt.n != 1 {
+		t.Errorf("expected the request to go through the custom client, got %d round trips", rt.n)
+	}
+}
+
+func TestChatStreamSendsReasoningEffort(t *testing.T) {
+	for _, effort := range []string{"", "high"} {
+		var body []byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}))
+		client := NewClient(srv.URL, "")
+		client.ReasoningEffort = effort
+		if _, err := client.ChatStream("m", nil, 0, nil, nil); err != nil {
+			t.Fatalf("ChatStream: %v", err)
+		}
+		srv.Close()
+
+		if got := strings.Contains(string(body), "reasoning_effort"); got != (effort != "") {
+			t.Errorf("effort %q: reasoning_effort sent = %v in %s", effort, got, body)
+		}
+		if effort != "" && !strings.Contains(string(body), `"reasoning_effort":"high"`) {
+			t.Errorf("expected reasoning_effort high in %s", body)
+		}
+	}
+}
+
+func TestChatStreamJSONMode(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL, "")
+	client.JSONMode = true
+	if _, err := client.ChatStream("m", nil, 0, nil, nil); err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if !strings.Contains(string(body), `"response_format":{"type":"json_object"}`) {
+		t.Errorf("expected JSON mode in %s", body)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	long := strings.Repeat("x", 80)
+	for _, name := range []string{"data", "code_2", "data.v2", "Dr. Who", "données", long} {
+		got := SanitizeName(name)
+		if !nameRe.MatchString(got) {
+			t.Errorf("SanitizeName(%q) = %q, not a valid name", name, got)
+		}
+		if got != SanitizeName(name) {
+			t.Errorf("SanitizeName(%q) is not stable", name)
+		}
+	}
+	if got := SanitizeName("code_2"); got != "code_2" {
+		t.Errorf("valid name changed: %q", got)
+	}
+	if SanitizeName("data.v2") == SanitizeName("data v2") {
+		t.Error("distinct names should stay distinct")
+	}
+}
+
+func TestChatStreamSanitizesNames(t *testing.T) {
+	var req ChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		for _, m := range req.Messages {
+			if m.Name != "" && !regexp.MustCompile(`^[a-zA-Z0-9_-]+$`).MatchString(m.Name) {
+				http.Error(w, `{"error":{"message":"Invalid 'messages.name'"}}`, http.StatusBadRequest)
+				return
+			}
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	messages := []Message{
+		{Role: "system", Content: "You are @code."},
+		{Role: "user", Name: "user", Content: "hi"},
+		{Role: "user", Name: "data.v2", Content: "@code? check this"},
+	}
+	if _, err := NewClient(srv.URL, "").ChatStream("m", messages, 0, nil, nil); err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if req.Messages[1].Name != "user" || !strings.HasPrefix(req.Messages[2].Name, "data_v2-") {
+		t.Errorf("unexpected names: %q, %q", req.Messages[1].Name, req.Messages[2].Name)
+	}
+	if messages[2].Name != "data.v2" {
+		t.Errorf("caller's messages were changed: %q", messages[2].Name)
+	}
+}
+
+func TestIsReasoningModel(t *testing.T) {
+	for model, want := range map[string]bool{
+		"o1":                 true,
+		"o3-mini":            true,
+		"openai/o4-mini":     true,
+		"gpt-5":              true,
+		"gpt-5-mini":         true,
+		"gpt-5-chat-latest":  false,
+		"gpt-4o":             false,
+		"olmo2":              false,
+		"qwen3:8b":           false,
+		"claude-sonnet-4-5":  false,
+		"openai/gpt-4o-mini": false,
+	} {
+		if got := IsReasoningModel(model); got != want {
+			t.Errorf("IsReasoningModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestChatStreamReasoningModel(t *testing.T) {
+	var req map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = nil
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+	messages := []Message{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "hi"}}
+	no := false
+
+	for _, tc := range []struct {
+		model     string
+		override  *bool
+		reasoning bool
+	}{
+		{"o3-mini", nil, true},
+		{"gpt-4o", nil, false},
+		{"o3-mini", &no, false},
+	} {
+		client := NewClient(srv.URL, "")
+		client.ReasoningModel = tc.override
+		if _, err := client.ChatStream(tc.model, messages, 0.7, nil, nil); err != nil {
+			t.Fatalf("ChatStream: %v", err)
+		}
+		role := req["messages"].([]any)[0].(map[string]any)["role"]
+		_, hasTemp := req["temperature"]
+		if tc.reasoning && (role != "developer" || hasTemp) {
+			t.Errorf("%s: expected a developer message and no temperature, got role %v, temperature %v", tc.model, role, req["temperature"])
+		}
+		if !tc.reasoning && (role != "system" || req["temperature"] != 0.7) {
+			t.Errorf("%s: expected a system message and temperature 0.7, got role %v, temperature %v", tc.model, role, req["temperature"])
+		}
+	}
+	if messages[0].Role != "system" {
+		t.Errorf("caller's messages were changed: %q", messages[0].Role)
+	}
+}
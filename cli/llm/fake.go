@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrFakeExhausted is returned by FakeClient when its script has run out.
+var ErrFakeExhausted = errors.New("fake LLM: no more replies")
+
+// FakeReply is one scripted response of a FakeClient.
+type FakeReply struct {
+	Content   string     // streamed word by word
+	ToolCalls []ToolCall // returned after the content
+	Err       error      // if set, returned instead (after streaming Content)
+}
+
+// FakeRequest records one call to a FakeClient.
+type FakeRequest struct {
+	Model    string
+	Messages []Message
+	Tools    []Tool
+}
+
+// FakeClient is a ChatStreamer that returns scripted replies in order, for
+// tests and offline demos. It is safe for concurrent use.
+type FakeClient struct {
+	mu       sync.Mutex
+	replies  []FakeReply
+	requests []FakeRequest
+}
+
+// NewFakeClient creates a fake that answers calls with replies, in order.
+func NewFakeClient(replies ...FakeReply) *FakeClient {
+	return &FakeClient{replies: replies}
+}
+
+// FakeToolCall builds a tool call for a FakeReply.
+func FakeToolCall(id, name, arguments string) ToolCall {
+	call := ToolCall{ID: id, Type: "function"}
+	call.Function.Name = name
+	call.Function.Arguments = arguments
+	return call
+}
+
+// ChatStreamContext records the request and plays the next scripted reply.
+func (f *FakeClient) ChatStreamContext(ctx context.Context, model string, messages []Message, temperature float64, tools []Tool, onToken func(string)) (*ChatResult, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, FakeRequest{Model: model, Messages: append([]Message(nil), messages...), Tools: tools})
+	if len(f.replies) == 0 {
+		f.mu.Unlock()
+		return nil, ErrFakeExhausted
+	}
+	reply := f.replies[0]
+	f.replies = f.replies[1:]
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if onToken != nil {
+		for _, word := range strings.SplitAfter(reply.Content, " ") {
+			if word != "" {
+				onToken(word)
+			}
+		}
+	}
+	if reply.Err != nil {
+		return &ChatResult{Content: reply.Content}, reply.Err
+	}
+	return &ChatResult{Content: reply.Content, ToolCalls: reply.ToolCalls}, nil
+}
+
+// Requests returns the requests received so far.
+func (f *FakeClient) Requests() []FakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeRequest(nil), f.requests...)
+}
@@ -0,0 +1,88 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotStarted is returned when a command is run before Start.
+var ErrNotStarted = errors.New("sandbox not started")
+
+// TimeoutError is returned when a command exceeds the sandbox timeout.
+type TimeoutError struct {
+	Duration time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("command timed out after %v", e.Duration)
+}
+
+// DockerError is returned by Start when Docker isn't installed or its
+// daemon isn't reachable.
+type DockerError struct {
+	NotFound bool   // no docker binary on PATH (otherwise the daemon isn't running)
+	Detail   string // docker's own message, if any
+}
+
+func (e *DockerError) Error() string {
+	msg := "Docker daemon not running"
+	if e.NotFound {
+		msg = "Docker not found"
+	}
+	if e.Detail != "" {
+		msg += " (" + e.Detail + ")"
+	}
+	return msg + " — install or start Docker, or remove the sandbox workstation from the blueprint to run without tools"
+}
+
+// ImageError is returned when the sandbox image cannot be located or built.
+type ImageError struct {
+	Msg string // human-readable description
+	Err error  // underlying cause, if any
+}
+
+func (e *ImageError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Msg, e.Err)
+	}
+	return e.Msg
+}
+
+func (e *ImageError) Unwrap() error { return e.Err }
+
+// StartError is returned when the sandbox container fails to start.
+type StartError struct {
+	Image string
+	Err   error
+}
+
+func (e *StartError) Error() string {
+	return fmt.Sprintf("failed to start container (image: %s): %v", e.Image, e.Err)
+}
+
+func (e *StartError) Unwrap() error { return e.Err }
+
+// MountError is returned when a mount's host path doesn't exist. Docker
+// would silently create it as an empty, root-owned directory.
+type MountError struct {
+	Spec string // the mount, "host:container[:ro]"
+	Err  error
+}
+
+func (e *MountError) Error() string {
+	return fmt.Sprintf("mount %s: %v", e.Spec, e.Err)
+}
+
+func (e *MountError) Unwrap() error { return e.Err }
+
+// InitError is returned when the sandbox's init script fails.
+type InitError struct {
+	Err error // usually an *exec.ExitError
+}
+
+func (e *InitError) Error() string {
+	return fmt.Sprintf("init script failed: %v", e.Err)
+}
+
+func (e *InitError) Unwrap() error { return e.Err }
@@ -3,26 +3,39 @@ package sandbox
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/openfloorcontrol/ofc/clock"
 )
 
 const (
-	DefaultImage   = "python:3.11-slim"
-	DefaultTimeout = 30 * time.Second
+	DefaultImage       = "python:3.11-slim"
+	DefaultTimeout     = 30 * time.Second
+	DefaultInitTimeout = 5 * time.Minute // for Init, which may start services
 )
 
 // Sandbox manages a Docker container for code execution
 type Sandbox struct {
-	ContainerID    string
-	Image          string
-	DockerfileDir  string // directory containing Dockerfile (empty = use Image directly)
-	WorkspaceDir   string
-	Timeout        time.Duration
+	ContainerID   string
+	Image         string
+	DockerfileDir string // directory containing Dockerfile (empty = use Image directly)
+	WorkspaceDir  string
+	User          string   // passed to docker run --user (e.g. "1000:1000"; empty = the image's user)
+	Mounts        []string // extra bind mounts, "host:container[:ro]"; relative host paths are resolved against the current directory
+	Timeout       time.Duration
+	Log           io.Writer        // image build/pull progress and docker's output (nil = os.Stdout)
+	Progress      func(msg string) // receives that progress a line at a time instead of Log, if set
+	Clock         clock.Clock      // times out Execute (nil = clock.Real)
 }
 
 // New creates a new sandbox
@@ -38,17 +51,21 @@ func New(workspaceDir, image, dockerfile string) *Sandbox {
 	}
 }
 
-// ensureImage builds the Docker image from Dockerfile if needed
-func (s *Sandbox) ensureImage() error {
+// ensureImage builds the Docker image from Dockerfile if needed, or pulls
+// it if there's no Dockerfile and it isn't present locally
+func (s *Sandbox) ensureImage(ctx context.Context) error {
 	if s.DockerfileDir == "" {
-		return nil
+		if imageExists(ctx, s.Image) {
+			return nil
+		}
+		return s.pullImage(ctx)
 	}
 
 	// Resolve to directory containing the Dockerfile
 	dockerfileDir := s.DockerfileDir
 	info, err := os.Stat(dockerfileDir)
 	if err != nil {
-		return fmt.Errorf("dockerfile path not found: %s", dockerfileDir)
+		return &ImageError{Msg: fmt.Sprintf("dockerfile path not found: %s", dockerfileDir)}
 	}
 	// If it points to a file, use its directory
 	if !info.IsDir() {
@@ -57,55 +74,184 @@ func (s *Sandbox) ensureImage() error {
 
 	dockerfilePath := filepath.Join(dockerfileDir, "Dockerfile")
 	if _, err := os.Stat(dockerfilePath); err != nil {
-		return fmt.Errorf("Dockerfile not found: %s", dockerfilePath)
+		return &ImageError{Msg: fmt.Sprintf("Dockerfile not found: %s", dockerfilePath)}
 	}
 
-	// Check if image exists and if Dockerfile is newer
-	needsBuild := false
-	imageTime := getImageCreatedTime(s.Image)
-	if imageTime.IsZero() {
-		needsBuild = true
-	} else {
-		dfInfo, err := os.Stat(dockerfilePath)
-		if err == nil && dfInfo.ModTime().After(imageTime) {
-			needsBuild = true
-		}
+	// Rebuild only when the build context's content has changed since the
+	// image was built, regardless of mtimes
+	hash, err := contextHash(dockerfileDir)
+	if err != nil {
+		return &ImageError{Msg: "failed to hash build context", Err: err}
 	}
-
-	if !needsBuild {
+	if imageLabel(ctx, s.Image, contextHashLabel) == hash {
 		return nil
 	}
 
-	fmt.Printf("\033[2m[System]: Building sandbox image (%s)...\033[0m\n", s.Image)
-	cmd := exec.Command("docker", "build", "-t", s.Image, dockerfileDir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to build image: %w", err)
+	s.status(fmt.Sprintf("Building sandbox image (%s)...", s.Image))
+	cmd := exec.CommandContext(ctx, "docker", "build", "-t", s.Image, "--label", contextHashLabel+"="+hash, dockerfileDir)
+	if err := s.runWithProgress(cmd); err != nil {
+		return &ImageError{Msg: "failed to build image", Err: err}
 	}
-	fmt.Printf("\033[2m[System]: Sandbox image ready\033[0m\n")
+	s.status("Sandbox image ready")
 	return nil
 }
 
-// getImageCreatedTime returns the creation time of a Docker image, or zero time if not found
-func getImageCreatedTime(image string) time.Time {
-	cmd := exec.Command("docker", "inspect", "-f", "{{.Created}}", image)
+// pullImage pulls the image with docker's progress going to the log, so a
+// first run doesn't sit silently inside docker run while it downloads.
+func (s *Sandbox) pullImage(ctx context.Context) error {
+	s.status(fmt.Sprintf("Pulling sandbox image (%s)...", s.Image))
+	cmd := exec.CommandContext(ctx, "docker", "pull", s.Image)
+	if err := s.runWithProgress(cmd); err != nil {
+		return &ImageError{Msg: "failed to pull image " + s.Image, Err: err}
+	}
+	s.status("Sandbox image ready")
+	return nil
+}
+
+// status reports a step of preparing the image, to Progress or the log.
+func (s *Sandbox) status(msg string) {
+	if s.Progress != nil {
+		s.Progress(msg)
+		return
+	}
+	fmt.Fprintf(s.logWriter(), "\033[2m[System]: %s\033[0m\n", msg)
+}
+
+// runWithProgress runs cmd with its output going to Progress, a line at a
+// time, or to the log.
+func (s *Sandbox) runWithProgress(cmd *exec.Cmd) error {
+	if s.Progress == nil {
+		cmd.Stdout = s.logWriter()
+		cmd.Stderr = cmd.Stdout
+		return cmd.Run()
+	}
+	w := &lineWriter{emit: s.Progress}
+	cmd.Stdout = w
+	cmd.Stderr = w // the same writer, so exec never calls Write concurrently
+	err := cmd.Run()
+	w.flush()
+	return err
+}
+
+// lineWriter passes each non-blank line written to it to emit.
+type lineWriter struct {
+	emit func(string)
+	buf  []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.line(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// flush emits a last line with no newline after it.
+func (w *lineWriter) flush() {
+	w.line(w.buf)
+	w.buf = nil
+}
+
+func (w *lineWriter) line(b []byte) {
+	if line := strings.TrimRight(string(b), "\r \t"); strings.TrimSpace(line) != "" {
+		w.emit(line)
+	}
+}
+
+// logWriter returns where build output goes, defaulting to stdout.
+func (s *Sandbox) logWriter() io.Writer {
+	if s.Log != nil {
+		return s.Log
+	}
+	return os.Stdout
+}
+
+// contextHashLabel is the image label recording the build context's hash.
+const contextHashLabel = "ofc.context-hash"
+
+// imageExists reports whether a Docker image is present locally
+func imageExists(ctx context.Context, image string) bool {
+	return exec.CommandContext(ctx, "docker", "image", "inspect", image).Run() == nil
+}
+
+// imageLabel returns a label of a local Docker image, or "" if the image or
+// label is missing
+func imageLabel(ctx context.Context, image, label string) string {
+	cmd := exec.CommandContext(ctx, "docker", "image", "inspect", "-f", fmt.Sprintf("{{index .Config.Labels %q}}", label), image)
 	output, err := cmd.Output()
 	if err != nil {
-		return time.Time{}
+		return ""
 	}
-	created := strings.TrimSpace(string(output))
-	t, err := time.Parse(time.RFC3339Nano, created)
+	return strings.TrimSpace(string(output))
+}
+
+// contextHash hashes the path, mode and content of every file in a build
+// context directory, so editing a file the Dockerfile COPYs triggers a
+// rebuild but a fresh checkout doesn't.
+func contextHash(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		fmt.Fprintf(h, "%s\x00%o\x00%d\x00", filepath.ToSlash(rel), info.Mode(), info.Size())
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
 	if err != nil {
-		return time.Time{}
+		return "", err
 	}
-	return t
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
 }
 
 // Start launches the sandbox container
 func (s *Sandbox) Start() error {
+	return s.StartContext(context.Background())
+}
+
+// StartContext is Start bounded by ctx: when ctx is done, the docker
+// commands still running (such as an image pull or build) are killed and
+// StartContext returns an error wrapping ctx.Err().
+func (s *Sandbox) StartContext(ctx context.Context) error {
+	err := s.start(ctx)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("sandbox start: %w", ctx.Err())
+	}
+	return err
+}
+
+func (s *Sandbox) start(ctx context.Context) error {
+	// Fail clearly up front rather than with a raw exec error from docker run
+	if err := checkDocker(ctx); err != nil {
+		return err
+	}
+
 	// Build image from Dockerfile if configured
-	if err := s.ensureImage(); err != nil {
+	if err := s.ensureImage(ctx); err != nil {
 		return err
 	}
 
@@ -120,18 +266,16 @@ func (s *Sandbox) Start() error {
 		os.MkdirAll(wsAbs, 0o755)
 	}
 
-	// Start container with workspace bind-mounted at the same absolute path
-	// so the agent can use real host paths and writes go through naturally.
-	args := []string{"run", "-d", "--rm", "-w", wsAbs}
-	if wsAbs != "" {
-		args = append(args, "-v", wsAbs+":"+wsAbs)
+	mounts, err := resolveMounts(s.Mounts)
+	if err != nil {
+		return err
 	}
-	args = append(args, s.Image, "sleep", "infinity")
-	cmd := exec.Command("docker", args...)
+
+	cmd := exec.CommandContext(ctx, "docker", s.runArgs(wsAbs, mounts)...)
 
 	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("failed to start container (image: %s): %w", s.Image, err)
+		return &StartError{Image: s.Image, Err: err}
 	}
 
 	s.ContainerID = strings.TrimSpace(string(output))
@@ -139,10 +283,73 @@ func (s *Sandbox) Start() error {
 	return nil
 }
 
+// checkDocker reports a *DockerError if the docker CLI is missing or
+// can't reach the daemon.
+func checkDocker(ctx context.Context) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return &DockerError{NotFound: true}
+	}
+	out, err := exec.CommandContext(ctx, "docker", "info", "--format", "{{.ServerVersion}}").CombinedOutput()
+	if err != nil {
+		detail := strings.TrimSpace(string(out))
+		if i := strings.IndexByte(detail, '\n'); i >= 0 {
+			detail = detail[:i]
+		}
+		return &DockerError{Detail: detail}
+	}
+	return nil
+}
+
+// runArgs returns the docker arguments that start the container, with the
+// workspace bind-mounted at the same absolute path so the agent can use
+// real host paths and writes go through naturally.
+func (s *Sandbox) runArgs(wsAbs string, mounts []string) []string {
+	args := []string{"run", "-d", "--rm", "-w", wsAbs}
+	if wsAbs != "" {
+		args = append(args, "-v", wsAbs+":"+wsAbs)
+	}
+	for _, m := range mounts {
+		args = append(args, "-v", m)
+	}
+	if s.User != "" {
+		args = append(args, "--user", s.User)
+	}
+	return append(args, s.Image, "sleep", "infinity")
+}
+
+// resolveMounts makes the host path of each mount absolute, checking
+// that it exists.
+func resolveMounts(specs []string) ([]string, error) {
+	var mounts []string
+	for _, spec := range specs {
+		host, rest, _ := strings.Cut(spec, ":")
+		abs, err := filepath.Abs(host)
+		if err != nil {
+			return nil, &MountError{Spec: spec, Err: err}
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return nil, &MountError{Spec: spec, Err: err}
+		}
+		mounts = append(mounts, abs+":"+rest)
+	}
+	return mounts, nil
+}
+
+// HostUser returns the current user's "UID:GID", so files the sandbox
+// creates in the workspace belong to them rather than root. It returns ""
+// where there are no Unix IDs (Windows).
+func HostUser() string {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid < 0 || gid < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", uid, gid)
+}
+
 // Execute runs a command in the sandbox
 func (s *Sandbox) Execute(command string) (string, error) {
 	if s.ContainerID == "" {
-		return "", fmt.Errorf("sandbox not started")
+		return "", ErrNotStarted
 	}
 
 	cmd := exec.Command("docker", "exec", s.ContainerID, "bash", "-c", command)
@@ -151,11 +358,15 @@ func (s *Sandbox) Execute(command string) (string, error) {
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	// Create a channel for the result
+	// Start before waiting, so a timeout always has a process to kill
 	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Run()
-	}()
+	if err := cmd.Start(); err != nil {
+		done <- err
+	} else {
+		go func() {
+			done <- cmd.Wait()
+		}()
+	}
 
 	// Wait with timeout
 	select {
@@ -173,10 +384,70 @@ func (s *Sandbox) Execute(command string) (string, error) {
 		}
 		return strings.TrimSpace(output), nil
 
-	case <-time.After(s.Timeout):
+	case <-s.clock().After(s.Timeout):
 		cmd.Process.Kill()
-		return "", fmt.Errorf("command timed out after %v", s.Timeout)
+		return "", &TimeoutError{Duration: s.Timeout}
+	}
+}
+
+// WriteFile writes content to path in the sandbox, creating its parent
+// directories. The content goes in over stdin, so it lands byte for byte,
+// whatever it contains.
+func (s *Sandbox) WriteFile(path, content string) error {
+	if s.ContainerID == "" {
+		return ErrNotStarted
+	}
+
+	cmd := exec.Command("docker", "exec", "-i", s.ContainerID,
+		"bash", "-c", `mkdir -p "$(dirname "$1")" && cat > "$1"`, "bash", path)
+	cmd.Stdin = strings.NewReader(content)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// clock returns the sandbox's clock.
+func (s *Sandbox) clock() clock.Clock {
+	if s.Clock == nil {
+		return clock.Real
+	}
+	return s.Clock
+}
+
+// Init runs a setup script in the started container, such as starting a
+// database the agents will query. Unlike Execute it fails if the script
+// exits non-zero, with an *InitError. It returns the script's combined
+// output either way.
+func (s *Sandbox) Init(script string) (string, error) {
+	return s.InitContext(context.Background(), script)
+}
+
+// InitContext is Init bounded by ctx as well as DefaultInitTimeout. If ctx
+// is done first, the error wraps ctx.Err() rather than being a
+// *TimeoutError.
+func (s *Sandbox) InitContext(ctx context.Context, script string) (string, error) {
+	if s.ContainerID == "" {
+		return "", ErrNotStarted
+	}
+
+	initCtx, cancel := context.WithTimeout(ctx, DefaultInitTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(initCtx, "docker", "exec", s.ContainerID, "bash", "-c", script).CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if ctx.Err() != nil {
+		return output, fmt.Errorf("sandbox init: %w", ctx.Err())
+	}
+	if initCtx.Err() != nil {
+		return output, &TimeoutError{Duration: DefaultInitTimeout}
+	}
+	if err != nil {
+		return output, &InitError{Err: err}
 	}
+	return output, nil
 }
 
 // Stop kills the sandbox container
@@ -0,0 +1,257 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfloorcontrol/ofc/clock"
+)
+
+func TestExecuteNotStarted(t *testing.T) {
+	s := New("", "", "")
+	_, err := s.Execute("true")
+	if !errors.Is(err, ErrNotStarted) {
+		t.Fatalf("expected ErrNotStarted, got %v", err)
+	}
+	if err.Error() != "sandbox not started" {
+		t.Errorf("unexpected message: %q", err)
+	}
+	if _, err := s.Init("true"); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("expected ErrNotStarted from Init, got %v", err)
+	}
+}
+
+func TestExecuteTimeout(t *testing.T) {
+	// A docker that never finishes, so only the clock can end the command.
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte("#!/bin/sh\nexec /bin/sleep 60\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	fake := clock.NewFake(time.Now())
+	s := New("", "", "")
+	s.ContainerID = "test"
+	s.Clock = fake
+	errc := make(chan error, 1)
+	go func() {
+		_, err := s.Execute("true")
+		errc <- err
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(DefaultTimeout)
+	select {
+	case err := <-errc:
+		var te *TimeoutError
+		if !errors.As(err, &te) || te.Duration != DefaultTimeout {
+			t.Fatalf("expected a TimeoutError after %s, got %v", DefaultTimeout, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Execute did not time out when the clock advanced")
+	}
+}
+
+func TestWriteFileKeepsContentExact(t *testing.T) {
+	// A docker that runs the exec'd command on the host instead.
+	dir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$1 $2\" = \"exec -i\" ] || exit 1\nshift 3\nexec \"$@\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	s := New("", "", "")
+	if err := s.WriteFile("x", ""); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("expected ErrNotStarted, got %v", err)
+	}
+	s.ContainerID = "test"
+	path := filepath.Join(dir, "sub", "heredoc.sh")
+	content := "cat > out << 'OFC_EOF'\n$HOME `date` \"quoted\"\nOFC_EOF\necho done"
+	if err := s.WriteFile(path, content); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != content {
+		t.Errorf("file = %q, want %q", got, content)
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	var err error = &TimeoutError{Duration: 30 * time.Second}
+	if err.Error() != "command timed out after 30s" {
+		t.Errorf("unexpected timeout message: %q", err)
+	}
+
+	cause := errors.New("exit status 125")
+	err = &StartError{Image: "python:3.11-slim", Err: cause}
+	if err.Error() != "failed to start container (image: python:3.11-slim): exit status 125" {
+		t.Errorf("unexpected start message: %q", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("StartError should unwrap to its cause")
+	}
+
+	err = &InitError{Err: errors.New("exit status 1")}
+	if err.Error() != "init script failed: exit status 1" {
+		t.Errorf("unexpected init message: %q", err)
+	}
+}
+
+func TestStartWithoutDocker(t *testing.T) {
+	t.Setenv("PATH", "")
+	err := New("", "", "").Start()
+	var de *DockerError
+	if !errors.As(err, &de) || !de.NotFound {
+		t.Fatalf("expected DockerError{NotFound}, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "remove the sandbox workstation") {
+		t.Errorf("message should say how to run without docker: %q", err)
+	}
+}
+
+func TestStartContextKillsStalledPull(t *testing.T) {
+	// A docker whose image pull never finishes.
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncase \"$1\" in\ninfo) echo 27.0 ;;\nimage) exit 1 ;;\n*) exec /bin/sleep 60 ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s := New("", "", "")
+	s.Log = io.Discard
+	start := time.Now()
+	err := s.StartContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("StartContext took %s; the pull should have been killed", d)
+	}
+}
+
+func TestStartReportsPullProgress(t *testing.T) {
+	// A docker with no image, whose pull prints two lines, the last
+	// without a newline.
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncase \"$1\" in\ninfo) echo 27.0 ;;\nimage) exit 1 ;;\n" +
+		"pull) printf 'abc123: Pulling fs layer\\n\\nabc123: Pull complete' ;;\nrun) echo 0123456789abcdef ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	var progress []string
+	s := New("", "img:1", "")
+	s.Progress = func(msg string) { progress = append(progress, msg) }
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	want := []string{"Pulling sandbox image (img:1)...", "abc123: Pulling fs layer", "abc123: Pull complete", "Sandbox image ready"}
+	if strings.Join(progress, "|") != strings.Join(want, "|") {
+		t.Errorf("progress = %q, want %q", progress, want)
+	}
+}
+
+func TestInitContextStopsAtDeadline(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte("#!/bin/sh\nexec /bin/sleep 60\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s := New("", "", "")
+	s.ContainerID = "test"
+	_, err := s.InitContext(ctx, "true")
+	var te *TimeoutError
+	if !errors.Is(err, context.DeadlineExceeded) || errors.As(err, &te) {
+		t.Fatalf("expected the caller's deadline, not a TimeoutError; got %v", err)
+	}
+}
+
+func TestRunArgsUser(t *testing.T) {
+	s := New("", "img", "")
+	want := "run -d --rm -w /ws -v /ws:/ws img sleep infinity"
+	if got := strings.Join(s.runArgs("/ws", nil), " "); got != want {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+
+	s.User = "1000:1000"
+	want = "run -d --rm -w /ws -v /ws:/ws -v /data:/data:ro --user 1000:1000 img sleep infinity"
+	if got := strings.Join(s.runArgs("/ws", []string{"/data:/data:ro"}), " "); got != want {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+}
+
+func TestResolveMounts(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Mkdir("data", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := resolveMounts([]string{"data:/data:ro", dir + ":/cache"})
+	if err != nil {
+		t.Fatalf("resolveMounts: %v", err)
+	}
+	want := []string{filepath.Join(dir, "data") + ":/data:ro", dir + ":/cache"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("mounts = %q, want %q", got, want)
+	}
+
+	_, err = resolveMounts([]string{"missing:/missing"})
+	var merr *MountError
+	if !errors.As(err, &merr) || merr.Spec != "missing:/missing" || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a MountError for the missing path, got %v", err)
+	}
+}
+
+func TestContextHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func() string {
+		t.Helper()
+		h, err := contextHash(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	write("Dockerfile", "FROM python:3.11-slim\nCOPY requirements.txt .\n")
+	write("requirements.txt", "pandas\n")
+	before := hash()
+
+	// Touching a file without changing it keeps the hash
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "Dockerfile"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if h := hash(); h != before {
+		t.Errorf("hash changed with mtime only: %s != %s", h, before)
+	}
+
+	// Editing a file the Dockerfile copies changes it
+	write("requirements.txt", "pandas\nnumpy\n")
+	if h := hash(); h == before {
+		t.Error("hash should change when a context file's content changes")
+	}
+}